@@ -0,0 +1,146 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"time"
+)
+
+// Fields of the access logs of AWS Application Load Balancers, in order, named
+// as in the AWS documentation. The target and client fields hold both the IP
+// address and port, e.g. "1.2.3.4:5678".
+var albAccessFields = [...]accessField{
+	{"type", apacheToken},
+	{"time", apacheToken},
+	{"elb", apacheToken},
+	{"client", apacheToken},
+	{"target", apacheToken},
+	{"request_processing_time", apacheToken},
+	{"target_processing_time", apacheToken},
+	{"response_processing_time", apacheToken},
+	{"elb_status_code", apacheToken},
+	{"target_status_code", apacheToken},
+	{"received_bytes", apacheToken},
+	{"sent_bytes", apacheToken},
+	{"request", apacheQouted}, // Split in method, url and protocol.
+	{"user_agent", apacheQouted},
+	{"ssl_cipher", apacheToken},
+	{"ssl_protocol", apacheToken},
+	{"target_group_arn", apacheToken},
+	{"trace_id", apacheQouted},
+	{"domain_name", apacheQouted},
+	{"chosen_cert_arn", apacheQouted},
+	{"matched_rule_priority", apacheToken},
+	{"request_creation_time", apacheToken},
+	{"actions_executed", apacheQouted},
+	{"redirect_url", apacheQouted},
+	{"error_reason", apacheQouted},
+	{"target_list", apacheQouted},
+	{"target_status_code_list", apacheQouted},
+	{"classification", apacheQouted},
+	{"classification_reason", apacheQouted},
+	{"conn_trace_id", apacheToken},
+}
+
+// Number of fields in the first ALB access logs, up to trace_id.
+const albMinimumFields = 18
+
+// AWS Application Load Balancer access logs.
+var albAccessLog = accessLog{
+	server:         "ALB",
+	dataID:         "alb",
+	fields:         albAccessFields[:],
+	required:       albMinimumFields,
+	timestampField: "time",
+	layout:         time.RFC3339Nano,
+}
+
+// Fields of the access logs of AWS Classic Load Balancers, in order. Logs
+// written before ELB added the user agent end after the request.
+var elbAccessFields = [...]accessField{
+	{"time", apacheToken},
+	{"elb", apacheToken},
+	{"client", apacheToken},
+	{"backend", apacheToken},
+	{"request_processing_time", apacheToken},
+	{"backend_processing_time", apacheToken},
+	{"response_processing_time", apacheToken},
+	{"elb_status_code", apacheToken},
+	{"backend_status_code", apacheToken},
+	{"received_bytes", apacheToken},
+	{"sent_bytes", apacheToken},
+	{"request", apacheQouted}, // Split in method, url and protocol.
+	{"user_agent", apacheQouted},
+	{"ssl_cipher", apacheToken},
+	{"ssl_protocol", apacheToken},
+}
+
+// AWS Classic Load Balancer access logs.
+var elbAccessLog = accessLog{
+	server:         "ELB",
+	dataID:         "elb",
+	fields:         elbAccessFields[:],
+	required:       len(elbAccessFields) - 3,
+	timestampField: "time",
+	layout:         time.RFC3339Nano,
+}
+
+// Request types of ALB access logs, the first field.
+var albTypes = [...]string{"http", "https", "h2", "grpcs", "ws", "wss"}
+
+// ParseALBAccess parses an access log line of an AWS Application Load
+// Balancer, e.g. `https 2015-10-13T12:31:40.123456Z app/my-alb/abc
+// 1.2.3.4:5678 10.0.0.5:80 0.001 0.002 0.000 200 200 123 456 "GET
+// https://example.com:443/ HTTP/1.1" "curl/7.43.0" ...`, into Data["alb"], see
+// albAccessFields. Lines that don't start with a known type are parsed as
+// Classic Load Balancer lines, see parseELBAccess. Fields added by AWS after
+// conn_trace_id are ignored.
+func parseALBAccess(buf *buffer, msg *Message) error {
+	b := buf.bytes[buf.position:buf.length]
+	albType, _, _ := bytes.Cut(b, []byte{spaceByte})
+	if !isALBType(albType) {
+		return parseELBAccess(buf, msg)
+	}
+
+	if err := parseAccessLog(buf, msg, &albAccessLog); err != nil {
+		return err
+	}
+	buf.position = buf.length
+	splitAccessRequest(msg.Data["alb"], "url")
+	setAWSSeverity(msg)
+	return nil
+}
+
+// ParseELBAccess parses an access log line of an AWS Classic Load Balancer,
+// e.g. `2015-10-13T12:31:40.123456Z my-elb 1.2.3.4:5678 10.0.0.5:80 0.00004
+// 0.001 0.00003 200 200 0 29 "GET http://example.com:80/ HTTP/1.1"
+// "curl/7.43.0" - -`, into Data["elb"], see elbAccessFields.
+func parseELBAccess(buf *buffer, msg *Message) error {
+	if err := parseAccessLog(buf, msg, &elbAccessLog); err != nil {
+		return err
+	}
+	buf.position = buf.length
+	splitAccessRequest(msg.Data["elb"], "url")
+	setAWSSeverity(msg)
+	return nil
+}
+
+// isALBType checks if b is a request type of ALB access logs, see albTypes.
+func isALBType(b []byte) bool {
+	for _, albType := range albTypes {
+		if string(b) == albType {
+			return true
+		}
+	}
+	return false
+}
+
+// setAWSSeverity sets the facility and severity of load balancer access logs,
+// which don't have a priority.
+func setAWSSeverity(msg *Message) {
+	msg.Facility = UserLevel
+	msg.Severity = Informational
+}
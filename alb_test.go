@@ -0,0 +1,207 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageALBAccess(t *testing.T) {
+	t.Parallel()
+
+	elbRegular := &Message{
+		Facility:  UserLevel,
+		Severity:  Informational,
+		Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 123456000, time.UTC),
+		Data: map[string]map[string]string{
+			"elb": {
+				"time":                     "2015-10-13T12:31:40.123456Z",
+				"elb":                      "my-loadbalancer",
+				"client":                   "192.168.131.39:2817",
+				"backend":                  "10.0.0.1:80",
+				"request_processing_time":  "0.000073",
+				"backend_processing_time":  "0.001048",
+				"response_processing_time": "0.000057",
+				"elb_status_code":          "200",
+				"backend_status_code":      "200",
+				"received_bytes":           "0",
+				"sent_bytes":               "29",
+				"method":                   "GET",
+				"url":                      "https://www.example.com:443/",
+				"protocol":                 "HTTP/1.1",
+				"user_agent":               "curl/7.38.0",
+				"ssl_cipher":               "DHE-RSA-AES128-SHA",
+				"ssl_protocol":             "TLSv1.2",
+			},
+		},
+	}
+
+	tests := []struct {
+		Name     string
+		Format   format
+		Input    string
+		Expected *Message
+	}{
+		{
+			"ALBAccess", ALBAccess, string(minimumInputALBAccess),
+			&Message{
+				Facility:  UserLevel,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.UTC),
+				Data: map[string]map[string]string{
+					"alb": {
+						"type":                     "http",
+						"time":                     "2015-01-01T01:01:01Z",
+						"request_processing_time":  "0",
+						"target_processing_time":   "0",
+						"response_processing_time": "0",
+						"elb_status_code":          "0",
+						"received_bytes":           "0",
+						"sent_bytes":               "0",
+					},
+				},
+			},
+		},
+		{
+			"ALBAccess", ALBAccess, string(regularInputALBAccess),
+			&Message{
+				Facility:  UserLevel,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 123456000, time.UTC),
+				Data: map[string]map[string]string{
+					"alb": {
+						"type":                     "https",
+						"time":                     "2015-10-13T12:31:40.123456Z",
+						"elb":                      "app/my-loadbalancer/50dc6c495c0c9188",
+						"client":                   "192.168.131.39:2817",
+						"target":                   "10.0.0.1:80",
+						"request_processing_time":  "0.086",
+						"target_processing_time":   "0.048",
+						"response_processing_time": "0.037",
+						"elb_status_code":          "200",
+						"target_status_code":       "200",
+						"received_bytes":           "0",
+						"sent_bytes":               "57",
+						"method":                   "GET",
+						"url":                      "https://www.example.com:443/",
+						"protocol":                 "HTTP/1.1",
+						"user_agent":               "curl/7.46.0",
+						"ssl_cipher":               "ECDHE-RSA-AES128-GCM-SHA256",
+						"ssl_protocol":             "TLSv1.2",
+						"target_group_arn":         "arn:aws:elasticloadbalancing:us-east-2:123456789012:targetgroup/my-targets/73e2d6bc24d8a067",
+						"trace_id":                 "Root=1-58337281-1d84f3d73c47ec4e58577259",
+						"domain_name":              "www.example.com",
+						"chosen_cert_arn":          "arn:aws:acm:us-east-2:123456789012:certificate/12345678-1234-1234-1234-123456789012",
+						"matched_rule_priority":    "1",
+						"request_creation_time":    "2015-10-13T12:31:40.037000Z",
+						"actions_executed":         "authenticate,forward",
+						"target_list":              "10.0.0.1:80",
+						"target_status_code_list":  "200",
+						"conn_trace_id":            "TID_1234abcd5678ef90",
+					},
+				},
+			},
+		},
+		{
+			// Fields added after conn_trace_id are ignored.
+			"ALBAccess", ALBAccess, `h2 2015-10-13T12:31:40.123456Z app/my-alb/abc 1.2.3.4:5678 - -1 -1 -1 502 - 34 366 "GET https://example.com:443/ HTTP/2.0" "curl/7.46.0" ECDHE-RSA-AES128-GCM-SHA256 TLSv1.2 - "Root=1-58337364-23a8c76965a2ef7629b185e3" "-" "-" 0 2015-10-13T12:31:40.120000Z "fixed-response" "-" "-" "-" "-" "-" "-" TID_1 "new field"`,
+			&Message{
+				Facility:  UserLevel,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 123456000, time.UTC),
+				Data: map[string]map[string]string{
+					"alb": {
+						"type":                     "h2",
+						"time":                     "2015-10-13T12:31:40.123456Z",
+						"elb":                      "app/my-alb/abc",
+						"client":                   "1.2.3.4:5678",
+						"request_processing_time":  "-1",
+						"target_processing_time":   "-1",
+						"response_processing_time": "-1",
+						"elb_status_code":          "502",
+						"received_bytes":           "34",
+						"sent_bytes":               "366",
+						"method":                   "GET",
+						"url":                      "https://example.com:443/",
+						"protocol":                 "HTTP/2.0",
+						"user_agent":               "curl/7.46.0",
+						"ssl_cipher":               "ECDHE-RSA-AES128-GCM-SHA256",
+						"ssl_protocol":             "TLSv1.2",
+						"trace_id":                 "Root=1-58337364-23a8c76965a2ef7629b185e3",
+						"matched_rule_priority":    "0",
+						"request_creation_time":    "2015-10-13T12:31:40.120000Z",
+						"actions_executed":         "fixed-response",
+						"conn_trace_id":            "TID_1",
+					},
+				},
+			},
+		},
+		{"ALBAccess", ALBAccess, string(regularInputELBAccess), elbRegular},
+		{"ELBAccess", ELBAccess, string(regularInputELBAccess), elbRegular},
+		{
+			"ELBAccess", ELBAccess, string(minimumInputELBAccess),
+			&Message{
+				Facility:  UserLevel,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.UTC),
+				Data: map[string]map[string]string{
+					"elb": {
+						"time":                     "2015-01-01T01:01:01Z",
+						"request_processing_time":  "0",
+						"backend_processing_time":  "0",
+						"response_processing_time": "0",
+						"elb_status_code":          "0",
+						"received_bytes":           "0",
+						"sent_bytes":               "0",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), test.Format)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, %s) to return Message %#v, but got %#v",
+				test.Input, test.Name, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageALBAccessErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`http 2015-01-01T01:01:01Z - - - 0 0 0 0 - 0 0 "- - - " "-" - -`,
+			"syslog: ALBAccess: format incorrect at byte 62: unexpected end of message"},
+		{`http 13/Oct/2015:12:31:40 - - - 0 0 0 0 - 0 0 "- - - " "-" - - - "-"`,
+			"syslog: ALBAccess: format incorrect at byte 6: invalid ALB timestamp"},
+		{`tcp 2015-01-01T01:01:01Z - - - 0 0 0 0 - 0 0 "- - - "`,
+			"syslog: ALBAccess: format incorrect at byte 1: invalid ELB timestamp"},
+		{`2015-01-01T01:01:01Z - - - 0 0 0 0 - 0 0 - "-"`,
+			"syslog: ALBAccess: format incorrect at byte 42: expected byte '\"', but got '-'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), ALBAccess)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+)
+
+// Layout of the %t timestamp in Apache access logs.
+const apacheAccessTimestampLayout = "02/Jan/2006:15:04:05 -0700"
+
+// Kinds of fields in Apache logs.
+const (
+	apacheToken   = iota // Ends at a space, e.g. 200.
+	apacheBracket        // Enclosed in brackets, e.g. [10/Oct/2000:13:55:36 -0700].
+	apacheQouted         // Enclosed in qoutes, e.g. "GET / HTTP/1.1".
+)
+
+// accessField is a field of an access log line, with the name it's stored under
+// in the data element and its kind, e.g. apacheQouted.
+type accessField struct {
+	name string
+	kind int
+}
+
+// accessLog describes an access log format, see parseAccessLog.
+type accessLog struct {
+	server         string // Used in errors, e.g. "Apache".
+	dataID         string // Data element the fields are stored in.
+	fields         []accessField
+	required       int    // Number of fields that must be present.
+	timestampField string // Name of the field that is parsed as Timestamp.
+	layout         string // Layout of the timestamp.
+}
+
+// Fields of the Apache combined log format, in order. Fields without a name
+// are not stored. The common log format ends after body_bytes_sent.
+var apacheAccessFields = [...]accessField{
+	{"remote_addr", apacheToken},
+	{"", apacheToken}, // %l, the remote logname is practically never used.
+	{"remote_user", apacheToken},
+	{"time_local", apacheBracket},
+	{"request", apacheQouted},
+	{"status", apacheToken},
+	{"body_bytes_sent", apacheToken},
+	{"referer", apacheQouted},
+	{"user_agent", apacheQouted},
+}
+
+// Number of fields in the Apache common log format.
+const apacheCommonFields = 7
+
+// Apache access logs, in the combined or common log format.
+var apacheAccessLog = accessLog{
+	server:         "Apache",
+	dataID:         "request",
+	fields:         apacheAccessFields[:],
+	required:       apacheCommonFields,
+	timestampField: "time_local",
+	layout:         apacheAccessTimestampLayout,
+}
+
+// ParseApacheAccess parses an Apache access log line in the combined, or
+// common, log format into Data["request"]. Fields with the "-" placeholder are
+// not stored. The time_local field is also parsed as Timestamp.
+func parseApacheAccess(buf *buffer, msg *Message) error {
+	return parseAccessLog(buf, msg, &apacheAccessLog)
+}
+
+// parseAccessLog parses the space separated fields of an access log line into
+// the data element of the access log, see parseApacheAccess. The line may end
+// after the required fields.
+func parseAccessLog(buf *buffer, msg *Message, log *accessLog) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+
+	data := make(map[string]string, len(log.fields))
+	var i int
+	for n, field := range log.fields {
+		if n != 0 {
+			if i == len(b) && n >= log.required {
+				break
+			} else if i == len(b) {
+				buf.position = buf.length
+				return io.EOF
+			} else if b[i] != spaceByte {
+				return newUnexpectedByteError(startPos+i, b[i], spaceByte)
+			}
+			i++
+		}
+
+		value, end, err := apacheField(b, i, startPos, field.kind)
+		if err != nil {
+			buf.position = buf.length
+			return err
+		}
+
+		if field.name == log.timestampField {
+			timestamp, err := time.Parse(log.layout, value)
+			if err != nil {
+				pos := startPos + i
+				if field.kind != apacheToken {
+					pos++ // Skip the bracket or qoute.
+				}
+				return newFormatError(pos, ErrBadTimestamp, "invalid "+log.server+" timestamp")
+			}
+			msg.Timestamp = timestamp
+		}
+		if field.name != "" && value != nilValue {
+			data[field.name] = value
+		}
+		i = end
+	}
+
+	buf.position += i
+	msg.Data = map[string]map[string]string{log.dataID: data}
+	return nil
+}
+
+// splitAccessRequest splits the request field of an access log, e.g. "GET /
+// HTTP/1.1", in the method, target (stored under the given name) and protocol.
+// Parts with the "-" placeholder are not stored.
+func splitAccessRequest(data map[string]string, target string) {
+	request, ok := data["request"]
+	if !ok {
+		return
+	}
+	delete(data, "request")
+	method, rest, _ := strings.Cut(request, " ")
+	path, protocol, _ := strings.Cut(rest, " ")
+	protocol = strings.TrimSpace(protocol) // "- - - " for failed requests.
+	for _, field := range [...][2]string{{"method", method}, {target, path}, {"protocol", protocol}} {
+		if field[1] != "" && field[1] != nilValue {
+			data[field[0]] = field[1]
+		}
+	}
+}
+
+// ApacheField returns the value of the field of the given kind starting at
+// index i of b and the index after the field, pos is the position of b in the
+// message. Qouted values may contain escapes, see unescapeNginx. It returns
+// io.EOF if b ends before the field.
+func apacheField(b []byte, i, pos, kind int) (string, int, error) {
+	if i >= len(b) {
+		return "", i, io.EOF
+	}
+
+	switch kind {
+	case apacheBracket:
+		if b[i] != '[' {
+			return "", i, newUnexpectedByteError(pos+i, b[i], '[')
+		}
+		end := bytes.IndexByte(b[i+1:], ']')
+		if end == -1 {
+			return "", len(b), io.EOF
+		}
+		return string(b[i+1 : i+1+end]), i + end + 2, nil
+	case apacheQouted:
+		if b[i] != qouteByte {
+			return "", i, newUnexpectedByteError(pos+i, b[i], qouteByte)
+		}
+		escaped := false
+		for j := i + 1; j < len(b); j++ {
+			if b[j] == escapeByte {
+				escaped = true
+				j++
+			} else if b[j] == qouteByte {
+				if !escaped {
+					return string(b[i+1 : j]), j + 1, nil
+				}
+				return unescapeNginx(b[i+1 : j]), j + 1, nil
+			}
+		}
+		return "", len(b), io.EOF
+	default:
+		end := bytes.IndexByte(b[i:], spaceByte)
+		if end == -1 {
+			end = len(b) - i
+		} else if end == 0 {
+			return "", i, newFormatError(pos+i, nil, "expected a value, but got ' '")
+		}
+		return string(b[i : i+end]), i + end, nil
+	}
+}
+
+// Layouts of the timestamp in Apache error logs, 2.4 includes microseconds.
+var apacheErrorTimestampLayouts = [...]string{
+	"Mon Jan _2 15:04:05.000000 2006",
+	"Mon Jan _2 15:04:05 2006",
+}
+
+// ParseApacheError parses an Apache error log line, of both 2.2 and 2.4, e.g.
+// "[Tue Oct 13 12:31:40.123456 2015] [core:error] [pid 1187:tid 140] [client
+// 1.2.3.4:5678] AH00126: Invalid URI in request". The optional timestamp
+// replaces Timestamp and the level replaces the severity, like
+// parseNginxLevel. The module is stored in Data["data"]["module"], the pid
+// (and tid) as ProcessID, e.g. "1187:140", and other blocks, like the client,
+// in Data["data"]. The optional AH code is stored as MessageID and the
+// remainder as Message.
+func parseApacheError(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+
+	block, i, err := apacheBlock(b, 0, startPos)
+	if err == nil {
+		if timestamp, ok := parseApacheErrorTimestamp(block, buf.cfg.Location()); ok {
+			msg.Timestamp = timestamp
+			block, i, err = apacheBlock(b, skipSpaces(b, i), startPos)
+		}
+	}
+	if err != nil {
+		if err == io.EOF {
+			buf.position = buf.length
+		}
+		return err
+	}
+
+	levelPos := startPos + i - len(block) - 1
+	if err := setApacheLevel(buf, msg, block, levelPos); err != nil {
+		return err
+	}
+
+	// Optional blocks, e.g. "[pid 1187:tid 140]" and "[client 1.2.3.4]".
+	for {
+		j := skipSpaces(b, i)
+		if j >= len(b) || b[j] != '[' {
+			break
+		}
+		end := bytes.IndexByte(b[j:], ']')
+		if end == -1 {
+			break
+		}
+		key, value, ok := strings.Cut(string(b[j+1:j+end]), " ")
+		if !ok || key == "" || strings.IndexFunc(key, isNotNginxKeyRune) != -1 {
+			break
+		}
+
+		if key == "pid" {
+			msg.ProcessID = strings.Replace(value, ":tid ", ":", 1)
+		} else {
+			nginxData(msg)[key] = value
+		}
+		i = j + end + 1
+	}
+
+	message := bytes.TrimSpace(b[i:])
+	if code, rest, ok := bytes.Cut(message, []byte(": ")); ok && isApacheCode(code) {
+		msg.MessageID = string(code)
+		message = bytes.TrimSpace(rest)
+	}
+	msg.Message = string(message)
+	buf.position = buf.length
+	return nil
+}
+
+// ApacheBlock returns the content of the bracketed block starting at index i
+// of b and the index after the block, pos is the position of b in the
+// message.
+func apacheBlock(b []byte, i, pos int) ([]byte, int, error) {
+	if i >= len(b) {
+		return nil, i, io.EOF
+	} else if b[i] != '[' {
+		return nil, i, newUnexpectedByteError(pos+i, b[i], '[')
+	}
+
+	end := bytes.IndexByte(b[i:], ']')
+	if end == -1 {
+		return nil, len(b), io.EOF
+	}
+	return b[i+1 : i+end], i + end + 1, nil
+}
+
+// parseApacheErrorTimestamp parses the timestamp of an Apache error log.
+func parseApacheErrorTimestamp(b []byte, location *time.Location) (time.Time, bool) {
+	for _, layout := range apacheErrorTimestampLayouts {
+		if timestamp, err := time.ParseInLocation(layout, string(b), location); err == nil {
+			return timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// setApacheLevel sets the module and severity from the level block, e.g.
+// "core:error" or, in Apache 2.2, "error". The trace levels are mapped onto
+// Debug.
+func setApacheLevel(buf *buffer, msg *Message, block []byte, pos int) error {
+	level := string(block)
+	if module, l, ok := strings.Cut(level, ":"); ok {
+		nginxData(msg)["module"] = module
+		level = l
+		pos += len(module) + 1
+	}
+
+	severity, err := ParseSeverityName(level)
+	if strings.HasPrefix(level, "trace") && len(level) == len("trace1") && level[5] >= '1' && level[5] <= '8' {
+		severity, err = Debug, nil
+	}
+	if err != nil {
+		return newFormatError(pos, nil, "unknown Apache level '"+escapeSnippet([]byte(level))+"'")
+	}
+
+	if !buf.cfg.PrioritySeverity() {
+		msg.Severity = severity
+		if msg.Priority.IsValid() {
+			msg.Priority = CalculatePriority(msg.Facility, severity)
+		}
+	}
+	return nil
+}
+
+// isApacheCode checks if b is an Apache error code, "AH" followed by five
+// digits, e.g. AH00126.
+func isApacheCode(b []byte) bool {
+	return len(b) == 7 && b[0] == 'A' && b[1] == 'H' && skipDigits(b, 2) == len(b)
+}
+
+// isNotNginxKeyRune is the inverse of isNginxKeyByte, for use with
+// strings.IndexFunc.
+func isNotNginxKeyRune(r rune) bool {
+	return r >= 0x80 || !isNginxKeyByte(byte(r))
+}
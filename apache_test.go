@@ -0,0 +1,325 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageApacheAccess(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputApacheAccess),
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.UTC),
+				Hostname:  "h",
+				Appname:   "a",
+				Data: map[string]map[string]string{
+					"request": {
+						"remote_addr": "1",
+						"time_local":  "01/Jan/2015:01:01:01 +0000",
+						"request":     "",
+						"status":      "200",
+					},
+				},
+			},
+		},
+		{
+			string(regularInputApacheAccess),
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.FixedZone("", 2*60*60)),
+				Hostname:  "hostname",
+				Appname:   "apache",
+				Data: map[string]map[string]string{
+					"request": {
+						"remote_addr":     "192.168.1.255",
+						"remote_user":     "frank",
+						"time_local":      "13/Oct/2015:12:31:40 +0200",
+						"request":         "GET /index.html HTTP/1.1",
+						"status":          "200",
+						"body_bytes_sent": "2326",
+						"referer":         "http://example.com/start.html",
+						"user_agent":      "Mozilla/5.0 (X11; Linux x86_64)",
+					},
+				},
+			},
+		},
+		{
+			// Common log format, placeholders and a pid in the tag.
+			`<134>Oct 13 12:31:40 hostname apache[1187]: ::1 - - [13/Oct/2015:12:31:40 -0700] "-" 408 -`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.FixedZone("", -7*60*60)),
+				Hostname:  "hostname",
+				Appname:   "apache",
+				ProcessID: "1187",
+				Data: map[string]map[string]string{
+					"request": {
+						"remote_addr": "::1",
+						"time_local":  "13/Oct/2015:12:31:40 -0700",
+						"status":      "408",
+					},
+				},
+			},
+		},
+		{
+			// Escaped qoutes.
+			`<134>Oct 13 12:31:40 hostname apache: 10.0.0.1 - - [13/Oct/2015:12:31:40 +0000] "GET /?q=\"a b\" HTTP/1.1" 404 209 "-" "curl \"7.43.0\"\\ \x01"`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Hostname:  "hostname",
+				Appname:   "apache",
+				Data: map[string]map[string]string{
+					"request": {
+						"remote_addr":     "10.0.0.1",
+						"time_local":      "13/Oct/2015:12:31:40 +0000",
+						"request":         `GET /?q="a b" HTTP/1.1`,
+						"status":          "404",
+						"body_bytes_sent": "209",
+						"user_agent":      "curl \"7.43.0\"\\ \x01",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), ApacheAccess)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, ApacheAccess) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageApacheAccessErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`<134>Jan  1 01:01:01 h a: 1 - -`,
+			"syslog: ApacheAccess: format incorrect at byte 31: unexpected end of message"},
+		{`<134>Jan  1 01:01:01 h a: 1 - - [01/Jan/2015:01:01:01 +0000`,
+			"syslog: ApacheAccess: format incorrect at byte 59: unexpected end of message"},
+		{`<134>Jan  1 01:01:01 h a: 1 - - 01/Jan/2015:01:01:01 +0000] "" 200 -`,
+			"syslog: ApacheAccess: format incorrect at byte 33: expected byte '[', but got '0'"},
+		{`<134>Jan  1 01:01:01 h a: 1 - - [01/Jan/2015] "" 200 -`,
+			"syslog: ApacheAccess: format incorrect at byte 34: invalid Apache timestamp"},
+		{`<134>Jan  1 01:01:01 h a: 1 - - [01/Jan/2015:01:01:01 +0000] GET 200 -`,
+			"syslog: ApacheAccess: format incorrect at byte 62: expected byte '\"', but got 'G'"},
+		{`<134>Jan  1 01:01:01 h a: 1 - - [01/Jan/2015:01:01:01 +0000] "GET 200 -`,
+			"syslog: ApacheAccess: format incorrect at byte 71: unexpected end of message"},
+		{`<134>Jan  1 01:01:01 h a: 1  - - [01/Jan/2015:01:01:01 +0000] "" 200 -`,
+			"syslog: ApacheAccess: format incorrect at byte 29: expected a value, but got ' '"},
+		{`<134>Jan  1 01:01:01 h a: 1 - - [01/Jan/2015:01:01:01 +0000]"" 200 -`,
+			"syslog: ApacheAccess: format incorrect at byte 61: expected byte ' ', but got '\"'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), ApacheAccess)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageApacheError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputApacheError),
+			&Message{
+				Priority:  CalculatePriority(Local0, Error),
+				Facility:  Local0,
+				Severity:  Error,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+			},
+		},
+		{
+			string(regularInputApacheError),
+			&Message{
+				Priority:  CalculatePriority(Local0, Error),
+				Facility:  Local0,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 123456000, time.Local),
+				Hostname:  "hostname",
+				Appname:   "apache",
+				ProcessID: "1187:140",
+				MessageID: "AH00126",
+				Data: map[string]map[string]string{
+					"data": {
+						"module": "core",
+						"client": "1.2.3.4:5678",
+					},
+				},
+				Message: "Invalid URI in request",
+			},
+		},
+		{
+			// Apache 2.4, without the client and error code.
+			`<134>Oct 13 12:31:40 hostname apache: [Tue Oct 13 12:31:40.000001 2015] [mpm_event:notice] [pid 1187:tid 140] AH00489: Apache/2.4.18 (Ubuntu) configured -- resuming normal operations`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Notice),
+				Facility:  Local0,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 1000, time.Local),
+				Hostname:  "hostname",
+				Appname:   "apache",
+				ProcessID: "1187:140",
+				MessageID: "AH00489",
+				Data:      map[string]map[string]string{"data": {"module": "mpm_event"}},
+				Message:   "Apache/2.4.18 (Ubuntu) configured -- resuming normal operations",
+			},
+		},
+		{
+			// Apache 2.4, without the error code and a trace level.
+			`<134>Oct 13 12:31:40 hostname apache: [Tue Oct  6 12:31:40.500000 2015] [ssl:trace3] [pid 1187] [client 1.2.3.4:5678] [remote 10.0.0.1:80] SSL handshake: [start]`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Debug),
+				Facility:  Local0,
+				Severity:  Debug,
+				Timestamp: time.Date(2015, 10, 6, 12, 31, 40, 500000000, time.Local),
+				Hostname:  "hostname",
+				Appname:   "apache",
+				ProcessID: "1187",
+				Data: map[string]map[string]string{
+					"data": {
+						"module": "ssl",
+						"client": "1.2.3.4:5678",
+						"remote": "10.0.0.1:80",
+					},
+				},
+				Message: "SSL handshake: [start]",
+			},
+		},
+		{
+			// Apache 2.2.
+			`<134>Oct 13 12:31:40 hostname apache: [Tue Oct  6 12:31:40 2015] [error] [client 1.2.3.4] File does not exist: /var/www/favicon.ico`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Error),
+				Facility:  Local0,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 6, 12, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "apache",
+				Data:      map[string]map[string]string{"data": {"client": "1.2.3.4"}},
+				Message:   "File does not exist: /var/www/favicon.ico",
+			},
+		},
+		{
+			// Apache 2.2, without the client.
+			`<134>Oct 13 12:31:40 hostname apache: [Tue Oct 13 12:31:40 2015] [warn] RSA server certificate CommonName (CN) does NOT match server name!?`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Warning),
+				Facility:  Local0,
+				Severity:  Warning,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "apache",
+				Message:   "RSA server certificate CommonName (CN) does NOT match server name!?",
+			},
+		},
+		{
+			// Send using `ErrorLog syslog`, without the timestamp.
+			`<11>Oct 13 12:31:40 hostname httpd[1187]: [core:crit] [pid 1187:tid 140] AH00126: Invalid URI in request`,
+			&Message{
+				Priority:  CalculatePriority(UserLevel, Critical),
+				Facility:  UserLevel,
+				Severity:  Critical,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "httpd",
+				ProcessID: "1187:140",
+				MessageID: "AH00126",
+				Data:      map[string]map[string]string{"data": {"module": "core"}},
+				Message:   "Invalid URI in request",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), ApacheError)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, ApacheError) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageApacheErrorErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`<131>Jan  1 01:01:01 h a: `,
+			"syslog: ApacheError: format incorrect at byte 26: unexpected end of message"},
+		{`<131>Jan  1 01:01:01 h a: error`,
+			"syslog: ApacheError: format incorrect at byte 27: expected byte '[', but got 'e'"},
+		{`<131>Jan  1 01:01:01 h a: [error`,
+			"syslog: ApacheError: format incorrect at byte 32: unexpected end of message"},
+		{`<131>Jan  1 01:01:01 h a: [Tue Oct 13 12:31:40 2015]`,
+			"syslog: ApacheError: format incorrect at byte 52: unexpected end of message"},
+		{`<131>Jan  1 01:01:01 h a: [Tue Oct 13 12:31:40 2015] error`,
+			"syslog: ApacheError: format incorrect at byte 54: expected byte '[', but got 'e'"},
+		{`<131>Jan  1 01:01:01 h a: [failure] msg`,
+			"syslog: ApacheError: format incorrect at byte 28: unknown Apache level 'failure'"},
+		{`<131>Jan  1 01:01:01 h a: [core:trace9] msg`,
+			"syslog: ApacheError: format incorrect at byte 33: unknown Apache level 'trace9'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), ApacheError)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
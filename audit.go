@@ -0,0 +1,222 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// Fields that auditd hex encodes if their value contains a space, a qoute or
+// a control character, otherwise the value is qouted. The arguments of EXECVE
+// records, a0, a1, etc., are encoded the same way.
+var auditHexFields = map[string]bool{
+	"acct":      true,
+	"cmd":       true,
+	"comm":      true,
+	"cwd":       true,
+	"data":      true,
+	"exe":       true,
+	"key":       true,
+	"name":      true,
+	"path":      true,
+	"proctitle": true,
+	"vm":        true,
+}
+
+// The separator auditd uses between the fields of a record and the
+// interpreted fields it adds when forwarding the record, e.g. AUID="root".
+const auditEnrichedSeparator = 0x1d
+
+// ParseAudit parses a Linux audit record, e.g. "type=SYSCALL
+// msg=audit(1444733500.123:456): arch=c000003e syscall=59 success=yes
+// key=\"exec-log\"". The record type is stored as MessageID, the timestamp
+// in the audit(...) wrapper as Timestamp and the serial, and all other
+// fields, in Data["audit"]. Kernel records, which have "audit(...)" without
+// msg=, and the node= prefix of forwarded records are supported as well.
+//
+// The fields of the single qouted msg field of user space records, e.g.
+// msg='op=login acct="root" res=success', are stored as if they were fields
+// of the record. Hex encoded fields, see auditHexFields, are decoded, the NUL
+// bytes separating the arguments in proctitle are replaced with spaces.
+// Duplicate fields are handled like duplicate structured data params, see
+// WithDuplicates.
+func parseAudit(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+	buf.position = buf.length
+
+	data := map[string]string{}
+	var i int
+	if bytes.HasPrefix(b, []byte("node=")) {
+		node, end := auditToken(b, len("node="))
+		data["node"] = node
+		i = skipSpaces(b, end)
+	}
+
+	if !bytes.HasPrefix(b[i:], []byte("type=")) {
+		if len(b)-i < len("type=") && bytes.HasPrefix([]byte("type="), b[i:]) {
+			return io.EOF
+		}
+		return newFormatError(startPos+i, nil, "expected type=")
+	}
+	recordType, end := auditToken(b, i+len("type="))
+	if recordType == "" {
+		return newFormatError(startPos+end, nil, "expected a record type")
+	} else if len(recordType) > maxMessageIDLength {
+		return newFormatError(startPos+i, ErrFieldTooLong, "record type too long")
+	}
+	msg.MessageID = recordType
+	i = skipSpaces(b, end)
+
+	// The msg=audit(1444733500.123:456): wrapper.
+	i, err := parseAuditWrapper(buf, msg, data, b, i, startPos)
+	if err != nil {
+		return err
+	}
+
+	if err := parseAuditFields(buf, msg, data, b, i, startPos, recordType == "EXECVE"); err != nil {
+		return err
+	}
+	msg.Data = map[string]map[string]string{"audit": data}
+	return nil
+}
+
+// parseAuditWrapper parses the timestamp and serial in the audit(...) wrapper
+// starting at index i of b, returning the index after it.
+func parseAuditWrapper(buf *buffer, msg *Message, data map[string]string, b []byte, i, pos int) (int, error) {
+	const prefix = "msg=audit("
+	if bytes.HasPrefix(b[i:], []byte(prefix)) {
+		i += len(prefix)
+	} else if bytes.HasPrefix(b[i:], []byte(prefix[4:])) {
+		i += len(prefix) - 4
+	} else if len(b)-i < len(prefix) && bytes.HasPrefix([]byte(prefix), b[i:]) {
+		return i, io.EOF
+	} else {
+		return i, newFormatError(pos+i, nil, "expected msg=audit(")
+	}
+
+	end := bytes.IndexByte(b[i:], ')')
+	if end == -1 {
+		return i, io.EOF
+	}
+	timestamp, serial, ok := strings.Cut(string(b[i:i+end]), ":")
+	if !ok || !isDigits(serial) {
+		return i, newFormatError(pos+i, nil, "expected audit(timestamp:serial)")
+	}
+	t, err := parseUnixTimestamp(timestamp, buf.cfg.Location())
+	if err != nil {
+		return i, newFormatError(pos+i, ErrBadTimestamp, "invalid audit timestamp")
+	}
+	msg.Timestamp = t
+	data["serial"] = serial
+	i += end + 1
+
+	if i >= len(b) {
+		return i, io.EOF
+	} else if b[i] != ':' {
+		return i, newUnexpectedByteError(pos+i, b[i], ':')
+	}
+	return i + 1, nil
+}
+
+// parseAuditFields parses the space separated key=value fields starting at
+// index i of b into data, see parseAudit. If execve is true the arguments,
+// a0, a1, etc., are hex decoded as well.
+func parseAuditFields(buf *buffer, msg *Message, data map[string]string, b []byte, i, pos int, execve bool) error {
+	policy := buf.cfg.Duplicates()
+	for n := len(data); ; n++ {
+		for i < len(b) && (b[i] == spaceByte || b[i] == auditEnrichedSeparator) {
+			i++
+		}
+		if i == len(b) {
+			return nil
+		}
+
+		keyPos := i
+		end := bytes.IndexAny(b[i:], "= \x1d")
+		if end <= 0 || b[i+end] != equalByte {
+			return newFormatError(pos+keyPos, nil, "expected a key=value pair")
+		} else if buf.cfg.TooManyParams(n + 1) {
+			return newFormatError(pos+keyPos, ErrTooManyParams, "audit record has too many fields")
+		}
+		key := string(b[i : i+end])
+		i += end + 1
+
+		var value string
+		switch {
+		case i < len(b) && b[i] == '\'':
+			// Fields of a user space record, e.g. msg='op=login res=success'.
+			end := bytes.IndexByte(b[i+1:], '\'')
+			if end == -1 {
+				return io.EOF
+			}
+			inner := b[:i+1+end]
+			if err := parseAuditFields(buf, msg, data, inner, i+1, pos, execve); err != nil {
+				return err
+			}
+			i += end + 2
+			continue
+		case i < len(b) && b[i] == qouteByte:
+			end := bytes.IndexByte(b[i+1:], qouteByte)
+			if end == -1 {
+				return io.EOF
+			}
+			value = string(b[i+1 : i+1+end])
+			i += end + 2
+		default:
+			value, i = auditToken(b, i)
+			if auditHexFields[key] || (execve && isAuditArgument(key)) {
+				value = decodeAuditHex(key, value)
+			}
+		}
+
+		if previous, ok := data[key]; ok {
+			if policy == RejectDuplicates {
+				return newFormatError(pos+keyPos, ErrDuplicate, "duplicate audit field "+key)
+			} else if policy == CollectDuplicates {
+				msg.addRepeated("audit", key, previous, value)
+			}
+		}
+		data[key] = value
+	}
+}
+
+// auditToken returns the unqouted value starting at index i of b, ending at a
+// space or the end of b, and the index after it.
+func auditToken(b []byte, i int) (string, int) {
+	end := bytes.IndexAny(b[i:], " \x1d")
+	if end == -1 {
+		end = len(b) - i
+	}
+	return string(b[i : i+end]), i + end
+}
+
+// decodeAuditHex decodes the hex encoded value, or returns it unchanged if
+// it's not valid hex, e.g. "(null)".
+func decodeAuditHex(key, value string) string {
+	if value == "" {
+		return value
+	}
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		return value
+	}
+	if key == "proctitle" {
+		decoded = bytes.ReplaceAll(bytes.TrimRight(decoded, "\x00"), []byte{0}, []byte{spaceByte})
+	}
+	return string(decoded)
+}
+
+// isAuditArgument checks if key is an argument of an EXECVE record, e.g. a0,
+// or a part of a long argument, e.g. a1[0].
+func isAuditArgument(key string) bool {
+	if i := strings.IndexByte(key, '['); i != -1 {
+		key = key[:i]
+	}
+	return len(key) > 1 && key[0] == 'a' && isDigits(key[1:])
+}
@@ -0,0 +1,254 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseMessageAudit(t *testing.T) {
+	t.Parallel()
+
+	timestamp := time.Date(2015, 10, 13, 10, 51, 40, 123000000, time.UTC)
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputAudit),
+			&Message{
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.UTC),
+				Hostname:  "h",
+				Appname:   "a",
+				MessageID: "A",
+				Data:      map[string]map[string]string{"audit": {"serial": "1"}},
+			},
+		},
+		{
+			string(regularInputAudit),
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization2, Informational),
+				Facility:  SecurityAuthorization2,
+				Severity:  Informational,
+				Timestamp: timestamp,
+				Hostname:  "hostname",
+				Appname:   "audit",
+				ProcessID: "1187",
+				MessageID: "SYSCALL",
+				Data: map[string]map[string]string{
+					"audit": {
+						"serial":  "456",
+						"arch":    "c000003e",
+						"syscall": "59",
+						"success": "yes",
+						"exit":    "0",
+						"a0":      "55d5c4f0a2b8",
+						"a1":      "55d5c4f0a2e0",
+						"a2":      "55d5c4f0a300",
+						"a3":      "0",
+						"items":   "2",
+						"ppid":    "1186",
+						"pid":     "1187",
+						"auid":    "1000",
+						"uid":     "0",
+						"gid":     "0",
+						"euid":    "0",
+						"suid":    "0",
+						"fsuid":   "0",
+						"egid":    "0",
+						"sgid":    "0",
+						"fsgid":   "0",
+						"tty":     "pts0",
+						"ses":     "3",
+						"comm":    "ls",
+						"exe":     "/usr/bin/ls",
+						"subj":    "unconfined",
+						"key":     "exec-log",
+					},
+				},
+			},
+		},
+		{
+			// Hex encoded argument.
+			`<86>Oct 13 12:31:40 hostname audit[1187]: type=EXECVE msg=audit(1444733500.123:456): argc=3 a0="ls" a1="-la" a2=2F746D702F6D7920646972`,
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization2, Informational),
+				Facility:  SecurityAuthorization2,
+				Severity:  Informational,
+				Timestamp: timestamp,
+				Hostname:  "hostname",
+				Appname:   "audit",
+				ProcessID: "1187",
+				MessageID: "EXECVE",
+				Data: map[string]map[string]string{
+					"audit": {
+						"serial": "456",
+						"argc":   "3",
+						"a0":     "ls",
+						"a1":     "-la",
+						"a2":     "/tmp/my dir",
+					},
+				},
+			},
+		},
+		{
+			// Arguments separated by NUL bytes in proctitle.
+			`<86>Oct 13 12:31:40 hostname audit[1187]: type=PROCTITLE msg=audit(1444733500.123:456): proctitle=6C73002D6C61`,
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization2, Informational),
+				Facility:  SecurityAuthorization2,
+				Severity:  Informational,
+				Timestamp: timestamp,
+				Hostname:  "hostname",
+				Appname:   "audit",
+				ProcessID: "1187",
+				MessageID: "PROCTITLE",
+				Data: map[string]map[string]string{
+					"audit": {
+						"serial":    "456",
+						"proctitle": "ls -la",
+					},
+				},
+			},
+		},
+		{
+			// User space record, with the interpreted fields added by auditd.
+			"<86>Oct 13 12:31:40 hostname audit[1187]: node=web1 type=USER_LOGIN msg=audit(1444733500.123:457): pid=1187 uid=0 auid=1000 ses=3 subj=unconfined msg='op=login id=1000 exe=\"/usr/sbin/sshd\" hostname=10.0.0.1 addr=10.0.0.1 terminal=/dev/pts/0 res=success'\x1dUID=\"root\" AUID=\"thomas\"",
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization2, Informational),
+				Facility:  SecurityAuthorization2,
+				Severity:  Informational,
+				Timestamp: timestamp,
+				Hostname:  "hostname",
+				Appname:   "audit",
+				ProcessID: "1187",
+				MessageID: "USER_LOGIN",
+				Data: map[string]map[string]string{
+					"audit": {
+						"node":     "web1",
+						"serial":   "457",
+						"pid":      "1187",
+						"uid":      "0",
+						"auid":     "1000",
+						"ses":      "3",
+						"subj":     "unconfined",
+						"op":       "login",
+						"id":       "1000",
+						"exe":      "/usr/sbin/sshd",
+						"hostname": "10.0.0.1",
+						"addr":     "10.0.0.1",
+						"terminal": "/dev/pts/0",
+						"res":      "success",
+						"UID":      "root",
+						"AUID":     "thomas",
+					},
+				},
+			},
+		},
+		{
+			// Kernel record, (null) isn't hex.
+			`<4>Oct 13 12:31:40 hostname kernel: type=1400 audit(1444733500.123:458): apparmor="DENIED" operation="open" profile="/usr/sbin/ntpd" name="/etc/ssl/openssl.cnf" pid=1187 comm="ntpd" requested_mask="r" denied_mask="r" fsuid=0 ouid=0 key=(null)`,
+			&Message{
+				Priority:  CalculatePriority(Kernel, Warning),
+				Facility:  Kernel,
+				Severity:  Warning,
+				Timestamp: timestamp,
+				Hostname:  "hostname",
+				Appname:   "kernel",
+				MessageID: "1400",
+				Data: map[string]map[string]string{
+					"audit": {
+						"serial":         "458",
+						"apparmor":       "DENIED",
+						"operation":      "open",
+						"profile":        "/usr/sbin/ntpd",
+						"name":           "/etc/ssl/openssl.cnf",
+						"pid":            "1187",
+						"comm":           "ntpd",
+						"requested_mask": "r",
+						"denied_mask":    "r",
+						"fsuid":          "0",
+						"ouid":           "0",
+						"key":            "(null)",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Audit)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Audit) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageAuditErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`<0>Jan  1 01:01:01 h a: ty`,
+			"syslog: Audit: format incorrect at byte 26: unexpected end of message"},
+		{`<0>Jan  1 01:01:01 h a: arch=c000003e`,
+			"syslog: Audit: format incorrect at byte 25: expected type="},
+		{`<0>Jan  1 01:01:01 h a: type=A arch=c000003e`,
+			"syslog: Audit: format incorrect at byte 32: expected msg=audit("},
+		{`<0>Jan  1 01:01:01 h a: type=A msg=audit(1420074061:1`,
+			"syslog: Audit: format incorrect at byte 53: unexpected end of message"},
+		{`<0>Jan  1 01:01:01 h a: type=A msg=audit(1420074061)`,
+			"syslog: Audit: format incorrect at byte 42: expected audit(timestamp:serial)"},
+		{`<0>Jan  1 01:01:01 h a: type=A msg=audit(yesterday:1):`,
+			"syslog: Audit: format incorrect at byte 42: invalid audit timestamp"},
+		{`<0>Jan  1 01:01:01 h a: type=A msg=audit(1420074061:1): arch`,
+			"syslog: Audit: format incorrect at byte 57: expected a key=value pair"},
+		{`<0>Jan  1 01:01:01 h a: type=A msg=audit(1420074061:1): key="exec`,
+			"syslog: Audit: format incorrect at byte 65: unexpected end of message"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), Audit)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageAuditDuplicates(t *testing.T) {
+	t.Parallel()
+
+	input := []byte(`<0>Jan  1 01:01:01 h a: type=A msg=audit(1420074061:1): res=failed res=success`)
+	_, err := NewParser(Audit, WithDuplicates(RejectDuplicates))(input)
+	if !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("Expected parsing %q with RejectDuplicates to return an ErrDuplicate error, but got %v",
+			input, err)
+	}
+
+	msg, err := NewParser(Audit, WithDuplicates(CollectDuplicates))(input)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %q: %s", input, err)
+	}
+	expected := []string{"failed", "success"}
+	if got := msg.ParamValues("audit", "res"); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected msg.ParamValues(audit, res) to return %q, but got %q", expected, got)
+	}
+}
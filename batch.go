@@ -0,0 +1,83 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// LineError is an error parsing a single line in ParseAll.
+type LineError struct {
+	Line int // Index of the line in the input, starts at 0.
+	Err  error
+}
+
+func (err *LineError) Error() string {
+	return "syslog: line " + strconv.Itoa(err.Line) + ": " + err.Err.Error()
+}
+
+// Unwrap returns the error of the line, usually a *FormatError.
+func (err *LineError) Unwrap() error {
+	return err.Err
+}
+
+// ParseAll parses all lines with the given format, using the given number of
+// goroutines. If workers is zero or negative runtime.GOMAXPROCS is used.
+//
+// The returned messages are in the same order as the input lines, a line that
+// failed to parse has an incomplete message, see ParseMessage. A failed line
+// doesn't stop the parsing of the other lines. If all lines parsed
+// successfully errs is nil, otherwise it has the same length as lines and
+// holds a *LineError for each line that failed.
+func ParseAll(lines [][]byte, f Format, workers int) (msgs []*Message, errs []error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(lines) {
+		workers = len(lines)
+	}
+
+	msgs = make([]*Message, len(lines))
+	lineErrs := make([]*LineError, len(lines))
+	var failed int32
+
+	// Every worker takes the next line that isn't parsed yet, so slow lines
+	// don't hold up the other workers.
+	var next int64 = -1
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(lines) {
+					return
+				}
+
+				msg, err := parseMessage(lines[i], f, defaultConfig)
+				if err != nil {
+					lineErrs[i] = &LineError{Line: i, Err: err}
+					atomic.StoreInt32(&failed, 1)
+				}
+				msgs[i] = msg
+			}
+		}()
+	}
+	wg.Wait()
+
+	if failed != 0 {
+		errs = make([]error, len(lines))
+		for i, err := range lineErrs {
+			if err != nil {
+				errs[i] = err
+			}
+		}
+	}
+	return msgs, errs
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAll(t *testing.T) {
+	t.Parallel()
+
+	lines := [][]byte{
+		minimumInputRFC5424,
+		regularInputRFC5424,
+		[]byte("<1923>"),
+		longInputRFC5424,
+		[]byte(""),
+	}
+
+	for _, workers := range []int{0, 1, 2, 10} {
+		msgs, errs := ParseAll(lines, RFC5424, workers)
+		if len(msgs) != len(lines) || len(errs) != len(lines) {
+			t.Fatalf("Expected ParseAll(%d) to return %d messages and errors, but got %d and %d",
+				workers, len(lines), len(msgs), len(errs))
+		}
+
+		for i, line := range lines {
+			expected, expectedErr := ParseMessage(line, RFC5424)
+			if expectedErr != nil {
+				lineErr, ok := errs[i].(*LineError)
+				if !ok || lineErr.Line != i || lineErr.Err.Error() != expectedErr.Error() {
+					t.Fatalf("Expected ParseAll(%d) to return error %v for line %d, but got %v",
+						workers, expectedErr, i, errs[i])
+				} else if !messagesAreEqual(msgs[i], expected) {
+					t.Fatalf("Expected ParseAll(%d) to return Message %#v for line %d, but got %#v",
+						workers, expected, i, msgs[i])
+				}
+				continue
+			}
+
+			if errs[i] != nil {
+				t.Fatalf("Unexpected error for line %d: %s", i, errs[i])
+			} else if !messagesAreEqual(msgs[i], expected) {
+				t.Fatalf("Expected ParseAll(%d) to return Message %#v for line %d, but got %#v",
+					workers, expected, i, msgs[i])
+			}
+		}
+	}
+}
+
+func TestParseAllNoErrors(t *testing.T) {
+	t.Parallel()
+
+	msgs, errs := ParseAll([][]byte{minimumInputRFC5424, regularInputRFC5424}, RFC5424, 2)
+	if errs != nil {
+		t.Fatalf("Expected ParseAll() to return no errors, but got %v", errs)
+	} else if len(msgs) != 2 {
+		t.Fatalf("Expected ParseAll() to return 2 messages, but got %d", len(msgs))
+	}
+
+	if msgs, errs := ParseAll(nil, RFC5424, 0); len(msgs) != 0 || errs != nil {
+		t.Fatalf("Expected ParseAll(nil) to return nothing, but got %v and %v", msgs, errs)
+	}
+}
+
+func TestLineError(t *testing.T) {
+	t.Parallel()
+
+	err := &LineError{Line: 2, Err: newFormatError(5, nil, "priority too long")}
+	expected := "syslog: line 2: " + newFormatError(5, nil, "priority too long").Error()
+	if got := err.Error(); got != expected {
+		t.Fatalf("Expected LineError.Error() to return %q, but got %q", expected, got)
+	}
+}
+
+func TestLineErrorUnwrap(t *testing.T) {
+	t.Parallel()
+
+	lines := [][]byte{minimumInputRFC5424, []byte("<191>1 - hostname")}
+	_, errs := ParseAll(lines, RFC5424, 1)
+	if len(errs) != 2 || errs[1] == nil {
+		t.Fatalf("Expected ParseAll to return an error for the second line, but got %v", errs)
+	}
+
+	var formatErr *FormatError
+	if !errors.As(errs[1], &formatErr) {
+		t.Fatalf("Expected the LineError to wrap a *FormatError, but got %#v", errs[1])
+	} else if formatErr.Pos != 17 {
+		t.Fatalf("Expected the FormatError to have position 17, but got %d", formatErr.Pos)
+	} else if !errors.Is(errs[1], ErrTruncated) {
+		t.Fatalf("Expected the LineError to be ErrTruncated, but got %v", errs[1])
+	}
+}
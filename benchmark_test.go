@@ -4,12 +4,19 @@
 
 package syslog
 
-import "testing"
+import (
+	"runtime"
+	"testing"
+)
 
 func BenchmarkParseRFC5424Minimum(b *testing.B) { benchPM(minimumInputRFC5424, RFC5424, b) }
 func BenchmarkParseRFC5424Regular(b *testing.B) { benchPM(regularInputRFC5424, RFC5424, b) }
 func BenchmarkParseRFC5424Long(b *testing.B)    { benchPM(longInputRFC5424, RFC5424, b) }
 
+func BenchmarkParseRFC5424LazyMinimum(b *testing.B) { benchPM(minimumInputRFC5424, RFC5424Lazy, b) }
+func BenchmarkParseRFC5424LazyRegular(b *testing.B) { benchPM(regularInputRFC5424, RFC5424Lazy, b) }
+func BenchmarkParseRFC5424LazyLong(b *testing.B)    { benchPM(longInputRFC5424, RFC5424Lazy, b) }
+
 func BenchmarkParseNginxAccessMinimum(b *testing.B) { benchPM(minimumInputNginxAccess, NginxAccess, b) }
 func BenchmarkParseNginxAccessRegular(b *testing.B) { benchPM(regularInputNginxAccess, NginxAccess, b) }
 func BenchmarkParseNginxAccessLong(b *testing.B)    { benchPM(longInputNginxAccess, NginxAccess, b) }
@@ -18,6 +25,15 @@ func BenchmarkParseNginxErrorMinimum(b *testing.B) { benchPM(minimumInputNginxEr
 func BenchmarkParseNginxErrorRegular(b *testing.B) { benchPM(regularInputNginxError, NginxError, b) }
 func BenchmarkParseNginxErrorLong(b *testing.B)    { benchPM(longInputNginxError, NginxError, b) }
 
+func BenchmarkParseRFC5424RegularInterning(b *testing.B) {
+	parse := NewParser(RFC5424, WithInterning(128))
+	var msg *Message
+	for n := 0; n < b.N; n++ {
+		msg, _ = parse(regularInputRFC5424)
+	}
+	Msg = msg
+}
+
 var Msg *Message
 
 // Benchmark parse message.
@@ -28,3 +44,18 @@ func benchPM(input []byte, format format, b *testing.B) {
 	}
 	Msg = msg
 }
+
+func BenchmarkParseAll1(b *testing.B)          { benchParseAll(1, b) }
+func BenchmarkParseAllGOMAXPROCS(b *testing.B) { benchParseAll(runtime.GOMAXPROCS(0), b) }
+
+func benchParseAll(workers int, b *testing.B) {
+	lines := make([][]byte, 100000)
+	for i := range lines {
+		lines[i] = regularInputRFC5424
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		ParseAll(lines, RFC5424, workers)
+	}
+}
@@ -4,27 +4,58 @@
 
 package syslog
 
-import "io"
+import (
+	"bytes"
+	"io"
+	"sync"
+)
 
-// Buffer is our own custom buffer implementation.
+// Buffer holds the message being parsed and the position up to which it's
+// parsed, it's passed to every ParseFunc of a Format. A ParseFunc reads the
+// part of the message it parses, e.g. using ReadSlice, which advances the
+// position for the next ParseFunc.
+//
+// The bytes returned by the methods are part of the message and only valid
+// until the ParseFunc returns, they must be copied, e.g. by converting them
+// into a string, to be kept.
+//
 // Note: not safe for concurrent use!
-type buffer struct {
+type Buffer struct {
 	bytes    []byte // Do not modify.
 	length   int    // Do not modify.
 	position int
+	base     int // Position of the bytes in the original message, if any.
+	cfg      *config
 }
 
+// buffer is the internal name of Buffer.
+type buffer = Buffer
+
 // Pos returns the current position of the buffer, starts at 1.
-func (buf *buffer) Pos() int {
+func (buf *Buffer) Pos() int {
 	if buf.position == buf.length && buf.length != 0 {
-		return buf.length
+		return buf.base + buf.length
+	}
+	return buf.base + buf.position + 1
+}
+
+// Mark returns the current position of the buffer, which can be restored
+// using Restore. This allows a ParseFunc to look ahead, or try alternatives.
+func (buf *Buffer) Mark() int {
+	return buf.position
+}
+
+// Restore restores the position of the buffer to mark, as returned by Mark.
+func (buf *Buffer) Restore(mark int) {
+	if mark < 0 || mark > buf.length {
+		panic("syslog: Buffer.Restore with invalid mark")
 	}
-	return buf.position + 1
+	buf.position = mark
 }
 
 // Discard discards the given number of bytes. It returns the number of given
 // bytes discarded.
-func (buf *buffer) Discard(n int) (discarded int) {
+func (buf *Buffer) Discard(n int) (discarded int) {
 	if max := buf.maxRead(); n > max {
 		n = max
 	}
@@ -34,7 +65,7 @@ func (buf *buffer) Discard(n int) (discarded int) {
 
 // Peek peeks the next number of bytes. It only returns an io.EOF error if the
 // peek length is greater then the number of bytes remaining.
-func (buf *buffer) Peek(n int) ([]byte, error) {
+func (buf *Buffer) Peek(n int) ([]byte, error) {
 	var err error
 	if max := buf.maxRead(); n > max {
 		n = max
@@ -45,7 +76,7 @@ func (buf *buffer) Peek(n int) ([]byte, error) {
 
 // Readbyte reads a single byte. It only returns an io.EOF error if the buffer
 // is completely read.
-func (buf *buffer) ReadByte() (byte, error) {
+func (buf *Buffer) ReadByte() (byte, error) {
 	if buf.position == buf.length {
 		return 0, io.EOF
 	}
@@ -54,17 +85,19 @@ func (buf *buffer) ReadByte() (byte, error) {
 	return c, nil
 }
 
-// UnreadByte unreads a single byte, it panics if no bytes were read before.
-func (buf *buffer) UnreadByte() {
+// UnreadByte unreads a single byte. It returns a format error if no bytes were
+// read before.
+func (buf *Buffer) UnreadByte() error {
 	if buf.position == 0 {
-		panic("syslog: can't unread byte")
+		return newFormatError(buf.Pos(), nil, "can't unread byte")
 	}
 	buf.position--
+	return nil
 }
 
 // ReadSlice reads until the first appears of the given char. If the character
 // is not found it returns the remaing buffer and io.EOF as error.
-func (buf *buffer) ReadSlice(c byte) ([]byte, error) {
+func (buf *Buffer) ReadSlice(c byte) ([]byte, error) {
 	for i, cc := range buf.bytes[buf.position:] {
 		if cc == c {
 			end := buf.position + i + 1
@@ -79,8 +112,19 @@ func (buf *buffer) ReadSlice(c byte) ([]byte, error) {
 	return buf.bytes[n:], io.EOF
 }
 
+// PeekUntil returns the bytes up to, but not including, the first appearance
+// of the given char, without advancing the position. If the character is not
+// found it returns the remaining buffer and io.EOF as error.
+func (buf *Buffer) PeekUntil(c byte) ([]byte, error) {
+	b := buf.bytes[buf.position:buf.length]
+	if i := bytes.IndexByte(b, c); i != -1 {
+		return b[:i], nil
+	}
+	return b, io.EOF
+}
+
 // ReadAll returns the remaining bytes in the buffer.
-func (buf *buffer) ReadAll() []byte {
+func (buf *Buffer) ReadAll() []byte {
 	bytes := buf.bytes[buf.position:]
 	buf.position = buf.length
 	return bytes
@@ -88,7 +132,7 @@ func (buf *buffer) ReadAll() []byte {
 
 // MaxRead return the maximum number of bytes we can read, aka the number of
 // remaining bytes.
-func (buf *buffer) maxRead() int {
+func (buf *Buffer) maxRead() int {
 	return buf.length - buf.position
 }
 
@@ -99,3 +143,39 @@ func newBuffer(b []byte) *buffer {
 		length: len(b),
 	}
 }
+
+// Reset resets the buffer to read from the given bytes.
+func (buf *Buffer) reset(b []byte) {
+	buf.bytes = b
+	buf.length = len(b)
+	buf.position = 0
+	buf.base = 0
+	buf.cfg = nil
+}
+
+// BufferPool is a pool of buffers, shared by all parsers.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(buffer)
+	},
+}
+
+// GetBuffer gets a buffer from the pool, reset to read from the given bytes
+// using the given configuration. Once done the buffer must be returned using
+// putBuffer.
+func getBuffer(b []byte, cfg *config) *buffer {
+	buf := bufferPool.Get().(*buffer)
+	buf.reset(b)
+	buf.cfg = cfg
+	return buf
+}
+
+// PutBuffer returns the buffer to the pool. The buffer may not be used after
+// calling this function.
+//
+// Note: this means that no parseFunc may retain the buffer, or any slice of
+// the bytes it returned, after returning.
+func putBuffer(buf *Buffer) {
+	buf.reset(nil) // Don't keep the input alive.
+	bufferPool.Put(buf)
+}
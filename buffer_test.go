@@ -48,7 +48,9 @@ func TestBuffer(t *testing.T) {
 		t.Fatalf("Expected the position to be %d, but got %d", expected, got)
 	}
 
-	buf.UnreadByte()
+	if err := buf.UnreadByte(); err != nil {
+		t.Fatalf("Unexpected error buf.UnreadByte(): %s", err.Error())
+	}
 
 	if got, expected := buf.Pos(), 6; got != expected {
 		t.Fatalf("Expected the position to be %d, but got %d", expected, got)
@@ -118,22 +120,20 @@ func TestBufferReadLastByte(t *testing.T) {
 func TestBufferUnreadFirstByte(t *testing.T) {
 	t.Parallel()
 
-	buf := newBuffer([]byte{})
+	buf := newBuffer([]byte("abc"))
 
-	defer func() {
-		recv := recover()
-		if recv == nil {
-			t.Fatal("Expected a panic, but didn't get any")
-		}
-
-		got := recv.(string)
-		expected := "syslog: can't unread byte"
-		if got != expected {
-			t.Fatalf("Expected panic value to be %q, but got %q", expected, got)
-		}
-	}()
+	err := buf.UnreadByte()
+	expected := newFormatError(1, nil, "can't unread byte")
+	if err == nil {
+		t.Fatal("Expected buf.UnreadByte() to return an error, but got nil")
+	} else if got := err.Error(); got != expected.Error() {
+		t.Fatalf("Expected buf.UnreadByte() to return error %q, but got %q",
+			expected.Error(), got)
+	}
 
-	buf.UnreadByte()
+	if got, expected := buf.Pos(), 1; got != expected {
+		t.Fatalf("Expected the position to be %d, but got %d", expected, got)
+	}
 }
 
 func TestBufferReadSliceNotFound(t *testing.T) {
@@ -153,6 +153,35 @@ func TestBufferReadSliceNotFound(t *testing.T) {
 	}
 }
 
+func TestBufferPeekUntil(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input       string
+		expected    string
+		expectedErr error
+	}{
+		{"", "", io.EOF},
+		{"abc", "abc", io.EOF},
+		{" abc", "", nil},
+		{"abc def", "abc", nil},
+	}
+
+	for _, test := range tests {
+		buf := newBuffer([]byte(test.input))
+		b, err := buf.PeekUntil(' ')
+		if err != test.expectedErr || string(b) != test.expected {
+			t.Fatalf("Expected buf.PeekUntil(' ') to return %q and error %v, but got %q and %v",
+				test.expected, test.expectedErr, b, err)
+		}
+
+		if got := string(buf.ReadAll()); got != test.input {
+			t.Fatalf("Expected buf.PeekUntil(' ') to not advance the buffer, but "+
+				"%q remains of %q", got, test.input)
+		}
+	}
+}
+
 func TestBufferPosEmptyBuffer(t *testing.T) {
 	t.Parallel()
 
@@ -161,3 +190,49 @@ func TestBufferPosEmptyBuffer(t *testing.T) {
 		t.Fatalf("Expected the position to be %d, but got %d", expected, got)
 	}
 }
+
+func TestBufferReset(t *testing.T) {
+	t.Parallel()
+
+	buf := newBuffer([]byte("Some message"))
+	buf.Discard(5)
+
+	buf.reset([]byte("Other"))
+	if got, expected := buf.Pos(), 1; got != expected {
+		t.Fatalf("Expected the position to be %d, but got %d", expected, got)
+	}
+	if expected, got := "Other", string(buf.ReadAll()); got != expected {
+		t.Fatalf("Expected buf.ReadAll() to return %s, but got %s", expected, got)
+	}
+}
+
+func TestBufferMarkRestore(t *testing.T) {
+	t.Parallel()
+
+	buf := newBuffer([]byte("Some message"))
+	buf.Discard(5)
+	mark := buf.Mark()
+
+	buf.ReadAll()
+	buf.Restore(mark)
+	if got, expected := buf.Pos(), 6; got != expected {
+		t.Fatalf("Expected the position to be %d, but got %d", expected, got)
+	}
+	if expected, got := "message", string(buf.ReadAll()); got != expected {
+		t.Fatalf("Expected buf.ReadAll() to return %s, but got %s", expected, got)
+	}
+}
+
+func TestBufferPoolAllocations(t *testing.T) {
+	// Warm up the pool.
+	ParseMessage(minimumInputRFC5424, RFC5424)
+
+	// Only the returned Message should be allocated.
+	allocs := testing.AllocsPerRun(100, func() {
+		ParseMessage(minimumInputRFC5424, RFC5424)
+	})
+	if allocs > 1 {
+		t.Fatalf("Expected ParseMessage(%q, RFC5424) to allocate once, but got %v allocations",
+			minimumInputRFC5424, allocs)
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// cefPlaceholder is used for empty CEF header fields.
+const cefPlaceholder = "Unknown"
+
+// CEF severity per syslog severity, CEF uses a scale from 0 (lowest) to 10
+// (highest).
+var cefSeverities = [...]uint8{
+	Emergency:     10,
+	Alert:         9,
+	Critical:      8,
+	Error:         7,
+	Warning:       5,
+	Notice:        3,
+	Informational: 1,
+	Debug:         0,
+}
+
+// CEF formats the message in ArcSight's Common Event Format (CEF), with the
+// given vendor, product and version of the device in the header, e.g.
+//
+//	CEF:0|vendor|product|1.0|msgid|message|5|rt=1443647411000 dvchost=hostname data.name=value
+//
+// The signature id is the MessageID, or the Appname if the message id is
+// empty, the name is the Message. Empty header fields are formatted as
+// "Unknown". The severity is mapped onto the CEF scale of 0 to 10.
+//
+// The extension holds the timestamp (rt), hostname (dvchost), appname (dproc)
+// and process id (dvcpid) if set, followed by the structured data params as
+// dataID.name=value, sorted by data-ID and name.
+func (msg *Message) CEF(vendor, product, version string) []byte {
+	b := []byte("CEF:0")
+	signatureID := msg.MessageID
+	if signatureID == "" {
+		signatureID = msg.Appname
+	}
+	for _, field := range []string{vendor, product, version, signatureID, msg.Message} {
+		b = append(b, '|')
+		b = appendCEFHeader(b, field)
+	}
+
+	b = append(b, '|')
+	if severity := msg.Severity; severity.IsValid() {
+		b = strconv.AppendUint(b, uint64(cefSeverities[severity]), 10)
+	} else {
+		b = append(b, cefPlaceholder...)
+	}
+	b = append(b, '|')
+
+	start := len(b)
+	appendPair := func(key, value string) {
+		if len(b) != start {
+			b = append(b, spaceByte)
+		}
+		b = append(b, key...)
+		b = append(b, equalByte)
+		b = appendCEFExtension(b, value)
+	}
+
+	if !msg.Timestamp.IsZero() {
+		appendPair("rt", strconv.FormatInt(msg.Timestamp.UnixMilli(), 10))
+	}
+	values := []struct{ key, value string }{
+		{"dvchost", msg.Hostname},
+		{"dproc", msg.Appname},
+		{"dvcpid", msg.ProcessID},
+	}
+	for _, v := range values {
+		if v.value != "" {
+			appendPair(v.key, v.value)
+		}
+	}
+
+	var dataIDs, names [maxSortedKeys]string
+	for _, dataID := range getSortedMapMapKeys(msg.Data, dataIDs[:0]) {
+		params := msg.Data[dataID]
+		for _, name := range getSortedMapKeys(params, names[:0]) {
+			key := sanitize(dataID+"."+name, math.MaxInt, isNameByte)
+			appendPair(key, params[name])
+		}
+	}
+
+	return b
+}
+
+// appendCEFHeader appends a CEF header field, escaping pipes and backslashes
+// and replacing newlines with spaces. An empty value is replaced with
+// cefPlaceholder.
+func appendCEFHeader(b []byte, value string) []byte {
+	if value == "" {
+		return append(b, cefPlaceholder...)
+	}
+
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '|', escapeByte:
+			b = append(b, escapeByte, c)
+		case '\n', '\r':
+			b = append(b, spaceByte)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// appendCEFExtension appends a CEF extension value, escaping equal signs,
+// backslashes and newlines.
+func appendCEFExtension(b []byte, value string) []byte {
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case equalByte, escapeByte:
+			b = append(b, escapeByte, c)
+		case '\n':
+			b = append(b, escapeByte, 'n')
+		case '\r':
+			b = append(b, escapeByte, 'r')
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// Prefix of a CEF payload.
+var cefPrefix = []byte("CEF:")
+
+// Names of the CEF header fields in Message.Data["cef"], in order.
+var cefHeaderNames = [...]string{
+	"version",
+	"device_vendor",
+	"device_product",
+	"device_version",
+	"signature_id",
+	"name",
+	"severity",
+}
+
+// ParseCEF parses a CEF payload: "CEF:" followed by the seven pipe-delimited
+// header fields and the extension of key=value pairs, into Data["cef"] and
+// Data["extension"]. The signature id is also set as MessageID and the name
+// as Message. A valid CEF severity replaces the syslog severity, see
+// cefToSeverity.
+func parseCEF(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+	if !bytes.HasPrefix(b, cefPrefix) {
+		return newFormatError(startPos, nil, "expected CEF header")
+	}
+
+	header := make(map[string]string, len(cefHeaderNames))
+	i, ok := parsePipeFields(b, len(cefPrefix), header, cefHeaderNames[:])
+	if !ok {
+		buf.position = buf.length
+		return newFormatError(startPos+len(b), ErrTruncated,
+			"CEF header too short, expected "+strconv.Itoa(len(cefHeaderNames))+" fields")
+	}
+
+	extension, err := parseCEFExtension(b[i:], startPos+i)
+	buf.position = buf.length
+	if err != nil {
+		return err
+	}
+
+	msg.Data = map[string]map[string]string{
+		"cef":       header,
+		"extension": extension,
+	}
+	msg.MessageID = header["signature_id"]
+	msg.Message = header["name"]
+	if severity, ok := cefToSeverity(header["severity"]); ok {
+		msg.Severity = severity
+		if msg.Priority.IsValid() {
+			msg.Priority = CalculatePriority(msg.Facility, severity)
+		}
+	}
+	return nil
+}
+
+// ParsePipeFields parses pipe-delimited fields, starting at index i of b, into
+// header using the given names. The \| and \\ escapes are translated. It
+// returns the index after the last pipe, or false if b ends before all fields
+// are found.
+func parsePipeFields(b []byte, i int, header map[string]string, names []string) (int, bool) {
+	for _, name := range names {
+		start, escaped := i, 0
+		for ; i < len(b) && b[i] != '|'; i++ {
+			if b[i] == escapeByte && i+1 < len(b) && isCEFHeaderEscape(b[i+1]) {
+				escaped++
+				i++
+			}
+		}
+
+		if i >= len(b) {
+			return len(b), false
+		}
+		header[name] = unescape(b[start:i], escaped, isCEFHeaderEscape)
+		i++ // Pipe.
+	}
+	return i, true
+}
+
+// ParseCEFExtension parses the key=value pairs of a CEF extension, pos is the
+// position of b in the message. A value ends at the last space before the
+// next unescaped equal sign.
+func parseCEFExtension(b []byte, pos int) (map[string]string, error) {
+	extension := make(map[string]string)
+	i := skipSpaces(b, 0)
+	for i < len(b) {
+		keyStart := i
+		for i < len(b) && b[i] != equalByte && b[i] != spaceByte {
+			i++
+		}
+		if i == keyStart || i == len(b) || b[i] != equalByte {
+			return nil, newFormatError(pos+keyStart, nil, "expected CEF extension key=value")
+		}
+		key := string(b[keyStart:i])
+		i++ // Equal sign.
+
+		valueStart, valueEnd := i, len(b)
+		for ; i < len(b); i++ {
+			if b[i] == escapeByte {
+				i++
+			} else if b[i] == equalByte {
+				if j := bytes.LastIndexByte(b[valueStart:i], spaceByte); j != -1 {
+					valueEnd = valueStart + j
+					break
+				}
+			}
+		}
+
+		extension[key] = unescapeCEF(bytes.TrimRight(b[valueStart:valueEnd], " "))
+		i = skipSpaces(b, valueEnd)
+	}
+	return extension, nil
+}
+
+// skipSpaces returns the index of the first non-space byte in b, starting at
+// i.
+func skipSpaces(b []byte, i int) int {
+	for i < len(b) && b[i] == spaceByte {
+		i++
+	}
+	return i
+}
+
+// isCEFHeaderEscape checks if the byte is escaped inside a CEF header field.
+func isCEFHeaderEscape(c byte) bool {
+	return c == '|' || c == escapeByte
+}
+
+// unescapeCEF unescapes a CEF extension value, translating \=, \\, \n and \r.
+// Unknown escapes are left intact.
+func unescapeCEF(value []byte) string {
+	if bytes.IndexByte(value, escapeByte) == -1 {
+		return string(value)
+	}
+
+	unescaped := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == escapeByte && i+1 < len(value) {
+			switch value[i+1] {
+			case equalByte, escapeByte:
+				c = value[i+1]
+				i++
+			case 'n':
+				c = '\n'
+				i++
+			case 'r':
+				c = '\r'
+				i++
+			}
+		}
+		unescaped = append(unescaped, c)
+	}
+	return string(unescaped)
+}
+
+// cefToSeverity maps a CEF severity, either a number from 0 to 10 or one of
+// Low, Medium, High and Very-High, onto a syslog severity. It's the reverse of
+// the mapping used by Message.CEF, a number maps to the highest severity with
+// a CEF severity not above it.
+func cefToSeverity(s string) (Severity, bool) {
+	switch strings.ToLower(s) {
+	case "low":
+		s = "3"
+	case "medium":
+		s = "5"
+	case "high":
+		s = "7"
+	case "very-high":
+		s = "9"
+	}
+
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil || n > 10 {
+		return 0, false
+	}
+
+	for severity := Emergency; severity.IsValid(); severity++ {
+		if uint64(cefSeverities[severity]) <= n {
+			return severity, true
+		}
+	}
+	return Debug, true
+}
@@ -0,0 +1,262 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMessageCEF(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Msg                      *Message
+		Vendor, Product, Version string
+		Expected                 string
+	}{
+		{&Message{}, "", "", "", "CEF:0|Unknown|Unknown|Unknown|Unknown|Unknown|10|"},
+		{&Message{Severity: 8}, "vendor", "product", "1.0", "CEF:0|vendor|product|1.0|Unknown|Unknown|Unknown|"},
+		{
+			&Message{
+				Priority:  CalculatePriority(Local7, Warning),
+				Facility:  Local7,
+				Severity:  Warning,
+				Timestamp: time.Date(2015, 9, 30, 23, 10, 11, 123000000, locationCEST),
+				Hostname:  "hostname",
+				Appname:   "appname",
+				ProcessID: "123",
+				MessageID: "msgid",
+				Data: map[string]map[string]string{
+					"data":  {"name": "value"},
+					"data2": {"name": "value"},
+				},
+				Message: "message",
+			},
+			"vendor", "product", "1.0",
+			"CEF:0|vendor|product|1.0|msgid|message|5|rt=1443647411123 dvchost=hostname " +
+				"dproc=appname dvcpid=123 data.name=value data2.name=value",
+		},
+		{
+			&Message{Severity: Debug, Appname: "app|name", Message: "a|b\\c\nd"},
+			"ven|dor", `pro\duct`, "1.0",
+			`CEF:0|ven\|dor|pro\\duct|1.0|app\|name|a\|b\\c d|0|dproc=app|name`,
+		},
+		{
+			&Message{
+				Severity: Informational,
+				Data: map[string]map[string]string{
+					"data": {"equal": "a=b", "pipe": "a|b", "newline": "a\nb\r", "backslash": `a\b`},
+				},
+			},
+			"vendor", "product", "1.0",
+			`CEF:0|vendor|product|1.0|Unknown|Unknown|1|data.backslash=a\\b data.equal=a\=b ` +
+				`data.newline=a\nb\r data.pipe=a|b`,
+		},
+	}
+
+	for _, test := range tests {
+		got := string(test.Msg.CEF(test.Vendor, test.Product, test.Version))
+		if got != test.Expected {
+			t.Fatalf("Expected msg.CEF(%q, %q, %q) to return %q, but got %q",
+				test.Vendor, test.Product, test.Version, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageCEF(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputCEF),
+			&Message{
+				Priority:  CalculatePriority(Local0, Debug),
+				Facility:  Local0,
+				Severity:  Debug,
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.UTC),
+				Hostname:  "h",
+				MessageID: "s",
+				Data: map[string]map[string]string{
+					"cef": {
+						"version":        "0",
+						"device_vendor":  "v",
+						"device_product": "p",
+						"device_version": "1",
+						"signature_id":   "s",
+						"name":           "n",
+						"severity":       "0",
+					},
+					"extension": {},
+				},
+				Message: "n",
+			},
+		},
+		{
+			string(regularInputCEF),
+			&Message{
+				Priority:  CalculatePriority(Local0, Warning),
+				Facility:  Local0,
+				Severity:  Warning,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Hostname:  "dsm",
+				MessageID: "4000000",
+				Data: map[string]map[string]string{
+					"cef": {
+						"version":        "0",
+						"device_vendor":  "Trend Micro",
+						"device_product": "Deep Security Agent",
+						"device_version": "10.0.0",
+						"signature_id":   "4000000",
+						"name":           "Eicar_test_file",
+						"severity":       "6",
+					},
+					"extension": {
+						"cn1":      "1",
+						"cn1Label": "Host ID",
+						"dvchost":  "hostname",
+						"filePath": `C:\Users\trend\Desktop\eicar.exe`,
+						"act":      "Delete",
+						"msg":      "Realtime",
+					},
+				},
+				Message: "Eicar_test_file",
+			},
+		},
+		{
+			`<134>Oct 16 10:31:40 fw CEF:0|Check Point|VPN-1 & FireWall-1|Check Point|Log|https|Unknown|` +
+				`act=Accept dst=10.0.0.2 rt=1508149900000 spt=56080 src=10.0.0.1 dpt=443 proto=6 ` +
+				`msg=a\=b  with\nnewline\\ `,
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 16, 10, 31, 40, 0, time.UTC),
+				Hostname:  "fw",
+				MessageID: "Log",
+				Data: map[string]map[string]string{
+					"cef": {
+						"version":        "0",
+						"device_vendor":  "Check Point",
+						"device_product": "VPN-1 & FireWall-1",
+						"device_version": "Check Point",
+						"signature_id":   "Log",
+						"name":           "https",
+						"severity":       "Unknown",
+					},
+					"extension": {
+						"act":   "Accept",
+						"dst":   "10.0.0.2",
+						"rt":    "1508149900000",
+						"spt":   "56080",
+						"src":   "10.0.0.1",
+						"dpt":   "443",
+						"proto": "6",
+						"msg":   "a=b  with\nnewline\\",
+					},
+				},
+				Message: "https",
+			},
+		},
+		{
+			`<134>Oct 16 10:31:40 host CEF:0|Ven\|dor|Pro\\duct|1.0|100|Name|High|`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Error),
+				Facility:  Local0,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 16, 10, 31, 40, 0, time.UTC),
+				Hostname:  "host",
+				MessageID: "100",
+				Data: map[string]map[string]string{
+					"cef": {
+						"version":        "0",
+						"device_vendor":  "Ven|dor",
+						"device_product": `Pro\duct`,
+						"device_version": "1.0",
+						"signature_id":   "100",
+						"name":           "Name",
+						"severity":       "High",
+					},
+					"extension": {},
+				},
+				Message: "Name",
+			},
+		},
+	}
+
+	parse := NewParser(CEF, WithYear(2015), WithLocation(time.UTC))
+	for _, test := range tests {
+		got, err := parse([]byte(test.Input))
+		if err != nil {
+			t.Fatalf("Unexpected error parse(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected parse(%q) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageCEFErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{"<134>Jan  1 01:01:01 h LEEF:1.0|v|p|1|1|",
+			"syslog: CEF: format incorrect at byte 24: expected CEF header"},
+		{"<134>Jan  1 01:01:01 h CEF:0|v|p|1",
+			"syslog: CEF: format incorrect at byte 35: CEF header too short, expected 7 fields"},
+		{`<134>Jan  1 01:01:01 h CEF:0|v|p|1|s|n\|0|`,
+			"syslog: CEF: format incorrect at byte 43: CEF header too short, expected 7 fields"},
+		{"<134>Jan  1 01:01:01 h CEF:0|v|p|1|s|n|0|key",
+			"syslog: CEF: format incorrect at byte 42: expected CEF extension key=value"},
+		{"<134>Jan  1 01:01:01 h CEF:0|v|p|1|s|n|0|a=b =c",
+			"syslog: CEF: format incorrect at byte 46: expected CEF extension key=value"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), CEF)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestMessageCEFRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for severity := Emergency; severity.IsValid(); severity++ {
+		msg := &Message{
+			Priority:  CalculatePriority(Local0, severity),
+			Facility:  Local0,
+			Severity:  severity,
+			Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+			Hostname:  "host",
+			MessageID: "id",
+			Message:   "name",
+		}
+		input := "<134>Oct 13 12:31:40 host " + string(msg.CEF("vendor", "product", "1.0"))
+
+		got, err := NewParser(CEF, WithYear(2015), WithLocation(time.UTC))([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %q: %s", input, err)
+		} else if got.Severity != severity || got.Priority != msg.Priority {
+			t.Fatalf("Expected parsing %q to return severity %s, but got %s",
+				input, severity, got.Severity)
+		}
+	}
+}
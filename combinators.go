@@ -0,0 +1,178 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"regexp"
+	"time"
+)
+
+// The functions in this file return the ParseFuncs used by the formats of this
+// package, so they can be combined into custom formats. They return the same
+// function as used internally, so Lint recognises them.
+
+// ParsePriority returns a ParseFunc that parses the priority, e.g. "<191>".
+func ParsePriority() ParseFunc { return parsePriority }
+
+// CalculateFacility returns a ParseFunc that sets Message.Facility from the
+// priority, it must follow ParsePriority.
+func CalculateFacility() ParseFunc { return calculateFacility }
+
+// CalculateSeverity returns a ParseFunc that sets Message.Severity from the
+// priority, it must follow ParsePriority.
+func CalculateSeverity() ParseFunc { return calculateSeverity }
+
+// ParseVersion returns a ParseFunc that parses the RFC 5424 version, e.g. "1".
+func ParseVersion() ParseFunc { return parseVersion }
+
+// CheckTimestamp returns a ParseFunc that checks the RFC 3339 timestamp with
+// the stricter rules of RFC 5424 in strict mode, see WithStrict. It must
+// precede ParseTimestamp and doesn't consume anything.
+func CheckTimestamp() ParseFunc { return checkTimestamp }
+
+// ParseTimestamp returns a ParseFunc that parses the timestamp using the first
+// layout, see time.Parse, that matches. A timestamp of a layout without spaces,
+// e.g. time.RFC3339, ends at the next space, otherwise it must be as long as
+// the layout. The "-" nil value is accepted as well.
+func ParseTimestamp(layouts ...string) ParseFunc { return parseTimestamp(layouts...) }
+
+// ParseTimestampIn is like ParseTimestamp, but timestamps without a timezone
+// are parsed in loc, instead of the location set with WithLocation. This is
+// useful for a format of logs produced in a known timezone.
+func ParseTimestampIn(loc *time.Location, layouts ...string) ParseFunc {
+	return parseTimestampIn(loc, layouts...)
+}
+
+// InferYear returns a ParseFunc that adds the year to a timestamp parsed with a
+// layout without a year, e.g. "Jan _2 15:04:05", see WithYear. It must follow
+// ParseTimestamp.
+func InferYear() ParseFunc { return nginxFixTimestamp }
+
+// ParseHostname returns a ParseFunc that parses the hostname, ending at the
+// next space.
+func ParseHostname() ParseFunc { return parseHostname }
+
+// ParseAppname returns a ParseFunc that parses the appname, ending at the next
+// space.
+func ParseAppname() ParseFunc { return parseAppname }
+
+// ParseTag returns a ParseFunc that parses a RFC 3164 tag, e.g. "nginx:" or
+// "sshd[1187]:", into the appname and process id.
+func ParseTag() ParseFunc { return parseTag }
+
+// ParseProcessID returns a ParseFunc that parses the process id, ending at the
+// next space.
+func ParseProcessID() ParseFunc { return parseProcessID }
+
+// ParseMessageID returns a ParseFunc that parses the message id, ending at the
+// next space.
+func ParseMessageID() ParseFunc { return parseMessageID }
+
+// ParseData returns a ParseFunc that parses RFC 5424 structured data, e.g.
+// `[data name="value"]`, or the "-" nil value.
+func ParseData() ParseFunc { return parseData }
+
+// ParseMsg returns a ParseFunc that parses the remainder of the message as
+// Message.Message. It must be the last ParseFunc of a format.
+func ParseMsg() ParseFunc { return parseMsg }
+
+// Discard returns a ParseFunc that discards the next n bytes.
+func Discard(n int) ParseFunc { return discard(n) }
+
+// DiscardByte returns a ParseFunc that discards the next byte, which must be
+// c. Unlike DiscardSpace it always discards a single space, regardless of
+// WithLenientWhitespace.
+func DiscardByte(c byte) ParseFunc { return discardByte(c) }
+
+// Literal returns a ParseFunc that discards the next bytes, which must be
+// exactly s, e.g. "nginx: ".
+func Literal(s string) ParseFunc { return literal(s) }
+
+// DiscardUntil returns a ParseFunc that discards all bytes up to and including
+// c.
+func DiscardUntil(c byte) ParseFunc { return discardUntil(c) }
+
+// DiscardTo returns a ParseFunc that discards all bytes up to, but not
+// including, c, see DiscardUntil.
+func DiscardTo(c byte) ParseFunc { return discardTo(c) }
+
+// DiscardSpace returns a ParseFunc that discards the next byte, which must be
+// a space. With WithLenientWhitespace it discards all spaces and tabs, see
+// SkipWhitespace.
+func DiscardSpace() ParseFunc { return discardSpace }
+
+// SkipWhitespace returns a ParseFunc that discards all spaces and tabs, which
+// must be at least min bytes.
+func SkipWhitespace(min int) ParseFunc { return skipWhitespace(min) }
+
+// Capture returns a ParseFunc that reads the value up to the delim byte, or the
+// end of the message, and stores it in Message.Data[dataID][param], creating
+// the maps if needed. The delim byte is not consumed, see CaptureThrough.
+func Capture(dataID, param string, delim byte) ParseFunc { return capture(dataID, param, delim) }
+
+// CaptureThrough is like Capture, but also consumes the delim byte, which must
+// be present.
+func CaptureThrough(dataID, param string, delim byte) ParseFunc {
+	return captureThrough(dataID, param, delim)
+}
+
+// CaptureQuoted returns a ParseFunc that reads a qouted value, e.g. "value",
+// using the escaping rules of RFC 5424 param values, and stores it in
+// Message.Data[dataID][param], see Capture.
+func CaptureQuoted(dataID, param string) ParseFunc { return captureQouted(dataID, param) }
+
+// Optional returns a ParseFunc that calls fns, unless the message has less than
+// peekLength bytes remaining. If the first ParseFunc is called the others are
+// required.
+func Optional(peekLength int, fns ...ParseFunc) ParseFunc { return optional(peekLength, fns...) }
+
+// OptionalIf returns a ParseFunc that calls fns only if pred returns true for
+// the remainder of the message, e.g. NextIs('['). If the first ParseFunc is
+// called the others are required.
+func OptionalIf(pred func(*Buffer) bool, fns ...ParseFunc) ParseFunc {
+	return optionalIf(pred, fns...)
+}
+
+// NextIs returns a predicate, for OptionalIf, that checks if the next byte is
+// c.
+func NextIs(c byte) func(*Buffer) bool { return nextIs(c) }
+
+// Remaining returns a predicate, for OptionalIf, that checks if at least n
+// bytes remain.
+func Remaining(n int) func(*Buffer) bool { return remaining(n) }
+
+// NonBlank is a predicate, for OptionalIf, that checks if anything other than
+// whitespace remains.
+func NonBlank(buf *Buffer) bool { return nonBlank(buf) }
+
+// Alt returns a ParseFunc that tries the candidates in order, stopping at the
+// first that parses successfully, e.g. for a message with or without a
+// suffix. A failed candidate is rolled back before trying the next one, but
+// values it added to existing maps in Message.Data remain. If all candidates
+// fail the error of the last one is returned.
+func Alt(candidates ...Format) ParseFunc { return alt(candidates...) }
+
+// Many returns a ParseFunc that calls fn repeatedly, e.g. for a list of
+// elements, until fn fails without consuming anything or the end of the
+// message is reached. It fails if fn fails after consuming part of the
+// message, or if fn succeeded less than min times.
+func Many(fn ParseFunc, min int) ParseFunc { return many(fn, min) }
+
+// End returns a ParseFunc that checks that the entire message is parsed.
+func End() ParseFunc { return end }
+
+// Regex returns a ParseFunc that matches the regexp against the start of the
+// remainder of the message, as if it started with "^", and consumes the match.
+// The matched text and submatches, as returned by
+// regexp.Regexp.FindStringSubmatch, are passed to assign, which sets the
+// fields of the message.
+func Regex(re *regexp.Regexp, assign func(match []string, msg *Message) error) ParseFunc {
+	return regex(re, assign)
+}
+
+// RegexData is like Regex, but stores the named submatches in
+// Message.Data[dataID], e.g. `(?P<user>\w+)` is stored as
+// Message.Data[dataID]["user"]. Unnamed and unmatched submatches are ignored.
+func RegexData(re *regexp.Regexp, dataID string) ParseFunc { return regexData(re, dataID) }
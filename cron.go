@@ -0,0 +1,61 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import "strings"
+
+// ParseCron parses the message of a cron log line. The complete line is stored
+// as Message, lines in the "(user) ACTION (payload)" form are also parsed into
+// Data["cron"], see cronData.
+func parseCron(buf *buffer, msg *Message) error {
+	msg.Message = string(buf.bytes[buf.position:buf.length])
+	buf.position = buf.length
+
+	if data := cronData(msg.Message); data != nil {
+		msg.Data = map[string]map[string]string{"cron": data}
+	}
+	return nil
+}
+
+// cronData returns the fields of the cron message, or nil if the message isn't
+// in the "(user) ACTION (payload)" form, e.g. "(root) CMD (run-parts
+// /etc/cron.hourly)". The returned fields are "user", "action" and
+// "command". The payload ends at the last closing parenthesis, so commands
+// containing parentheses are returned completely.
+func cronData(message string) map[string]string {
+	message = strings.TrimRight(message, " ")
+	if !strings.HasPrefix(message, "(") {
+		return nil
+	}
+	user, rest, ok := strings.Cut(message[1:], ") ")
+	if !ok || user == "" {
+		return nil
+	}
+
+	action, payload, ok := strings.Cut(rest, " (")
+	if !ok || !isCronAction(action) || !strings.HasSuffix(payload, ")") {
+		return nil
+	}
+
+	return map[string]string{
+		"user":    user,
+		"action":  action,
+		"command": payload[:len(payload)-1],
+	}
+}
+
+// isCronAction checks if action is a cron action, upper case words, e.g. "CMD"
+// or "BEGIN EDIT".
+func isCronAction(action string) bool {
+	if action == "" || action[0] == spaceByte || action[len(action)-1] == spaceByte {
+		return false
+	}
+	for i := 0; i < len(action); i++ {
+		if c := action[i]; (c < 'A' || c > 'Z') && c != spaceByte {
+			return false
+		}
+	}
+	return true
+}
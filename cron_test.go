@@ -0,0 +1,167 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageCron(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputCron),
+			&Message{
+				Priority:  CalculatePriority(ClockDeamon, Informational),
+				Facility:  ClockDeamon,
+				Severity:  Informational,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+			},
+		},
+		{
+			string(regularInputCron),
+			&Message{
+				Priority:  CalculatePriority(ClockDeamon, Informational),
+				Facility:  ClockDeamon,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "CRON",
+				ProcessID: "1234",
+				Data: map[string]map[string]string{
+					"cron": {"user": "root", "action": "CMD", "command": "run-parts /etc/cron.hourly"},
+				},
+				Message: "(root) CMD (run-parts /etc/cron.hourly)",
+			},
+		},
+		{
+			// Command containing parentheses.
+			`<78>Oct 13 12:31:40 hostname CRON[1235]: (alice) CMD (test -x /usr/bin/backup && (backup --quiet) > /dev/null)`,
+			&Message{
+				Priority:  CalculatePriority(ClockDeamon, Informational),
+				Facility:  ClockDeamon,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "CRON",
+				ProcessID: "1235",
+				Data: map[string]map[string]string{
+					"cron": {"user": "alice", "action": "CMD", "command": "test -x /usr/bin/backup && (backup --quiet) > /dev/null"},
+				},
+				Message: "(alice) CMD (test -x /usr/bin/backup && (backup --quiet) > /dev/null)",
+			},
+		},
+		{
+			`<78>Oct 13 12:31:40 hostname cron[812]: (*system*) RELOAD (/etc/crontab)`,
+			&Message{
+				Priority:  CalculatePriority(ClockDeamon, Informational),
+				Facility:  ClockDeamon,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "cron",
+				ProcessID: "812",
+				Data: map[string]map[string]string{
+					"cron": {"user": "*system*", "action": "RELOAD", "command": "/etc/crontab"},
+				},
+				Message: "(*system*) RELOAD (/etc/crontab)",
+			},
+		},
+		{
+			`<78>Oct 13 12:31:40 hostname crontab[2001]: (alice) BEGIN EDIT (alice)`,
+			&Message{
+				Priority:  CalculatePriority(ClockDeamon, Informational),
+				Facility:  ClockDeamon,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "crontab",
+				ProcessID: "2001",
+				Data: map[string]map[string]string{
+					"cron": {"user": "alice", "action": "BEGIN EDIT", "command": "alice"},
+				},
+				Message: "(alice) BEGIN EDIT (alice)",
+			},
+		},
+		{
+			`<86>Oct 13 12:31:40 hostname CRON[1234]: pam_unix(cron:session): session opened for user root by (uid=0)`,
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization2, Informational),
+				Facility:  SecurityAuthorization2,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "CRON",
+				ProcessID: "1234",
+				Message:   "pam_unix(cron:session): session opened for user root by (uid=0)",
+			},
+		},
+		{
+			`<86>Oct 13 12:31:40 hostname CRON[1234]: pam_unix(cron:session): session closed for user root`,
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization2, Informational),
+				Facility:  SecurityAuthorization2,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "CRON",
+				ProcessID: "1234",
+				Message:   "pam_unix(cron:session): session closed for user root",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Cron)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Cron) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestCronData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected map[string]string
+	}{
+		{"(CRON) INFO (pidfile fd = 3)", map[string]string{
+			"user": "CRON", "action": "INFO", "command": "pidfile fd = 3",
+		}},
+		{"(root) CMD ()", map[string]string{"user": "root", "action": "CMD", "command": ""}},
+		{"(root) CMD (a) ", map[string]string{"user": "root", "action": "CMD", "command": "a"}},
+		{"", nil},
+		{"root CMD (a)", nil},
+		{"() CMD (a)", nil},
+		{"(root)CMD (a)", nil},
+		{"(root) CMD", nil},
+		{"(root) CMD (a", nil},
+		{"(root) cmd (a)", nil},
+		{"(root)  CMD (a)", nil},
+	}
+
+	for _, test := range tests {
+		got := cronData(test.Input)
+		if len(got) != len(test.Expected) || (got == nil) != (test.Expected == nil) {
+			t.Fatalf("Expected cronData(%q) to return %v, but got %v", test.Input, test.Expected, got)
+		}
+		for key, value := range test.Expected {
+			if got[key] != value {
+				t.Fatalf("Expected cronData(%q) to return %v, but got %v", test.Input, test.Expected, got)
+			}
+		}
+	}
+}
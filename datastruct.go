@@ -0,0 +1,296 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	structTag          = "sd"
+	structTagSkip      = "-"
+	structFieldJoin    = "_"
+	structTimestampFmt = time.RFC3339Nano
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// FieldError is an error converting a single data param into a struct field.
+type FieldError struct {
+	Field string // Name of the struct field, nested fields are joined by dots.
+	Param string // Name of the data param.
+	Value string
+	Err   error
+}
+
+func (err *FieldError) Error() string {
+	return "syslog: can't convert data param " + err.Param + "=" +
+		strconv.Quote(err.Value) + " into field " + err.Field + ": " + err.Err.Error()
+}
+
+// StructError is returned by Message.DataStruct if one or more data params
+// could not be converted, it holds an error per field.
+type StructError []*FieldError
+
+func (err StructError) Error() string {
+	msgs := make([]string, len(err))
+	for i, fieldErr := range err {
+		msgs[i] = fieldErr.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// SetDataStruct sets Data[dataID] to the params created from the exported
+// fields of the struct v, which may also be a pointer to a struct. It replaces
+// any params previously set for dataID.
+//
+// The name of the param is the field name, or the name in the `sd:"name"` tag
+// of the field. Fields with the tag `sd:"-"` are skipped. Fields of a nested
+// struct are flattened into the params, their names are prefixed with the name
+// of the nested struct joined by an underscore, e.g. "outer_inner". Embedded
+// structs are flattened without a prefix.
+//
+// The following types are supported and converted into strings:
+//
+//	string: unchanged.
+//	bool: using strconv.FormatBool.
+//	int, int8, int16, int32, int64: using strconv.FormatInt.
+//	uint, uint8, uint16, uint32, uint64: using strconv.FormatUint.
+//	float32, float64: using strconv.FormatFloat, with the 'g' format.
+//	time.Time: in the RFC 3339 format, see time.RFC3339Nano.
+//
+// All other types return an error, without modifying the Message.
+func (msg *Message) SetDataStruct(dataID string, v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return errors.New("syslog: SetDataStruct requires a struct, got " + typeName(v))
+	}
+
+	params := map[string]string{}
+	if err := structToParams(value, "", "", params); err != nil {
+		return err
+	}
+
+	if msg.Data == nil {
+		msg.Data = map[string]map[string]string{}
+	}
+	msg.Data[dataID] = params
+	return nil
+}
+
+// DataStruct populates the struct pointed to by v with the params in
+// Data[dataID], it's the inverse of SetDataStruct and uses the same naming and
+// conversion rules. Fields without a matching param are left unchanged.
+//
+// If a param can't be converted into the type of its field all other fields
+// are still set and a StructError is returned holding an error for each field
+// that failed.
+func (msg *Message) DataStruct(dataID string, v interface{}) error {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return errors.New("syslog: DataStruct requires a non-nil pointer to a " +
+			"struct, got " + typeName(v))
+	}
+
+	var errs StructError
+	if err := paramsToStruct(ptr.Elem(), "", "", msg.Data[dataID], &errs); err != nil {
+		return err
+	} else if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+// typeName returns the name of the type of v, like the %T verb of package fmt.
+func typeName(v interface{}) string {
+	if v == nil {
+		return "<nil>"
+	}
+	return reflect.TypeOf(v).String()
+}
+
+// structField returns the param name of the field and whether or not it
+// should be skipped.
+func structField(field reflect.StructField, prefix string) (string, bool) {
+	if field.PkgPath != "" { // Unexported.
+		return "", true
+	}
+
+	name := field.Name
+	if tag := field.Tag.Get(structTag); tag == structTagSkip {
+		return "", true
+	} else if tag != "" {
+		name = tag
+	}
+
+	if prefix != "" {
+		name = prefix + structFieldJoin + name
+	}
+	return name, false
+}
+
+// isNestedStruct checks if the field should be flattened.
+func isNestedStruct(field reflect.StructField) bool {
+	return field.Type.Kind() == reflect.Struct && field.Type != timeType
+}
+
+func nestedPrefix(field reflect.StructField, name, prefix string) string {
+	if field.Anonymous && field.Tag.Get(structTag) == "" {
+		return prefix
+	}
+	return name
+}
+
+func structToParams(value reflect.Value, prefix, path string, params map[string]string) error {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, skip := structField(field, prefix)
+		if skip {
+			continue
+		}
+		fieldPath := path + field.Name
+
+		if isNestedStruct(field) {
+			err := structToParams(value.Field(i), nestedPrefix(field, name, prefix),
+				fieldPath+".", params)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		param, err := formatField(value.Field(i))
+		if err != nil {
+			return errors.New("syslog: field " + fieldPath + ": " + err.Error())
+		}
+		params[name] = param
+	}
+	return nil
+}
+
+func paramsToStruct(value reflect.Value, prefix, path string, params map[string]string, errs *StructError) error {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, skip := structField(field, prefix)
+		if skip {
+			continue
+		}
+		fieldPath := path + field.Name
+
+		if isNestedStruct(field) {
+			err := paramsToStruct(value.Field(i), nestedPrefix(field, name, prefix),
+				fieldPath+".", params, errs)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !isSupportedField(field.Type) {
+			return errors.New("syslog: field " + fieldPath + ": unsupported type " +
+				field.Type.String())
+		}
+
+		param, ok := params[name]
+		if !ok {
+			continue
+		}
+
+		if err := parseField(value.Field(i), param); err != nil {
+			*errs = append(*errs, &FieldError{
+				Field: fieldPath,
+				Param: name,
+				Value: param,
+				Err:   err,
+			})
+		}
+	}
+	return nil
+}
+
+func isSupportedField(typ reflect.Type) bool {
+	if typ == timeType {
+		return true
+	}
+
+	switch typ.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func formatField(value reflect.Value) (string, error) {
+	if !isSupportedField(value.Type()) {
+		return "", errors.New("unsupported type " + value.Type().String())
+	}
+
+	if value.Type() == timeType {
+		return value.Interface().(time.Time).Format(structTimestampFmt), nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	default: // Float32 and float64.
+		return strconv.FormatFloat(value.Float(), 'g', -1, value.Type().Bits()), nil
+	}
+}
+
+func parseField(value reflect.Value, param string) error {
+	if value.Type() == timeType {
+		t, err := time.Parse(structTimestampFmt, param)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(param)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(param)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(param, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(param, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	default: // Float32 and float64.
+		f, err := strconv.ParseFloat(param, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dataStructInner struct {
+	Port uint16 `sd:"port"`
+}
+
+type DataStructEmbedded struct {
+	Region string `sd:"region"`
+}
+
+type dataStructAll struct {
+	DataStructEmbedded
+	String    string
+	Renamed   string `sd:"renamed_field"`
+	Skipped   string `sd:"-"`
+	unexposed string
+	Bool      bool
+	Int       int
+	Int8      int8
+	Int16     int16
+	Int32     int32
+	Int64     int64
+	Uint      uint
+	Uint8     uint8
+	Uint16    uint16
+	Uint32    uint32
+	Uint64    uint64
+	Float32   float32
+	Float64   float64
+	Time      time.Time
+	Upstream  dataStructInner `sd:"upstream"`
+}
+
+func TestDataStructRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	input := dataStructAll{
+		DataStructEmbedded: DataStructEmbedded{Region: "eu-west"},
+		String:             "value",
+		Renamed:            "renamed",
+		Skipped:            "skipped",
+		unexposed:          "unexposed",
+		Bool:               true,
+		Int:                -1,
+		Int8:               -8,
+		Int16:              -16,
+		Int32:              -32,
+		Int64:              -64,
+		Uint:               1,
+		Uint8:              8,
+		Uint16:             16,
+		Uint32:             32,
+		Uint64:             64,
+		Float32:            0.5,
+		Float64:            0.001,
+		Time:               time.Date(2015, 9, 30, 23, 10, 11, 123, time.UTC),
+		Upstream:           dataStructInner{Port: 8080},
+	}
+
+	var msg Message
+	if err := msg.SetDataStruct("request", &input); err != nil {
+		t.Fatalf("Unexpected error msg.SetDataStruct(): %s", err.Error())
+	}
+
+	expectedParams := map[string]string{
+		"region":        "eu-west",
+		"String":        "value",
+		"renamed_field": "renamed",
+		"Bool":          "true",
+		"Int":           "-1",
+		"Int8":          "-8",
+		"Int16":         "-16",
+		"Int32":         "-32",
+		"Int64":         "-64",
+		"Uint":          "1",
+		"Uint8":         "8",
+		"Uint16":        "16",
+		"Uint32":        "32",
+		"Uint64":        "64",
+		"Float32":       "0.5",
+		"Float64":       "0.001",
+		"Time":          "2015-09-30T23:10:11.000000123Z",
+		"upstream_port": "8080",
+	}
+	if got := msg.Data["request"]; !reflect.DeepEqual(got, expectedParams) {
+		t.Fatalf("Expected msg.SetDataStruct() to set params %v, but got %v",
+			expectedParams, got)
+	}
+
+	var got dataStructAll
+	if err := msg.DataStruct("request", &got); err != nil {
+		t.Fatalf("Unexpected error msg.DataStruct(): %s", err.Error())
+	}
+
+	expected := input
+	expected.Skipped = ""
+	expected.unexposed = ""
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected msg.DataStruct() to return %#v, but got %#v", expected, got)
+	}
+}
+
+func TestSetDataStructUnsupported(t *testing.T) {
+	t.Parallel()
+
+	input := struct {
+		Name  string
+		Items []int
+	}{"name", []int{1}}
+
+	msg := Message{Data: map[string]map[string]string{"data": {"name": "value"}}}
+	err := msg.SetDataStruct("data", input)
+	if err == nil {
+		t.Fatal("Expected msg.SetDataStruct() to return an error, but got nil")
+	} else if expected := "syslog: field Items: unsupported type []int"; err.Error() != expected {
+		t.Fatalf("Expected msg.SetDataStruct() to return error %q, but got %q",
+			expected, err.Error())
+	}
+
+	if got := msg.Data["data"]["name"]; got != "value" {
+		t.Fatal("Expected msg.SetDataStruct() to not modify the message on error")
+	}
+
+	var output struct{ Items []int }
+	if err := msg.DataStruct("data", &output); err == nil {
+		t.Fatal("Expected msg.DataStruct() to return an error, but got nil")
+	}
+
+	expected := "syslog: SetDataStruct requires a struct, got string"
+	if err := msg.SetDataStruct("data", "string"); err == nil || err.Error() != expected {
+		t.Fatalf("Expected msg.SetDataStruct() with a string to return error %q, but got %v",
+			expected, err)
+	}
+	expected = "syslog: DataStruct requires a non-nil pointer to a struct, got struct { Items []int }"
+	if err := msg.DataStruct("data", output); err == nil || err.Error() != expected {
+		t.Fatalf("Expected msg.DataStruct() with a non-pointer to return error %q, but got %v",
+			expected, err)
+	}
+	expected = "syslog: DataStruct requires a non-nil pointer to a struct, got <nil>"
+	if err := msg.DataStruct("data", nil); err == nil || err.Error() != expected {
+		t.Fatalf("Expected msg.DataStruct() with nil to return error %q, but got %v",
+			expected, err)
+	}
+}
+
+func TestDataStructConversionErrors(t *testing.T) {
+	t.Parallel()
+
+	msg := Message{Data: map[string]map[string]string{
+		"data": {
+			"Int":    "abc",
+			"Bool":   "yes please",
+			"String": "value",
+			"Uint8":  "256",
+		},
+	}}
+
+	var output struct {
+		Int    int
+		Bool   bool
+		String string
+		Uint8  uint8
+	}
+	err := msg.DataStruct("data", &output)
+	structErr, ok := err.(StructError)
+	if !ok {
+		t.Fatalf("Expected msg.DataStruct() to return a StructError, but got %#v", err)
+	}
+
+	var fields []string
+	for _, fieldErr := range structErr {
+		fields = append(fields, fieldErr.Field)
+	}
+	if got, expected := strings.Join(fields, ","), "Int,Bool,Uint8"; got != expected {
+		t.Fatalf("Expected errors for fields %s, but got %s", expected, got)
+	}
+
+	if output.String != "value" {
+		t.Fatalf("Expected valid fields to still be set, but got %q", output.String)
+	}
+}
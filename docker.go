@@ -0,0 +1,75 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Appname of Docker messages with a tag of only the container ID.
+const dockerAppname = "docker"
+
+// Length of the container IDs used in tags, the short form of the ID.
+const dockerShortIDLength = 12
+
+// ParseDockerHeader parses the header written by Docker's syslog log driver,
+// which depends on the syslog-format option. Headers starting with version 1
+// are parsed using dockerRFC5424Header, all other headers using
+// dockerRFC3164Header. Both include the message.
+func parseDockerHeader(buf *buffer, msg *Message) error {
+	header := dockerRFC3164Header
+	if b, _ := buf.Peek(2); bytes.Equal(b, []byte("1 ")) {
+		header = dockerRFC5424Header
+	}
+
+	for _, fn := range header {
+		if err := fn(buf, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitDockerTag splits the tag, the Appname, on the first slash, e.g.
+// "docker/web" or "nginx/4f6a0e1c2b3d". The first part remains the Appname,
+// the second part is stored in Data["docker"]["container"]. A tag of only the
+// short container ID is stored as container with "docker" as Appname. The
+// RFC5424 format uses the tag as message id as well, if so MessageID is
+// cleared.
+func splitDockerTag(buf *buffer, msg *Message) error {
+	tag := msg.Appname
+	appname, container, ok := strings.Cut(tag, "/")
+	if !ok && isDockerShortID(tag) {
+		appname, container, ok = dockerAppname, tag, true
+	}
+	if !ok || appname == "" || container == "" {
+		return nil
+	}
+
+	msg.Appname = appname
+	if msg.MessageID == tag {
+		msg.MessageID = ""
+	}
+	if msg.Data == nil {
+		msg.Data = map[string]map[string]string{}
+	}
+	msg.Data["docker"] = map[string]string{"container": container}
+	return nil
+}
+
+// isDockerShortID checks if tag is a short container ID, 12 lower case hex
+// characters.
+func isDockerShortID(tag string) bool {
+	if len(tag) != dockerShortIDLength {
+		return false
+	}
+	for i := 0; i < len(tag); i++ {
+		if c := tag[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageDocker(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputDocker),
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+			},
+		},
+		{
+			string(regularInputDocker),
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Version:   1,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 123456000, time.FixedZone("", 2*60*60)),
+				Hostname:  "hostname",
+				Appname:   "docker",
+				ProcessID: "1234",
+				Data:      map[string]map[string]string{"docker": {"container": "web"}},
+				Message:   "GET / HTTP/1.1 200",
+			},
+		},
+		{
+			// RFC5424 with the container ID as tag.
+			`<27>1 2015-10-13T12:31:40Z hostname 4f6a0e1c2b3d 1234 4f6a0e1c2b3d - panic: runtime error`,
+			&Message{
+				Priority:  CalculatePriority(System, Error),
+				Facility:  System,
+				Severity:  Error,
+				Version:   1,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Hostname:  "hostname",
+				Appname:   "docker",
+				ProcessID: "1234",
+				Data:      map[string]map[string]string{"docker": {"container": "4f6a0e1c2b3d"}},
+				Message:   "panic: runtime error",
+			},
+		},
+		{
+			// RFC3164.
+			`<30>Oct 13 12:31:40 hostname docker/web[1234]: GET / HTTP/1.1 200`,
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "docker",
+				ProcessID: "1234",
+				Data:      map[string]map[string]string{"docker": {"container": "web"}},
+				Message:   "GET / HTTP/1.1 200",
+			},
+		},
+		{
+			// RFC3164 with an image prefix.
+			`<30>Oct 13 12:31:40 hostname nginx/4f6a0e1c2b3d[1234]: started`,
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1234",
+				Data:      map[string]map[string]string{"docker": {"container": "4f6a0e1c2b3d"}},
+				Message:   "started",
+			},
+		},
+		{
+			// Custom tag without a container.
+			`<30>Oct 13 12:31:40 hostname web[1234]: started`,
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "web",
+				ProcessID: "1234",
+				Message:   "started",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Docker)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Docker) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageDockerErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`<30>1 2015-10-13 hostname docker/web 1 docker/web - m`,
+			"syslog: Docker: format incorrect at byte 7: timestamp is not following an accepted format"},
+		{`<30>Oct 13 hostname docker/web[1]: m`,
+			"syslog: Docker: format incorrect at byte 5: timestamp is not following an accepted format"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), Docker)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
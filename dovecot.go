@@ -0,0 +1,160 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import "strings"
+
+// ParseDovecot parses the message of a Dovecot log line, e.g. "imap-login:
+// Login: user=<alice>, method=PLAIN, rip=1.2.3.4, TLS" or "imap(alice):
+// Disconnected: Logged out in=123 out=4567". The service, e.g. "imap-login",
+// replaces the Appname and the user in parentheses, e.g. "alice" in
+// "imap(alice)", is stored in Data["dovecot"]["user"]. The attributes are
+// stored in Data["dovecot"], see dovecotAttributes, and the text before them
+// as Message.
+func parseDovecot(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	s := string(buf.bytes[buf.position:buf.length])
+	buf.position = buf.length
+
+	data := map[string]string{}
+	if service, rest, ok := strings.Cut(s, ": "); ok && isDovecotService(service) {
+		s = rest
+		if name, user, ok := strings.Cut(service, "("); ok {
+			service = name
+			// The remote IP follows the user in auth logs, e.g.
+			// "passwd-file(alice,1.2.3.4)", and a pid and session ID can
+			// follow the parentheses, e.g. "imap(alice)<1234><abc>".
+			user, _, _ = strings.Cut(user, ")")
+			user, rip, _ := strings.Cut(user, ",")
+			if user != "" {
+				data["user"] = user
+			}
+			if rip != "" {
+				data["rip"] = rip
+			}
+		}
+		if len(service) > maxAppNameLength {
+			return newFormatError(startPos, ErrFieldTooLong, "service too long")
+		}
+		msg.Appname = service
+	}
+
+	msg.Message = s
+	for i := 0; i < len(s); i += 2 {
+		j := strings.Index(s[i:], ": ")
+		if j == -1 {
+			// Session logs end with space separated attributes, e.g.
+			// "Disconnected: Logged out in=123 out=4567".
+			msg.Message = dovecotTrailingAttributes(s, data)
+			break
+		}
+		i += j
+		if dovecotAttributes(s[i+2:], data) {
+			msg.Message = s[:i]
+			break
+		}
+	}
+
+	if len(data) != 0 {
+		msg.Data = map[string]map[string]string{"dovecot": data}
+	}
+	return nil
+}
+
+// DovecotAttributes parses the comma-separated attributes in s into data, e.g.
+// "user=<alice>, method=PLAIN, rip=1.2.3.4, TLS". Values enclosed in angle
+// brackets are unwrapped, they may contain commas. Flags without a value, e.g.
+// TLS, have the value "1". It returns false, without modifying data, if s
+// isn't a list of attributes with at least one key=value attribute.
+func dovecotAttributes(s string, data map[string]string) bool {
+	attributes := make(map[string]string, 8)
+	hasValue := false
+	for s != "" {
+		var item string
+		if i := strings.Index(s, "=<"); i > 0 && !strings.Contains(s[:i], ", ") {
+			end := strings.IndexByte(s[i:], '>')
+			if end == -1 {
+				return false
+			}
+			item, s = s[:i+end+1], s[i+end+1:]
+		} else if end := strings.Index(s, ", "); end != -1 {
+			item, s = s[:end], s[end:]
+		} else {
+			item, s = s, ""
+		}
+
+		if s != "" {
+			if !strings.HasPrefix(s, ", ") {
+				return false
+			}
+			s = s[2:]
+		}
+
+		key, value, ok := strings.Cut(item, "=")
+		if !isDovecotKey(key) {
+			return false
+		} else if !ok {
+			value = "1"
+		} else {
+			hasValue = true
+		}
+		attributes[key] = unwrapDovecotValue(value)
+	}
+
+	if !hasValue {
+		return false
+	}
+	for key, value := range attributes {
+		data[key] = value
+	}
+	return true
+}
+
+// dovecotTrailingAttributes parses the space separated key=value attributes at
+// the end of s into data and returns the text before them. The attributes may
+// also be separated by a comma, e.g. "top=0/0, retr=1/512" in POP3 logs.
+func dovecotTrailingAttributes(s string, data map[string]string) string {
+	end := len(s)
+	for end > 0 {
+		start := strings.LastIndexByte(s[:end], spaceByte) + 1
+		key, value, ok := strings.Cut(s[start:end], "=")
+		if !ok || !isDovecotKey(key) {
+			break
+		}
+		data[key] = unwrapDovecotValue(strings.TrimSuffix(value, ","))
+		if end = start; end > 0 {
+			end-- // Space.
+		}
+	}
+	return strings.TrimRight(s[:end], " ")
+}
+
+// unwrapDovecotValue removes the angle brackets around value, if any.
+func unwrapDovecotValue(value string) string {
+	if len(value) >= 2 && value[0] == '<' && value[len(value)-1] == '>' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// isDovecotService checks if s is a Dovecot service, e.g. "imap-login" or
+// "imap(alice)".
+func isDovecotService(s string) bool {
+	name, _, _ := strings.Cut(s, "(")
+	return isDovecotKey(name) && !strings.Contains(s, " ")
+}
+
+// isDovecotKey checks if s is a valid service name or attribute key.
+func isDovecotKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isNginxKeyByte(s[i]) && s[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
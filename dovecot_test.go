@@ -0,0 +1,157 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageDovecot(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputDovecot),
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+			},
+		},
+		{
+			// Login.
+			string(regularInputDovecot),
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "imap-login",
+				Data: map[string]map[string]string{
+					"dovecot": {
+						"user":    "alice",
+						"method":  "PLAIN",
+						"rip":     "1.2.3.4",
+						"lip":     "10.0.0.1",
+						"mpid":    "1234",
+						"TLS":     "1",
+						"session": "Lx9a2b3cQeAKAAAB",
+					},
+				},
+				Message: "Login",
+			},
+		},
+		{
+			// Disconnect, with space separated attributes.
+			`<22>Oct 13 12:31:40 hostname dovecot: imap(alice): Disconnected: Logged out in=123 out=4567`,
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "imap",
+				Data: map[string]map[string]string{
+					"dovecot": {
+						"user": "alice",
+						"in":   "123",
+						"out":  "4567",
+					},
+				},
+				Message: "Disconnected: Logged out",
+			},
+		},
+		{
+			// POP3 disconnect, with a pid and session ID after the user.
+			`<22>Oct 13 12:31:40 hostname dovecot[987]: pop3(bob)<4321><Lx9a2b3cQeAKAAAB>: Disconnected: Logged out top=0/0, retr=1/512, del=1/1, size=512`,
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "pop3",
+				ProcessID: "987",
+				Data: map[string]map[string]string{
+					"dovecot": {
+						"user": "bob",
+						"top":  "0/0",
+						"retr": "1/512",
+						"del":  "1/1",
+						"size": "512",
+					},
+				},
+				Message: "Disconnected: Logged out",
+			},
+		},
+		{
+			// Authentication failure.
+			`<22>Oct 13 12:31:40 hostname dovecot: imap-login: Disconnected (auth failed, 1 attempts in 2 secs): user=<alice>, method=PLAIN, rip=1.2.3.4, lip=10.0.0.1, TLS, session=<Lx9a2b3cQeAKAAAB>`,
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "imap-login",
+				Data: map[string]map[string]string{
+					"dovecot": {
+						"user":    "alice",
+						"method":  "PLAIN",
+						"rip":     "1.2.3.4",
+						"lip":     "10.0.0.1",
+						"TLS":     "1",
+						"session": "Lx9a2b3cQeAKAAAB",
+					},
+				},
+				Message: "Disconnected (auth failed, 1 attempts in 2 secs)",
+			},
+		},
+		{
+			// Authentication failure of the auth process.
+			`<22>Oct 13 12:31:40 hostname dovecot: auth: passwd-file(alice,1.2.3.4): unknown user`,
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "auth",
+				Message:   "passwd-file(alice,1.2.3.4): unknown user",
+			},
+		},
+		{
+			// Without attributes.
+			`<22>Oct 13 12:31:40 hostname dovecot: master: Dovecot v2.2.22 starting up for imap, pop3 (core dumps disabled)`,
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "master",
+				Message:   "Dovecot v2.2.22 starting up for imap, pop3 (core dumps disabled)",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Dovecot)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Dovecot) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
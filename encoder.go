@@ -0,0 +1,252 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Encoder formats messages in the RFC5424 format, its fields control how. The
+// zero value formats messages the same way as Message.Bytes.
+type Encoder struct {
+	// BOM adds the UTF-8 BOM before every message, marking it as UTF-8.
+	// Messages with UTF8 set always get the BOM, so together with the
+	// WithRawMessage option for the Parser the message can be formatted exactly
+	// as it was received.
+	BOM bool
+
+	// Newline adds a newline after the message in WriteTo.
+	Newline bool
+
+	// Truncate makes the encoder fix the fields that Message.Validate reports,
+	// so that the formatted message can always be parsed. Fields that are too
+	// long are truncated, invalid bytes are replaced with an underscore and
+	// invalid UTF-8 with the replacement character. An invalid priority or a
+	// year outside of 0-9999 is clamped and invalid raw structured data is
+	// dropped.
+	Truncate bool
+
+	// UTC formats the timestamp in UTC, rather than in its own location.
+	UTC bool
+
+	// Layout used to format the timestamp, see SetTimestampLayout.
+	timestampLayout string
+}
+
+// Used by Message.Bytes.
+var defaultEncoder = &Encoder{}
+
+// Timestamps used to check the layout in SetTimestampLayout, one with a
+// positive offset and one in UTC.
+var layoutCheckTimestamps = []time.Time{
+	time.Date(2015, 9, 30, 23, 10, 11, 123456789, time.FixedZone("", 2*60*60)),
+	time.Date(2015, 1, 2, 3, 4, 5, 6789, time.UTC),
+}
+
+// SetTimestampLayout sets the layout used to format the timestamp, see
+// time.Time.Format, e.g. "2006-01-02T15:04:05.000Z07:00" for millisecond
+// precision. Defaults to time.RFC3339Nano.
+//
+// It returns an error with kind ErrBadTimestamp if the layout doesn't format
+// timestamps as RFC 5424 requires, e.g. without a timezone or with more than 6
+// digits of fractional seconds, leaving the layout unchanged.
+func (enc *Encoder) SetTimestampLayout(layout string) error {
+	parse := NewParser(RFC5424, WithStrict())
+	for _, timestamp := range layoutCheckTimestamps {
+		formatted := timestamp.Format(layout)
+		msg, err := parse([]byte("<0>1 " + formatted + " - - - - -"))
+		if err != nil {
+			return &InvalidFieldError{Field: "timestamp layout", Err: ErrBadTimestamp,
+				Msg: strconv.Quote(layout) + " formats invalid timestamp " + strconv.Quote(formatted)}
+		}
+
+		// The layout may drop (part of) the fractional seconds, but otherwise
+		// the timestamp must be the same.
+		_, offset := timestamp.Zone()
+		_, gotOffset := msg.Timestamp.Zone()
+		if diff := timestamp.Sub(msg.Timestamp); diff < 0 || diff >= time.Second || offset != gotOffset {
+			return &InvalidFieldError{Field: "timestamp layout", Err: ErrBadTimestamp,
+				Msg: strconv.Quote(layout) + " formats incorrect timestamp " + strconv.Quote(formatted)}
+		}
+	}
+
+	enc.timestampLayout = layout
+	return nil
+}
+
+// Bytes formats the message in a RFC5424 format.
+func (enc *Encoder) Bytes(msg *Message) []byte {
+	return enc.AppendFormat(nil, msg)
+}
+
+// AppendFormat is like Bytes, but appends the formatted message to b and
+// returns the extended buffer. It doesn't allocate if b has enough capacity.
+func (enc *Encoder) AppendFormat(b []byte, msg *Message) []byte {
+	if enc.Truncate {
+		msg = sanitizeMessage(msg, enc.BOM)
+	}
+
+	// Format priority: <pri>, e.g. <0>, <191>
+	b = append(b, priorityStart)
+	b = strconv.AppendUint(b, uint64(msg.priority()), 10)
+	b = append(b, priorityEnd)
+
+	// Add optional version and a space, e.g. 1, 10
+	if msg.Version != 0 {
+		b = strconv.AppendUint(b, uint64(msg.Version), 10)
+	}
+	b = append(b, spaceByte)
+
+	// Add values, with a nil value for a zero value.
+	b = enc.appendTimestamp(b, msg.Timestamp)
+	b = addValue(b, msg.Hostname)
+	b = addValue(b, msg.Appname)
+	b = addValue(b, msg.ProcessID)
+	b = addValue(b, msg.MessageID)
+
+	if msg.Data == nil && msg.RawData != "" {
+		b = append(b, msg.RawData...)
+	} else {
+		b = addData(b, msg.Data)
+	}
+
+	if msg.Message != "" {
+		b = append(b, spaceByte)
+		if enc.BOM || msg.UTF8 {
+			b = append(b, bom...)
+		}
+		b = append(b, msg.Message...)
+	}
+
+	return b
+}
+
+// appendTimestamp appends the timestamp and a space, or the nil value for a
+// zero timestamp.
+func (enc *Encoder) appendTimestamp(b []byte, t time.Time) []byte {
+	if t.IsZero() {
+		return append(b, nilValueByte, spaceByte)
+	}
+
+	if enc.UTC {
+		t = t.UTC()
+	}
+	layout := enc.timestampLayout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	b = t.AppendFormat(b, layout)
+	return append(b, spaceByte)
+}
+
+// WriteTo writes the message in a RFC5424 format to w, followed by a newline
+// if enc.Newline is set. If w provides an AvailableBuffer method, like
+// bufio.Writer, the message is formatted directly into the buffer of w.
+func (enc *Encoder) WriteTo(w io.Writer, msg *Message) (int64, error) {
+	var b []byte
+	if bw, ok := w.(interface{ AvailableBuffer() []byte }); ok {
+		b = bw.AvailableBuffer()
+	}
+
+	b = enc.AppendFormat(b, msg)
+	if enc.Newline {
+		b = append(b, '\n')
+	}
+
+	n, err := w.Write(b)
+	if err == nil && n < len(b) {
+		err = io.ErrShortWrite
+	}
+	return int64(n), err
+}
+
+// BytesRFC3164 formats the message in the older BSD format, RFC3164:
+// "<pri>Mmm dd hh:mm:ss hostname tag[pid]: message". The tag is the appname
+// and the pid the process id, the version and message id are dropped. The
+// structured data is added to the message as `dataID.name="value"` pairs.
+//
+// A zero timestamp is replaced with the current time and an empty hostname
+// with the hostname of the machine.
+func (enc *Encoder) BytesRFC3164(msg *Message) []byte {
+	return enc.AppendRFC3164(nil, msg)
+}
+
+// AppendRFC3164 is like BytesRFC3164, but appends the formatted message to b
+// and returns the extended buffer.
+func (enc *Encoder) AppendRFC3164(b []byte, msg *Message) []byte {
+	b = append(b, priorityStart)
+	b = strconv.AppendUint(b, uint64(msg.priority()), 10)
+	b = append(b, priorityEnd)
+
+	timestamp := msg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	b = timestamp.AppendFormat(b, time.Stamp)
+	b = append(b, spaceByte)
+
+	hostname := msg.Hostname
+	if hostname == "" {
+		hostname = localHostname()
+	}
+	b = append(b, hostname...)
+	b = append(b, spaceByte)
+
+	// Tag and optional pid, e.g. "appname[123]: ".
+	if msg.Appname != "" {
+		b = append(b, msg.Appname...)
+		if msg.ProcessID != "" {
+			b = append(b, '[')
+			b = append(b, msg.ProcessID...)
+			b = append(b, ']')
+		}
+		b = append(b, colonByte, spaceByte)
+	}
+
+	b = append(b, msg.Message...)
+	return appendFlatData(b, msg.Data, msg.Message != "")
+}
+
+// appendFlatData appends the data as ` dataID.name="value"` pairs, the first
+// pair is only prefixed with a space if space is true.
+func appendFlatData(b []byte, data map[string]map[string]string, space bool) []byte {
+	var dataIDs, names [maxSortedKeys]string
+	for _, dataID := range getSortedMapMapKeys(data, dataIDs[:0]) {
+		params := data[dataID]
+		for _, name := range getSortedMapKeys(params, names[:0]) {
+			if space {
+				b = append(b, spaceByte)
+			}
+			space = true
+
+			b = append(b, dataID...)
+			b = append(b, '.')
+			b = append(b, name...)
+			b = append(b, equalByte, qouteByte)
+			value := params[name]
+			for i := 0; i < len(value); i++ {
+				if c := value[i]; c == qouteByte || c == escapeByte {
+					b = append(b, escapeByte)
+				}
+				b = append(b, value[i])
+			}
+			b = append(b, qouteByte)
+		}
+	}
+	return b
+}
+
+// localHostname returns the hostname of the machine, or "localhost" if it
+// can't be determined.
+func localHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "localhost"
+	}
+	return hostname
+}
@@ -0,0 +1,302 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncoder(t *testing.T) {
+	t.Parallel()
+
+	msg := &Message{Version: 1, Message: "message"}
+	tests := []struct {
+		Encoder  *Encoder
+		Expected string
+	}{
+		{&Encoder{}, "<0>1 - - - - - - message"},
+		{&Encoder{BOM: true}, "<0>1 - - - - - - " + string(bom) + "message"},
+	}
+
+	for _, test := range tests {
+		if got := string(test.Encoder.Bytes(msg)); got != test.Expected {
+			t.Fatalf("Expected Encoder%+v.Bytes() to return %q, but got %q",
+				*test.Encoder, test.Expected, got)
+		}
+	}
+}
+
+func TestEncoderRawMessageRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input   string
+		Encoder *Encoder
+	}{
+		{"<191>1 - - - - - - \t\t padded message  ", &Encoder{}},
+		{"<191>1 - - - - - - " + string(bom) + "\t\t padded méssage  ", &Encoder{BOM: true}},
+		{"<191>1 - - - - - - " + string(bom) + "\t\t padded méssage  ", &Encoder{}},
+		{"<191>1 - - - - - -  ", &Encoder{}},
+	}
+
+	parse := NewParser(RFC5424, WithRawMessage())
+	for _, test := range tests {
+		msg, err := parse([]byte(test.Input))
+		if err != nil {
+			t.Fatalf("Unexpected error parse(%q): %s", test.Input, err.Error())
+		}
+
+		if got := string(test.Encoder.Bytes(msg)); got != test.Input {
+			t.Fatalf("Expected the message to round-trip as %q, but got %q",
+				test.Input, got)
+		}
+	}
+}
+
+func TestEncoderRFC3164(t *testing.T) {
+	t.Parallel()
+
+	timestamp := time.Date(2015, 10, 6, 14, 38, 12, 0, time.UTC)
+	tests := []struct {
+		Msg      *Message
+		Expected string
+	}{
+		{
+			&Message{Priority: 13, Timestamp: timestamp, Hostname: "host", Message: "message"},
+			"<13>Oct  6 14:38:12 host message",
+		},
+		{
+			&Message{
+				Priority:  CalculatePriority(Local7, Debug),
+				Version:   1,
+				Timestamp: time.Date(2015, 10, 16, 14, 38, 12, 0, time.UTC),
+				Hostname:  "host",
+				Appname:   "app",
+				MessageID: "msgid",
+				Message:   "message",
+			},
+			"<191>Oct 16 14:38:12 host app: message",
+		},
+		{
+			&Message{
+				Priority:  CalculatePriority(Local7, Debug),
+				Timestamp: timestamp,
+				Hostname:  "host",
+				Appname:   "app",
+				ProcessID: "123",
+				Message:   "message",
+			},
+			"<191>Oct  6 14:38:12 host app[123]: message",
+		},
+		{
+			&Message{
+				Timestamp: timestamp,
+				Hostname:  "host",
+				Appname:   "app",
+				ProcessID: "123",
+				Data: map[string]map[string]string{
+					"data":  {"name": `a "b" \c`, "name2": "value"},
+					"data2": {"name": "value"},
+				},
+				Message: "message",
+			},
+			`<0>Oct  6 14:38:12 host app[123]: message data.name="a \"b\" \\c" data.name2="value" data2.name="value"`,
+		},
+		{
+			&Message{
+				Timestamp: timestamp,
+				Hostname:  "host",
+				Appname:   "app",
+				Data:      map[string]map[string]string{"data": {"name": "value"}},
+			},
+			`<0>Oct  6 14:38:12 host app: data.name="value"`,
+		},
+	}
+
+	for _, test := range tests {
+		if got := string(test.Msg.BytesRFC3164()); got != test.Expected {
+			t.Fatalf("Expected msg.BytesRFC3164() to return %q, but got %q",
+				test.Expected, got)
+		}
+	}
+}
+
+func TestEncoderRFC3164Defaults(t *testing.T) {
+	t.Parallel()
+
+	got := string((&Message{Message: "message"}).BytesRFC3164())
+	if !strings.HasPrefix(got, "<0>") {
+		t.Fatalf("Expected msg.BytesRFC3164() to start with %q, but got %q", "<0>", got)
+	}
+
+	got = got[len("<0>"):]
+	if _, err := time.Parse(time.Stamp, got[:len(time.Stamp)]); err != nil {
+		t.Fatalf("Expected msg.BytesRFC3164() to contain the current time, but got %q: %s",
+			got, err)
+	}
+
+	expected := " " + localHostname() + " message"
+	if got := got[len(time.Stamp):]; got != expected {
+		t.Fatalf("Expected msg.BytesRFC3164() to end with %q, but got %q", expected, got)
+	}
+}
+
+func TestEncoderWriteTo(t *testing.T) {
+	t.Parallel()
+
+	msg := &Message{Version: 1, Message: "message"}
+	tests := []struct {
+		Encoder  *Encoder
+		Expected string
+	}{
+		{&Encoder{}, "<0>1 - - - - - - message"},
+		{&Encoder{Newline: true}, "<0>1 - - - - - - message\n"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		n, err := test.Encoder.WriteTo(&buf, msg)
+		if err != nil {
+			t.Fatalf("Unexpected error Encoder%+v.WriteTo(): %s", *test.Encoder, err)
+		} else if got := buf.String(); got != test.Expected {
+			t.Fatalf("Expected Encoder%+v.WriteTo() to write %q, but got %q",
+				*test.Encoder, test.Expected, got)
+		} else if n != int64(len(test.Expected)) {
+			t.Fatalf("Expected Encoder%+v.WriteTo() to return %d, but got %d",
+				*test.Encoder, len(test.Expected), n)
+		}
+
+		buf.Reset()
+		w := bufio.NewWriter(&buf)
+		n, err = test.Encoder.WriteTo(w, msg)
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error Encoder%+v.WriteTo(): %s", *test.Encoder, err)
+		} else if got := buf.String(); got != test.Expected || n != int64(len(test.Expected)) {
+			t.Fatalf("Expected Encoder%+v.WriteTo() to write %q (%d), but got %q (%d)",
+				*test.Encoder, test.Expected, len(test.Expected), got, n)
+		}
+	}
+}
+
+func TestMessageWriteTo(t *testing.T) {
+	t.Parallel()
+
+	msg := &Message{Version: 1, Message: "message"}
+	var _ io.WriterTo = msg
+
+	var buf bytes.Buffer
+	n, err := msg.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error msg.WriteTo(): %s", err)
+	} else if got, expected := buf.String(), msg.String(); got != expected || n != int64(len(expected)) {
+		t.Fatalf("Expected msg.WriteTo() to write %q (%d), but got %q (%d)",
+			expected, len(expected), got, n)
+	}
+}
+
+// shortWriter writes at most n bytes and returns err.
+type shortWriter struct {
+	n   int
+	err error
+}
+
+func (w shortWriter) Write(b []byte) (int, error) {
+	if len(b) > w.n {
+		return w.n, w.err
+	}
+	return len(b), nil
+}
+
+func TestEncoderWriteToShortWrite(t *testing.T) {
+	t.Parallel()
+
+	errWrite := errors.New("write error")
+	tests := []struct {
+		Writer   shortWriter
+		Expected error
+	}{
+		{shortWriter{5, nil}, io.ErrShortWrite},
+		{shortWriter{5, errWrite}, errWrite},
+		{shortWriter{0, errWrite}, errWrite},
+	}
+
+	msg := &Message{Version: 1, Message: "message"}
+	for _, test := range tests {
+		n, err := msg.WriteTo(test.Writer)
+		if err != test.Expected {
+			t.Fatalf("Expected msg.WriteTo() to return error %v, but got %v", test.Expected, err)
+		} else if n != int64(test.Writer.n) {
+			t.Fatalf("Expected msg.WriteTo() to return %d, but got %d", test.Writer.n, n)
+		}
+	}
+}
+
+func TestEncoderTimestamp(t *testing.T) {
+	t.Parallel()
+
+	timestamp := time.Date(2015, 9, 30, 23, 10, 11, 123456789, locationCEST)
+	tests := []struct {
+		Layout   string
+		UTC      bool
+		Expected string
+	}{
+		{"", false, "2015-09-30T23:10:11.123456789+02:00"},
+		{"", true, "2015-09-30T21:10:11.123456789Z"},
+		{time.RFC3339, false, "2015-09-30T23:10:11+02:00"},
+		{"2006-01-02T15:04:05.000Z07:00", true, "2015-09-30T21:10:11.123Z"},
+		{"2006-01-02T15:04:05.000000Z07:00", false, "2015-09-30T23:10:11.123456+02:00"},
+		{"2006-01-02T15:04:05.999999Z07:00", true, "2015-09-30T21:10:11.123456Z"},
+	}
+
+	for _, test := range tests {
+		enc := &Encoder{UTC: test.UTC}
+		if test.Layout != "" {
+			if err := enc.SetTimestampLayout(test.Layout); err != nil {
+				t.Fatalf("Unexpected error enc.SetTimestampLayout(%q): %s", test.Layout, err)
+			}
+		}
+
+		expected := "<0>1 " + test.Expected + " - - - - -"
+		got := string(enc.Bytes(&Message{Version: 1, Timestamp: timestamp}))
+		if got != expected {
+			t.Fatalf("Expected Encoder{UTC: %t} with layout %q to return %q, but got %q",
+				test.UTC, test.Layout, expected, got)
+		}
+	}
+}
+
+func TestEncoderSetTimestampLayoutInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04:05Z",
+		"2006-01-02T15:04:05+02:00",
+		"2006-01-02 15:04:05Z07:00",
+		"2006-01-02T15:04:05.000000000Z07:00",
+		time.Stamp,
+	}
+
+	for _, layout := range tests {
+		enc := &Encoder{}
+		err := enc.SetTimestampLayout(layout)
+		if !errors.Is(err, ErrBadTimestamp) {
+			t.Fatalf("Expected enc.SetTimestampLayout(%q) to return %q, but got %v",
+				layout, ErrBadTimestamp, err)
+		} else if enc.timestampLayout != "" {
+			t.Fatalf("Expected enc.SetTimestampLayout(%q) to not change the layout", layout)
+		}
+	}
+}
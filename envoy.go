@@ -0,0 +1,49 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import "time"
+
+// Fields of the default Envoy access log format, in order.
+var envoyAccessFields = [...]accessField{
+	{"start_time", apacheBracket},
+	{"request", apacheQouted}, // Split in method, path and protocol.
+	{"response_code", apacheToken},
+	{"response_flags", apacheToken},
+	{"bytes_received", apacheToken},
+	{"bytes_sent", apacheToken},
+	{"duration", apacheToken},
+	{"upstream_service_time", apacheToken},
+	{"x_forwarded_for", apacheQouted},
+	{"user_agent", apacheQouted},
+	{"request_id", apacheQouted},
+	{"authority", apacheQouted},
+	{"upstream_host", apacheQouted},
+}
+
+// Envoy access logs, in the default format.
+var envoyAccessLog = accessLog{
+	server:         "Envoy",
+	dataID:         "envoy",
+	fields:         envoyAccessFields[:],
+	required:       len(envoyAccessFields),
+	timestampField: "start_time",
+	layout:         time.RFC3339Nano,
+}
+
+// ParseEnvoyAccess parses an Envoy access log line in the default format, e.g.
+// `[2015-10-13T12:31:40.123Z] "GET /path HTTP/2" 200 - 0 1234 5 4 "-"
+// "curl/7.43.0" "uuid" "host" "10.0.0.5:8080"`, into Data["envoy"], see
+// envoyAccessFields. The request is stored as method, path and protocol.
+// Fields with the "-" placeholder are not stored. The start_time field is also
+// parsed as Timestamp.
+func parseEnvoyAccess(buf *buffer, msg *Message) error {
+	if err := parseAccessLog(buf, msg, &envoyAccessLog); err != nil {
+		return err
+	}
+
+	splitAccessRequest(msg.Data["envoy"], "path")
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageEnvoyAccess(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputEnvoyAccess),
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.UTC),
+				Hostname:  "h",
+				Appname:   "a",
+				Data: map[string]map[string]string{
+					"envoy": {
+						"start_time":     "2015-01-01T01:01:01Z",
+						"response_code":  "0",
+						"bytes_received": "0",
+						"bytes_sent":     "0",
+						"duration":       "0",
+					},
+				},
+			},
+		},
+		{
+			string(regularInputEnvoyAccess),
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 123000000, time.UTC),
+				Hostname:  "hostname",
+				Appname:   "envoy",
+				ProcessID: "1",
+				Data: map[string]map[string]string{
+					"envoy": {
+						"start_time":            "2015-10-13T12:31:40.123Z",
+						"method":                "GET",
+						"path":                  "/api/users?page=2",
+						"protocol":              "HTTP/1.1",
+						"response_code":         "200",
+						"bytes_received":        "0",
+						"bytes_sent":            "1234",
+						"duration":              "5",
+						"upstream_service_time": "4",
+						"x_forwarded_for":       "10.0.0.1",
+						"user_agent":            "curl/7.43.0",
+						"request_id":            "5f1d6a84-7b5c-4f0c-9b1f-1c2d3e4f5a6b",
+						"authority":             "api.example.com",
+						"upstream_host":         "10.0.0.5:8080",
+					},
+				},
+			},
+		},
+		{
+			// gRPC request, with response flags and without an upstream.
+			`<134>Oct 13 12:31:40 hostname envoy: [2015-10-13T12:31:40.000Z] "POST /helloworld.Greeter/SayHello HTTP/2" 503 UF,URX 12 91 1002 - "-" "grpc-go/1.32.0" "0b7f1c9e-2b4a-4d8e-9c6f-3a5b7d9e1f20" "greeter:50051" "-"`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Hostname:  "hostname",
+				Appname:   "envoy",
+				Data: map[string]map[string]string{
+					"envoy": {
+						"start_time":     "2015-10-13T12:31:40.000Z",
+						"method":         "POST",
+						"path":           "/helloworld.Greeter/SayHello",
+						"protocol":       "HTTP/2",
+						"response_code":  "503",
+						"response_flags": "UF,URX",
+						"bytes_received": "12",
+						"bytes_sent":     "91",
+						"duration":       "1002",
+						"user_agent":     "grpc-go/1.32.0",
+						"request_id":     "0b7f1c9e-2b4a-4d8e-9c6f-3a5b7d9e1f20",
+						"authority":      "greeter:50051",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), EnvoyAccess)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, EnvoyAccess) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageEnvoyAccessErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`<134>Jan  1 01:01:01 h a: [2015-01-01T01:01:01Z] "GET / HTTP/1.1" 200 - 0 0 0`,
+			"syslog: EnvoyAccess: format incorrect at byte 77: unexpected end of message"},
+		{`<134>Jan  1 01:01:01 h a: [13/Oct/2015:12:31:40 +0000] "-" 0 - 0 0 0 - "-" "-" "-" "-" "-"`,
+			"syslog: EnvoyAccess: format incorrect at byte 28: invalid Envoy timestamp"},
+		{`<134>Jan  1 01:01:01 h a: [2015-01-01T01:01:01Z] GET 0 - 0 0 0 - "-" "-" "-" "-" "-"`,
+			"syslog: EnvoyAccess: format incorrect at byte 50: expected byte '\"', but got 'G'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), EnvoyAccess)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Kinds of errors returned by the parser, use errors.Is to check for them.
+var (
+	// ErrTruncated is returned if the message ended before the format was
+	// completely parsed.
+	ErrTruncated = errors.New("syslog: message truncated")
+
+	// ErrBadPriority is the kind of a FormatError returned for an invalid
+	// priority.
+	ErrBadPriority = errors.New("syslog: invalid priority")
+
+	// ErrBadTimestamp is the kind of a FormatError returned for a timestamp
+	// that doesn't follow any of the accepted formats.
+	ErrBadTimestamp = errors.New("syslog: invalid timestamp")
+
+	// ErrFieldTooLong is the kind of a FormatError returned for a field that
+	// is longer than allowed.
+	ErrFieldTooLong = errors.New("syslog: field too long")
+
+	// ErrDuplicate is the kind of a FormatError returned for a duplicate
+	// structured data element or param, see DuplicatePolicy.
+	ErrDuplicate = errors.New("syslog: duplicate structured data")
+
+	// ErrMessageTooLong is the kind of a FormatError returned for a message
+	// that is longer than allowed, see WithMaxMessageLength.
+	ErrMessageTooLong = errors.New("syslog: message too long")
+
+	// ErrTooManyParams is the kind of a FormatError returned for a structured
+	// data element with more params than allowed, see WithMaxParams.
+	ErrTooManyParams = errors.New("syslog: too many data params")
+)
+
+// snippetContext is the number of bytes before and after the error position
+// included in the snippet.
+const snippetContext = 20
+
+// FormatError is returned if a message doesn't follow the format it's parsed
+// with.
+type FormatError struct {
+	Pos int // Position of the error in the message, starts at 1.
+	Msg string
+	Err error // Kind of error, e.g. ErrBadPriority, may be nil.
+
+	// Format is the name of the format the message was parsed with, see
+	// Format.String. It's only set by ParseMessage and Parser, if the format
+	// has a name.
+	Format string
+
+	// Snippet is a copy of the part of the message around Pos, at most 20 bytes
+	// before and after it. SnippetPos is the index of Pos in Snippet. These are
+	// only set by ParseMessage and Parser.
+	Snippet    []byte
+	SnippetPos int
+
+	// Whether or not the message continues before and after the snippet.
+	cutBefore, cutAfter bool
+}
+
+// Error returns the error message. If the snippet is set it's added on a new
+// line, escaping non-printable bytes, with a caret pointing at the position.
+func (err *FormatError) Error() string {
+	msg := "syslog: format incorrect at byte " + strconv.Itoa(err.Pos) + ": " + err.Msg
+	if err.Format != "" {
+		msg = "syslog: " + err.Format + ": " + msg[len("syslog: "):]
+	}
+	if err.Snippet == nil {
+		return msg
+	}
+
+	var prefix, suffix string
+	if err.cutBefore {
+		prefix = "..."
+	}
+	if err.cutAfter {
+		suffix = "..."
+	}
+
+	before := prefix + escapeSnippet(err.Snippet[:err.SnippetPos])
+	return msg + "\n\t" + before + escapeSnippet(err.Snippet[err.SnippetPos:]) +
+		suffix + "\n\t" + strings.Repeat(" ", len(before)) + "^"
+}
+
+// Unwrap returns the kind of error.
+func (err *FormatError) Unwrap() error {
+	return err.Err
+}
+
+// setSnippet sets the snippet from the message the error occurred in.
+func (err *FormatError) setSnippet(b []byte) {
+	index := err.Pos - 1
+	if index < 0 {
+		index = 0
+	} else if index > len(b) {
+		index = len(b)
+	}
+
+	start, end := index-snippetContext, index+snippetContext+1
+	if start < 0 {
+		start = 0
+	}
+	if end > len(b) {
+		end = len(b)
+	}
+
+	err.Snippet = append([]byte{}, b[start:end]...)
+	err.SnippetPos = index - start
+	err.cutBefore = start > 0
+	err.cutAfter = end < len(b)
+}
+
+// escapeSnippet escapes all non-printable bytes.
+func escapeSnippet(b []byte) string {
+	const hex = "0123456789abcdef"
+	escaped := make([]byte, 0, len(b))
+	for _, c := range b {
+		switch {
+		case c >= ' ' && c <= '~':
+			escaped = append(escaped, c)
+		case c == '\n':
+			escaped = append(escaped, `\n`...)
+		case c == '\r':
+			escaped = append(escaped, `\r`...)
+		case c == '\t':
+			escaped = append(escaped, `\t`...)
+		default:
+			escaped = append(escaped, '\\', 'x', hex[c>>4], hex[c&0xf])
+		}
+	}
+	return string(escaped)
+}
+
+// newFormatError creates a new FormatError at the given position, or 0 if the
+// position is unknown, with an optional kind.
+func newFormatError(pos int, kind error, msg string) error {
+	return &FormatError{Pos: pos, Msg: msg, Err: kind}
+}
+
+// newUnexpectedByteError creates a new FormatError for the unexpected byte c at
+// the given position.
+func newUnexpectedByteError(pos int, c byte, expected ...byte) error {
+	msg := "expected byte "
+	for i, e := range expected {
+		if i != 0 {
+			msg += " or "
+		}
+		msg += "'" + string(e) + "'"
+	}
+	return newFormatError(pos, nil, msg+", but got '"+string(c)+"'")
+}
@@ -0,0 +1,170 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormatError(t *testing.T) {
+	t.Parallel()
+
+	err := newFormatError(5, nil, "priority not closed")
+	if got, expected := err.Error(), "syslog: format incorrect at byte 5: priority not closed"; got != expected {
+		t.Fatalf("Expected FormatError.Error() to return %q, but got %q", expected, got)
+	}
+}
+
+func TestParseMessageFormatError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input       string
+		ExpectedPos int
+		ExpectedMsg string
+	}{
+		{"<1923>", 5, "priority too long"},
+		{"<191>1 2015-09-30 - - - - -", 8, "timestamp is not following an accepted format"},
+		{"<191>1 - - - - - -xy", 19, "expected byte ' ', but got 'x'"},
+		{"<191>1 - - - - - -x", 19, "expected byte ' ', but got 'x'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), RFC5424)
+
+		var formatErr *FormatError
+		if !errors.As(err, &formatErr) {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		if formatErr.Pos != test.ExpectedPos || formatErr.Msg != test.ExpectedMsg {
+			t.Fatalf("Expected ParseMessage(%q) to return error at %d: %q, but got %d: %q",
+				test.Input, test.ExpectedPos, test.ExpectedMsg, formatErr.Pos, formatErr.Msg)
+		}
+	}
+}
+
+func TestFormatErrorSnippet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input              string
+		ExpectedSnippet    string
+		ExpectedSnippetPos int
+		ExpectedError      string
+	}{
+		{
+			"<1923>",
+			"<1923>", 4,
+			"syslog: RFC5424: format incorrect at byte 5: priority too long\n\t<1923>\n\t    ^",
+		},
+		{
+			"<191>1 - - - - - -xy",
+			"<191>1 - - - - - -xy", 18,
+			"syslog: RFC5424: format incorrect at byte 19: expected byte ' ', but got 'x'\n\t<191>1 - - - - - -xy\n\t                  ^",
+		},
+		{
+			"<191>1 - hostname-that-is-rather-long app procid msgid -\tmessage that is rather long",
+			"g app procid msgid -\tmessage that is rath", 20,
+			"syslog: RFC5424: format incorrect at byte 57: expected byte ' ', but got '\t'\n\t" +
+				`...g app procid msgid -\tmessage that is rath...` + "\n\t" +
+				"                       ^",
+		},
+		{
+			"<191>1 - - - - - -\x00\xff",
+			"<191>1 - - - - - -\x00\xff", 18,
+			"syslog: RFC5424: format incorrect at byte 19: expected byte ' ', but got '\x00'\n\t" +
+				`<191>1 - - - - - -\x00\xff` + "\n\t" +
+				"                  ^",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), RFC5424)
+
+		var formatErr *FormatError
+		if !errors.As(err, &formatErr) {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		if got := string(formatErr.Snippet); got != test.ExpectedSnippet {
+			t.Fatalf("Expected ParseMessage(%q) to return snippet %q, but got %q",
+				test.Input, test.ExpectedSnippet, got)
+		} else if got := formatErr.SnippetPos; got != test.ExpectedSnippetPos {
+			t.Fatalf("Expected ParseMessage(%q) to return snippet position %d, but got %d",
+				test.Input, test.ExpectedSnippetPos, got)
+		} else if got := formatErr.Error(); got != test.ExpectedError {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.ExpectedError, got)
+		}
+	}
+}
+
+func TestParseMessageErrorKinds(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected error
+	}{
+		{"", ErrTruncated},
+		{"<191>1 - - -", ErrTruncated},
+		{"<1923>", ErrBadPriority},
+		{"<>", ErrBadPriority},
+		{"<a>", ErrBadPriority},
+		{"<191", ErrBadPriority},
+		{"<191>1 2015-09-30 - - - - -", ErrBadTimestamp},
+		{"<191>1 - " + generateString("hostname", maxHostnameLength+1) + " - - - -", ErrFieldTooLong},
+		{"<191>1 - - " + generateString("appname", maxAppNameLength+1) + " - - -", ErrFieldTooLong},
+		{"<191>1 - - - - - [d " + generateString("name", maxDataParamLength+1) + `="v"]`, ErrFieldTooLong},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), RFC5424)
+		if !errors.Is(err, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %v",
+				test.Input, test.Expected, err)
+		}
+	}
+}
+
+func TestParseMessageErrorPositions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{"<191>2", "syslog: RFC5424: format incorrect at byte 6: unexpected end of message"},
+		{"<191>1 - " + generateString("hostname", maxHostnameLength+1) + " - - - -",
+			"syslog: RFC5424: format incorrect at byte 10: hostname too long"},
+		{"<191>1 - - - - - [d " + generateString("name", maxDataParamLength+1) + `="v"]`,
+			"syslog: RFC5424: format incorrect at byte 21: data param name too long"},
+		{`<191>1 - - - - - [data name=value]`,
+			"syslog: RFC5424: format incorrect at byte 29: expected byte '\"', but got 'v'"},
+		{`<191>1 - - - - - [data name="value"x]`,
+			"syslog: RFC5424: format incorrect at byte 36: expected byte ']' or ' ', but got 'x'"},
+		{`<191>1 - - - - - [data name="value"]x`,
+			"syslog: RFC5424: format incorrect at byte 37: expected byte ' ' or '[', but got 'x'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), RFC5424)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
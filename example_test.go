@@ -0,0 +1,110 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Thomasdezeeuw/syslog"
+)
+
+// parseLevel is a custom ParseFunc that parses a level in brackets, e.g.
+// "[WARN]", and replaces the severity.
+func parseLevel(buf *syslog.Buffer, msg *syslog.Message) error {
+	pos := buf.Pos()
+	b, err := buf.ReadSlice(']')
+	if err == io.EOF {
+		return io.EOF
+	} else if b[0] != '[' {
+		return &syslog.FormatError{Pos: pos, Msg: "expected a level"}
+	}
+
+	level := string(bytes.Trim(b, "[]"))
+	if level == "WARN" {
+		level = "warning"
+	}
+	severity, err := syslog.ParseSeverityName(level)
+	if err != nil {
+		return &syslog.FormatError{Pos: pos + 1, Msg: "unknown level " + level}
+	}
+	msg.Severity = severity
+	return nil
+}
+
+func Example_customFormat() {
+	// Format: <34>2015-10-13 12:31:40 web1 app[1187]: [WARN] disk almost full.
+	format := syslog.Format{
+		syslog.ParsePriority(),
+		syslog.CalculateFacility(),
+		syslog.ParseTimestamp("2006-01-02 15:04:05"),
+		syslog.DiscardSpace(),
+		syslog.ParseHostname(),
+		syslog.DiscardSpace(),
+		syslog.ParseTag(),
+		syslog.DiscardSpace(),
+		parseLevel,
+		syslog.ParseMsg(),
+	}
+
+	parse := syslog.NewParser(format, syslog.WithLocation(time.UTC))
+	msg, err := parse([]byte("<34>2015-10-13 12:31:40 web1 app[1187]: [WARN] disk almost full"))
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(msg.Facility, msg.Severity, msg.Timestamp)
+	fmt.Println(msg.Hostname, msg.Appname, msg.ProcessID)
+	fmt.Println(msg.Message)
+	// Output:
+	// Security/authorization Warning 2015-10-13 12:31:40 +0000 UTC
+	// web1 app 1187
+	// disk almost full
+}
+
+// appLog matches the log lines of a proprietary Java application, e.g.
+// "2015-10-13 12:31:40,123 [billing-3] ERROR com.acme.Billing - payment failed".
+var appLog = regexp.MustCompile(`(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d,\d{3}) ` +
+	`\[([^\]]+)\] +(\w+) +(\S+) - (.*)`)
+
+// assignAppLog sets the fields of the message from a match of appLog.
+func assignAppLog(match []string, msg *syslog.Message) error {
+	timestamp, err := time.Parse("2006-01-02 15:04:05,000", match[1])
+	if err != nil {
+		return err
+	}
+	severity, err := syslog.ParseSeverityName(strings.ToLower(match[3]))
+	if err != nil {
+		return err
+	}
+
+	msg.Timestamp = timestamp
+	msg.ProcessID = match[2]
+	msg.Severity = severity
+	msg.Appname = match[4]
+	msg.Message = match[5]
+	return nil
+}
+
+func Example_regexFormat() {
+	format := syslog.Format{syslog.Regex(appLog, assignAppLog)}
+
+	msg, err := syslog.ParseMessage([]byte("2015-10-13 12:31:40,123 [billing-3] ERROR com.acme.Billing - payment failed"), format)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(msg.Severity, msg.Timestamp)
+	fmt.Println(msg.Appname, msg.ProcessID)
+	fmt.Println(msg.Message)
+	// Output:
+	// Error 2015-10-13 12:31:40.123 +0000 UTC
+	// com.acme.Billing billing-3
+	// payment failed
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+)
+
+// Actions of fail2ban action lines, e.g. "[sshd] Ban 203.0.113.7".
+var fail2banActions = map[string]bool{
+	"Ban":    true,
+	"Unban":  true,
+	"Found":  true,
+	"Ignore": true,
+}
+
+// ParseFail2ban parses a fail2ban log line, starting with the component, e.g.
+// "fail2ban.actions [1187]: NOTICE [sshd] Ban 203.0.113.7". fail2ban pads the
+// component and level with spaces, which are skipped. The component is stored
+// as Appname, the pid as ProcessID and the level replaces the severity, like
+// parseNginxLevel. The remainder is stored as Message. For action lines, see
+// fail2banActions, the jail, action and IP address are stored in
+// Data["fail2ban"].
+func parseFail2ban(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+	buf.position = buf.length
+
+	i := bytes.IndexAny(b, " [:")
+	if i == -1 {
+		return io.EOF
+	} else if i == 0 {
+		return newFormatError(startPos, nil, "expected a fail2ban component")
+	} else if i > maxAppNameLength {
+		return newFormatError(startPos, ErrFieldTooLong, "appname too long")
+	}
+	msg.Appname = string(b[:i])
+
+	i = skipSpaces(b, i)
+	if i < len(b) && b[i] == '[' {
+		end := bytes.IndexByte(b[i:], ']')
+		if end == -1 {
+			return io.EOF
+		} else if !isDigits(string(b[i+1 : i+end])) {
+			return newFormatError(startPos+i+1, nil, "invalid fail2ban pid")
+		} else if end-1 > maxProcessIDLength {
+			return newFormatError(startPos+i+1, ErrFieldTooLong, "processID too long")
+		}
+		msg.ProcessID = string(b[i+1 : i+end])
+		i += end + 1
+	}
+	if i >= len(b) {
+		return io.EOF
+	} else if b[i] != ':' {
+		return newUnexpectedByteError(startPos+i, b[i], ':')
+	}
+	i = skipSpaces(b, i+1)
+
+	levelPos := i
+	level, _, _ := bytes.Cut(b[i:], []byte{spaceByte})
+	if len(level) == 0 {
+		return io.EOF
+	}
+	severity, err := ParseSeverityName(string(level))
+	if err != nil {
+		return newFormatError(startPos+levelPos, nil, "unknown fail2ban level '"+escapeSnippet(level)+"'")
+	}
+	if !buf.cfg.PrioritySeverity() {
+		msg.Severity = severity
+		if msg.Priority.IsValid() {
+			msg.Priority = CalculatePriority(msg.Facility, severity)
+		}
+	}
+
+	message := string(b[skipSpaces(b, i+len(level)):])
+	msg.Message = message
+	if data, ok := fail2banAction(message); ok {
+		msg.Data = map[string]map[string]string{"fail2ban": data}
+	}
+	return nil
+}
+
+// fail2banAction returns the jail, action and IP address of an action line,
+// e.g. "[sshd] Ban 203.0.113.7". It returns false if the message isn't an
+// action line.
+func fail2banAction(message string) (map[string]string, bool) {
+	if !strings.HasPrefix(message, "[") {
+		return nil, false
+	}
+	jail, rest, ok := strings.Cut(message[1:], "] ")
+	if !ok || jail == "" {
+		return nil, false
+	}
+	fields := strings.Fields(rest)
+	if len(fields) < 2 || !fail2banActions[fields[0]] || net.ParseIP(fields[1]) == nil {
+		return nil, false
+	}
+	return map[string]string{"jail": jail, "action": fields[0], "ip": fields[1]}, true
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageFail2ban(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputFail2ban),
+			&Message{
+				Priority:  CalculatePriority(Kernel, Informational),
+				Severity:  Informational,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+			},
+		},
+		{
+			string(regularInputFail2ban),
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization, Notice),
+				Facility:  SecurityAuthorization,
+				Severity:  Notice,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "fail2ban.actions",
+				ProcessID: "1187",
+				Data: map[string]map[string]string{
+					"fail2ban": {"jail": "sshd", "action": "Ban", "ip": "203.0.113.7"},
+				},
+				Message: "[sshd] Ban 203.0.113.7",
+			},
+		},
+		{
+			`<38>Oct 13 12:41:40 hostname fail2ban.actions        [1187]: NOTICE  [sshd] Unban 2001:db8::7`,
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization, Notice),
+				Facility:  SecurityAuthorization,
+				Severity:  Notice,
+				Timestamp: inferredDate(10, 13, 12, 41, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "fail2ban.actions",
+				ProcessID: "1187",
+				Data: map[string]map[string]string{
+					"fail2ban": {"jail": "sshd", "action": "Unban", "ip": "2001:db8::7"},
+				},
+				Message: "[sshd] Unban 2001:db8::7",
+			},
+		},
+		{
+			`<38>Oct 13 12:31:39 hostname fail2ban.filter         [1187]: INFO    [sshd] Found 203.0.113.7 - 2015-10-13 12:31:39`,
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization, Informational),
+				Facility:  SecurityAuthorization,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 39, time.Local),
+				Hostname:  "hostname",
+				Appname:   "fail2ban.filter",
+				ProcessID: "1187",
+				Data: map[string]map[string]string{
+					"fail2ban": {"jail": "sshd", "action": "Found", "ip": "203.0.113.7"},
+				},
+				Message: "[sshd] Found 203.0.113.7 - 2015-10-13 12:31:39",
+			},
+		},
+		{
+			// Not an action line.
+			`<38>Oct 13 12:31:40 hostname fail2ban.jail           [1187]: INFO    Jail 'sshd' started`,
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization, Informational),
+				Facility:  SecurityAuthorization,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "fail2ban.jail",
+				ProcessID: "1187",
+				Message:   "Jail 'sshd' started",
+			},
+		},
+		{
+			// Jail, but not an IP address.
+			`<38>Oct 13 12:31:40 hostname fail2ban.actions: WARNING [sshd] 203.0.113.7 already banned`,
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization, Warning),
+				Facility:  SecurityAuthorization,
+				Severity:  Warning,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "fail2ban.actions",
+				Message:   "[sshd] 203.0.113.7 already banned",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Fail2ban)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Fail2ban) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageFail2banErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`<38>Oct 13 12:31:40 hostname fail2ban.actions [1187]`,
+			"syslog: Fail2ban: format incorrect at byte 52: unexpected end of message"},
+		{`<38>Oct 13 12:31:40 hostname fail2ban.actions [pid]: NOTICE`,
+			"syslog: Fail2ban: format incorrect at byte 48: invalid fail2ban pid"},
+		{`<38>Oct 13 12:31:40 hostname fail2ban.actions [1187] NOTICE`,
+			"syslog: Fail2ban: format incorrect at byte 53: expected byte ':', but got ' '"},
+		{`<38>Oct 13 12:31:40 hostname fail2ban.actions [1187]: LOUD [sshd] Ban 203.0.113.7`,
+			"syslog: Fail2ban: format incorrect at byte 55: unknown fail2ban level 'LOUD'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), Fail2ban)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"io"
+	"strings"
+)
+
+// Columns of filterlog lines, as documented by pfSense. The columns start with
+// the columns common to all lines, followed by the columns of the IP version
+// and then the columns of the protocol. Columns without a name are not stored.
+var (
+	filterlogColumns = [...]string{
+		"rule", "", "", "tracker", "interface", "reason", "action", "direction",
+		"ipversion",
+	}
+	filterlogIPv4Columns = [...]string{
+		"", "", "", "", "", "", "", // tos, ecn, ttl, id, offset, flags and protocol id.
+		"proto", "length", "src", "dst",
+	}
+	filterlogIPv6Columns = [...]string{
+		"", "", "", // class, flow label and hop limit.
+		"proto",
+		"", // protocol id.
+		"length", "src", "dst",
+	}
+	filterlogTCPColumns = [...]string{
+		"srcport", "dstport", "datalength", "flags",
+		"", "", "", "", "", // sequence and ACK number, window, urg and options.
+	}
+	filterlogUDPColumns = [...]string{"srcport", "dstport", "datalength"}
+	// The remaining ICMP columns depend on the type.
+	filterlogICMPColumns = [...]string{"icmptype"}
+)
+
+// ParseFilterlog parses the CSV message of the filterlog of the pfSense and
+// OPNsense firewalls, e.g. "5,,,1000000103,igb0,match,block,in,4,0x0,,64,0,0,
+// DF,17,udp,76,1.2.3.4,5.6.7.8,123,123,56", into Data["filterlog"]. Which
+// columns follow the common columns depends on the IP version and protocol,
+// see filterlogColumns. Empty and unknown columns are not stored.
+func parseFilterlog(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+	buf.position = buf.length
+
+	columns := strings.Split(string(b), ",")
+	data := make(map[string]string, 16)
+	rest, ok := addFilterlogColumns(data, columns, filterlogColumns[:])
+	if !ok {
+		return io.EOF
+	}
+
+	switch version := columns[len(filterlogColumns)-1]; version {
+	case "4":
+		rest, ok = addFilterlogColumns(data, rest, filterlogIPv4Columns[:])
+	case "6":
+		rest, ok = addFilterlogColumns(data, rest, filterlogIPv6Columns[:])
+	default:
+		pos := startPos + len(strings.Join(columns[:len(filterlogColumns)-1], ",")) + 1
+		return newFormatError(pos, nil, "unknown IP version '"+escapeSnippet([]byte(version))+"'")
+	}
+	if !ok {
+		return io.EOF
+	}
+
+	switch data["proto"] {
+	case "tcp":
+		_, ok = addFilterlogColumns(data, rest, filterlogTCPColumns[:])
+	case "udp":
+		_, ok = addFilterlogColumns(data, rest, filterlogUDPColumns[:])
+	case "icmp":
+		_, ok = addFilterlogColumns(data, rest, filterlogICMPColumns[:])
+	}
+	if !ok {
+		return io.EOF
+	}
+
+	msg.Data = map[string]map[string]string{"filterlog": data}
+	return nil
+}
+
+// addFilterlogColumns adds the non-empty columns, that have a name in names, to
+// data and returns the remaining columns. It returns false if there are less
+// columns than names.
+func addFilterlogColumns(data map[string]string, columns, names []string) ([]string, bool) {
+	if len(columns) < len(names) {
+		return nil, false
+	}
+
+	for i, name := range names {
+		if name != "" && columns[i] != "" {
+			data[name] = columns[i]
+		}
+	}
+	return columns[len(names):], true
+}
@@ -0,0 +1,225 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageFilterlog(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputFilterlog),
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+				Data:      map[string]map[string]string{"filterlog": {"ipversion": "6"}},
+			},
+		},
+		{
+			// IPv4 TCP.
+			string(regularInputFilterlog),
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "filterlog",
+				ProcessID: "12345",
+				Data: map[string]map[string]string{
+					"filterlog": {
+						"rule":       "5",
+						"tracker":    "1000000103",
+						"interface":  "igb0",
+						"reason":     "match",
+						"action":     "block",
+						"direction":  "in",
+						"ipversion":  "4",
+						"proto":      "tcp",
+						"length":     "60",
+						"src":        "192.168.1.10",
+						"dst":        "10.0.0.1",
+						"srcport":    "51515",
+						"dstport":    "22",
+						"datalength": "0",
+						"flags":      "S",
+					},
+				},
+			},
+		},
+		{
+			// IPv4 UDP.
+			`<134>Oct 13 12:31:40 hostname filterlog: 9,,,1000000105,em0,match,pass,out,4,0x0,,64,0,0,DF,17,udp,76,192.168.1.1,129.6.15.28,123,123,56`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "filterlog",
+				Data: map[string]map[string]string{
+					"filterlog": {
+						"rule":       "9",
+						"tracker":    "1000000105",
+						"interface":  "em0",
+						"reason":     "match",
+						"action":     "pass",
+						"direction":  "out",
+						"ipversion":  "4",
+						"proto":      "udp",
+						"length":     "76",
+						"src":        "192.168.1.1",
+						"dst":        "129.6.15.28",
+						"srcport":    "123",
+						"dstport":    "123",
+						"datalength": "56",
+					},
+				},
+			},
+		},
+		{
+			// IPv4 ICMP, the echo id and sequence are not stored.
+			`<134>Oct 13 12:31:40 hostname filterlog: 7,,,1000000104,igb1,match,block,in,4,0x0,,63,4242,0,none,1,icmp,84,192.168.1.10,8.8.8.8,request,4321,1`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "filterlog",
+				Data: map[string]map[string]string{
+					"filterlog": {
+						"rule":      "7",
+						"tracker":   "1000000104",
+						"interface": "igb1",
+						"reason":    "match",
+						"action":    "block",
+						"direction": "in",
+						"ipversion": "4",
+						"proto":     "icmp",
+						"length":    "84",
+						"src":       "192.168.1.10",
+						"dst":       "8.8.8.8",
+						"icmptype":  "request",
+					},
+				},
+			},
+		},
+		{
+			// IPv6 TCP.
+			`<134>Oct 13 12:31:40 hostname filterlog: 4,,,1000000103,igb0,match,block,in,6,0x00,0x00000,64,tcp,6,40,2001:db8::1,2001:db8::2,51515,443,0,S,123456789,,64800,,mss`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "filterlog",
+				Data: map[string]map[string]string{
+					"filterlog": {
+						"rule":       "4",
+						"tracker":    "1000000103",
+						"interface":  "igb0",
+						"reason":     "match",
+						"action":     "block",
+						"direction":  "in",
+						"ipversion":  "6",
+						"proto":      "tcp",
+						"length":     "40",
+						"src":        "2001:db8::1",
+						"dst":        "2001:db8::2",
+						"srcport":    "51515",
+						"dstport":    "443",
+						"datalength": "0",
+						"flags":      "S",
+					},
+				},
+			},
+		},
+		{
+			// IPv6 ICMP, without protocol columns.
+			`<134>Oct 13 12:31:40 hostname filterlog: 4,,,1000000103,igb0,match,block,in,6,0x00,0x00000,255,ipv6-icmp,58,32,fe80::1,ff02::1`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "filterlog",
+				Data: map[string]map[string]string{
+					"filterlog": {
+						"rule":      "4",
+						"tracker":   "1000000103",
+						"interface": "igb0",
+						"reason":    "match",
+						"action":    "block",
+						"direction": "in",
+						"ipversion": "6",
+						"proto":     "ipv6-icmp",
+						"length":    "32",
+						"src":       "fe80::1",
+						"dst":       "ff02::1",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Filterlog)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Filterlog) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageFilterlogErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`<134>Jan  1 01:01:01 h a: 5,,,1000000103,igb0,match,block,in`,
+			"syslog: Filterlog: format incorrect at byte 60: unexpected end of message"},
+		{`<134>Jan  1 01:01:01 h a: 5,,,1000000103,igb0,match,block,in,5,0x0`,
+			"syslog: Filterlog: format incorrect at byte 62: unknown IP version '5'"},
+		{`<134>Jan  1 01:01:01 h a: 5,,,1000000103,igb0,match,block,in,4,0x0,,64,0,0,DF,17,udp,76,1.2.3.4`,
+			"syslog: Filterlog: format incorrect at byte 95: unexpected end of message"},
+		{`<134>Jan  1 01:01:01 h a: 5,,,1000000103,igb0,match,block,in,4,0x0,,64,0,0,DF,17,udp,76,1.2.3.4,5.6.7.8,123,123`,
+			"syslog: Filterlog: format incorrect at byte 111: unexpected end of message"},
+		{`<134>Jan  1 01:01:01 h a: 5,,,1000000103,igb0,match,block,in,6,0x00,0x00000,64,tcp,6,40,::1,::2,80,443,0,S`,
+			"syslog: Filterlog: format incorrect at byte 106: unexpected end of message"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), Filterlog)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
@@ -6,13 +6,25 @@ package syslog
 
 import "time"
 
-type format []parseFunc
+// Format is a list of ParseFuncs which parse a message in order, each parsing
+// a part of the message, e.g. RFC5424. A custom Format can be created by
+// combining the ParseFuncs of this package, e.g. ParsePriority and
+// ParseTimestamp, with custom ParseFuncs.
+type Format []ParseFunc
+
+// format is the internal name of Format.
+type format = Format
 
 var (
 	// RFC5424 is the format specified in RFC 5424. See
 	// https://tools.ietf.org/html/rfc5424 for more information.
 	RFC5424 = rfc5424Format
 
+	// RFC5424Lazy is the same format as RFC5424, but it doesn't parse the
+	// structured data. Instead the structured data is stored in
+	// Message.RawData, use Message.ParsedData to parse it on first access.
+	RFC5424Lazy = rfc5424LazyFormat
+
 	// NginxAccess is the format to parse Nginx syslog access logs. To allow the
 	// Message.Data to be filled the following `log_format` is required to be
 	// used in the configuration of Nginx:
@@ -32,59 +44,679 @@ var (
 	//
 	// Using this `log_format` the Message.Data["Request"] will be filled with
 	// the data from Nginx. Using the above `log_format` we can access the status
-	// using Message.Data["Request"]["status"].
+	// using Message.Data["Request"]["status"]. The escapes Nginx uses in the
+	// values, e.g. \x22 for a qoute or those of escape=json, are decoded.
+	// Multiple elements, optionally separated by whitespace, are supported,
+	// e.g. '[request ...] [upstream ...]'.
 	//
 	// Note: because Nginx doesn't supply a timezone or year in the logs, the
-	// timezone and current year from the server that is parsing the log is used.
+	// timezone from the server that is parsing the log is used and the year is
+	// inferred from the current time, see WithYear. Use the WithLocation and
+	// WithYear options to set them explicitly.
 	NginxAccess = nginxAccessFormat
 
-	// NginxError is the format to parse Nginx syslog error logs.
+	// NginxError is the format to parse Nginx syslog error logs. The
+	// "pid#tid" that Nginx adds before the message, e.g. "1187#1187", is
+	// stored as ProcessID and the connection id, e.g. "*46", as
+	// Data["data"]["connection"].
+	//
+	// The timestamp Nginx adds to the message, e.g. "2015/10/13 01:31:40", is
+	// used rather than the one in the syslog header, which is only used if the
+	// former can't be parsed. Neither includes a timezone, see the note at
+	// NginxAccess.
+	NginxError = nginxErrorFormat
+
+	// CEF is the format to parse ArcSight's Common Event Format (CEF) wrapped
+	// in a BSD style syslog header, e.g. "<134>Oct 13 12:31:40 host
+	// CEF:0|Vendor|Product|1.0|100|Name|5|src=10.0.0.1 dst=10.0.0.2". The
+	// header fields are stored in Message.Data["cef"], with the names
+	// "version", "device_vendor", "device_product", "device_version",
+	// "signature_id", "name" and "severity". The extension is stored in
+	// Message.Data["extension"]. The signature id is also stored as
+	// MessageID, the name as Message and the CEF severity replaces the
+	// severity of the syslog header.
 	//
 	// Note: please see the note at NginxAccess about the timezone and year for
 	// the parsing of the timestamp.
-	NginxError = nginxErrorFormat
+	CEF = cefFormat
+
+	// LEEF is the format to parse IBM's Log Event Extended Format (LEEF),
+	// versions 1.0 and 2.0, wrapped in a BSD style syslog header, e.g.
+	// "<13>Oct 13 12:31:40 host LEEF:2.0|Vendor|Product|1.0|EventID|^|src=10.0.0.1^dst=10.0.0.2".
+	// The header fields are stored in Message.Data["leef"], with the names
+	// "version", "vendor", "product", "product_version", "event_id" and, if
+	// declared, "delimiter". The attributes are stored in
+	// Message.Data["attributes"]. The event id is also stored as MessageID.
+	//
+	// Note: please see the note at NginxAccess about the timezone and year for
+	// the parsing of the timestamp.
+	LEEF = leefFormat
+
+	// ApacheAccess is the format to parse Apache httpd access logs in the
+	// combined log format, send to syslog using logger, e.g.
+	// `CustomLog "|/usr/bin/logger -t apache" combined`. The fields are stored
+	// in Message.Data["request"], with the names "remote_addr", "remote_user",
+	// "time_local", "request", "status", "body_bytes_sent", "referer" and
+	// "user_agent". Fields with the "-" placeholder are not stored. Lines in
+	// the common log format, without the referer and user agent, are accepted
+	// as well. The timestamp of the line, which includes the year and
+	// timezone, replaces the timestamp of the syslog header.
+	ApacheAccess = apacheAccessFormat
+
+	// ApacheError is the format to parse Apache httpd error logs, of both 2.2
+	// and 2.4, send to syslog using logger, e.g.
+	// `ErrorLog "|/usr/bin/logger -t apache"`. The timestamp of the line,
+	// which includes the year, replaces the timestamp of the syslog header and
+	// the level, e.g. "error", replaces the severity. The trace levels are
+	// mapped onto Debug. The module is stored in Message.Data["data"]["module"]
+	// and the pid and tid as ProcessID, e.g. "1187:140". Other blocks, like
+	// the client, are stored in Message.Data["data"] as well, e.g.
+	// Data["data"]["client"]. The error code, e.g. "AH00126", is stored as
+	// MessageID and the remainder of the line as Message. Only the level is
+	// required, which also allows logs send using `ErrorLog syslog`, which
+	// don't include the timestamp.
+	ApacheError = apacheErrorFormat
+
+	// Postfix is the format to parse the logs of the Postfix mail server. The
+	// sub-program of the tag, e.g. "smtpd" in "postfix/smtpd[1234]:", is
+	// stored in Message.Data["postfix"]["process"] and the Appname is set to
+	// "postfix". The queue ID, e.g. "3F2A41C0A5" or "NOQUEUE", is stored as
+	// MessageID. The key=value attributes that follow it, e.g.
+	// "to=<a@b>, relay=mx[1.2.3.4]:25, status=sent (250 2.0.0 OK)", are stored
+	// in Message.Data["postfix"], keeping the details in parentheses attached
+	// to the value. Lines without attributes, e.g. "connect from ...", store
+	// the text in Message.
+	Postfix = postfixFormat
+
+	// SSHD is the format to parse the logs of the OpenSSH daemon. The message
+	// is always stored in Message. Messages about authentication, starting
+	// with "Accepted", "Failed", "Invalid user" or "Disconnected from", are
+	// also parsed into Message.Data["ssh"], with the fields "result", e.g.
+	// "accepted" or "failed", "method", "user", "invalid_user" ("true"),
+	// "source_ip", "port" and "key_fingerprint", if present in the message.
+	SSHD = sshdFormat
+
+	// Sudo is the format to parse the logs of sudo. The message is always
+	// stored in Message. Messages about running a command, e.g.
+	// "alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/bin/ls", are
+	// also parsed into Message.Data["sudo"], with the invoking user as "user"
+	// and the KEY=VALUE pairs using their key, e.g. "TTY" and "COMMAND". The
+	// command is stored completely, even if it contains " ; ". If the command
+	// was denied, e.g. "alice : user NOT in sudoers ; TTY=pts/0 ; ...", the
+	// reason is stored as "reason" and the severity is raised to Alert.
+	Sudo = sudoFormat
+
+	// Cron is the format to parse the logs of (Vixie) cron. The message is
+	// always stored in Message. Messages in the "(user) ACTION (payload)"
+	// form, e.g. "(root) CMD (run-parts /etc/cron.hourly)", are also parsed
+	// into Message.Data["cron"], with the fields "user", "action", e.g. "CMD"
+	// or "BEGIN EDIT", and "command". The payload ends at the last closing
+	// parenthesis, so commands containing parentheses are stored completely.
+	Cron = cronFormat
+
+	// Netfilter is the format to parse netfilter (iptables) logs, logged by
+	// the kernel. The optional kernel timestamp, e.g. "[12345.678]", is
+	// skipped and the optional log prefix, e.g. "IPTABLES-DROP:", is stored as
+	// MessageID, without the trailing colon. The KEY=VALUE fields, e.g.
+	// "IN=eth0 OUT= SRC=1.2.3.4 PROTO=TCP SYN", are stored in
+	// Message.Data["netfilter"], flags without a value have the value "1" and
+	// empty values are stored as empty strings. Kernel messages without
+	// fields are stored as Message.
+	Netfilter = netfilterFormat
+
+	// Dmesg is the format to parse kernel messages as read from /dev/kmsg or
+	// printed by `dmesg -r`, without a syslog timestamp or hostname, e.g.
+	// "<6>[ 8940.406075] usb 1-1: new high-speed USB device". The optional
+	// uptime, the seconds since boot, is stored as string, to keep its
+	// precision, in Message.Data["kernel"]["uptime"], e.g. "8940.406075". The
+	// subsystem before the colon, e.g. "usb", is stored as Appname and the
+	// device, e.g. "1-1", in Message.Data["kernel"]["device"]. The remainder
+	// is stored as Message.
+	Dmesg = dmesgFormat
+
+	// JournalJSON is the format to parse systemd journal entries as written
+	// by `journalctl -o json`, a JSON object per line, see ParseJournalJSON.
+	// The journal fields MESSAGE, PRIORITY, SYSLOG_FACILITY,
+	// SYSLOG_IDENTIFIER, _PID, _HOSTNAME, MESSAGE_ID and __REALTIME_TIMESTAMP
+	// are mapped onto Message, all other fields are stored in
+	// Message.Data["journal"]. If SYSLOG_IDENTIFIER or _PID is missing _COMM
+	// and SYSLOG_PID are used instead, and if SYSLOG_FACILITY is missing the
+	// facility is UserLevel. The Priority is only set if the entry has a
+	// PRIORITY. For fields with multiple values, which the journal writes as
+	// an array, the first value is used and the other values are stored in
+	// Message.Data["journal"], see Message.ParamValues to get all of them.
+	JournalJSON = journalJSONFormat
+
+	// Docker is the format to parse logs of Docker containers, send using the
+	// syslog log driver (--log-driver=syslog). Both the RFC5424 and RFC3164
+	// headers, depending on the syslog-format option, are supported. The tag
+	// is split on the first slash, e.g. "docker/web", the first part is
+	// stored as Appname and the container name or ID in
+	// Message.Data["docker"]["container"]. A tag of only the short container
+	// ID, the default, uses "docker" as Appname. The pid of the Docker daemon,
+	// e.g. "docker/web[1234]:", is stored as ProcessID. In the RFC5424 format
+	// the tag is also used as message id, it's not stored as MessageID.
+	Docker = dockerFormat
+
+	// Logplex is the format to parse the frames of Heroku's Logplex log
+	// drains, RFC5424 messages prefixed with their length in bytes, e.g.
+	// "83 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed".
+	// The frame must be exactly the length of the octet count. The dyno, e.g.
+	// "web.3", is stored as ProcessID. Logplex doesn't send structured data,
+	// the nil value "-" is accepted but optional. If the message is in the
+	// logfmt format, e.g. `at=info method=GET path="/" status=200`, the pairs
+	// are also stored in Message.Data["logplex"]. Use SplitLogplexFrames to
+	// split the body of a drain request, which holds multiple frames.
+	Logplex = logplexFormat
+
+	// RFC3164 is the format to parse the older BSD syslog format, e.g.
+	// "<30>Oct 13 12:31:40 hostname app[123]: message". It's lenient, to
+	// support the variants written by e.g. Busybox and embedded devices. The
+	// timestamp can also be a RFC 3339 timestamp, e.g.
+	// "2015-10-13T12:31:40+02:00", of the RFC 3164 timestamp the year is
+	// inferred. The hostname is optional, if the value after the timestamp
+	// ends with a colon, e.g. "app:", it's parsed as tag.
+	RFC3164 = rfc3164Format
+
+	// FortiGate is the format to parse logs of FortiGate firewalls, which
+	// only have a priority followed by key=value pairs, e.g.
+	// `<189>date=2015-10-13 time=12:31:40 devname="FGT60E" level="notice"`.
+	// The pairs are stored in Message.Data["fortigate"], values can be
+	// qouted to contain spaces. Duplicate keys are handled like duplicate
+	// structured data params, see WithDuplicates. The date and time keys,
+	// and the tz key if present, are parsed as Timestamp, devname is stored
+	// as Hostname and the level, e.g. "warning", replaces the severity.
+	FortiGate = fortiGateFormat
+
+	// Filterlog is the format to parse the firewall logs of pfSense and
+	// OPNsense, logged by filterlog as CSV, e.g. `<134>Oct 13 12:31:40
+	// hostname filterlog: 5,,,1000000103,igb0,match,block,in,4,...`. The
+	// columns depend on the IP version and protocol, the named columns, e.g.
+	// rule, interface, action, direction, proto, src, dst, srcport, dstport
+	// and (TCP) flags, are stored in Message.Data["filterlog"]. Empty columns
+	// are not stored.
+	Filterlog = filterlogFormat
+
+	// Dovecot is the format to parse the logs of the Dovecot IMAP and POP3
+	// server. The service, e.g. "imap-login" in "dovecot: imap-login: Login:
+	// user=<alice>, rip=1.2.3.4, TLS", is stored as Appname and a user in
+	// parentheses, e.g. "imap(alice)", in Message.Data["dovecot"]["user"].
+	// The comma-separated attributes, or the space separated attributes at
+	// the end of session logs, e.g. "Logged out in=123 out=4567", are stored
+	// in Message.Data["dovecot"], without the angle brackets around values.
+	// Flags, e.g. "TLS", have the value "1". The text before the attributes
+	// is stored as Message.
+	Dovecot = dovecotFormat
+
+	// Postgres is the format to parse the logs of PostgreSQL, with
+	// log_destination=syslog. The line and chunk number of the marker, e.g.
+	// 3 and 1 in "[3-1]", are stored in Message.Data["postgres"]["line"] and
+	// Message.Data["postgres"]["chunk"]. The level, e.g. "LOG" or "ERROR",
+	// is stored in Message.Data["postgres"]["level"] and replaces the
+	// severity, the log_line_prefix before it in
+	// Message.Data["postgres"]["prefix"]. The text is stored as Message.
+	// PostgreSQL splits long lines into multiple chunks, use
+	// PostgresReassembler to merge them.
+	Postgres = postgresFormat
+
+	// MySQLError is the format to parse the error logs of MySQL and MariaDB,
+	// e.g. "2015-10-13T12:31:40.123456Z 12 [Warning] [MY-010055] [Server]
+	// IP address '1.2.3.4' could not be resolved". The timestamp, of MySQL
+	// 5.7 and newer, MariaDB or the older "151013 12:31:40" style, replaces
+	// the Timestamp. The thread id is stored as ProcessID and the level,
+	// e.g. "Warning", replaces the severity. The error code of MySQL 8.0 is
+	// stored as MessageID and the subsystem in
+	// Message.Data["mysql"]["subsystem"]. The remainder is stored as Message.
+	MySQLError = mysqlErrorFormat
+
+	// Redis is the format to parse the logs of Redis, e.g. "1187:M 13 Oct
+	// 2015 12:31:40.123 * Background saving started by pid 1200". The pid is
+	// stored as ProcessID and the role, e.g. "master" or "sentinel", in
+	// Message.Data["redis"]["role"]. The timestamp replaces the Timestamp and
+	// the level symbol replaces the severity: "#" is Warning, "*" Notice,
+	// "-" Informational and "." Debug. The remainder is stored as Message.
+	// The format of Redis before 3.0, "[1187] 13 Oct 12:31:40.123 * ...",
+	// without the role and year, is also accepted.
+	Redis = redisFormat
+
+	// PHPFPM is the format to parse the logs of php-fpm, e.g.
+	// `[13-Oct-2015 12:31:40] WARNING: [pool www] child 1187 said into
+	// stderr: "PHP message: ..."`. The optional timestamp replaces the
+	// Timestamp and the level, e.g. "WARNING", replaces the severity. The
+	// pool is stored in Message.Data["phpfpm"]["pool"] and the pid of the
+	// child as ProcessID. The remainder is stored as Message, the outer
+	// qoutes of the stderr output of children are removed. Lines without a
+	// timestamp, level or pool are continuation lines, see
+	// PHPFPMReassembler.
+	PHPFPM = phpfpmFormat
+
+	// GunicornAccess is the format to parse the access logs of Gunicorn, e.g.
+	// `gunicorn.access: 10.0.0.1 - - [13/Oct/2015:12:31:40 +0200] "GET /api
+	// HTTP/1.1" 200 123 "-" "python-requests/2.7" 4567`. The fields are
+	// stored in Message.Data["request"] like ApacheAccess, the optional
+	// trailing duration of the request, in microseconds, is stored as
+	// "duration_us".
+	GunicornAccess = gunicornAccessFormat
+
+	// UWSGIAccess is the format to parse the default request logs of uWSGI,
+	// e.g. "[pid: 1187|app: 0|req: 1/1] 10.0.0.1 () {34 vars in 512 bytes}
+	// [Tue Oct 13 12:31:40 2015] GET /api => generated 123 bytes in 12 msecs
+	// (HTTP/1.1 200) 2 headers in 79 bytes (1 switches on core 0)". The pid
+	// is stored as ProcessID and the request in Message.Data["request"],
+	// using the same names as ApacheAccess, with the duration, in
+	// milliseconds, stored as "duration_ms".
+	UWSGIAccess = uwsgiAccessFormat
+
+	// EnvoyAccess is the format to parse the access logs of Envoy, in the
+	// default format, e.g. `[2015-10-13T12:31:40.123Z] "GET /path HTTP/2" 200
+	// - 0 1234 5 4 "-" "curl/7.43.0" "uuid" "host" "10.0.0.5:8080"`. The
+	// fields are stored in Message.Data["envoy"], with the request split in
+	// method, path and protocol. Fields with the "-" placeholder are not
+	// stored. The start time is parsed as Timestamp.
+	EnvoyAccess = envoyAccessFormat
+
+	// ALBAccess is the format to parse the access logs of AWS Application
+	// Load Balancers, as stored in S3, e.g. `https
+	// 2015-10-13T12:31:40.123456Z app/my-alb/abc 1.2.3.4:5678 10.0.0.5:80
+	// 0.001 0.002 0.000 200 200 123 456 "GET https://example.com:443/
+	// HTTP/1.1" "curl/7.43.0" ...`. The fields are stored in
+	// Message.Data["alb"], using the names of the AWS documentation, with the
+	// request split in method, url and protocol. Fields with the "-"
+	// placeholder are not stored. The time is parsed as Timestamp. Lines of
+	// Classic Load Balancers are detected and parsed like ELBAccess.
+	ALBAccess = albAccessFormat
+
+	// ELBAccess is the format to parse the access logs of AWS Classic Load
+	// Balancers, as stored in S3, e.g. `2015-10-13T12:31:40.123456Z my-elb
+	// 1.2.3.4:5678 10.0.0.5:80 0.00004 0.001 0.00003 200 200 0 29 "GET
+	// http://example.com:80/ HTTP/1.1" "curl/7.43.0" - -`. The fields are
+	// stored in Message.Data["elb"], like ALBAccess.
+	ELBAccess = elbAccessFormat
+
+	// Audit is the format to parse Linux audit records forwarded through
+	// syslog, e.g. `audit[1187]: type=SYSCALL
+	// msg=audit(1444733500.123:456): arch=c000003e syscall=59 success=yes
+	// key="exec-log"`. The record type is stored as MessageID and the
+	// timestamp in the audit(...) wrapper as Timestamp. The serial and the
+	// fields are stored in Message.Data["audit"], including the fields of
+	// the msg='...' field of user space records. Hex encoded fields, e.g.
+	// proctitle, are decoded.
+	Audit = auditFormat
+
+	// Fail2ban is the format to parse the logs of fail2ban, e.g.
+	// "fail2ban.actions [1187]: NOTICE [sshd] Ban 203.0.113.7". The
+	// component, e.g. fail2ban.actions, is stored as Appname and the level
+	// replaces the severity. The message after the level is stored as
+	// Message. For Ban, Unban, Found and Ignore lines the jail, action and IP
+	// address are also stored in Message.Data["fail2ban"].
+	Fail2ban = fail2banFormat
+
+	// Keepalived is the format to parse the logs of keepalived, e.g.
+	// "Keepalived_vrrp[1187]: VRRP_Instance(VI_1) Transition to MASTER
+	// STATE". The message is stored as Message. State transitions, priority
+	// changes and failed scripts and health checks are classified in
+	// Message.Data["vrrp"], with the name of the instance or group, see
+	// parseKeepalived.
+	Keepalived = keepalivedFormat
 )
 
+// NginxAccessWith returns the NginxAccess format, which parses the timestamp
+// using the given options, e.g. WithLocation and WithYear. The options take
+// precedence over the options of the Parser, which allows parsing logs of
+// producers in different timezones, or historical logs, with a single Parser.
+//
+// The returned format is named "NginxAccess", see Format.String. Every call
+// records the name of the new format, so create it once, not per message.
+func NginxAccessWith(opts ...Option) Format {
+	return formatWith(nginxAccessFormat, opts)
+}
+
+// NginxErrorWith returns the NginxError format, which parses the timestamps
+// using the given options, see NginxAccessWith. The returned format is named
+// "NginxError".
+func NginxErrorWith(opts ...Option) Format {
+	return formatWith(nginxErrorFormat, opts)
+}
+
+// formatWith returns a copy of the format, with the options applied to the
+// stages that parse the timestamp. The copy has the same name as the format.
+func formatWith(f format, opts []Option) format {
+	withOpts := make(format, len(f))
+	for i, fn := range f {
+		withOpts[i] = fn
+		if s := stageOf(fn); s.flags&stageTimestamp != 0 {
+			withOpts[i] = setStage(withOptions(opts, fn), s)
+		}
+	}
+	nameDerived(withOpts, f)
+	return withOpts
+}
+
 // Format: <191>10 2015-09-30T23:10:11+02:00 hostname appname procid msgid [data name="value"] message.
 var rfc5424Format = format{
-	parsePriority, //<191>
+	ParsePriority(), //<191>
+	CalculateFacility(),
+	CalculateSeverity(),
+	ParseVersion(), //10
+	DiscardSpace(),
+	CheckTimestamp(),
+	ParseTimestamp(time.RFC3339, time.RFC3339Nano), // 2015-09-30T23:10:11+02:00
+	DiscardSpace(),
+	ParseHostname(), // hostname
+	DiscardSpace(),
+	ParseAppname(), // appname
+	DiscardSpace(),
+	ParseProcessID(), // procid
+	DiscardSpace(),
+	ParseMessageID(), // msgid
+	DiscardSpace(),
+	ParseData(), // [data name="value"]
+	OptionalIf(hasMessage, DiscardSpace(), ParseMsg()), // message
+}
+
+// Same as rfc5424Format, but with parseRawData.
+var rfc5424LazyFormat = format{
+	ParsePriority(),
+	CalculateFacility(),
+	CalculateSeverity(),
+	ParseVersion(),
+	DiscardSpace(),
+	CheckTimestamp(),
+	ParseTimestamp(time.RFC3339, time.RFC3339Nano),
+	DiscardSpace(),
+	ParseHostname(),
+	DiscardSpace(),
+	ParseAppname(),
+	DiscardSpace(),
+	ParseProcessID(),
+	DiscardSpace(),
+	ParseMessageID(),
+	DiscardSpace(),
+	parseRawData,
+	OptionalIf(hasMessage, DiscardSpace(), ParseMsg()),
+}
+
+// Format: <190>Oct  5 12:05:15 hostname nginx: [request remote_addr="192.168.1.255" status="200"].
+var nginxAccessFormat = format{
+	ParsePriority(), // <190>
+	CalculateFacility(),
+	CalculateSeverity(),
+	ParseTimestamp("Jan _2 15:04:05"), // Oct  5 12:05:15
+	InferYear(),                       // adds the years.
+	DiscardSpace(),
+	ParseHostname(), // hostname
+	DiscardSpace(),
+	ParseTag(), // nginx:
+	DiscardSpace(),
+	parseNginxAccessData, // [request remote_addr="192.168.1.255" status="200"]
+}
+
+// Format: <187>Oct 13 12:31:40 hostname nginx: 2015/10/13 01:31:40 [error] 1187#1187: *46 open() "/usr/share/nginx/html/test" failed (2: No such file or directory), client: 192.168.1.255, server: localhost, request: "GET /test HTTP/1.1", host: "192.168.1.254".
+var nginxErrorFormat = format{
+	ParsePriority(), // <187>
+	CalculateFacility(),
+	CalculateSeverity(),
+	ParseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	InferYear(),                       // adds the years.
+	DiscardSpace(),
+	ParseHostname(), // hostname
+	DiscardSpace(),
+	ParseTag(), // nginx:
+	DiscardSpace(),
+	parseNginxTimestamp, // 2015/10/13 01:31:40, replaces the timestamp.
+	DiscardSpace(),
+	DiscardByte('['),
+	parseNginxLevel, // error], replaces the severity.
+	DiscardSpace(),
+	parseNginxProcessID,  // 1187#1187:
+	parseNginxConnection, // *46
+	parseNginxMsg,        // open() "/usr/share/nginx/html/test" failed (2: No such file or directory),
+	Optional(1, DiscardSpace(), parseNginxData), // client: 192.168.1.255, server: localhost, request: "GET /test HTTP/1.1", host: "192.168.1.254"
+}
+
+// Format: <134>Oct 13 12:31:40 hostname CEF:0|Vendor|Product|1.0|100|Name|5|src=10.0.0.1 dst=10.0.0.2.
+var cefFormat = format{
+	parsePriority, // <134>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseCEF, // CEF:0|Vendor|Product|1.0|100|Name|5|src=10.0.0.1 dst=10.0.0.2
+}
+
+// Format: <13>Oct 13 12:31:40 hostname LEEF:2.0|Vendor|Product|1.0|EventID|^|src=10.0.0.1^dst=10.0.0.2.
+var leefFormat = format{
+	parsePriority, // <13>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseLEEF, // LEEF:2.0|Vendor|Product|1.0|EventID|^|src=10.0.0.1^dst=10.0.0.2
+}
+
+// Format: <134>Oct 13 12:31:40 hostname apache: 127.0.0.1 - frank [13/Oct/2015:12:31:40 +0200] "GET / HTTP/1.1" 200 2326 "http://example.com/" "Mozilla/5.0".
+var apacheAccessFormat = format{
+	parsePriority, // <134>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag, // apache:
+	discardSpace,
+	parseApacheAccess, // 127.0.0.1 - frank [13/Oct/2015:12:31:40 +0200] "GET / HTTP/1.1" 200 2326 "http://example.com/" "Mozilla/5.0"
+}
+
+// Format: <131>Oct 13 12:31:40 hostname apache: [Tue Oct 13 12:31:40.123456 2015] [core:error] [pid 1187:tid 140] [client 1.2.3.4:5678] AH00126: Invalid URI in request.
+var apacheErrorFormat = format{
+	parsePriority, // <131>
 	calculateFacility,
 	calculateSeverity,
-	parseVersion, //10
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag, // apache:
 	discardSpace,
-	parseTimestamp(time.RFC3339, time.RFC3339Nano), // 2015-09-30T23:10:11+02:00
+	parseApacheError, // [Tue Oct 13 12:31:40.123456 2015] [core:error] [pid 1187:tid 140] [client 1.2.3.4:5678] AH00126: Invalid URI in request
+}
+
+// Format: <22>Oct 13 12:31:40 hostname postfix/smtpd[1234]: 3F2A41C0A5: client=relay.example.com[1.2.3.4].
+var postfixFormat = format{
+	parsePriority, // <22>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
 	discardSpace,
 	parseHostname, // hostname
 	discardSpace,
-	parseAppname, // appname
+	parseTag, // postfix/smtpd[1234]:
+	discardSpace,
+	parsePostfix, // 3F2A41C0A5: client=relay.example.com[1.2.3.4]
+}
+
+// Format: <38>Oct 13 12:31:40 hostname sshd[4711]: Failed password for invalid user admin from 203.0.113.7 port 52314 ssh2.
+var sshdFormat = format{
+	parsePriority, // <38>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
 	discardSpace,
-	parseProcessID, // procid
+	parseHostname, // hostname
 	discardSpace,
-	parseMessageID, // msgid
+	parseTag, // sshd[4711]:
 	discardSpace,
-	parseData,                           // [data name="value"]
+	parseSSHD, // Failed password for invalid user admin from 203.0.113.7 port 52314 ssh2
+}
+
+// Format: <85>Oct 13 12:31:40 hostname sudo:    alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/bin/systemctl restart nginx.
+var sudoFormat = format{
+	parsePriority, // <85>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag,  // sudo:
+	parseSudo, //    alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/bin/systemctl restart nginx
+}
+
+// Format: <78>Oct 13 12:31:40 hostname CRON[1234]: (root) CMD (run-parts /etc/cron.hourly).
+var cronFormat = format{
+	parsePriority, // <78>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag, // CRON[1234]:
+	discardSpace,
+	parseCron, // (root) CMD (run-parts /etc/cron.hourly)
+}
+
+// Format: <4>Oct 13 12:31:40 hostname kernel: [12345.678] IPTABLES-DROP: IN=eth0 OUT= SRC=1.2.3.4 DST=5.6.7.8 PROTO=TCP SPT=51515 DPT=22 SYN.
+var netfilterFormat = format{
+	parsePriority, // <4>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag, // kernel:
+	discardSpace,
+	parseNetfilter, // [12345.678] IPTABLES-DROP: IN=eth0 OUT= SRC=1.2.3.4 DST=5.6.7.8 PROTO=TCP SPT=51515 DPT=22 SYN
+}
+
+// Format: <6>[ 8940.406075] usb 1-1: new high-speed USB device.
+var dmesgFormat = format{
+	parsePriority, // <6>
+	calculateFacility,
+	calculateSeverity,
+	parseKernel, // [ 8940.406075] usb 1-1: new high-speed USB device
+}
+
+// Format: {"__REALTIME_TIMESTAMP":"1444739500123456","PRIORITY":"6","_HOSTNAME":"hostname","MESSAGE":"message"}.
+var journalJSONFormat = format{
+	parseJournalJSON, // {"__REALTIME_TIMESTAMP":"1444739500123456","PRIORITY":"6","_HOSTNAME":"hostname","MESSAGE":"message"}
+}
+
+// Format: <30>1 2015-10-13T12:31:40.123456+02:00 hostname docker/web 1234 docker/web - message.
+// Format: <30>Oct 13 12:31:40 hostname docker/web[1234]: message.
+var dockerFormat = format{
+	parsePriority, // <30>
+	calculateFacility,
+	calculateSeverity,
+	parseDockerHeader, // See dockerRFC5424Header and dockerRFC3164Header.
+	splitDockerTag,    // docker/web
+}
+
+// Format: 1 2015-10-13T12:31:40.123456+02:00 hostname docker/web 1234 docker/web - message.
+var dockerRFC5424Header = format{
+	parseVersion, // 1
+	discardSpace,
+	checkTimestamp,
+	parseTimestamp(time.RFC3339, time.RFC3339Nano), // 2015-10-13T12:31:40.123456+02:00
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseAppname, // docker/web
+	discardSpace,
+	parseProcessID, // 1234
+	discardSpace,
+	parseMessageID, // docker/web
+	discardSpace,
+	parseData,                           // -
 	optional(2, discardSpace, parseMsg), // message
 }
 
-// Format: <190>Oct  5 12:05:15 hostname nginx: [request remote_addr="192.168.1.255" status="200"].
-var nginxAccessFormat = format{
-	parsePriority, // <190>
+// Format: Oct 13 12:31:40 hostname docker/web[1234]: message.
+var dockerRFC3164Header = format{
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag,                            // docker/web[1234]:
+	optional(2, discardSpace, parseMsg), // message
+}
+
+// Format: 83 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed from starting to up.
+var logplexFormat = format{
+	parseOctetCount, // 83
+	parsePriority,   // <40>
+	calculateFacility,
+	calculateSeverity,
+	parseVersion, // 1
+	discardSpace,
+	checkTimestamp,
+	parseTimestamp(time.RFC3339, time.RFC3339Nano), // 2012-11-30T06:45:29+00:00
+	discardSpace,
+	parseHostname, // host
+	discardSpace,
+	parseAppname, // app
+	discardSpace,
+	parseProcessID, // web.3
+	discardSpace,
+	parseMessageID, // -
+	optional(2, discardSpace, discardNilData, parseMsg), // State changed from starting to up
+	parseLogplexData,
+}
+
+// Format: <30>Oct 13 12:31:40 hostname app[123]: message.
+var rfc3164Format = format{
+	parsePriority, // <30>
 	calculateFacility,
 	calculateSeverity,
-	parseTimestamp("Jan _2 15:04:05"), // Oct  5 12:05:15
+	parseBSDTimestamp, // Oct 13 12:31:40 or 2015-10-13T12:31:40+02:00
+	discardSpace,
+	parseOptionalHostname,               // hostname
+	parseTag,                            // app[123]:
+	optional(2, discardSpace, parseMsg), // message
+}
+
+// Format: <189>date=2015-10-13 time=12:31:40 devname="FGT60E" logid="0000000013" type="traffic" level="notice".
+var fortiGateFormat = format{
+	parsePriority, // <189>
+	calculateFacility,
+	calculateSeverity,
+	parseFortiGate, // date=2015-10-13 time=12:31:40 devname="FGT60E" logid="0000000013" type="traffic" level="notice"
+}
+
+// Format: <134>Oct 13 12:31:40 hostname filterlog: 5,,,1000000103,igb0,match,block,in,4,0x0,,64,0,0,DF,17,udp,76,1.2.3.4,5.6.7.8,123,123,56.
+var filterlogFormat = format{
+	parsePriority, // <134>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
 	nginxFixTimestamp,                 // adds the years.
 	discardSpace,
 	parseHostname, // hostname
 	discardSpace,
-	parseAppname,    // nginx:
-	nginxFixAppName, // nginx: -> nginx
+	parseTag, // filterlog:
 	discardSpace,
-	parseData, // [request remote_addr="192.168.1.255" status="200"]
+	parseFilterlog, // 5,,,1000000103,igb0,match,block,in,4,0x0,,64,0,0,DF,17,udp,76,1.2.3.4,5.6.7.8,123,123,56
 }
 
-// Format: <187>Oct 13 12:31:40 hostname nginx: 2015/10/13 01:31:40 [error] 1187#1187: *46 open() "/usr/share/nginx/html/test" failed (2: No such file or directory), client: 192.168.1.255, server: localhost, request: "GET /test HTTP/1.1", host: "192.168.1.254".
-var nginxErrorFormat = format{
-	parsePriority, // <187>
+// Format: <22>Oct 13 12:31:40 hostname dovecot: imap-login: Login: user=<alice>, method=PLAIN, rip=1.2.3.4, lip=10.0.0.1, mpid=1234, TLS.
+var dovecotFormat = format{
+	parsePriority, // <22>
 	calculateFacility,
 	calculateSeverity,
 	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
@@ -92,15 +724,165 @@ var nginxErrorFormat = format{
 	discardSpace,
 	parseHostname, // hostname
 	discardSpace,
-	parseAppname,    // nginx:
-	nginxFixAppName, // nginx: -> nginx
+	parseTag, // dovecot:
+	discardSpace,
+	parseDovecot, // imap-login: Login: user=<alice>, method=PLAIN, rip=1.2.3.4, lip=10.0.0.1, mpid=1234, TLS
+}
+
+// Format: <134>Oct 13 12:31:40 hostname postgres[1234]: [3-1] user=app,db=prod LOG:  statement: SELECT 1.
+var postgresFormat = format{
+	parsePriority, // <134>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
 	discardSpace,
-	discard(19), // Timestamp is provided again (2015/10/13 01:31:40).
+	parseTag, // postgres[1234]:
 	discardSpace,
-	discardByte('['),
-	discardUntil(']'), // Severity is given again ([Error]).
+	parsePostgres, // [3-1] user=app,db=prod LOG:  statement: SELECT 1
+}
+
+// Format: <27>Oct 13 12:31:40 hostname mysqld: 2015-10-13T12:31:40.123456Z 12 [Warning] [MY-010055] [Server] IP address '1.2.3.4' could not be resolved.
+var mysqlErrorFormat = format{
+	parsePriority, // <27>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag, // mysqld:
+	discardSpace,
+	parseMySQLError, // 2015-10-13T12:31:40.123456Z 12 [Warning] [MY-010055] [Server] IP address '1.2.3.4' could not be resolved
+}
+
+// Format: <30>Oct 13 12:31:40 hostname redis: 1187:M 13 Oct 2015 12:31:40.123 * Background saving started by pid 1200.
+var redisFormat = format{
+	parsePriority, // <30>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag, // redis:
+	discardSpace,
+	parseRedis, // 1187:M 13 Oct 2015 12:31:40.123 * Background saving started by pid 1200
+}
+
+// Format: <28>Oct 13 12:31:40 hostname php-fpm: [13-Oct-2015 12:31:40] WARNING: [pool www] child 1187 said into stderr: "PHP message: PHP Warning: ...".
+var phpfpmFormat = format{
+	parsePriority, // <28>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag, // php-fpm:
+	discardSpace,
+	parsePHPFPM, // [13-Oct-2015 12:31:40] WARNING: [pool www] child 1187 said into stderr: "PHP message: PHP Warning: ..."
+}
+
+// Format: <134>Oct 13 12:31:40 hostname gunicorn.access: 10.0.0.1 - - [13/Oct/2015:12:31:40 +0200] "GET /api HTTP/1.1" 200 123 "-" "python-requests/2.7" 4567.
+var gunicornAccessFormat = format{
+	parsePriority, // <134>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag, // gunicorn.access:
+	discardSpace,
+	parseGunicornAccess, // 10.0.0.1 - - [13/Oct/2015:12:31:40 +0200] "GET /api HTTP/1.1" 200 123 "-" "python-requests/2.7" 4567
+}
+
+// Format: <134>Oct 13 12:31:40 hostname uwsgi: [pid: 1187|app: 0|req: 1/1] 10.0.0.1 () {34 vars in 512 bytes} [Tue Oct 13 12:31:40 2015] GET /api => generated 123 bytes in 12 msecs (HTTP/1.1 200) 2 headers in 79 bytes (1 switches on core 0).
+var uwsgiAccessFormat = format{
+	parsePriority, // <134>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag, // uwsgi:
+	discardSpace,
+	parseUWSGIAccess, // [pid: 1187|app: 0|req: 1/1] 10.0.0.1 () {34 vars in 512 bytes} [Tue Oct 13 12:31:40 2015] GET /api => generated 123 bytes in 12 msecs (HTTP/1.1 200) 2 headers in 79 bytes (1 switches on core 0)
+}
+
+// Format: <134>Oct 13 12:31:40 hostname envoy: [2015-10-13T12:31:40.123Z] "GET /path HTTP/2" 200 - 0 1234 5 4 "-" "curl/7.43.0" "uuid" "host" "10.0.0.5:8080".
+var envoyAccessFormat = format{
+	parsePriority, // <134>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag, // envoy:
+	discardSpace,
+	parseEnvoyAccess, // [2015-10-13T12:31:40.123Z] "GET /path HTTP/2" 200 - 0 1234 5 4 "-" "curl/7.43.0" "uuid" "host" "10.0.0.5:8080"
+}
+
+// Format: https 2015-10-13T12:31:40.123456Z app/my-alb/abc 1.2.3.4:5678 10.0.0.5:80 0.001 0.002 0.000 200 200 123 456 "GET https://example.com:443/ HTTP/1.1" "curl/7.43.0" ....
+var albAccessFormat = format{
+	parseALBAccess, // https 2015-10-13T12:31:40.123456Z app/my-alb/abc 1.2.3.4:5678 10.0.0.5:80 0.001 0.002 0.000 200 200 123 456 "GET https://example.com:443/ HTTP/1.1" "curl/7.43.0" ...
+}
+
+// Format: 2015-10-13T12:31:40.123456Z my-elb 1.2.3.4:5678 10.0.0.5:80 0.00004 0.001 0.00003 200 200 0 29 "GET http://example.com:80/ HTTP/1.1" "curl/7.43.0" - -.
+var elbAccessFormat = format{
+	parseELBAccess, // 2015-10-13T12:31:40.123456Z my-elb 1.2.3.4:5678 10.0.0.5:80 0.00004 0.001 0.00003 200 200 0 29 "GET http://example.com:80/ HTTP/1.1" "curl/7.43.0" - -
+}
+
+// Format: <86>Oct 13 12:31:40 hostname audit[1187]: type=SYSCALL msg=audit(1444733500.123:456): arch=c000003e syscall=59 success=yes key="exec-log".
+var auditFormat = format{
+	parsePriority, // <86>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseTag, // audit[1187]:
+	discardSpace,
+	parseAudit, // type=SYSCALL msg=audit(1444733500.123:456): arch=c000003e syscall=59 success=yes key="exec-log"
+}
+
+// Format: <38>Oct 13 12:31:40 hostname fail2ban.actions [1187]: NOTICE [sshd] Ban 203.0.113.7.
+var fail2banFormat = format{
+	parsePriority, // <38>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
+	discardSpace,
+	parseFail2ban, // fail2ban.actions [1187]: NOTICE [sshd] Ban 203.0.113.7
+}
+
+// Format: <30>Oct 13 12:31:40 hostname Keepalived_vrrp[1187]: VRRP_Instance(VI_1) Transition to MASTER STATE.
+var keepalivedFormat = format{
+	parsePriority, // <30>
+	calculateFacility,
+	calculateSeverity,
+	parseTimestamp("Jan _2 15:04:05"), // Oct 13 12:31:40
+	nginxFixTimestamp,                 // adds the years.
+	discardSpace,
+	parseHostname, // hostname
 	discardSpace,
-	parseNginxMsg, // 1187#1187: *46 open() "/usr/share/nginx/html/test" failed (2: No such file or directory),
+	parseTag, // Keepalived_vrrp[1187]:
 	discardSpace,
-	parseNginxData, // client: 192.168.1.255, server: localhost, request: "GET /test HTTP/1.1", host: "192.168.1.254"
+	parseKeepalived, // VRRP_Instance(VI_1) Transition to MASTER STATE
 }
@@ -0,0 +1,150 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+)
+
+// Layouts of the date and time keys, and the optional tz key, of FortiGate
+// logs.
+const (
+	fortiGateTimestampLayout   = "2006-01-02 15:04:05"
+	fortiGateTimestampTZLayout = "2006-01-02 15:04:05 -0700"
+)
+
+// ParseFortiGate parses the key=value pairs of a FortiGate log into
+// Data["fortigate"], e.g. `date=2015-10-13 time=12:31:40 devname="FGT60E"
+// level="notice"`. Values can be qouted, which allows them to contain spaces.
+// Duplicate keys are handled like duplicate structured data params, see
+// WithDuplicates. The date and time keys, and optional tz key, are parsed
+// into Timestamp, devname is stored as Hostname and level replaces the
+// severity, like parseNginxLevel.
+func parseFortiGate(buf *buffer, msg *Message) error {
+	policy := buf.cfg.Duplicates()
+	data := map[string]string{}
+	var levelPos, timestampPos int
+	for n := 1; ; n++ {
+		b := buf.bytes[buf.position:buf.length]
+		i := indexNonSpace(b)
+		if i == len(b) {
+			buf.position = buf.length
+			break
+		}
+		buf.position += i
+		b = b[i:]
+
+		keyPos := buf.Pos()
+		end := bytes.IndexAny(b, "= \"")
+		if end <= 0 || b[end] != equalByte {
+			return newFormatError(keyPos, nil, "expected a key=value pair")
+		} else if buf.cfg.TooManyParams(n) {
+			return newFormatError(keyPos, ErrTooManyParams, "FortiGate log has too many keys")
+		}
+		key := string(b[:end])
+		buf.position += end + 1
+
+		value, err := parseFortiGateValue(buf)
+		if err != nil {
+			return err
+		}
+
+		if previous, ok := data[key]; ok {
+			if policy == RejectDuplicates {
+				return newFormatError(keyPos, ErrDuplicate, "duplicate FortiGate key "+key)
+			} else if policy == CollectDuplicates {
+				msg.addRepeated("fortigate", key, previous, value)
+			}
+		}
+		data[key] = value
+
+		switch key {
+		case "level":
+			levelPos = keyPos
+		case "date", "time", "tz":
+			if timestampPos == 0 {
+				timestampPos = keyPos
+			}
+		}
+	}
+
+	if len(data) == 0 {
+		return io.EOF
+	}
+	msg.Data = map[string]map[string]string{"fortigate": data}
+
+	if date, ok := data["date"]; ok {
+		layout, value := fortiGateTimestampLayout, date+" "+data["time"]
+		if tz, ok := data["tz"]; ok {
+			layout, value = fortiGateTimestampTZLayout, value+" "+tz
+		}
+		timestamp, err := time.ParseInLocation(layout, value, buf.cfg.Location())
+		if err != nil {
+			return newFormatError(timestampPos, ErrBadTimestamp, "invalid FortiGate timestamp")
+		}
+		msg.Timestamp = timestamp
+	}
+
+	if devname, ok := data["devname"]; ok {
+		if len(devname) > maxHostnameLength {
+			return newFormatError(buf.Pos(), ErrFieldTooLong, "hostname too long")
+		}
+		msg.Hostname = devname
+	}
+
+	if level, ok := data["level"]; ok {
+		severity, err := ParseSeverityName(level)
+		if strings.EqualFold(level, "information") {
+			severity, err = Informational, nil
+		}
+		if err != nil {
+			return newFormatError(levelPos, nil, "unknown FortiGate level '"+escapeSnippet([]byte(level))+"'")
+		}
+
+		if !buf.cfg.PrioritySeverity() {
+			msg.Severity = severity
+			if msg.Priority.IsValid() {
+				msg.Priority = CalculatePriority(msg.Facility, severity)
+			}
+		}
+	}
+	return nil
+}
+
+// parseFortiGateValue parses a, optionally qouted, value. Qouted values end at
+// the next qoute, not preceded by a backslash, unqouted values at the next
+// space.
+func parseFortiGateValue(buf *buffer) (string, error) {
+	b := buf.bytes[buf.position:buf.length]
+	if len(b) == 0 || b[0] != qouteByte {
+		end := bytes.IndexByte(b, spaceByte)
+		if end == -1 {
+			end = len(b)
+		}
+		buf.position += end
+		return string(b[:end]), nil
+	}
+
+	escaped := false
+	for i := 1; i < len(b); i++ {
+		switch b[i] {
+		case escapeByte:
+			escaped = true
+			i++
+		case qouteByte:
+			buf.position += i + 1
+			if !escaped {
+				return string(b[1:i]), nil
+			}
+			value := strings.ReplaceAll(string(b[1:i]), `\"`, `"`)
+			return strings.ReplaceAll(value, `\\`, `\`), nil
+		}
+	}
+	buf.position = buf.length
+	return "", io.EOF
+}
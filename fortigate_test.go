@@ -0,0 +1,197 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseMessageFortiGate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputFortiGate),
+			&Message{
+				Priority: CalculatePriority(Local7, Notice),
+				Facility: Local7,
+				Severity: Notice,
+				Data:     map[string]map[string]string{"fortigate": {"a": ""}},
+			},
+		},
+		{
+			// Traffic log.
+			string(regularInputFortiGate),
+			&Message{
+				Priority:  CalculatePriority(Local7, Notice),
+				Facility:  Local7,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.Local),
+				Hostname:  "FGT60E",
+				Data: map[string]map[string]string{
+					"fortigate": {
+						"date":     "2015-10-13",
+						"time":     "12:31:40",
+						"devname":  "FGT60E",
+						"devid":    "FGT60E4Q16000000",
+						"logid":    "0000000013",
+						"type":     "traffic",
+						"subtype":  "forward",
+						"level":    "notice",
+						"vd":       "root",
+						"srcip":    "192.168.1.10",
+						"srcport":  "51515",
+						"srcintf":  "internal",
+						"dstip":    "93.184.216.34",
+						"dstport":  "443",
+						"dstintf":  "wan1",
+						"proto":    "6",
+						"action":   "accept",
+						"policyid": "1",
+						"service":  "HTTPS",
+						"sentbyte": "1234",
+						"rcvdbyte": "5678",
+					},
+				},
+			},
+		},
+		{
+			// UTM log, with a time zone and an escaped qoute.
+			`<188>date=2015-10-13 time=12:31:40 devname="FGT60E" logid="0211008192" type="utm" subtype="virus" level="warning" tz="+0200" action="blocked" url="http://example.com/eicar.com" msg="File is infected: \"EICAR_TEST_FILE\""`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Warning),
+				Facility:  Local7,
+				Severity:  Warning,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.FixedZone("", 2*60*60)),
+				Hostname:  "FGT60E",
+				Data: map[string]map[string]string{
+					"fortigate": {
+						"date":    "2015-10-13",
+						"time":    "12:31:40",
+						"devname": "FGT60E",
+						"logid":   "0211008192",
+						"type":    "utm",
+						"subtype": "virus",
+						"level":   "warning",
+						"tz":      "+0200",
+						"action":  "blocked",
+						"url":     "http://example.com/eicar.com",
+						"msg":     `File is infected: "EICAR_TEST_FILE"`,
+					},
+				},
+			},
+		},
+		{
+			// Event log, with the information level and trailing space.
+			`<189>date=2015-10-13 time=12:31:40 devname=FGT60E logid="0100032001" type="event" subtype="system" level="information" user="admin" ui="https(10.0.0.1)" msg="Administrator admin logged in successfully from https(10.0.0.1)" `,
+			&Message{
+				Priority:  CalculatePriority(Local7, Informational),
+				Facility:  Local7,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.Local),
+				Hostname:  "FGT60E",
+				Data: map[string]map[string]string{
+					"fortigate": {
+						"date":    "2015-10-13",
+						"time":    "12:31:40",
+						"devname": "FGT60E",
+						"logid":   "0100032001",
+						"type":    "event",
+						"subtype": "system",
+						"level":   "information",
+						"user":    "admin",
+						"ui":      "https(10.0.0.1)",
+						"msg":     "Administrator admin logged in successfully from https(10.0.0.1)",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), FortiGate)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, FortiGate) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageFortiGateErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`<189>`,
+			"syslog: FortiGate: format incorrect at byte 5: unexpected end of message"},
+		{`<189>   `,
+			"syslog: FortiGate: format incorrect at byte 8: unexpected end of message"},
+		{`<189>date`,
+			"syslog: FortiGate: format incorrect at byte 6: expected a key=value pair"},
+		{`<189>type="traffic" =1`,
+			"syslog: FortiGate: format incorrect at byte 21: expected a key=value pair"},
+		{`<189>msg="abc def`,
+			"syslog: FortiGate: format incorrect at byte 17: unexpected end of message"},
+		{`<189>date=2015-10-13 time=12:31`,
+			"syslog: FortiGate: format incorrect at byte 6: invalid FortiGate timestamp"},
+		{`<189>date=2015-10-13 time=12:31:40 tz=CEST`,
+			"syslog: FortiGate: format incorrect at byte 6: invalid FortiGate timestamp"},
+		{`<189>type="event" level="failure"`,
+			"syslog: FortiGate: format incorrect at byte 19: unknown FortiGate level 'failure'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), FortiGate)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageFortiGateDuplicates(t *testing.T) {
+	t.Parallel()
+
+	input := []byte(`<189>type="utm" virus="a" virus="b"`)
+
+	msg, err := ParseMessage(input, FortiGate)
+	if err != nil {
+		t.Fatalf("Unexpected error ParseMessage(%q): %s", input, err)
+	} else if got := msg.Data["fortigate"]["virus"]; got != "b" {
+		t.Fatalf("Expected ParseMessage(%q) to keep the last value, but got %q", input, got)
+	}
+
+	_, err = NewParser(FortiGate, WithDuplicates(RejectDuplicates))(input)
+	if !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("Expected parsing %q with RejectDuplicates to return an ErrDuplicate error, but got %v",
+			input, err)
+	}
+
+	msg, err = NewParser(FortiGate, WithDuplicates(CollectDuplicates))(input)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %q: %s", input, err)
+	}
+	expected := []string{"a", "b"}
+	if got := msg.ParamValues("fortigate", "virus"); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected msg.ParamValues(fortigate, virus) to return %q, but got %q", expected, got)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GELF fields used for the header fields of a Message, these can't collide
+// with the fields of the structured data params as they don't include an
+// underscore after the leading one.
+const (
+	gelfAppname   = "_appname"
+	gelfProcessID = "_procid"
+	gelfMessageID = "_msgid"
+	gelfFacility  = "_facility"
+)
+
+// GELF formats the message as a Graylog Extended Log Format (GELF) 1.1 JSON
+// document. The short_message is the first line of Message, the full_message
+// the entire Message if it has multiple lines. The timestamp is formatted as
+// seconds since the Unix epoch, with the fractional seconds as decimals. The
+// level is the severity and host the hostname, or the hostname of the machine
+// if empty.
+//
+// The appname, process id, message id and facility are added as the
+// additional fields _appname, _procid, _msgid and _facility. Every structured
+// data param is added as the additional field _dataID_name, bytes not allowed
+// in a GELF field name are replaced with an underscore. The structured data is
+// parsed first if needed, see ParsedData, returning its error if any.
+func (msg *Message) GELF() ([]byte, error) {
+	data, err := msg.ParsedData()
+	if err != nil {
+		return nil, err
+	}
+
+	hostname := msg.Hostname
+	if hostname == "" {
+		hostname = localHostname()
+	}
+
+	shortMessage, _, multiline := strings.Cut(msg.Message, "\n")
+	m := map[string]interface{}{
+		"version":       "1.1",
+		"host":          hostname,
+		"short_message": shortMessage,
+		"level":         msg.Severity,
+	}
+	if multiline {
+		m["full_message"] = msg.Message
+	}
+	if !msg.Timestamp.IsZero() {
+		m["timestamp"] = gelfTimestamp(msg.Timestamp)
+	}
+	if msg.Facility != 0 {
+		m[gelfFacility] = msg.Facility
+	}
+
+	values := []struct{ key, value string }{
+		{gelfAppname, msg.Appname},
+		{gelfProcessID, msg.ProcessID},
+		{gelfMessageID, msg.MessageID},
+	}
+	for _, v := range values {
+		if v.value != "" {
+			m[v.key] = v.value
+		}
+	}
+
+	for dataID, params := range data {
+		for name, value := range params {
+			key := "_" + sanitize(dataID, math.MaxInt, isGELFFieldByte) +
+				"_" + sanitize(name, math.MaxInt, isGELFFieldByte)
+			m[key] = value
+		}
+	}
+
+	return json.Marshal(m)
+}
+
+// gelfTimestamp returns the timestamp as seconds since the Unix epoch, without
+// losing precision.
+func gelfTimestamp(t time.Time) json.Number {
+	seconds, nanoseconds := t.Unix(), t.Nanosecond()
+	negative := seconds < 0 && nanoseconds != 0
+	if negative {
+		// E.g. -2s + 0.25s is formatted as -1.75.
+		seconds, nanoseconds = seconds+1, 1e9-nanoseconds
+	}
+
+	s := strconv.FormatInt(seconds, 10)
+	if negative && seconds == 0 {
+		s = "-0"
+	}
+	if nanoseconds != 0 {
+		fraction := strconv.Itoa(1e9 + nanoseconds)[1:]
+		s += "." + strings.TrimRight(fraction, "0")
+	}
+	return json.Number(s)
+}
+
+// isGELFFieldByte checks if the byte is allowed in a GELF additional field
+// name.
+func isGELFFieldByte(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || (c >= '0' && c <= '9') ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// FromGELF creates a message from a GELF document, the reverse of
+// Message.GELF. The Message is the full_message, or the short_message if not
+// present, and the timestamp is in UTC. Additional fields, other than those
+// used by Message.GELF for the header fields, are added to the structured data
+// by splitting the name at the first underscore after the leading one, e.g.
+// "_dataID_name". Additional fields without such an underscore are ignored.
+func FromGELF(b []byte) (*Message, error) {
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return nil, errors.New("syslog: invalid GELF: " + err.Error())
+	}
+
+	msg := &Message{Severity: Alert} // Default level in GELF.
+	for key, value := range m {
+		var err error
+		switch key {
+		case "version":
+		case "host":
+			msg.Hostname, err = gelfString(key, value)
+		case "short_message":
+			if msg.Message == "" {
+				msg.Message, err = gelfString(key, value)
+			}
+		case "full_message":
+			msg.Message, err = gelfString(key, value)
+		case "timestamp":
+			msg.Timestamp, err = parseGELFTimestamp(value)
+		case "level":
+			var n uint8
+			n, err = gelfUint8(key, value)
+			msg.Severity = Severity(n)
+		case gelfFacility:
+			var n uint8
+			n, err = gelfUint8(key, value)
+			msg.Facility = Facility(n)
+		case gelfAppname:
+			msg.Appname, err = gelfString(key, value)
+		case gelfProcessID:
+			msg.ProcessID, err = gelfString(key, value)
+		case gelfMessageID:
+			msg.MessageID, err = gelfString(key, value)
+		default:
+			dataID, name, ok := strings.Cut(strings.TrimPrefix(key, "_"), "_")
+			if !ok || !strings.HasPrefix(key, "_") || dataID == "" {
+				continue
+			}
+
+			var s string
+			if s, err = gelfString(key, value); err == nil {
+				if msg.Data == nil {
+					msg.Data = make(map[string]map[string]string)
+				}
+				if msg.Data[dataID] == nil {
+					msg.Data[dataID] = make(map[string]string)
+				}
+				msg.Data[dataID][name] = s
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	msg.Priority = CalculatePriority(msg.Facility, msg.Severity)
+	return msg, nil
+}
+
+// gelfString returns the value of a GELF field as string, numbers are
+// formatted as they appear in the document.
+func gelfString(key string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	}
+	return "", errors.New("syslog: invalid GELF field " + key + ": expected a string")
+}
+
+// gelfUint8 returns the value of a GELF field as uint8.
+func gelfUint8(key string, value interface{}) (uint8, error) {
+	if v, ok := value.(json.Number); ok {
+		if n, err := strconv.ParseUint(v.String(), 10, 8); err == nil {
+			return uint8(n), nil
+		}
+	}
+	return 0, errors.New("syslog: invalid GELF field " + key + ": expected a number between 0 and 255")
+}
+
+// parseGELFTimestamp parses a timestamp in seconds since the Unix epoch, with
+// optional decimals, without losing precision.
+func parseGELFTimestamp(value interface{}) (time.Time, error) {
+	v, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, errors.New("syslog: invalid GELF field timestamp: expected a number")
+	}
+
+	s, fraction, _ := strings.Cut(v.String(), ".")
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || len(fraction) > 9 {
+		return time.Time{}, errors.New("syslog: invalid GELF field timestamp: " + v.String())
+	}
+
+	var nanoseconds int64
+	if fraction != "" {
+		fraction += strings.Repeat("0", 9-len(fraction))
+		if nanoseconds, err = strconv.ParseInt(fraction, 10, 64); err != nil || nanoseconds < 0 {
+			return time.Time{}, errors.New("syslog: invalid GELF field timestamp: " + v.String())
+		}
+	}
+	if strings.HasPrefix(s, "-") {
+		nanoseconds = -nanoseconds
+	}
+	return time.Unix(seconds, nanoseconds).UTC(), nil
+}
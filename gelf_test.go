@@ -0,0 +1,158 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMessageGELF(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Msg      *Message
+		Expected string
+	}{
+		{
+			&Message{Hostname: "host"},
+			`{"host":"host","level":0,"short_message":"","version":"1.1"}`,
+		},
+		{
+			&Message{
+				Priority:  CalculatePriority(Local7, Warning),
+				Facility:  Local7,
+				Severity:  Warning,
+				Version:   1,
+				Timestamp: time.Date(2015, 9, 30, 23, 10, 11, 123000000, locationCEST),
+				Hostname:  "hostname",
+				Appname:   "appname",
+				ProcessID: "123",
+				MessageID: "msgid",
+				Data: map[string]map[string]string{
+					"data":   {"name": "value"},
+					"da ta!": {"na.me": "value"},
+				},
+				Message: "first line\nsecond line",
+			},
+			`{"_appname":"appname","_da_ta__na.me":"value","_data_name":"value","_facility":23,` +
+				`"_msgid":"msgid","_procid":"123","full_message":"first line\nsecond line",` +
+				`"host":"hostname","level":4,"short_message":"first line",` +
+				`"timestamp":1443647411.123,"version":"1.1"}`,
+		},
+		{
+			&Message{Hostname: "host", Timestamp: time.Date(1969, 12, 31, 23, 59, 58, 250000000, time.UTC)},
+			`{"host":"host","level":0,"short_message":"","timestamp":-1.75,"version":"1.1"}`,
+		},
+		{
+			&Message{Hostname: "host", RawData: `[data name="value"]`},
+			`{"_data_name":"value","host":"host","level":0,"short_message":"","version":"1.1"}`,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := test.Msg.GELF()
+		if err != nil {
+			t.Fatalf("Unexpected error msg.GELF(): %s", err)
+		} else if string(got) != test.Expected {
+			t.Fatalf("Expected msg.GELF() to return %s, but got %s", test.Expected, got)
+		}
+	}
+
+	if _, err := (&Message{RawData: "[data"}).GELF(); err == nil {
+		t.Fatal("Expected msg.GELF() to return the error of parsing the raw data, but got nil")
+	}
+}
+
+func TestMessageGELFRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []*Message{
+		{
+			Priority:  CalculatePriority(Local7, Debug),
+			Facility:  Local7,
+			Severity:  Debug,
+			Timestamp: time.Date(2015, 9, 30, 21, 10, 11, 123456789, time.UTC),
+			Hostname:  "hostname",
+			Appname:   "appname",
+			ProcessID: "procid",
+			MessageID: "msgid",
+			Data: map[string]map[string]string{
+				"data":  {"name": "value", "name2": "value2"},
+				"data2": {"name": "value"},
+			},
+			Message: "message",
+		},
+		{
+			Priority:  CalculatePriority(Kernel, Emergency),
+			Timestamp: time.Date(2015, 9, 30, 21, 10, 11, 1, time.UTC),
+			Hostname:  "hostname",
+			Message:   "multi\nline\nmessage",
+		},
+		{
+			Priority:  CalculatePriority(UserLevel, Error),
+			Facility:  UserLevel,
+			Severity:  Error,
+			Timestamp: time.Date(1969, 12, 31, 23, 59, 59, 999999999, time.UTC),
+			Hostname:  "hostname",
+			Data:      map[string]map[string]string{"data": {"under_score": "value"}},
+		},
+	}
+
+	for _, msg := range tests {
+		b, err := msg.GELF()
+		if err != nil {
+			t.Fatalf("Unexpected error msg.GELF(): %s", err)
+		}
+
+		got, err := FromGELF(b)
+		if err != nil {
+			t.Fatalf("Unexpected error FromGELF(%s): %s", b, err)
+		} else if !messagesAreEqual(got, msg) {
+			t.Fatalf("Expected FromGELF(%s) to return %#v, but got %#v", b, msg, got)
+		}
+	}
+}
+
+func TestFromGELF(t *testing.T) {
+	t.Parallel()
+
+	input := `{"version":"1.1","host":"host","short_message":"message","timestamp":1443647411,` +
+		`"_data_count":10,"_ignored":"value","id":"1"}`
+	expected := &Message{
+		Priority:  CalculatePriority(Kernel, Alert),
+		Severity:  Alert,
+		Timestamp: time.Date(2015, 9, 30, 21, 10, 11, 0, time.UTC),
+		Hostname:  "host",
+		Data:      map[string]map[string]string{"data": {"count": "10"}},
+		Message:   "message",
+	}
+
+	got, err := FromGELF([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error FromGELF(%s): %s", input, err)
+	} else if !messagesAreEqual(got, expected) {
+		t.Fatalf("Expected FromGELF(%s) to return %#v, but got %#v", input, expected, got)
+	}
+
+	errorTests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`{`, "syslog: invalid GELF: unexpected EOF"},
+		{`{"host":true}`, "syslog: invalid GELF field host: expected a string"},
+		{`{"level":8.5}`, "syslog: invalid GELF field level: expected a number between 0 and 255"},
+		{`{"timestamp":"now"}`, "syslog: invalid GELF field timestamp: expected a number"},
+		{`{"timestamp":1.0000000001}`, "syslog: invalid GELF field timestamp: 1.0000000001"},
+	}
+
+	for _, test := range errorTests {
+		_, err := FromGELF([]byte(test.Input))
+		if err == nil || err.Error() != test.Expected {
+			t.Fatalf("Expected FromGELF(%s) to return error %q, but got %v",
+				test.Input, test.Expected, err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+// Handler handles a single parsed message.
+type Handler interface {
+	Handle(*Message)
+}
+
+// HandlerFunc is an adapter to allow the use of ordinary functions as a
+// Handler.
+type HandlerFunc func(*Message)
+
+// Handle calls fn(msg).
+func (fn HandlerFunc) Handle(msg *Message) {
+	fn(msg)
+}
+
+// ThresholdRoute routes all messages with a severity of at least MinSeverity to
+// the Handler. Note that a lower Severity is more severe, so a route with
+// MinSeverity Error is satisfied by Emergency, Alert, Critical and Error
+// messages.
+type ThresholdRoute struct {
+	MinSeverity Severity
+	Handler     Handler
+}
+
+// levelTee dispatches messages based on a lookup table indexed by severity.
+// The table is never modified after creation, so it's safe for concurrent use.
+type levelTee struct {
+	routes   [maxSeverity + 1]Handler
+	fallback Handler
+}
+
+func (tee *levelTee) Handle(msg *Message) {
+	var handler Handler
+	if msg.Severity.IsValid() {
+		handler = tee.routes[msg.Severity]
+	}
+	if handler == nil {
+		handler = tee.fallback
+	}
+	if handler != nil {
+		handler.Handle(msg)
+	}
+}
+
+// NewLevelTee creates a Handler that dispatches each message to the handler
+// registered for its exact severity. Messages with a severity not in routes
+// (including invalid severities) are passed to fallback, which may be nil to
+// drop those messages.
+//
+// The returned Handler is safe for concurrent use, given that the handlers it
+// dispatches to are.
+func NewLevelTee(routes map[Severity]Handler, fallback Handler) Handler {
+	tee := &levelTee{fallback: fallback}
+	for severity, handler := range routes {
+		if severity.IsValid() {
+			tee.routes[severity] = handler
+		}
+	}
+	return tee
+}
+
+// NewThresholdTee creates a Handler that dispatches each message to the
+// handler of the first route whose MinSeverity is satisfied, see
+// ThresholdRoute. Messages that don't satisfy any route are dropped.
+//
+// The returned Handler is safe for concurrent use, given that the handlers it
+// dispatches to are.
+func NewThresholdTee(pairs []ThresholdRoute) Handler {
+	// The first matching route for every severity is known up front, so
+	// dispatching is a simple lookup.
+	tee := &levelTee{}
+	for severity := Severity(0); severity.IsValid(); severity++ {
+		for _, route := range pairs {
+			if severity <= route.MinSeverity {
+				tee.routes[severity] = route.Handler
+				break
+			}
+		}
+	}
+	return tee
+}
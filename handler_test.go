@@ -0,0 +1,180 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// countingHandler counts the number of messages it handled, safe for
+// concurrent use.
+type countingHandler struct {
+	n int64
+}
+
+func (h *countingHandler) Handle(msg *Message) {
+	atomic.AddInt64(&h.n, 1)
+}
+
+func (h *countingHandler) count() int64 {
+	return atomic.LoadInt64(&h.n)
+}
+
+func TestHandlerFunc(t *testing.T) {
+	t.Parallel()
+
+	var got *Message
+	handler := HandlerFunc(func(msg *Message) { got = msg })
+
+	msg := &Message{Message: "message"}
+	handler.Handle(msg)
+	if got != msg {
+		t.Fatalf("Expected HandlerFunc.Handle to call the function with %v, but got %v",
+			msg, got)
+	}
+}
+
+func TestLevelTee(t *testing.T) {
+	t.Parallel()
+
+	var errors, debug, fallback countingHandler
+	tee := NewLevelTee(map[Severity]Handler{
+		Error:       &errors,
+		Debug:       &debug,
+		Severity(8): &debug, // Invalid, should be ignored.
+	}, &fallback)
+
+	tests := []struct {
+		Severity Severity
+		Expected *countingHandler
+	}{
+		{Error, &errors},
+		{Debug, &debug},
+		{Emergency, &fallback},
+		{Warning, &fallback},
+		{Severity(8), &fallback},
+	}
+
+	for _, test := range tests {
+		before := test.Expected.count()
+		tee.Handle(&Message{Severity: test.Severity})
+		if got := test.Expected.count() - before; got != 1 {
+			t.Fatalf("Expected message with severity %s to be handled once by "+
+				"the expected handler, but got %d", test.Severity, got)
+		}
+	}
+
+	if got, expected := errors.count()+debug.count()+fallback.count(), int64(len(tests)); got != expected {
+		t.Fatalf("Expected %d messages to be handled in total, but got %d", expected, got)
+	}
+}
+
+func TestLevelTeeNoFallback(t *testing.T) {
+	t.Parallel()
+
+	var errors countingHandler
+	tee := NewLevelTee(map[Severity]Handler{Error: &errors}, nil)
+
+	tee.Handle(&Message{Severity: Debug})
+	tee.Handle(&Message{Severity: Error})
+	if got, expected := errors.count(), int64(1); got != expected {
+		t.Fatalf("Expected %d message to be handled, but got %d", expected, got)
+	}
+}
+
+func TestThresholdTee(t *testing.T) {
+	t.Parallel()
+
+	var critical, errors, rest countingHandler
+	tee := NewThresholdTee([]ThresholdRoute{
+		{MinSeverity: Critical, Handler: &critical},
+		// Overlaps with the route above, only gets Error.
+		{MinSeverity: Error, Handler: &errors},
+		// Overlaps with the routes above, never wins for Error and more severe.
+		{MinSeverity: Informational, Handler: &rest},
+	})
+
+	tests := []struct {
+		Severity Severity
+		Expected *countingHandler
+	}{
+		{Emergency, &critical},
+		{Alert, &critical},
+		{Critical, &critical},
+		{Error, &errors},
+		{Warning, &rest},
+		{Notice, &rest},
+		{Informational, &rest},
+		{Debug, nil},
+		{Severity(8), nil},
+	}
+
+	for _, test := range tests {
+		var before int64
+		if test.Expected != nil {
+			before = test.Expected.count()
+		}
+		total := critical.count() + errors.count() + rest.count()
+
+		tee.Handle(&Message{Severity: test.Severity})
+
+		if test.Expected == nil {
+			if got := critical.count() + errors.count() + rest.count(); got != total {
+				t.Fatalf("Expected message with severity %s to be dropped, but it was handled",
+					test.Severity)
+			}
+		} else if got := test.Expected.count() - before; got != 1 {
+			t.Fatalf("Expected message with severity %s to be handled once by "+
+				"the expected handler, but got %d", test.Severity, got)
+		}
+	}
+}
+
+func TestTeeNoAllocations(t *testing.T) {
+	var handler countingHandler
+	levelTee := NewLevelTee(map[Severity]Handler{Error: &handler}, &handler)
+	thresholdTee := NewThresholdTee([]ThresholdRoute{{Warning, &handler}})
+	msg := &Message{Severity: Error}
+
+	for name, tee := range map[string]Handler{"level": levelTee, "threshold": thresholdTee} {
+		if allocs := testing.AllocsPerRun(100, func() { tee.Handle(msg) }); allocs != 0 {
+			t.Fatalf("Expected %s tee to not allocate, but got %v allocations", name, allocs)
+		}
+	}
+}
+
+func TestTeeConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const goroutines, messages = 8, 1000
+
+	var low, high, fallback countingHandler
+	tees := []Handler{
+		NewLevelTee(map[Severity]Handler{Emergency: &high, Debug: &low}, &fallback),
+		NewThresholdTee([]ThresholdRoute{{Error, &high}, {Debug, &low}}),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < messages; n++ {
+				msg := &Message{Severity: Severity((i + n) % (maxSeverity + 1))}
+				for _, tee := range tees {
+					tee.Handle(msg)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	got := low.count() + high.count() + fallback.count()
+	if expected := int64(goroutines * messages * len(tees)); got != expected {
+		t.Fatalf("Expected %d messages to be handled, but got %d", expected, got)
+	}
+}
@@ -6,7 +6,7 @@ package syslog
 
 import (
 	"io"
-	"strings"
+	"strconv"
 	"time"
 )
 
@@ -15,8 +15,15 @@ import (
 // once. So if multiple functions are passed the second part is required if the
 // first part is present.
 func optional(peekLength int, fns ...parseFunc) parseFunc {
-	return func(buf *buffer, msg *Message) error {
-		if _, err := buf.Peek(peekLength); err == io.EOF {
+	fn := optionalIf(remaining(peekLength), fns...)
+	return setStage(fn, stage{name: "Optional", flags: stageOptional, wraps: fns})
+}
+
+// OptionalIf calls fns only if pred returns true. Once the first function is
+// called the others are required.
+func optionalIf(pred func(*buffer) bool, fns ...parseFunc) parseFunc {
+	return setStage(func(buf *buffer, msg *Message) error {
+		if !pred(buf) {
 			return nil
 		}
 
@@ -26,32 +33,181 @@ func optional(peekLength int, fns ...parseFunc) parseFunc {
 			}
 		}
 		return nil
+	}, stage{name: "OptionalIf", flags: stageOptional, wraps: fns})
+}
+
+// NextIs returns a predicate, for optionalIf, that checks if the next byte is
+// c.
+func nextIs(c byte) func(*buffer) bool {
+	return func(buf *buffer) bool {
+		return buf.position < buf.length && buf.bytes[buf.position] == c
+	}
+}
+
+// Remaining returns a predicate, for optionalIf, that checks if at least n
+// bytes remain.
+func remaining(n int) func(*buffer) bool {
+	return func(buf *buffer) bool {
+		return buf.maxRead() >= n
+	}
+}
+
+// NonBlank is a predicate, for optionalIf, that checks if any byte other than
+// whitespace remains.
+func nonBlank(buf *buffer) bool {
+	b := buf.bytes[buf.position:buf.length]
+	return indexNonSpace(b) < len(b)
+}
+
+// HasMessage is a predicate, for optionalIf, that checks if the message
+// (MSG) follows, i.e. anything other than whitespace remains. With
+// WithRawMessage the whitespace is part of the message, so anything
+// remaining is checked.
+func hasMessage(buf *buffer) bool {
+	if buf.cfg.RawMessage() {
+		return buf.maxRead() != 0
+	}
+	return nonBlank(buf)
+}
+
+// Alt tries the candidates in order, returning after the first one that
+// parses successfully. After a failed candidate the buffer and the fields of
+// the message are restored before trying the next one, note however that
+// values added to existing maps in Message.Data are not removed. If all
+// candidates fail the error of the last one is returned.
+func alt(candidates ...format) parseFunc {
+	return func(buf *buffer, msg *Message) error {
+		mark := buf.Mark()
+		saved := *msg
+		var err error
+		for _, candidate := range candidates {
+			if err = parseSequence(buf, msg, candidate); err == nil {
+				return nil
+			}
+			buf.Restore(mark)
+			*msg = saved
+		}
+		return err
+	}
+}
+
+// Many calls fn repeatedly, until it fails without consuming anything or the
+// message is completely parsed. It returns an error if fn failed after
+// consuming part of the message, or if fn succeeded less than min times.
+func many(fn parseFunc, min int) parseFunc {
+	return func(buf *buffer, msg *Message) error {
+		for n := 0; ; n++ {
+			if n >= min && buf.position == buf.length {
+				return nil
+			}
+
+			mark := buf.Mark()
+			if err := fn(buf, msg); err != nil {
+				if n < min || buf.position != mark {
+					return err
+				}
+				return nil
+			} else if buf.position == mark {
+				// Didn't consume anything, calling it again won't either.
+				if n+1 < min {
+					return newFormatError(buf.Pos(), nil, "expected at least "+
+						strconv.Itoa(min)+" elements, but got "+strconv.Itoa(n+1))
+				}
+				return nil
+			}
+		}
+	}
+}
+
+// ParseSequence calls all fns, returning the first error. An io.EOF error is
+// returned as a format error at the position it occurred, so it remains
+// correct if the buffer is restored.
+func parseSequence(buf *buffer, msg *Message, fns []parseFunc) error {
+	for _, fn := range fns {
+		if err := fn(buf, msg); err != nil {
+			if err == io.EOF {
+				err = newFormatError(buf.Pos(), ErrTruncated, "unexpected end of message")
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// End checks that the entire message is parsed.
+func end(buf *buffer, msg *Message) error {
+	if buf.position != buf.length {
+		return newFormatError(buf.Pos(), nil, "expected end of message")
 	}
+	return nil
 }
 
-// Requires Priority to be set on the Message.
+// WithOptions runs fn with the options applied on top of the configuration of
+// the Parser.
+func withOptions(opts []Option, fn parseFunc) parseFunc {
+	if len(opts) == 0 {
+		return fn
+	}
+
+	return func(buf *buffer, msg *Message) error {
+		var cfg config
+		parent := buf.cfg
+		if parent != nil {
+			cfg = *parent
+		}
+		for _, opt := range opts {
+			opt(&cfg)
+		}
+
+		buf.cfg = &cfg
+		err := fn(buf, msg)
+		buf.cfg = parent
+		return err
+	}
+}
+
+// Requires Priority to be set on the Message, an invalid priority is ignored.
 func calculateFacility(buf *buffer, msg *Message) error {
-	msg.Facility = msg.Priority.CalculateFacility()
+	if msg.Priority.IsValid() {
+		msg.Facility = msg.Priority.CalculateFacility()
+	}
 	return nil
 }
 
-// Requires Priority to be set on the Message.
+// Requires Priority to be set on the Message, an invalid priority is ignored.
 func calculateSeverity(buf *buffer, msg *Message) error {
-	msg.Severity = msg.Priority.CalculateSeverity()
+	if msg.Priority.IsValid() {
+		msg.Severity = msg.Priority.CalculateSeverity()
+	}
 	return nil
 }
 
 // Requires Timestamp to be set on the Message.
-// This adds the years to the timestamp.
+// This adds the years to the timestamp, see WithYear.
 func nginxFixTimestamp(buf *buffer, msg *Message) error {
-	msg.Timestamp = msg.Timestamp.AddDate(time.Now().Year(), 0, 0)
+	if buf.cfg.HasYear() {
+		msg.Timestamp = msg.Timestamp.AddDate(buf.cfg.Year(), 0, 0)
+	} else {
+		msg.Timestamp = inferYear(msg.Timestamp, buf.cfg.Now())
+	}
 	return nil
 }
 
-// Requires Appname to be set on the Message.
-// The format Nginx uses adds a colon to seperate the appname from the message
-// (or structered data), we trim that colon using this function.
-func nginxFixAppName(buf *buffer, msg *Message) error {
-	msg.Appname = strings.TrimSuffix(msg.Appname, ":")
-	return nil
+// Maximum time a timestamp with an inferred year can be in the future.
+const maxInferredFuture = 48 * time.Hour
+
+// inferYear returns the timestamp, which has no year, in the latest year that
+// doesn't put it more than maxInferredFuture after now. A timestamp on the
+// 29th of February is moved to a leap year.
+func inferYear(t, now time.Time) time.Time {
+	for year := now.Year() + 1; year >= now.Year()-8; year-- {
+		timestamp := time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(),
+			t.Second(), t.Nanosecond(), t.Location())
+		if timestamp.Month() != t.Month() {
+			continue // 29th of February in a non-leap year.
+		} else if !timestamp.After(now.Add(maxInferredFuture)) {
+			return timestamp
+		}
+	}
+	return t.AddDate(now.Year(), 0, 0)
 }
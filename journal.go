@@ -0,0 +1,209 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// journalDataID is the data-ID of the structured data element holding the
+// journal fields that are not mapped onto a field of Message.
+const journalDataID = "journal"
+
+// ParseJournalJSON parses a single line of `journalctl -o json` output, see
+// JournalJSON for how the journal fields are mapped. Invalid JSON returns a
+// *FormatError, which includes the start of the line in its snippet.
+func ParseJournalJSON(b []byte) (*Message, error) {
+	return ParseMessage(b, JournalJSON)
+}
+
+// parseJournalJSON parses a JSON journal entry, see JournalJSON.
+func parseJournalJSON(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+	if len(b) == 0 {
+		return io.EOF
+	}
+	buf.position = buf.length
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return newFormatError(startPos, nil, "invalid journal JSON: expected an object")
+		}
+		return newFormatError(startPos+jsonErrorOffset(err), nil,
+			"invalid journal JSON: "+strings.TrimPrefix(err.Error(), "json: "))
+	}
+
+	msg.Facility = UserLevel
+	var hasPriority bool
+	for name, raw := range fields {
+		values, err := journalValues(raw)
+		if err != nil {
+			return newFormatError(startPos, nil, "invalid value of journal field "+name)
+		} else if len(values) == 0 {
+			continue
+		}
+
+		value, mapped := values[0], true
+		switch name {
+		case "MESSAGE":
+			msg.Message = value
+		case "PRIORITY":
+			n, err := strconv.ParseUint(value, 10, 8)
+			if err != nil || !Severity(n).IsValid() {
+				return newFormatError(startPos, ErrBadPriority, "invalid journal PRIORITY")
+			}
+			msg.Severity, hasPriority = Severity(n), true
+		case "SYSLOG_FACILITY":
+			n, err := strconv.ParseUint(value, 10, 8)
+			if err != nil || !Facility(n).IsValid() {
+				return newFormatError(startPos, ErrBadPriority, "invalid journal SYSLOG_FACILITY")
+			}
+			msg.Facility = Facility(n)
+		case "SYSLOG_IDENTIFIER":
+			msg.Appname = value
+		case "_PID":
+			msg.ProcessID = value
+		case "_HOSTNAME":
+			msg.Hostname = value
+		case "MESSAGE_ID":
+			msg.MessageID = value
+		case "__REALTIME_TIMESTAMP":
+			usec, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return newFormatError(startPos, ErrBadTimestamp, "invalid journal __REALTIME_TIMESTAMP")
+			}
+			msg.Timestamp = time.UnixMicro(usec).In(buf.cfg.Location())
+		default:
+			mapped = false
+		}
+
+		if !mapped {
+			addJournalValue(msg, name, value)
+		}
+		for _, value := range values[1:] {
+			addJournalValue(msg, name, value)
+		}
+	}
+
+	// Fallbacks for entries not logged using syslog(3) or sd_journal_print(3).
+	if msg.Appname == "" {
+		msg.Appname = msg.Data[journalDataID]["_COMM"]
+	}
+	if msg.ProcessID == "" {
+		msg.ProcessID = msg.Data[journalDataID]["SYSLOG_PID"]
+	}
+
+	if hasPriority {
+		msg.Priority = CalculatePriority(msg.Facility, msg.Severity)
+	}
+
+	switch {
+	case len(msg.Hostname) > maxHostnameLength:
+		return newFormatError(startPos, ErrFieldTooLong, "hostname too long")
+	case len(msg.Appname) > maxAppNameLength:
+		return newFormatError(startPos, ErrFieldTooLong, "appname too long")
+	case len(msg.ProcessID) > maxProcessIDLength:
+		return newFormatError(startPos, ErrFieldTooLong, "processID too long")
+	case len(msg.MessageID) > maxMessageIDLength:
+		return newFormatError(startPos, ErrFieldTooLong, "messageID too long")
+	}
+	return nil
+}
+
+// journalValues returns the values of a journal field. The journal uses a
+// string for a single value, null for values that are too large, an array of
+// bytes for binary values and an array of values for fields that are set
+// multiple times.
+func journalValues(raw json.RawMessage) ([]string, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		if value, ok := journalBinary(v); ok {
+			return []string{value}, nil
+		}
+
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			switch e := e.(type) {
+			case nil:
+			case string:
+				values = append(values, e)
+			case []interface{}:
+				value, ok := journalBinary(e)
+				if !ok {
+					return nil, errors.New("invalid journal value")
+				}
+				values = append(values, value)
+			default:
+				return nil, errors.New("invalid journal value")
+			}
+		}
+		return values, nil
+	default:
+		return nil, errors.New("invalid journal value")
+	}
+}
+
+// journalBinary returns the binary value, an array of bytes, as string.
+func journalBinary(v []interface{}) (string, bool) {
+	if len(v) == 0 {
+		return "", false
+	}
+
+	b := make([]byte, len(v))
+	for i, e := range v {
+		n, ok := e.(float64)
+		if !ok || n < 0 || n > 255 || n != float64(byte(n)) {
+			return "", false
+		}
+		b[i] = byte(n)
+	}
+	return string(b), true
+}
+
+// addJournalValue adds the value of the journal field to Data, keeping all
+// values of fields with multiple values, see Message.ParamValues.
+func addJournalValue(msg *Message, name, value string) {
+	if msg.Data == nil {
+		msg.Data = map[string]map[string]string{}
+	}
+	data := msg.Data[journalDataID]
+	if data == nil {
+		data = map[string]string{}
+		msg.Data[journalDataID] = data
+	}
+
+	if previous, ok := data[name]; ok {
+		msg.addRepeated(journalDataID, name, previous, value)
+		return
+	}
+	data[name] = value
+}
+
+// jsonErrorOffset returns the offset of the JSON syntax error, the index of
+// the byte that caused it, or 0 if unknown.
+func jsonErrorOffset(err error) int {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) && syntaxErr.Offset > 0 {
+		return int(syntaxErr.Offset) - 1
+	}
+	return 0
+}
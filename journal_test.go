@@ -0,0 +1,162 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseJournalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputJournalJSON),
+			&Message{Facility: UserLevel},
+		},
+		{
+			string(regularInputJournalJSON),
+			&Message{
+				Priority:  CalculatePriority(System, Error),
+				Facility:  System,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 123456000, time.UTC),
+				Hostname:  "hostname",
+				Appname:   "systemd",
+				ProcessID: "1",
+				MessageID: "be02cf6855d2428ba40df7e9d022f03d",
+				Data: map[string]map[string]string{
+					"journal": {
+						"__CURSOR":              "s=6d1a;i=1",
+						"__MONOTONIC_TIMESTAMP": "8940406075",
+						"_BOOT_ID":              "5b3d7f1e",
+						"_COMM":                 "systemd",
+						"_SYSTEMD_UNIT":         "init.scope",
+						"UNIT":                  "nginx.service",
+					},
+				},
+				Message: "Failed to start A high performance web server.",
+			},
+		},
+		{
+			// Fallbacks, null and binary values.
+			`{"MESSAGE":[104,105,10],"_COMM":"nginx","SYSLOG_PID":"42","PRIORITY":"6","LARGE":null}`,
+			&Message{
+				Priority:  CalculatePriority(UserLevel, Informational),
+				Facility:  UserLevel,
+				Severity:  Informational,
+				Appname:   "nginx",
+				ProcessID: "42",
+				Data: map[string]map[string]string{
+					"journal": {"_COMM": "nginx", "SYSLOG_PID": "42"},
+				},
+				Message: "hi\n",
+			},
+		},
+		{
+			// Multiple values.
+			`{"MESSAGE":["a","b",[99]],"TAG":["x","y"]}`,
+			&Message{
+				Facility: UserLevel,
+				Data: map[string]map[string]string{
+					"journal": {"MESSAGE": "b", "TAG": "x"},
+				},
+				Message: "a",
+				repeated: map[string]map[string][]string{
+					"journal": {"MESSAGE": {"b", "c"}, "TAG": {"x", "y"}},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseJournalJSON([]byte(test.Input))
+		if err != nil {
+			t.Fatalf("Unexpected error ParseJournalJSON(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseJournalJSON(%q) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseJournalJSONParamValues(t *testing.T) {
+	t.Parallel()
+
+	const input = `{"MESSAGE":"m","TAG":["x","y","z"]}`
+	msg, err := ParseJournalJSON([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error ParseJournalJSON(%q): %s", input, err)
+	}
+
+	expected := []string{"x", "y", "z"}
+	if got := msg.ParamValues("journal", "TAG"); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected msg.ParamValues(journal, TAG) to return %q, but got %q", expected, got)
+	}
+}
+
+func TestParseJournalJSONErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input        string
+		Expected     string
+		ExpectedKind error
+	}{
+		{``, "syslog: JournalJSON: format incorrect at byte 1: unexpected end of message", ErrTruncated},
+		{`{"MESSAGE":"m"`,
+			"syslog: JournalJSON: format incorrect at byte 14: invalid journal JSON: unexpected end of JSON input", nil},
+		{`{"MESSAGE":m}`,
+			"syslog: JournalJSON: format incorrect at byte 12: invalid journal JSON: invalid character 'm' looking for beginning of value", nil},
+		{`["MESSAGE"]`,
+			"syslog: JournalJSON: format incorrect at byte 1: invalid journal JSON: expected an object", nil},
+		{`{"MESSAGE":1}`, "syslog: JournalJSON: format incorrect at byte 1: invalid value of journal field MESSAGE", nil},
+		{`{"MESSAGE":[256]}`, "syslog: JournalJSON: format incorrect at byte 1: invalid value of journal field MESSAGE", nil},
+		{`{"MESSAGE":["a",1]}`, "syslog: JournalJSON: format incorrect at byte 1: invalid value of journal field MESSAGE", nil},
+		{`{"PRIORITY":"8"}`, "syslog: JournalJSON: format incorrect at byte 1: invalid journal PRIORITY", ErrBadPriority},
+		{`{"SYSLOG_FACILITY":"a"}`, "syslog: JournalJSON: format incorrect at byte 1: invalid journal SYSLOG_FACILITY", ErrBadPriority},
+		{`{"__REALTIME_TIMESTAMP":"now"}`,
+			"syslog: JournalJSON: format incorrect at byte 1: invalid journal __REALTIME_TIMESTAMP", ErrBadTimestamp},
+		{`{"SYSLOG_IDENTIFIER":"` + generateString("a", maxAppNameLength+1) + `"}`,
+			"syslog: JournalJSON: format incorrect at byte 1: appname too long", ErrFieldTooLong},
+	}
+
+	for _, test := range tests {
+		_, err := ParseJournalJSON([]byte(test.Input))
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseJournalJSON(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		} else if test.ExpectedKind != nil && !errors.Is(err, test.ExpectedKind) {
+			t.Fatalf("Expected ParseJournalJSON(%q) to return error %q, but got %v",
+				test.Input, test.ExpectedKind, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseJournalJSON(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseJournalJSONErrorSnippet(t *testing.T) {
+	t.Parallel()
+
+	const input = `{"MESSAGE":"message",oops}`
+	_, err := ParseJournalJSON([]byte(input))
+	expected := "syslog: JournalJSON: format incorrect at byte 22: invalid journal JSON: invalid character 'o' looking for beginning of object key string\n" +
+		"\t...\"MESSAGE\":\"message\",oops}\n" +
+		"\t                       ^"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected ParseJournalJSON(%q) to return error %q, but got %v", input, expected, err)
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jsonMessage is the JSON representation of a Message.
+type jsonMessage struct {
+	Priority  Priority                     `json:"priority,omitempty"`
+	Facility  json.RawMessage              `json:"facility,omitempty"`
+	Severity  json.RawMessage              `json:"severity,omitempty"`
+	Version   uint                         `json:"version,omitempty"`
+	Timestamp string                       `json:"timestamp,omitempty"`
+	Hostname  string                       `json:"hostname,omitempty"`
+	Appname   string                       `json:"appname,omitempty"`
+	ProcessID string                       `json:"process_id,omitempty"`
+	MessageID string                       `json:"message_id,omitempty"`
+	Data      map[string]map[string]string `json:"data,omitempty"`
+	RawData   string                       `json:"raw_data,omitempty"`
+	Message   string                       `json:"message,omitempty"`
+	UTF8      bool                         `json:"utf8,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The fields use lowercase names, e.g.
+// "hostname" and "process_id", fields with a zero value are omitted. The
+// facility and severity are formatted as their names, see Facility.String and
+// Severity.String, or as a number if the name isn't unique or the value is
+// invalid. The timestamp is formatted using time.RFC3339Nano.
+func (msg *Message) MarshalJSON() ([]byte, error) {
+	m := jsonMessage{
+		Priority:  msg.Priority,
+		Version:   msg.Version,
+		Hostname:  msg.Hostname,
+		Appname:   msg.Appname,
+		ProcessID: msg.ProcessID,
+		MessageID: msg.MessageID,
+		Data:      msg.Data,
+		RawData:   msg.RawData,
+		Message:   msg.Message,
+		UTF8:      msg.UTF8,
+	}
+
+	if msg.Facility != 0 {
+		f, ok := facilityByName(msg.Facility.String())
+		m.Facility = jsonLevel(msg.Facility.String(), uint8(msg.Facility), ok && f == msg.Facility)
+	}
+	if msg.Severity != 0 {
+		m.Severity = jsonLevel(msg.Severity.String(), uint8(msg.Severity), msg.Severity.IsValid())
+	}
+	if !msg.Timestamp.IsZero() {
+		m.Timestamp = msg.Timestamp.Format(time.RFC3339Nano)
+	}
+
+	return json.Marshal(m)
+}
+
+// jsonLevel returns the name of a facility or severity as JSON string, or the
+// number if the name can't be used.
+func jsonLevel(name string, n uint8, useName bool) json.RawMessage {
+	if useName {
+		return json.RawMessage(strconv.Quote(name))
+	}
+	return json.RawMessage(strconv.Itoa(int(n)))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, it accepts the format created by
+// MarshalJSON. The facility and severity can be either a name, compared case
+// insensitively, or a number.
+func (msg *Message) UnmarshalJSON(b []byte) error {
+	var m jsonMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	var facility Facility
+	if len(m.Facility) != 0 {
+		n, err := unmarshalLevel(m.Facility, "facility", func(name string) (uint8, bool) {
+			f, ok := facilityByName(name)
+			return uint8(f), ok
+		})
+		if err != nil {
+			return err
+		}
+		facility = Facility(n)
+	}
+
+	var severity Severity
+	if len(m.Severity) != 0 {
+		n, err := unmarshalLevel(m.Severity, "severity", func(name string) (uint8, bool) {
+			s, ok := severityByName(name)
+			return uint8(s), ok
+		})
+		if err != nil {
+			return err
+		}
+		severity = Severity(n)
+	}
+
+	var timestamp time.Time
+	if m.Timestamp != "" {
+		var err error
+		if timestamp, err = time.Parse(time.RFC3339Nano, m.Timestamp); err != nil {
+			return errors.New("syslog: invalid timestamp in JSON: " + err.Error())
+		}
+	}
+
+	*msg = Message{
+		Priority:  m.Priority,
+		Facility:  facility,
+		Severity:  severity,
+		Version:   m.Version,
+		Timestamp: timestamp,
+		Hostname:  m.Hostname,
+		Appname:   m.Appname,
+		ProcessID: m.ProcessID,
+		MessageID: m.MessageID,
+		Data:      m.Data,
+		Message:   m.Message,
+		RawData:   m.RawData,
+		UTF8:      m.UTF8,
+	}
+	return nil
+}
+
+// unmarshalLevel unmarshals a facility or severity, either as name or number.
+func unmarshalLevel(b json.RawMessage, kind string, byName func(string) (uint8, bool)) (uint8, error) {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		if n, ok := byName(name); ok {
+			return n, nil
+		}
+		return 0, errors.New("syslog: unknown " + kind + " in JSON: " + name)
+	}
+
+	var n uint8
+	if err := json.Unmarshal(b, &n); err != nil {
+		return 0, errors.New("syslog: invalid " + kind + " in JSON: " + string(b))
+	}
+	return n, nil
+}
+
+// facilityByName returns the first facility with the name, see
+// Facility.String.
+func facilityByName(name string) (Facility, bool) {
+	for facility := Facility(0); facility.IsValid(); facility++ {
+		if strings.EqualFold(facility.String(), name) {
+			return facility, true
+		}
+	}
+	return 0, false
+}
+
+// severityByName returns the severity with the name, see Severity.String.
+func severityByName(name string) (Severity, bool) {
+	for severity := Severity(0); severity.IsValid(); severity++ {
+		if strings.EqualFold(severity.String(), name) {
+			return severity, true
+		}
+	}
+	return 0, false
+}
+
+// JSONEncoder writes messages in the JSON Lines format, a compact JSON object
+// per line, see Message.MarshalJSON for the format of the objects. It's safe
+// for concurrent use.
+type JSONEncoder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONEncoder returns a new JSONEncoder writing to w.
+func NewJSONEncoder(w io.Writer) *JSONEncoder {
+	return &JSONEncoder{w: w}
+}
+
+// Encode writes the message as a single line to the underlying writer.
+func (enc *JSONEncoder) Encode(msg *Message) error {
+	b, err := msg.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	enc.mu.Lock()
+	defer enc.mu.Unlock()
+	_, err = enc.w.Write(b)
+	return err
+}
+
+// Flush flushes the underlying writer if it has a Flush method, like
+// bufio.Writer, otherwise it does nothing.
+func (enc *JSONEncoder) Flush() error {
+	enc.mu.Lock()
+	defer enc.mu.Unlock()
+	if w, ok := enc.w.(interface{ Flush() error }); ok {
+		return w.Flush()
+	}
+	return nil
+}
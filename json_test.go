@@ -0,0 +1,200 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMessageJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	input, err := ParseMessage(regularInputRFC5424, RFC5424)
+	if err != nil {
+		t.Fatalf("Unexpected error ParseMessage(%q): %s", regularInputRFC5424, err)
+	}
+
+	b, err := json.Marshal(input)
+	if err != nil {
+		t.Fatalf("Unexpected error json.Marshal(): %s", err)
+	}
+
+	expected := `{"priority":191,"facility":"Local 7","severity":"Debug","version":10,` +
+		`"timestamp":"2015-09-30T23:10:11+02:00","hostname":"hostname",` +
+		`"appname":"appname","process_id":"procid","message_id":"msgid",` +
+		`"data":{"data":{"name":"value"}},"message":"message"}`
+	if got := string(b); got != expected {
+		t.Fatalf("Expected json.Marshal() to return %s, but got %s", expected, got)
+	}
+
+	var got Message
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Unexpected error json.Unmarshal(%s): %s", b, err)
+	} else if !messagesAreEqual(&got, input) {
+		t.Fatalf("Expected json.Unmarshal(%s) to return %#v, but got %#v", b, input, got)
+	}
+}
+
+func TestMessageMarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Msg      *Message
+		Expected string
+	}{
+		{&Message{}, `{}`},
+		{&Message{Facility: SecurityAuthorization2, Severity: Severity(8)}, `{"facility":10,"severity":8}`},
+		{&Message{Facility: SecurityAuthorization, Severity: Alert}, `{"facility":"Security/authorization","severity":"Alert"}`},
+		{&Message{Timestamp: time.Date(2015, 9, 30, 23, 10, 11, 123, time.UTC)}, `{"timestamp":"2015-09-30T23:10:11.000000123Z"}`},
+		{&Message{RawData: "[data]", UTF8: true}, `{"raw_data":"[data]","utf8":true}`},
+	}
+
+	for _, test := range tests {
+		b, err := json.Marshal(test.Msg)
+		if err != nil {
+			t.Fatalf("Unexpected error json.Marshal(%#v): %s", test.Msg, err)
+		} else if got := string(b); got != test.Expected {
+			t.Fatalf("Expected json.Marshal(%#v) to return %s, but got %s",
+				test.Msg, test.Expected, got)
+		}
+	}
+}
+
+func TestMessageUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{`{}`, &Message{}},
+		{`{"facility":"local 7","severity":"debug"}`, &Message{Facility: Local7, Severity: Debug}},
+		{`{"facility":23,"severity":7}`, &Message{Facility: Local7, Severity: Debug}},
+		{`{"facility":"Security/authorization","severity":"WARNING"}`,
+			&Message{Facility: SecurityAuthorization, Severity: Warning}},
+		{`{"hostname":"host","timestamp":"2015-09-30T23:10:11Z","data":{"a":{}}}`, &Message{
+			Hostname:  "host",
+			Timestamp: time.Date(2015, 9, 30, 23, 10, 11, 0, time.UTC),
+			Data:      map[string]map[string]string{"a": {}},
+		}},
+	}
+
+	for _, test := range tests {
+		var got Message
+		if err := json.Unmarshal([]byte(test.Input), &got); err != nil {
+			t.Fatalf("Unexpected error json.Unmarshal(%s): %s", test.Input, err)
+		} else if !messagesAreEqual(&got, test.Expected) {
+			t.Fatalf("Expected json.Unmarshal(%s) to return %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+
+	errorTests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`{"severity":"loud"}`, "syslog: unknown severity in JSON: loud"},
+		{`{"facility":true}`, "syslog: invalid facility in JSON: true"},
+		{`{"severity":256}`, "syslog: invalid severity in JSON: 256"},
+		{`{"timestamp":"yesterday"}`, `syslog: invalid timestamp in JSON: parsing time "yesterday" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "yesterday" as "2006"`},
+	}
+
+	for _, test := range errorTests {
+		var got Message
+		if err := json.Unmarshal([]byte(test.Input), &got); err == nil {
+			t.Fatalf("Expected json.Unmarshal(%s) to return an error, but got nil", test.Input)
+		} else if err.Error() != test.Expected {
+			t.Fatalf("Expected json.Unmarshal(%s) to return error %q, but got %q",
+				test.Input, test.Expected, err.Error())
+		}
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input  []byte
+		Format format
+	}{
+		{regularInputRFC5424, RFC5424},
+		{regularInputNginxAccess, NginxAccess},
+		{regularInputNginxError, NginxError},
+	}
+
+	var expected []*Message
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	enc := NewJSONEncoder(w)
+	for _, test := range tests {
+		msg, err := ParseMessage(test.Input, test.Format)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		}
+		expected = append(expected, msg)
+
+		if err := enc.Encode(msg); err != nil {
+			t.Fatalf("Unexpected error enc.Encode(): %s", err)
+		}
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("Expected the JSONEncoder to not write before flushing, but got %q", buf.String())
+	} else if err := enc.Flush(); err != nil {
+		t.Fatalf("Unexpected error enc.Flush(): %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected the JSONEncoder to write %d lines, but got %d: %q",
+			len(expected), len(lines), buf.String())
+	}
+
+	for i, line := range lines {
+		var got Message
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("Unexpected error json.Unmarshal(%q): %s", line, err)
+		} else if !messagesAreEqual(&got, expected[i]) {
+			t.Fatalf("Expected json.Unmarshal(%q) to return %#v, but got %#v",
+				line, expected[i], &got)
+		}
+	}
+}
+
+func TestJSONEncoderConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const n = 10
+	var buf bytes.Buffer
+	enc := NewJSONEncoder(&buf)
+	msg := &Message{Hostname: "hostname", Message: "message"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := enc.Encode(msg); err != nil {
+				t.Errorf("Unexpected error enc.Encode(): %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := enc.Flush(); err != nil {
+		t.Fatalf("Unexpected error enc.Flush(): %s", err)
+	}
+
+	expected := strings.Repeat(`{"hostname":"hostname","message":"message"}`+"\n", n)
+	if got := buf.String(); got != expected {
+		t.Fatalf("Expected the JSONEncoder to write %q, but got %q", expected, got)
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonDataID is the data-ID of the structured data element holding the JSON
+// fields that are not mapped onto a field of Message, see JSONFormat.
+const jsonDataID = "json"
+
+// Timestamp layout for JSONFormat of timestamps in seconds since the Unix
+// epoch, e.g. 1444739500.123.
+const jsonUnixLayout = "unix"
+
+// Level names used by logging libraries that ParseSeverityName doesn't accept.
+var jsonLevelAliases = map[string]Severity{
+	"fatal":  Critical,
+	"dpanic": Critical,
+	"trace":  Debug,
+}
+
+// jsonFields are the keys of the JSON fields that are mapped onto the fields
+// of Message, see JSONFormat.
+type jsonFields struct {
+	timestamp, layout, severity, hostname, appname, processID, messageID, message string
+}
+
+// JSONFormat returns a format to parse messages that are a single JSON object,
+// e.g. the access logs of Caddy and Traefik. Nested objects are flattened,
+// using a dotted path as key, e.g. {"request":{"method":"GET"}} becomes
+// "request.method". Numbers keep the form they have in the JSON, e.g. "200" or
+// "0.003", booleans become "true" or "false", arrays are kept as JSON and null
+// values are dropped.
+//
+// The fieldMap maps fields of Message onto the (flattened) keys of the JSON
+// fields, e.g. {"Message": "msg"}. The fields Timestamp, Severity, Hostname,
+// Appname, ProcessID, MessageID and Message can be mapped. The layout of the
+// timestamp, by default time.RFC3339Nano, is set with the TimestampLayout
+// field, "unix" is accepted for timestamps in seconds since the Unix epoch,
+// e.g. 1444739500.123. The level names are parsed using ParseSeverityName,
+// "fatal", "dpanic" and "trace" are accepted as well. The JSON fields that are
+// not mapped onto a field are stored in Message.Data["json"].
+//
+// It panics if the fieldMap contains a name that isn't one of the fields
+// above.
+func JSONFormat(fieldMap map[string]string) Format {
+	var fields jsonFields
+	for name, key := range fieldMap {
+		switch name {
+		case "Timestamp":
+			fields.timestamp = key
+		case "TimestampLayout":
+			fields.layout = key
+		case "Severity":
+			fields.severity = key
+		case "Hostname":
+			fields.hostname = key
+		case "Appname":
+			fields.appname = key
+		case "ProcessID":
+			fields.processID = key
+		case "MessageID":
+			fields.messageID = key
+		case "Message":
+			fields.message = key
+		default:
+			panic("syslog: can't map JSON field onto unknown Message field " + name)
+		}
+	}
+	if fields.layout == "" {
+		fields.layout = time.RFC3339Nano
+	}
+
+	return format{parseJSONObject(fields)}
+}
+
+// parseJSONObject returns a parseFunc that parses a JSON object, see
+// JSONFormat.
+func parseJSONObject(fields jsonFields) parseFunc {
+	return func(buf *buffer, msg *Message) error {
+		startPos := buf.Pos()
+		b := buf.bytes[buf.position:buf.length]
+		if len(b) == 0 {
+			return io.EOF
+		}
+		buf.position = buf.length
+
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		var object map[string]interface{}
+		if err := dec.Decode(&object); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				return newFormatError(startPos, nil, "invalid JSON: expected an object")
+			} else if err == io.ErrUnexpectedEOF {
+				return io.EOF
+			}
+			return newFormatError(startPos+jsonErrorOffset(err), nil,
+				"invalid JSON: "+strings.TrimPrefix(err.Error(), "json: "))
+		} else if object == nil {
+			return newFormatError(startPos, nil, "invalid JSON: expected an object")
+		} else if dec.More() {
+			return newFormatError(startPos+int(dec.InputOffset()), nil, "invalid JSON: data after the object")
+		}
+
+		data := make(map[string]string, len(object))
+		flattenJSON(data, "", object)
+		if err := setJSONFields(buf, msg, fields, data, startPos); err != nil {
+			return err
+		}
+
+		if len(data) != 0 {
+			msg.Data = map[string]map[string]string{jsonDataID: data}
+		}
+		return nil
+	}
+}
+
+// setJSONFields sets the fields of msg to the mapped JSON fields, removing them
+// from data.
+func setJSONFields(buf *buffer, msg *Message, fields jsonFields, data map[string]string, pos int) error {
+	take := func(key string) (string, bool) {
+		value, ok := data[key]
+		if ok && key != "" {
+			delete(data, key)
+			return value, true
+		}
+		return "", false
+	}
+
+	msg.Facility = UserLevel
+	if value, ok := take(fields.timestamp); ok {
+		timestamp, err := parseJSONTimestamp(value, fields.layout, buf.cfg.Location())
+		if err != nil {
+			return newFormatError(pos, ErrBadTimestamp, "invalid JSON timestamp field "+fields.timestamp)
+		}
+		msg.Timestamp = timestamp
+	}
+	if value, ok := take(fields.severity); ok {
+		severity, err := ParseSeverityName(value)
+		if alias, ok := jsonLevelAliases[strings.ToLower(value)]; ok {
+			severity, err = alias, nil
+		}
+		if err != nil {
+			return newFormatError(pos, nil, "unknown JSON level '"+escapeSnippet([]byte(value))+"'")
+		}
+		msg.Severity = severity
+		msg.Priority = CalculatePriority(msg.Facility, severity)
+	}
+	msg.Hostname, _ = take(fields.hostname)
+	msg.Appname, _ = take(fields.appname)
+	msg.ProcessID, _ = take(fields.processID)
+	msg.MessageID, _ = take(fields.messageID)
+	msg.Message, _ = take(fields.message)
+
+	switch {
+	case len(msg.Hostname) > maxHostnameLength:
+		return newFormatError(pos, ErrFieldTooLong, "hostname too long")
+	case len(msg.Appname) > maxAppNameLength:
+		return newFormatError(pos, ErrFieldTooLong, "appname too long")
+	case len(msg.ProcessID) > maxProcessIDLength:
+		return newFormatError(pos, ErrFieldTooLong, "processID too long")
+	case len(msg.MessageID) > maxMessageIDLength:
+		return newFormatError(pos, ErrFieldTooLong, "messageID too long")
+	}
+	return nil
+}
+
+// parseJSONTimestamp parses the timestamp using the layout, see JSONFormat.
+func parseJSONTimestamp(value, layout string, location *time.Location) (time.Time, error) {
+	if layout != jsonUnixLayout {
+		return time.ParseInLocation(layout, value, location)
+	}
+	return parseUnixTimestamp(value, location)
+}
+
+// parseUnixTimestamp parses a timestamp in seconds since the Unix epoch, with
+// an optional fraction, e.g. 1444739500.123.
+func parseUnixTimestamp(value string, location *time.Location) (time.Time, error) {
+	// Parse the fraction ourselves, a float64 isn't precise enough for
+	// nanoseconds.
+	seconds, fraction, _ := strings.Cut(value, ".")
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var nsec int64
+	if fraction != "" {
+		if !isDigits(fraction) {
+			return time.Time{}, errors.New("invalid fraction")
+		} else if len(fraction) > 9 {
+			fraction = fraction[:9]
+		}
+		nsec, _ = strconv.ParseInt(fraction, 10, 64)
+		for i := len(fraction); i < 9; i++ {
+			nsec *= 10
+		}
+		if strings.HasPrefix(seconds, "-") {
+			nsec = -nsec
+		}
+	}
+	return time.Unix(sec, nsec).In(location), nil
+}
+
+// flattenJSON adds the JSON value v to data, under key. Objects are flattened
+// using a dotted path, see JSONFormat.
+func flattenJSON(data map[string]string, key string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, value := range v {
+			if key != "" {
+				name = key + "." + name
+			}
+			flattenJSON(data, name, value)
+		}
+	case string:
+		data[key] = v
+	case json.Number:
+		data[key] = v.String()
+	case bool:
+		data[key] = strconv.FormatBool(v)
+	case []interface{}:
+		b, _ := json.Marshal(v)
+		data[key] = string(b)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	// The default JSON access log of Caddy.
+	caddyJSON = JSONFormat(map[string]string{
+		"Timestamp":       "ts",
+		"TimestampLayout": "unix",
+		"Severity":        "level",
+		"Hostname":        "request.host",
+		"Appname":         "logger",
+		"Message":         "msg",
+	})
+
+	// The JSON access log of Traefik.
+	traefikJSON = JSONFormat(map[string]string{
+		"Timestamp": "time",
+		"Severity":  "level",
+		"Hostname":  "RequestHost",
+		"Appname":   "entryPointName",
+		"Message":   "msg",
+	})
+)
+
+func TestParseMessageJSONFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Format   format
+		Input    string
+		Expected *Message
+	}{
+		{
+			"Caddy", caddyJSON, string(minimumInputJSONCaddy),
+			&Message{Facility: UserLevel},
+		},
+		{
+			"Caddy", caddyJSON, string(regularInputJSONCaddy),
+			&Message{
+				Priority:  CalculatePriority(UserLevel, Informational),
+				Facility:  UserLevel,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 123456700, time.UTC),
+				Hostname:  "example.com",
+				Appname:   "http.log.access.log0",
+				Data: map[string]map[string]string{
+					"json": {
+						"request.remote_ip":          "2001:db8::1",
+						"request.remote_port":        "51515",
+						"request.proto":              "HTTP/2.0",
+						"request.method":             "GET",
+						"request.uri":                "/api?page=2",
+						"request.headers.User-Agent": `["curl/7.43.0"]`,
+						"request.headers.Accept":     `["*/*"]`,
+						"request.tls.resumed":        "false",
+						"request.tls.version":        "772",
+						"request.tls.server_name":    "example.com",
+						"bytes_read":                 "0",
+						"user_id":                    "",
+						"duration":                   "0.003027",
+						"size":                       "1234",
+						"status":                     "200",
+						"resp_headers.Server":        `["Caddy"]`,
+						"resp_headers.Content-Type":  `["application/json"]`,
+					},
+				},
+				Message: "handled request",
+			},
+		},
+		{
+			"Caddy, error", caddyJSON, `{"level":"error","ts":1444739500,"logger":"http.log.error","msg":"dial tcp 10.0.0.5:8080: connect: connection refused","request":{"method":"GET","host":"example.com","uri":"/"},"duration":0.5,"status":502}`,
+			&Message{
+				Priority:  CalculatePriority(UserLevel, Error),
+				Facility:  UserLevel,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Hostname:  "example.com",
+				Appname:   "http.log.error",
+				Data: map[string]map[string]string{
+					"json": {
+						"request.method": "GET",
+						"request.uri":    "/",
+						"duration":       "0.5",
+						"status":         "502",
+					},
+				},
+				Message: "dial tcp 10.0.0.5:8080: connect: connection refused",
+			},
+		},
+		{
+			"Traefik", traefikJSON, string(minimumInputJSONTraefik),
+			&Message{
+				Facility:  UserLevel,
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.UTC),
+			},
+		},
+		{
+			"Traefik", traefikJSON, string(regularInputJSONTraefik),
+			&Message{
+				Priority:  CalculatePriority(UserLevel, Informational),
+				Facility:  UserLevel,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Hostname:  "example.com",
+				Appname:   "websecure",
+				Data: map[string]map[string]string{
+					"json": {
+						"ClientAddr":            "10.0.0.1:51515",
+						"ClientHost":            "10.0.0.1",
+						"ClientPort":            "51515",
+						"ClientUsername":        "-",
+						"DownstreamContentSize": "1234",
+						"DownstreamStatus":      "200",
+						"Duration":              "3027000",
+						"OriginContentSize":     "1234",
+						"OriginDuration":        "2500000",
+						"OriginStatus":          "200",
+						"Overhead":              "527000",
+						"RequestAddr":           "example.com",
+						"RequestContentSize":    "0",
+						"RequestCount":          "42",
+						"RequestMethod":         "GET",
+						"RequestPath":           "/api?page=2",
+						"RequestPort":           "-",
+						"RequestProtocol":       "HTTP/1.1",
+						"RequestScheme":         "https",
+						"RetryAttempts":         "0",
+						"RouterName":            "api@docker",
+						"ServiceAddr":           "172.17.0.3:8080",
+						"ServiceName":           "api@docker",
+						"ServiceURL.Scheme":     "http",
+						"ServiceURL.Host":       "172.17.0.3:8080",
+						"StartLocal":            "2015-10-13T14:31:40.123456789+02:00",
+						"StartUTC":              "2015-10-13T12:31:40.123456789Z",
+						"TLSCipher":             "TLS_AES_128_GCM_SHA256",
+						"TLSVersion":            "1.3",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), test.Format)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, %s) to return Message %#v, but got %#v",
+				test.Input, test.Name, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageJSONFormatErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{``, "syslog: format incorrect at byte 1: unexpected end of message"},
+		{`{"level":"info"`, "syslog: format incorrect at byte 15: unexpected end of message"},
+		{`[]`, "syslog: format incorrect at byte 1: invalid JSON: expected an object"},
+		{`null`, "syslog: format incorrect at byte 1: invalid JSON: expected an object"},
+		{`{"level":info}`, "syslog: format incorrect at byte 10: invalid JSON: invalid character 'i' looking for beginning of value"},
+		{`{} {}`, "syslog: format incorrect at byte 4: invalid JSON: data after the object"},
+		{`{"ts":"yesterday"}`, "syslog: format incorrect at byte 1: invalid JSON timestamp field ts"},
+		{`{"ts":1444739500.5e3}`, "syslog: format incorrect at byte 1: invalid JSON timestamp field ts"},
+		{`{"level":"loud"}`, "syslog: format incorrect at byte 1: unknown JSON level 'loud'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), caddyJSON)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestJSONFormatUnknownField(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("Expected JSONFormat to panic with an unknown Message field")
+		}
+	}()
+	JSONFormat(map[string]string{"Level": "level"})
+}
@@ -0,0 +1,114 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import "strings"
+
+// Prefixes of the keepalived VRRP messages about an instance, group or
+// script, followed by its name in parentheses, e.g. "VRRP_Instance(VI_1)".
+// keepalived 2 drops the prefix, e.g. "(VI_1)".
+var keepalivedPrefixes = [...]struct {
+	prefix, key string
+}{
+	{"VRRP_Instance(", "instance"},
+	{"VRRP_Group(", "instance"},
+	{"VRRP_Script(", "script"},
+	{"(", "instance"},
+}
+
+// ParseKeepalived parses the message of a keepalived log line, e.g.
+// "VRRP_Instance(VI_1) Transition to MASTER STATE", which is stored as
+// Message. The following events are stored in Data["vrrp"]["event"]:
+//   - transition, a state change of an instance or group, with the new state
+//     in Data["vrrp"]["state"], e.g. "Entering BACKUP STATE",
+//   - priority, a change of the priority of an instance, with the new
+//     priority in Data["vrrp"]["priority"], e.g. "Changing effective priority
+//     from 100 to 90", and
+//   - fault, a failed script or health check, e.g. "VRRP_Script(chk) failed"
+//     or "TCP connection to [10.0.0.5]:80 failed.". The checked service, if
+//     any, is stored in Data["vrrp"]["service"].
+//
+// The name of the instance, or group, is stored in Data["vrrp"]["instance"],
+// the name of a script in Data["vrrp"]["script"]. Lines without one of the
+// events above are only stored as Message.
+func parseKeepalived(buf *buffer, msg *Message) error {
+	message := string(buf.bytes[buf.position:buf.length])
+	buf.position = buf.length
+
+	msg.Message = message
+	if data := keepalivedEvent(message); data != nil {
+		msg.Data = map[string]map[string]string{"vrrp": data}
+	}
+	return nil
+}
+
+// keepalivedEvent returns the data of the event in the message, or nil if the
+// message isn't one of the events of parseKeepalived.
+func keepalivedEvent(message string) map[string]string {
+	data := map[string]string{}
+	rest := message
+	for _, p := range keepalivedPrefixes {
+		if !strings.HasPrefix(message, p.prefix) {
+			continue
+		}
+		name, r, ok := strings.Cut(message[len(p.prefix):], ") ")
+		if ok && name != "" {
+			data[p.key] = name
+			rest = r
+		}
+		break
+	}
+
+	fields := strings.Fields(rest)
+	switch {
+	case len(fields) == 4 && fields[0] == "Transition" && fields[1] == "to" && strings.EqualFold(fields[3], "state"),
+		len(fields) == 4 && fields[0] == "Now" && fields[1] == "in" && strings.EqualFold(fields[3], "state"):
+		data["event"], data["state"] = "transition", fields[2]
+	case len(fields) == 3 && fields[0] == "Entering" && strings.EqualFold(fields[2], "state"),
+		len(fields) == 5 && strings.HasPrefix(rest, "Syncing instances to ") && strings.EqualFold(fields[4], "state"):
+		data["event"], data["state"] = "transition", fields[len(fields)-2]
+	case strings.HasPrefix(rest, "Changing effective priority from ") && len(fields) == 7 && fields[5] == "to":
+		data["event"], data["priority"] = "priority", fields[6]
+	case data["script"] != "" && (rest == "failed" || strings.HasPrefix(rest, "failed ")):
+		data["event"] = "fault"
+	case keepalivedCheckFailed(rest):
+		data["event"] = "fault"
+		if service := keepalivedService(rest); service != "" {
+			data["service"] = service
+		}
+	default:
+		return nil
+	}
+	return data
+}
+
+// keepalivedCheckFailed checks if the message reports a failed health check,
+// e.g. "TCP connection to [10.0.0.5]:80 failed." or "Check on service
+// [10.0.0.5]:80 failed after 3 retry.".
+func keepalivedCheckFailed(message string) bool {
+	return (strings.HasPrefix(message, "Check on service ") ||
+		strings.HasSuffix(strings.SplitN(message, " ", 2)[0], "_CHECK") ||
+		strings.Contains(message, " connection to ")) &&
+		strings.Contains(message, " failed")
+}
+
+// keepalivedService returns the first service in the message, e.g.
+// "[10.0.0.5]:80" is returned as "10.0.0.5:80".
+func keepalivedService(message string) string {
+	start := strings.IndexByte(message, '[')
+	if start == -1 {
+		return ""
+	}
+	end := strings.IndexByte(message[start:], ']')
+	if end == -1 {
+		return ""
+	}
+	service := message[start+1 : start+end]
+	if port := message[start+end+1:]; strings.HasPrefix(port, ":") {
+		port = port[1:]
+		service += ":" + port[:skipDigits([]byte(port), 0)]
+	}
+	return service
+}
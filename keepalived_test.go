@@ -0,0 +1,133 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageKeepalived(t *testing.T) {
+	t.Parallel()
+
+	const header = "<30>Oct 13 12:31:40 hostname Keepalived_vrrp[1187]: "
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputKeepalived),
+			&Message{
+				Priority:  CalculatePriority(Kernel, Emergency),
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+			},
+		},
+		{
+			string(regularInputKeepalived),
+			keepalivedMessage("VRRP_Instance(VI_1) Transition to MASTER STATE",
+				map[string]string{"instance": "VI_1", "event": "transition", "state": "MASTER"}),
+		},
+		{
+			header + "VRRP_Instance(VI_1) Entering MASTER STATE",
+			keepalivedMessage("VRRP_Instance(VI_1) Entering MASTER STATE",
+				map[string]string{"instance": "VI_1", "event": "transition", "state": "MASTER"}),
+		},
+		{
+			// keepalived 2 format.
+			header + "(VI_1) Entering BACKUP STATE",
+			keepalivedMessage("(VI_1) Entering BACKUP STATE",
+				map[string]string{"instance": "VI_1", "event": "transition", "state": "BACKUP"}),
+		},
+		{
+			header + "VRRP_Instance(VI_1) Now in FAULT state",
+			keepalivedMessage("VRRP_Instance(VI_1) Now in FAULT state",
+				map[string]string{"instance": "VI_1", "event": "transition", "state": "FAULT"}),
+		},
+		{
+			header + "VRRP_Group(VG_1) Syncing instances to BACKUP state",
+			keepalivedMessage("VRRP_Group(VG_1) Syncing instances to BACKUP state",
+				map[string]string{"instance": "VG_1", "event": "transition", "state": "BACKUP"}),
+		},
+		{
+			header + "VRRP_Instance(VI_1) Changing effective priority from 100 to 90",
+			keepalivedMessage("VRRP_Instance(VI_1) Changing effective priority from 100 to 90",
+				map[string]string{"instance": "VI_1", "event": "priority", "priority": "90"}),
+		},
+		{
+			header + "VRRP_Script(chk_haproxy) failed",
+			keepalivedMessage("VRRP_Script(chk_haproxy) failed",
+				map[string]string{"script": "chk_haproxy", "event": "fault"}),
+		},
+		{
+			// Not an event.
+			header + "VRRP_Instance(VI_1) Received advert with higher priority 150, ours 100",
+			keepalivedMessage("VRRP_Instance(VI_1) Received advert with higher priority 150, ours 100", nil),
+		},
+		{
+			// Health checker.
+			"<30>Oct 13 12:31:40 hostname Keepalived_healthcheckers[1188]: TCP connection to [10.0.0.5]:80 failed.",
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "Keepalived_healthcheckers",
+				ProcessID: "1188",
+				Data: map[string]map[string]string{
+					"vrrp": {"event": "fault", "service": "10.0.0.5:80"},
+				},
+				Message: "TCP connection to [10.0.0.5]:80 failed.",
+			},
+		},
+		{
+			"<30>Oct 13 12:31:40 hostname Keepalived_healthcheckers[1188]: HTTP_CHECK on service [10.0.0.5]:8080 failed after 3 retry.",
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "Keepalived_healthcheckers",
+				ProcessID: "1188",
+				Data: map[string]map[string]string{
+					"vrrp": {"event": "fault", "service": "10.0.0.5:8080"},
+				},
+				Message: "HTTP_CHECK on service [10.0.0.5]:8080 failed after 3 retry.",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Keepalived)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Keepalived) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+// keepalivedMessage returns the expected message of a Keepalived_vrrp log line
+// with the given message and data.
+func keepalivedMessage(message string, data map[string]string) *Message {
+	msg := &Message{
+		Priority:  CalculatePriority(System, Informational),
+		Facility:  System,
+		Severity:  Informational,
+		Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+		Hostname:  "hostname",
+		Appname:   "Keepalived_vrrp",
+		ProcessID: "1187",
+		Message:   message,
+	}
+	if data != nil {
+		msg.Data = map[string]map[string]string{"vrrp": data}
+	}
+	return msg
+}
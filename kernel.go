@@ -0,0 +1,86 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import "bytes"
+
+// ParseKernel parses a kernel message, e.g. "[ 8940.406075] usb 1-1: new
+// high-speed USB device". The optional uptime, the seconds since boot, is
+// stored in Data["kernel"]["uptime"], without the padding, e.g.
+// "8940.406075". The subsystem before the colon is stored as Appname and the
+// optional device following it in Data["kernel"]["device"], e.g. "1-1" or
+// "sda1" for "EXT4-fs (sda1):". The remainder is stored as Message.
+func parseKernel(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+	buf.position = buf.length
+
+	data := map[string]string{}
+	uptime, rest := kernelUptime(b)
+	if uptime != "" {
+		data["uptime"] = uptime
+	}
+
+	if i := bytes.Index(rest, []byte(": ")); i > 0 {
+		subsystem, device, hasDevice := bytes.Cut(rest[:i], []byte{spaceByte})
+		if hasDevice && len(device) > 2 && device[0] == '(' && device[len(device)-1] == ')' {
+			device = device[1 : len(device)-1]
+		}
+
+		if isKernelToken(subsystem) && (!hasDevice || isKernelToken(device)) {
+			if len(subsystem) > maxAppNameLength {
+				return newFormatError(startPos+len(b)-len(rest), ErrFieldTooLong, "appname too long")
+			}
+			msg.Appname = string(subsystem)
+			if hasDevice {
+				data["device"] = string(device)
+			}
+			rest = rest[i+2:]
+		}
+	}
+
+	msg.Message = string(rest)
+	if len(data) != 0 {
+		msg.Data = map[string]map[string]string{"kernel": data}
+	}
+	return nil
+}
+
+// kernelUptime returns the uptime of the kernel timestamp, the seconds since
+// boot, e.g. "1234.567890" for "[ 1234.567890] ", and b without the
+// timestamp. If b doesn't start with a timestamp it returns an empty uptime
+// and b unchanged.
+func kernelUptime(b []byte) (string, []byte) {
+	if len(b) == 0 || b[0] != '[' {
+		return "", b
+	}
+
+	for i := 1; i < len(b); i++ {
+		if c := b[i]; c == ']' {
+			uptime := bytes.TrimLeft(b[1:i], " ")
+			if len(uptime) == 0 || bytes.IndexByte(uptime, spaceByte) != -1 {
+				break
+			}
+			return string(uptime), b[skipSpaces(b, i+1):]
+		} else if c != spaceByte && c != '.' && (c < '0' || c > '9') {
+			break
+		}
+	}
+	return "", b
+}
+
+// isKernelToken checks if b is a single printable word, e.g. a subsystem like
+// "usb" or a device like "1-1".
+func isKernelToken(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for _, c := range b {
+		if c <= spaceByte || c >= 0x7f || c == ':' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMessageDmesg(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputDmesg),
+			&Message{
+				Priority: CalculatePriority(Kernel, Informational),
+				Facility: Kernel,
+				Severity: Informational,
+			},
+		},
+		{
+			string(regularInputDmesg),
+			&Message{
+				Priority: CalculatePriority(Kernel, Informational),
+				Facility: Kernel,
+				Severity: Informational,
+				Appname:  "usb",
+				Data: map[string]map[string]string{
+					"kernel": {"uptime": "8940.406075", "device": "1-1"},
+				},
+				Message: "new high-speed USB device number 2 using xhci_hcd",
+			},
+		},
+		{
+			// Without the uptime.
+			`<6>usb 1-1: new high-speed USB device`,
+			&Message{
+				Priority: CalculatePriority(Kernel, Informational),
+				Facility: Kernel,
+				Severity: Informational,
+				Appname:  "usb",
+				Data:     map[string]map[string]string{"kernel": {"device": "1-1"}},
+				Message:  "new high-speed USB device",
+			},
+		},
+		{
+			`<4>[    0.000100] e1000e: eth0 NIC Link is Up 1000 Mbps Full Duplex`,
+			&Message{
+				Priority: CalculatePriority(Kernel, Warning),
+				Facility: Kernel,
+				Severity: Warning,
+				Appname:  "e1000e",
+				Data:     map[string]map[string]string{"kernel": {"uptime": "0.000100"}},
+				Message:  "eth0 NIC Link is Up 1000 Mbps Full Duplex",
+			},
+		},
+		{
+			`<6>[12345.000001] EXT4-fs (sda1): mounted filesystem with ordered data mode`,
+			&Message{
+				Priority: CalculatePriority(Kernel, Informational),
+				Facility: Kernel,
+				Severity: Informational,
+				Appname:  "EXT4-fs",
+				Data: map[string]map[string]string{
+					"kernel": {"uptime": "12345.000001", "device": "sda1"},
+				},
+				Message: "mounted filesystem with ordered data mode",
+			},
+		},
+		{
+			// Without a subsystem.
+			`<5>[    0.000000] Linux version 4.2.0-1-amd64 (gcc version 4.9.3 (Debian 4.9.3-2) ) #1 SMP Debian 4.2.1-2 (2015-09-27)`,
+			&Message{
+				Priority: CalculatePriority(Kernel, Notice),
+				Facility: Kernel,
+				Severity: Notice,
+				Data:     map[string]map[string]string{"kernel": {"uptime": "0.000000"}},
+				Message:  "Linux version 4.2.0-1-amd64 (gcc version 4.9.3 (Debian 4.9.3-2) ) #1 SMP Debian 4.2.1-2 (2015-09-27)",
+			},
+		},
+		{
+			`<6>NET: Registered protocol family 10`,
+			&Message{
+				Priority: CalculatePriority(Kernel, Informational),
+				Facility: Kernel,
+				Severity: Informational,
+				Appname:  "NET",
+				Message:  "Registered protocol family 10",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Dmesg)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Dmesg) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageDmesgErrors(t *testing.T) {
+	t.Parallel()
+
+	input := "<6>[1.0] " + generateString("s", maxAppNameLength+1) + ": message"
+	expected := "syslog: Dmesg: format incorrect at byte 10: appname too long"
+	_, err := ParseMessage([]byte(input), Dmesg)
+	formatErr, ok := err.(*FormatError)
+	if !ok {
+		t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v", input, err)
+	}
+
+	formatErr.Snippet = nil
+	if got := formatErr.Error(); got != expected {
+		t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q", input, expected, got)
+	} else if !errors.Is(err, ErrFieldTooLong) {
+		t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %v",
+			input, ErrFieldTooLong, err)
+	}
+}
+
+func TestKernelUptime(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input          string
+		ExpectedUptime string
+		ExpectedRest   string
+	}{
+		{"", "", ""},
+		{"[12345.678] a", "12345.678", "a"},
+		{"[    0.000000]  a", "0.000000", "a"},
+		{"[12345.678]", "12345.678", ""},
+		{"[] a", "", "[] a"},
+		{"[   ] a", "", "[   ] a"},
+		{"[1 2] a", "", "[1 2] a"},
+		{"[UFW BLOCK] a", "", "[UFW BLOCK] a"},
+		{"[12345.678 a", "", "[12345.678 a"},
+		{"a [1.2] b", "", "a [1.2] b"},
+	}
+
+	for _, test := range tests {
+		uptime, rest := kernelUptime([]byte(test.Input))
+		if uptime != test.ExpectedUptime || string(rest) != test.ExpectedRest {
+			t.Fatalf("Expected kernelUptime(%q) to return %q and %q, but got %q and %q",
+				test.Input, test.ExpectedUptime, test.ExpectedRest, uptime, rest)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Prefix of a LEEF payload.
+var leefPrefix = []byte("LEEF:")
+
+// Names of the LEEF header fields in Message.Data["leef"], after the version.
+var leefHeaderNames = [...]string{
+	"vendor",
+	"product",
+	"product_version",
+	"event_id",
+}
+
+// ParseLEEF parses a LEEF payload: "LEEF:" followed by the pipe-delimited
+// header fields and the attributes, into Data["leef"] and Data["attributes"].
+// LEEF 1.0 attributes are delimited by tabs, LEEF 2.0 may declare another
+// delimiter in an optional sixth header field. The event id is also set as
+// MessageID.
+func parseLEEF(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+	if !bytes.HasPrefix(b, leefPrefix) {
+		return newFormatError(startPos, nil, "expected LEEF header")
+	}
+
+	header := make(map[string]string, len(leefHeaderNames)+2)
+	i, ok := parsePipeFields(b, len(leefPrefix), header, []string{"version"})
+	if ok {
+		switch version := header["version"]; version {
+		case "1.0", "2.0":
+		default:
+			return newFormatError(startPos+len(leefPrefix), nil, "unsupported LEEF version "+
+				strconv.Quote(version))
+		}
+		i, ok = parsePipeFields(b, i, header, leefHeaderNames[:])
+	}
+	if !ok {
+		buf.position = buf.length
+		return newFormatError(startPos+len(b), ErrTruncated, "LEEF header too short")
+	}
+
+	delimiter := byte('\t')
+	if header["version"] == "2.0" {
+		// The delimiter field is optional, it's only present if the next field
+		// is a valid delimiter.
+		if j := bytes.IndexByte(b[i:], '|'); j != -1 {
+			if d, ok := leefDelimiter(string(b[i : i+j])); ok {
+				header["delimiter"] = string(b[i : i+j])
+				delimiter = d
+				i += j + 1
+			}
+		}
+	}
+
+	attributes, err := parseLEEFAttributes(b[i:], startPos+i, delimiter)
+	buf.position = buf.length
+	if err != nil {
+		return err
+	}
+
+	msg.Data = map[string]map[string]string{
+		"leef":       header,
+		"attributes": attributes,
+	}
+	msg.MessageID = header["event_id"]
+	return nil
+}
+
+// leefDelimiter returns the delimiter declared in a LEEF 2.0 header: a single
+// character or a hex value prefixed with "x" or "0x", e.g. "^" or "x09". An
+// empty field declares the default, a tab.
+func leefDelimiter(s string) (byte, bool) {
+	switch {
+	case s == "":
+		return '\t', true
+	case len(s) == 1:
+		return s[0], true
+	case strings.HasPrefix(s, "x") || strings.HasPrefix(s, "0x"):
+		n, err := strconv.ParseUint(s[strings.IndexByte(s, 'x')+1:], 16, 8)
+		return byte(n), err == nil
+	}
+	return 0, false
+}
+
+// ParseLEEFAttributes parses the key=value attributes, separated by the
+// delimiter, pos is the position of b in the message. Empty attributes are
+// skipped.
+func parseLEEFAttributes(b []byte, pos int, delimiter byte) (map[string]string, error) {
+	attributes := make(map[string]string)
+	for i := 0; i < len(b); {
+		end := bytes.IndexByte(b[i:], delimiter)
+		if end == -1 {
+			end = len(b)
+		} else {
+			end += i
+		}
+
+		if attr := b[i:end]; len(attr) != 0 {
+			j := bytes.IndexByte(attr, equalByte)
+			if j < 1 {
+				return nil, newFormatError(pos+i, nil, "expected LEEF attribute key=value")
+			}
+			attributes[string(attr[:j])] = string(attr[j+1:])
+		}
+		i = end + 1
+	}
+	return attributes, nil
+}
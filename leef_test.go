@@ -0,0 +1,191 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageLEEF(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputLEEF),
+			&Message{
+				Priority:  CalculatePriority(UserLevel, Notice),
+				Facility:  UserLevel,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.UTC),
+				Hostname:  "h",
+				MessageID: "e",
+				Data: map[string]map[string]string{
+					"leef": {
+						"version":         "1.0",
+						"vendor":          "v",
+						"product":         "p",
+						"product_version": "1",
+						"event_id":        "e",
+					},
+					"attributes": {},
+				},
+			},
+		},
+		{
+			"<13>Oct 13 12:31:40 host LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|" +
+				"src=192.0.2.0\tdst=172.50.123.1\tsev=5\tcat=anomaly\tmsg=a=b c\t\tusrName=joe.black",
+			&Message{
+				Priority:  CalculatePriority(UserLevel, Notice),
+				Facility:  UserLevel,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Hostname:  "host",
+				MessageID: "15345",
+				Data: map[string]map[string]string{
+					"leef": {
+						"version":         "1.0",
+						"vendor":          "Microsoft",
+						"product":         "MSExchange",
+						"product_version": "4.0 SP1",
+						"event_id":        "15345",
+					},
+					"attributes": {
+						"src":     "192.0.2.0",
+						"dst":     "172.50.123.1",
+						"sev":     "5",
+						"cat":     "anomaly",
+						"msg":     "a=b c",
+						"usrName": "joe.black",
+					},
+				},
+			},
+		},
+		{
+			string(regularInputLEEF),
+			&Message{
+				Priority:  CalculatePriority(UserLevel, Notice),
+				Facility:  UserLevel,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Hostname:  "host",
+				MessageID: "41",
+				Data: map[string]map[string]string{
+					"leef": {
+						"version":         "2.0",
+						"vendor":          "Lancope",
+						"product":         "StealthWatch",
+						"product_version": "1.0",
+						"event_id":        "41",
+						"delimiter":       "^",
+					},
+					"attributes": {
+						"src":     "192.0.2.0",
+						"dst":     "172.50.123.1",
+						"sev":     "5",
+						"cat":     "anomaly",
+						"srcPort": "81",
+						"dstPort": "21",
+						"usrName": "joe.black",
+					},
+				},
+			},
+		},
+		{
+			"<13>Oct 13 12:31:40 host LEEF:2.0|Vendor|Product|1.0|id|x7C|a=1|b=\t2",
+			&Message{
+				Priority:  CalculatePriority(UserLevel, Notice),
+				Facility:  UserLevel,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Hostname:  "host",
+				MessageID: "id",
+				Data: map[string]map[string]string{
+					"leef": {
+						"version":         "2.0",
+						"vendor":          "Vendor",
+						"product":         "Product",
+						"product_version": "1.0",
+						"event_id":        "id",
+						"delimiter":       "x7C",
+					},
+					"attributes": {"a": "1", "b": "\t2"},
+				},
+			},
+		},
+		{
+			"<13>Oct 13 12:31:40 host LEEF:2.0|Vendor|Product|1.0|id|a=1\tb=2",
+			&Message{
+				Priority:  CalculatePriority(UserLevel, Notice),
+				Facility:  UserLevel,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Hostname:  "host",
+				MessageID: "id",
+				Data: map[string]map[string]string{
+					"leef": {
+						"version":         "2.0",
+						"vendor":          "Vendor",
+						"product":         "Product",
+						"product_version": "1.0",
+						"event_id":        "id",
+					},
+					"attributes": {"a": "1", "b": "2"},
+				},
+			},
+		},
+	}
+
+	parse := NewParser(LEEF, WithYear(2015), WithLocation(time.UTC))
+	for _, test := range tests {
+		got, err := parse([]byte(test.Input))
+		if err != nil {
+			t.Fatalf("Unexpected error parse(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected parse(%q) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageLEEFErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{"<13>Jan  1 01:01:01 h CEF:0|v|p|1|s|n|0|",
+			"syslog: LEEF: format incorrect at byte 23: expected LEEF header"},
+		{"<13>Jan  1 01:01:01 h LEEF:3.0|v|p|1|e|",
+			`syslog: LEEF: format incorrect at byte 28: unsupported LEEF version "3.0"`},
+		{"<13>Jan  1 01:01:01 h LEEF:1.0|v|p|1",
+			"syslog: LEEF: format incorrect at byte 37: LEEF header too short"},
+		{"<13>Jan  1 01:01:01 h LEEF:1.0",
+			"syslog: LEEF: format incorrect at byte 31: LEEF header too short"},
+		{"<13>Jan  1 01:01:01 h LEEF:1.0|v|p|1|e|a=1\tb",
+			"syslog: LEEF: format incorrect at byte 44: expected LEEF attribute key=value"},
+		{"<13>Jan  1 01:01:01 h LEEF:2.0|v|p|1|e|^|a=1^=2",
+			"syslog: LEEF: format incorrect at byte 46: expected LEEF attribute key=value"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), LEEF)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// Problem is a mistake in a format, found by Lint.
+type Problem struct {
+	Index int // Index of the ParseFunc in the format.
+	Msg   string
+}
+
+func (problem Problem) String() string {
+	return "stage " + strconv.Itoa(problem.Index) + ": " + problem.Msg
+}
+
+// Lint checks the format for obvious mistakes, that make (part of) the format
+// impossible to succeed or redundant. It returns all problems found, or nil if
+// none are found.
+//
+// It detects the following mistakes:
+//   - CalculateFacility or CalculateSeverity before ParsePriority,
+//   - stages after ParseMsg, which reads the remainder of the message, also
+//     if it's wrapped in Optional or OptionalIf,
+//   - DiscardSpace or DiscardByte(' ') directly after a stage that already
+//     consumed the space, e.g. DiscardSpace, DiscardUntil(' '), CaptureThrough
+//     with a space or SkipWhitespace,
+//   - Optional wrapping nothing,
+//   - ParseTimestamp without any layouts and
+//   - nil ParseFuncs.
+func Lint(f Format) []Problem {
+	var problems []Problem
+	var seenPriority bool
+	for i, fn := range f {
+		add := func(msg string) {
+			problems = append(problems, Problem{Index: i, Msg: msg})
+		}
+
+		s := stageOf(fn)
+		switch {
+		case fn == nil:
+			add("nil ParseFunc")
+		case s.flags&stagePriority != 0:
+			seenPriority = true
+		case s.flags&stageNeedsPriority != 0 && !seenPriority:
+			add(s.name + " before ParsePriority")
+		case s.flags&stageDiscardsSpace != 0 && i > 0 &&
+			stageOf(f[i-1]).flags&stageConsumesSpace != 0:
+			add(s.name + " after a stage that already consumed the space")
+		case s.flags&stageOptional != 0 && len(s.wraps) == 0:
+			add(s.name + " wraps no ParseFuncs")
+		case s.flags&stageNoLayouts != 0:
+			add(s.name + " without layouts")
+		}
+
+		if i > 0 && readsRemainder(f[i-1]) && s.flags&stageMessageOnly == 0 {
+			add("unreachable, ParseMsg in stage " + strconv.Itoa(i-1) +
+				" reads the remainder of the message")
+		}
+	}
+	return problems
+}
+
+// ReadsRemainder checks if the parseFunc reads the remainder of the message,
+// i.e. it's parseMsg or optional ending with it.
+func readsRemainder(fn parseFunc) bool {
+	s := stageOf(fn)
+	if s.flags&stageOptional != 0 && len(s.wraps) != 0 {
+		return readsRemainder(s.wraps[len(s.wraps)-1])
+	}
+	return s.flags&stageReadsRemainder != 0
+}
+
+// stage describes what a parseFunc does, for Lint. It's recorded by the
+// function that creates the parseFunc, see setStage.
+type stage struct {
+	name  string // Exported name, e.g. "ParsePriority".
+	flags stageFlags
+	wraps []parseFunc // The parseFuncs called by optional.
+}
+
+type stageFlags uint16
+
+const (
+	stagePriority       stageFlags = 1 << iota // Parses the priority.
+	stageNeedsPriority                         // Uses the priority.
+	stageDiscardsSpace                         // Only discards a space.
+	stageConsumesSpace                         // Consumes the trailing space.
+	stageReadsRemainder                        // Reads the remainder of the message.
+	stageMessageOnly                           // Only uses the parsed fields.
+	stageTimestamp                             // Parses the timestamp, see formatWith.
+	stageOptional                              // Optional, calls stage.wraps.
+	stageNoLayouts                             // ParseTimestamp without layouts.
+)
+
+// stages holds the stages of the parseFuncs of this package that Lint and
+// formatWith need to know about, by funcID. It keeps the parseFuncs alive,
+// which is fine as formats are created once.
+var stages = struct {
+	sync.RWMutex
+	m map[unsafe.Pointer]stage
+}{m: map[unsafe.Pointer]stage{}}
+
+func init() {
+	setStage(parsePriority, stage{name: "ParsePriority", flags: stagePriority})
+	setStage(calculateFacility, stage{name: "CalculateFacility",
+		flags: stageNeedsPriority | stageMessageOnly})
+	setStage(calculateSeverity, stage{name: "CalculateSeverity",
+		flags: stageNeedsPriority | stageMessageOnly})
+	setStage(discardSpace, stage{name: "DiscardSpace",
+		flags: stageDiscardsSpace | stageConsumesSpace})
+	setStage(parseMsg, stage{name: "ParseMsg", flags: stageReadsRemainder})
+	setStage(parseTimestampNoFormats, stage{name: "ParseTimestamp", flags: stageNoLayouts})
+	setStage(nginxFixTimestamp, stage{name: "InferYear", flags: stageTimestamp})
+	setStage(parseNginxTimestamp, stage{flags: stageTimestamp})
+	setStage(parseLogplexData, stage{flags: stageMessageOnly})
+}
+
+// setStage records the stage of fn and returns fn.
+func setStage(fn parseFunc, s stage) parseFunc {
+	stages.Lock()
+	stages.m[funcID(fn)] = s
+	stages.Unlock()
+	return fn
+}
+
+// stageOf returns the stage of fn, which is empty if it wasn't recorded.
+func stageOf(fn parseFunc) stage {
+	stages.RLock()
+	defer stages.RUnlock()
+	return stages.m[funcID(fn)]
+}
+
+// funcID returns the identity of the function value. Unlike funcPointer,
+// which returns the code shared by all closures of a function literal, it's
+// different for every closure.
+func funcID(fn parseFunc) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&fn))
+}
+
+func funcPointer(fn parseFunc) uintptr {
+	return reflect.ValueOf(fn).Pointer()
+}
+
+// FuncName returns the name of the function, without the package path, e.g.
+// "syslog.parseHostname".
+func funcName(fn parseFunc) string {
+	if fn == nil {
+		return "nil"
+	}
+	name := runtime.FuncForPC(funcPointer(fn)).Name()
+	if i := strings.LastIndexByte(name, '/'); i != -1 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// InvalidFormatError is returned by Format.Validate, it holds the problems
+// found in the format.
+type InvalidFormatError []Problem
+
+func (err InvalidFormatError) Error() string {
+	msgs := make([]string, len(err))
+	for i, problem := range err {
+		msgs[i] = problem.String()
+	}
+	return "syslog: invalid format: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks the format for mistakes that make it impossible to succeed,
+// returning an InvalidFormatError if any are found. Next to the mistakes found
+// by Lint it rejects a format without any ParseFuncs.
+func (f Format) Validate() error {
+	if len(f) == 0 {
+		return InvalidFormatError{{Index: 0, Msg: "format has no ParseFuncs"}}
+	} else if problems := Lint(f); len(problems) != 0 {
+		return InvalidFormatError(problems)
+	}
+	return nil
+}
+
+// StageError is returned by Format.DryRun, it holds the stage of the format
+// that failed.
+type StageError struct {
+	Index int    // Index of the ParseFunc in the format.
+	Func  string // Name of the ParseFunc, e.g. "syslog.parseHostname".
+	Err   error  // Returned by the ParseFunc, usually a *FormatError.
+}
+
+func (err *StageError) Error() string {
+	return "syslog: stage " + strconv.Itoa(err.Index) + " (" + err.Func +
+		") failed: " + err.Err.Error()
+}
+
+// Unwrap returns the error returned by the ParseFunc.
+func (err *StageError) Unwrap() error {
+	return err.Err
+}
+
+// DryRun parses the sample with the format, configured with the given
+// options, to test a (custom) format. If parsing fails a *StageError is
+// returned, which holds the index and name of the ParseFunc that failed.
+// Like ParseMessage the message is always returned.
+func (f Format) DryRun(sample []byte, opts ...Option) (*Message, error) {
+	msg, stage, err := parseStages(sample, f, newConfig(opts))
+	if err != nil && stage != -1 {
+		err = &StageError{Index: stage, Func: funcName(f[stage]), Err: err}
+	}
+	return msg, err
+}
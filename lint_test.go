@@ -0,0 +1,259 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Format   format
+		Expected []Problem
+	}{
+		{"RFC5424", RFC5424, nil},
+		{"RFC5424Lazy", RFC5424Lazy, nil},
+		{"NginxAccess", NginxAccess, nil},
+		{"NginxError", NginxError, nil},
+		{"CEF", CEF, nil},
+		{"LEEF", LEEF, nil},
+		{"ApacheAccess", ApacheAccess, nil},
+		{"ApacheError", ApacheError, nil},
+		{"Postfix", Postfix, nil},
+		{"SSHD", SSHD, nil},
+		{"Sudo", Sudo, nil},
+		{"Cron", Cron, nil},
+		{"Netfilter", Netfilter, nil},
+		{"Dmesg", Dmesg, nil},
+		{"JournalJSON", JournalJSON, nil},
+		{"Docker", Docker, nil},
+		{"dockerRFC5424Header", dockerRFC5424Header, nil},
+		{"dockerRFC3164Header", dockerRFC3164Header, nil},
+		{"Logplex", Logplex, nil},
+		{"RFC3164", RFC3164, nil},
+		{"FortiGate", FortiGate, nil},
+		{"Filterlog", Filterlog, nil},
+		{"Dovecot", Dovecot, nil},
+		{"Postgres", Postgres, nil},
+		{"MySQLError", MySQLError, nil},
+		{"Redis", Redis, nil},
+		{"PHPFPM", PHPFPM, nil},
+		{"GunicornAccess", GunicornAccess, nil},
+		{"UWSGIAccess", UWSGIAccess, nil},
+		{"EnvoyAccess", EnvoyAccess, nil},
+		{"JSONCaddy", caddyJSON, nil},
+		{"JSONTraefik", traefikJSON, nil},
+		{"ALBAccess", ALBAccess, nil},
+		{"ELBAccess", ELBAccess, nil},
+		{"Audit", Audit, nil},
+		{"Fail2ban", Fail2ban, nil},
+		{"Keepalived", Keepalived, nil},
+		{"NginxAccessWith", NginxAccessWith(WithYear(2015)), nil},
+		{"empty", format{}, nil},
+		{
+			"calculate before priority",
+			format{calculateFacility, calculateSeverity, parsePriority, calculateFacility},
+			[]Problem{
+				{0, "CalculateFacility before ParsePriority"},
+				{1, "CalculateSeverity before ParsePriority"},
+			},
+		},
+		{
+			"two parseMsg",
+			format{parsePriority, parseMsg, parseMsg},
+			[]Problem{{2, "unreachable, ParseMsg in stage 1 reads the remainder of the message"}},
+		},
+		{
+			"stage after parseMsg",
+			format{parseMsg, discardSpace, parseHostname},
+			[]Problem{{1, "unreachable, ParseMsg in stage 0 reads the remainder of the message"}},
+		},
+		{
+			"double space",
+			format{parseHostname, discardSpace, discardByte(' '), parseAppname},
+			[]Problem{{2, "DiscardByte(' ') after a stage that already consumed the space"}},
+		},
+		{
+			"space consumed by previous stage",
+			format{
+				discardUntil(' '), discardSpace,
+				captureThrough("data", "name", ' '), discardSpace,
+				skipWhitespace(1), discardSpace,
+				discardUntil('x'), discardSpace,
+				captureThrough("data", "name", 'x'), discardSpace,
+			},
+			[]Problem{
+				{1, "DiscardSpace after a stage that already consumed the space"},
+				{3, "DiscardSpace after a stage that already consumed the space"},
+				{5, "DiscardSpace after a stage that already consumed the space"},
+			},
+		},
+		{
+			"stage after optional parseMsg",
+			format{
+				optional(2, discardSpace, parseMsg), parseHostname,
+				optionalIf(nonBlank, parseMsg), discardSpace,
+				optional(2, parseHostname), parseAppname,
+			},
+			[]Problem{
+				{1, "unreachable, ParseMsg in stage 0 reads the remainder of the message"},
+				{3, "unreachable, ParseMsg in stage 2 reads the remainder of the message"},
+			},
+		},
+		{
+			"post-processing after parseMsg",
+			format{parsePriority, parseMsg, calculateFacility, parseLogplexData},
+			nil,
+		},
+		{
+			"empty optional",
+			format{parsePriority, optional(2)},
+			[]Problem{{1, "Optional wraps no ParseFuncs"}},
+		},
+		{
+			"timestamp without formats",
+			format{parseTimestamp(), discardSpace, parseTimestamp(time.RFC3339)},
+			[]Problem{{0, "ParseTimestamp without layouts"}},
+		},
+		{
+			"nil stage",
+			format{parsePriority, nil, parseMsg},
+			[]Problem{{1, "nil ParseFunc"}},
+		},
+	}
+
+	for _, test := range tests {
+		got := Lint(test.Format)
+		if !reflect.DeepEqual(got, test.Expected) {
+			t.Fatalf("Expected Lint(%s) to return %v, but got %v",
+				test.Name, test.Expected, got)
+		}
+	}
+}
+
+func TestProblemString(t *testing.T) {
+	t.Parallel()
+
+	problem := Problem{Index: 2, Msg: "Optional wraps no ParseFuncs"}
+	if got, expected := problem.String(), "stage 2: Optional wraps no ParseFuncs"; got != expected {
+		t.Fatalf("Expected Problem.String() to return %q, but got %q", expected, got)
+	}
+}
+
+func TestFormatValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Format   Format
+		Expected string
+	}{
+		{"RFC5424", RFC5424, ""},
+		{"custom", Format{ParsePriority(), CalculateFacility(), DiscardSpace(), ParseMsg()}, ""},
+		{"empty", Format{}, "syslog: invalid format: stage 0: format has no ParseFuncs"},
+		{"nil", nil, "syslog: invalid format: stage 0: format has no ParseFuncs"},
+		{
+			"problems",
+			Format{CalculateSeverity(), ParsePriority(), Optional(2), nil},
+			"syslog: invalid format: stage 0: CalculateSeverity before ParsePriority; " +
+				"stage 2: Optional wraps no ParseFuncs; stage 3: nil ParseFunc",
+		},
+	}
+
+	for _, test := range tests {
+		err := test.Format.Validate()
+		if test.Expected == "" {
+			if err != nil {
+				t.Fatalf("Unexpected error validating the %s format: %s", test.Name, err)
+			}
+			continue
+		}
+
+		var invalidErr InvalidFormatError
+		if !errors.As(err, &invalidErr) {
+			t.Fatalf("Expected validating the %s format to return an InvalidFormatError, but got %#v",
+				test.Name, err)
+		} else if got := err.Error(); got != test.Expected {
+			t.Fatalf("Expected validating the %s format to return error %q, but got %q",
+				test.Name, test.Expected, got)
+		}
+	}
+}
+
+func TestFormatDryRun(t *testing.T) {
+	t.Parallel()
+
+	format := Format{ParsePriority(), CalculateFacility(), ParseHostname(), DiscardSpace(), ParseMsg()}
+
+	msg, err := format.DryRun([]byte("<34>web1 message"))
+	if err != nil {
+		t.Fatalf("Unexpected error in DryRun: %s", err)
+	} else if expected := (&Message{Priority: 34, Facility: SecurityAuthorization, Hostname: "web1", Message: "message"}); !messagesAreEqual(msg, expected) {
+		t.Fatalf("Expected DryRun to return Message %#v, but got %#v", expected, msg)
+	}
+
+	tests := []struct {
+		Input         string
+		ExpectedIndex int
+		ExpectedFunc  string
+		ExpectedError string
+	}{
+		{"<34>", 2, "syslog.parseHostname",
+			"syslog: stage 2 (syslog.parseHostname) failed: syslog: format incorrect at byte 4: unexpected end of message"},
+		{"<34>web1-message", 3, "syslog.discardSpace",
+			"syslog: stage 3 (syslog.discardSpace) failed: syslog: format incorrect at byte 16: unexpected end of message"},
+		{"34>", 0, "syslog.parsePriority",
+			"syslog: stage 0 (syslog.parsePriority) failed: syslog: format incorrect at byte 1: expected byte '<', but got '3'"},
+	}
+
+	for _, test := range tests {
+		_, err := format.DryRun([]byte(test.Input))
+		var stageErr *StageError
+		if !errors.As(err, &stageErr) {
+			t.Fatalf("Expected DryRun(%q) to return a *StageError, but got %#v", test.Input, err)
+		} else if stageErr.Index != test.ExpectedIndex || stageErr.Func != test.ExpectedFunc {
+			t.Fatalf("Expected DryRun(%q) to fail in stage %d (%s), but got stage %d (%s)",
+				test.Input, test.ExpectedIndex, test.ExpectedFunc, stageErr.Index, stageErr.Func)
+		}
+
+		var formatErr *FormatError
+		if !errors.As(err, &formatErr) {
+			t.Fatalf("Expected DryRun(%q) to wrap a *FormatError, but got %#v", test.Input, err)
+		}
+		formatErr.Snippet = nil
+		if got := err.Error(); got != test.ExpectedError {
+			t.Fatalf("Expected DryRun(%q) to return error %q, but got %q",
+				test.Input, test.ExpectedError, got)
+		}
+	}
+}
+
+func TestNewParserWithLint(t *testing.T) {
+	t.Parallel()
+
+	// Should not panic.
+	NewParser(RFC5424, WithLint())
+
+	defer func() {
+		recv := recover()
+		if recv == nil {
+			t.Fatal("Expected NewParser() to panic, but it didn't")
+		}
+
+		expected := "syslog: format incorrect, stage 0: CalculateFacility before ParsePriority"
+		if got, ok := recv.(string); !ok || got != expected {
+			t.Fatalf("Expected NewParser() to panic with message %q, but got %v",
+				expected, recv)
+		}
+	}()
+
+	NewParser(format{calculateFacility, parsePriority}, WithLint())
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Logfmt formats the message in the logfmt format, e.g.
+//
+//	ts=2015-09-30T23:10:11+02:00 host=hostname app=appname severity=debug facility=local7 msg=message data.name=value
+//
+// The keys are ts, host, app, pid, msgid, severity, facility and msg, followed
+// by the structured data params as dataID.name, sorted by data-ID and name.
+// Fields with a zero value are omitted. Values containing spaces, quotes,
+// equals signs or non-printable bytes are quoted, see strconv.Quote.
+func (msg *Message) Logfmt() []byte {
+	return msg.AppendLogfmt(nil)
+}
+
+// AppendLogfmt is like Logfmt, but appends the formatted message to b and
+// returns the extended buffer.
+func (msg *Message) AppendLogfmt(b []byte) []byte {
+	start := len(b)
+	appendPair := func(key, value string) {
+		if len(b) != start {
+			b = append(b, spaceByte)
+		}
+		b = append(b, key...)
+		b = append(b, equalByte)
+		b = appendLogfmtValue(b, value)
+	}
+
+	if !msg.Timestamp.IsZero() {
+		appendPair("ts", msg.Timestamp.Format(time.RFC3339Nano))
+	}
+	values := []struct{ key, value string }{
+		{"host", msg.Hostname},
+		{"app", msg.Appname},
+		{"pid", msg.ProcessID},
+		{"msgid", msg.MessageID},
+	}
+	for _, v := range values {
+		if v.value != "" {
+			appendPair(v.key, v.value)
+		}
+	}
+	if msg.Severity != 0 {
+		appendPair("severity", logfmtLevel(msg.Severity.String(), uint8(msg.Severity), msg.Severity.IsValid()))
+	}
+	if msg.Facility != 0 {
+		appendPair("facility", logfmtLevel(msg.Facility.String(), uint8(msg.Facility), msg.Facility.IsValid()))
+	}
+	if msg.Message != "" {
+		appendPair("msg", msg.Message)
+	}
+
+	var dataIDs, names [maxSortedKeys]string
+	for _, dataID := range getSortedMapMapKeys(msg.Data, dataIDs[:0]) {
+		params := msg.Data[dataID]
+		for _, name := range getSortedMapKeys(params, names[:0]) {
+			key := sanitize(dataID+"."+name, math.MaxInt, isNameByte)
+			appendPair(key, params[name])
+		}
+	}
+
+	return b
+}
+
+// logfmtLevel returns the lowercase name of a facility or severity without
+// spaces, e.g. "local7", or the number if it's invalid.
+func logfmtLevel(name string, n uint8, valid bool) string {
+	if !valid {
+		return strconv.Itoa(int(n))
+	}
+	return strings.ReplaceAll(strings.ToLower(name), " ", "")
+}
+
+// appendLogfmtValue appends the value, quoting it if required.
+func appendLogfmtValue(b []byte, value string) []byte {
+	if value == "" {
+		return append(b, qouteByte, qouteByte)
+	}
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; !isPrintASCII(c) || c == equalByte || c == qouteByte {
+			return strconv.AppendQuote(b, value)
+		}
+	}
+	return append(b, value...)
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMessageLogfmt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Msg      *Message
+		Expected string
+	}{
+		{&Message{}, ""},
+		{&Message{Priority: 1, Severity: Alert}, "severity=alert"},
+		{&Message{Facility: 24, Severity: 8}, "severity=8 facility=24"},
+		{&Message{Message: "message"}, "msg=message"},
+		{
+			&Message{
+				Priority:  CalculatePriority(Local7, Warning),
+				Facility:  Local7,
+				Severity:  Warning,
+				Version:   1,
+				Timestamp: time.Date(2015, 9, 30, 23, 10, 11, 0, locationCEST),
+				Hostname:  "hostname",
+				Appname:   "appname",
+				ProcessID: "123",
+				MessageID: "msgid",
+				Data: map[string]map[string]string{
+					"data":  {"name": "value", "empty": ""},
+					"data2": {"name": "value"},
+				},
+				Message: "message",
+			},
+			`ts=2015-09-30T23:10:11+02:00 host=hostname app=appname pid=123 msgid=msgid ` +
+				`severity=warning facility=local7 msg=message data.empty="" data.name=value data2.name=value`,
+		},
+		{
+			&Message{
+				Hostname: "host name",
+				Data: map[string]map[string]string{
+					"data":  {"quote": `a "b"`, "equal": "a=b", "newline": "a\nb", "utf8": "héllo"},
+					"da ta": {"na=me": "value"},
+				},
+				Message: `message with "quotes"`,
+			},
+			`host="host name" msg="message with \"quotes\"" da_ta.na_me=value data.equal="a=b" ` +
+				`data.newline="a\nb" data.quote="a \"b\"" data.utf8="héllo"`,
+		},
+	}
+
+	for _, test := range tests {
+		if got := string(test.Msg.Logfmt()); got != test.Expected {
+			t.Fatalf("Expected msg.Logfmt() to return %q, but got %q", test.Expected, got)
+		}
+	}
+
+	prefix := "prefix "
+	msg := &Message{Message: "message"}
+	if got, expected := string(msg.AppendLogfmt([]byte(prefix))), prefix+"msg=message"; got != expected {
+		t.Fatalf("Expected msg.AppendLogfmt(%q) to return %q, but got %q", prefix, expected, got)
+	}
+}
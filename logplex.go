@@ -0,0 +1,188 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Maximum number of digits in the octet count of a frame.
+const maxOctetCountLength = 9
+
+// errOctetCount is returned by SplitLogplexFrames for a frame that doesn't
+// start with a valid octet count.
+var errOctetCount = errors.New("syslog: invalid octet count")
+
+// SplitLogplexFrames is a bufio.SplitFunc that splits a Logplex drain body,
+// multiple frames prefixed with their length in bytes, e.g. "83 <40>1 ...",
+// into single frames, including the octet count, that can be parsed with the
+// Logplex format. Whitespace between frames is skipped.
+//
+//	scanner := bufio.NewScanner(req.Body)
+//	scanner.Split(syslog.SplitLogplexFrames)
+//	for scanner.Scan() {
+//		msg, err := syslog.ParseMessage(scanner.Bytes(), syslog.Logplex)
+//		// ...
+//	}
+func SplitLogplexFrames(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := 0
+	for start < len(data) && isSpace(data[start]) {
+		start++
+	}
+	if start == len(data) {
+		return len(data), nil, nil
+	}
+
+	n, i, err := octetCount(data[start:])
+	if err == io.EOF {
+		if atEOF {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return start, nil, nil
+	} else if err != nil {
+		return 0, nil, err
+	}
+
+	end := start + i + n
+	if end > len(data) {
+		if atEOF {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return start, nil, nil
+	}
+	return end, data[start:end], nil
+}
+
+// octetCount returns the octet count at the start of b and the index of the
+// frame after the space following it. It returns io.EOF if b ends before the
+// space.
+func octetCount(b []byte) (int, int, error) {
+	i := skipDigits(b, 0)
+	if i == len(b) && i <= maxOctetCountLength {
+		return 0, 0, io.EOF
+	} else if i == 0 || i > maxOctetCountLength || b[i] != spaceByte {
+		return 0, 0, errOctetCount
+	}
+
+	n, _ := parseDigits(b[:i])
+	return int(n), i + 1, nil
+}
+
+// parseOctetCount parses the octet count of a frame, the length of the frame in
+// bytes followed by a space. The frame must be exactly the length of the
+// remainder of the message.
+func parseOctetCount(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+
+	n, i, err := octetCount(b)
+	if err == io.EOF {
+		buf.position = buf.length
+		return err
+	} else if err != nil {
+		return newFormatError(startPos, nil, "invalid octet count")
+	}
+
+	buf.position += i
+	if length := len(b) - i; n > length {
+		buf.position = buf.length
+		return io.EOF
+	} else if n < length {
+		return newFormatError(startPos, nil, "frame longer than octet count "+strconv.Itoa(n))
+	}
+	return nil
+}
+
+// discardNilData discards the nil structured data, "-", and the space
+// following it, if present.
+func discardNilData(buf *buffer, msg *Message) error {
+	b := buf.bytes[buf.position:buf.length]
+	if len(b) == 1 && b[0] == nilValueByte {
+		buf.position++
+	} else if len(b) > 1 && b[0] == nilValueByte && b[1] == spaceByte {
+		buf.position += 2
+	}
+	return nil
+}
+
+// parseLogplexData parses the Message into Data["logplex"] if it's in the
+// logfmt format, see parseLogfmt.
+func parseLogplexData(buf *buffer, msg *Message) error {
+	if data := parseLogfmt(msg.Message); data != nil {
+		if msg.Data == nil {
+			msg.Data = map[string]map[string]string{}
+		}
+		msg.Data["logplex"] = data
+	}
+	return nil
+}
+
+// parseLogfmt parses the logfmt pairs in s, e.g. `at=info path="/" status=200`.
+// Quoted values are unquoted using strconv.Unquote. It returns nil if s isn't
+// completely made up of key=value pairs.
+func parseLogfmt(s string) map[string]string {
+	data := map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " ")
+		if s == "" {
+			break
+		}
+
+		i := strings.IndexByte(s, equalByte)
+		if i <= 0 || strings.ContainsAny(s[:i], " \"") {
+			return nil
+		}
+		key := s[:i]
+		s = s[i+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := logfmtQouteEnd(s)
+			if end == -1 {
+				return nil
+			}
+			unquoted, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil
+			}
+			value, s = unquoted, s[end+1:]
+			if s != "" && s[0] != spaceByte {
+				return nil
+			}
+		} else {
+			end := strings.IndexByte(s, spaceByte)
+			if end == -1 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+			if strings.ContainsAny(value, "=\"") {
+				return nil
+			}
+		}
+		data[key] = value
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
+// logfmtQouteEnd returns the index of the closing qoute of the qouted value at
+// the start of s, or -1 if it's not closed.
+func logfmtQouteEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case escapeByte:
+			i++
+		case qouteByte:
+			return i
+		}
+	}
+	return -1
+}
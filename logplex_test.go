@@ -0,0 +1,215 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestParseMessageLogplex(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputLogplex),
+			&Message{Version: 1},
+		},
+		{
+			string(regularInputLogplex),
+			&Message{
+				Priority:  CalculatePriority(Local3, Informational),
+				Facility:  Local3,
+				Severity:  Informational,
+				Version:   1,
+				Timestamp: time.Date(2012, 11, 30, 6, 45, 29, 123456000, time.UTC),
+				Hostname:  "host",
+				Appname:   "heroku",
+				ProcessID: "router",
+				Data: map[string]map[string]string{
+					"logplex": {
+						"at":      "info",
+						"method":  "GET",
+						"path":    "/",
+						"host":    "example.herokuapp.com",
+						"fwd":     "1.2.3.4",
+						"dyno":    "web.1",
+						"connect": "1ms",
+						"service": "18ms",
+						"status":  "200",
+					},
+				},
+				Message: `at=info method=GET path="/" host=example.herokuapp.com fwd="1.2.3.4" dyno=web.1 connect=1ms service=18ms status=200`,
+			},
+		},
+		{
+			// Plain text, including the newline in the frame.
+			"83 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed from starting to up\n",
+			&Message{
+				Priority:  CalculatePriority(Internal, Emergency),
+				Facility:  Internal,
+				Severity:  Emergency,
+				Version:   1,
+				Timestamp: time.Date(2012, 11, 30, 6, 45, 29, 0, time.UTC),
+				Hostname:  "host",
+				Appname:   "app",
+				ProcessID: "web.3",
+				Message:   "State changed from starting to up",
+			},
+		},
+		{
+			// With nil structured data and not completely logfmt.
+			"59 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - - x=1 done",
+			&Message{
+				Priority:  CalculatePriority(Internal, Emergency),
+				Facility:  Internal,
+				Severity:  Emergency,
+				Version:   1,
+				Timestamp: time.Date(2012, 11, 30, 6, 45, 29, 0, time.UTC),
+				Hostname:  "host",
+				Appname:   "app",
+				ProcessID: "web.3",
+				Message:   "x=1 done",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Logplex)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Logplex) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageLogplexErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{"", "syslog: Logplex: format incorrect at byte 1: unexpected end of message"},
+		{"14", "syslog: Logplex: format incorrect at byte 2: unexpected end of message"},
+		{"<0>1 - - - - -", "syslog: Logplex: format incorrect at byte 1: invalid octet count"},
+		{"1234567890 <0>1 - - - - -", "syslog: Logplex: format incorrect at byte 1: invalid octet count"},
+		{"15 <0>1 - - - - -", "syslog: Logplex: format incorrect at byte 17: unexpected end of message"},
+		{"13 <0>1 - - - - -", "syslog: Logplex: format incorrect at byte 1: frame longer than octet count 13"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), Logplex)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestSplitLogplexFrames(t *testing.T) {
+	t.Parallel()
+
+	frames := []string{
+		"83 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed from starting to up\n",
+		"59 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - - x=1 done",
+		string(regularInputLogplex),
+	}
+	body := strings.Join(frames, "") + "\n"
+
+	// One byte at a time, to test incomplete frames.
+	scanner := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(body)))
+	scanner.Split(SplitLogplexFrames)
+	var got []string
+	for scanner.Scan() {
+		got = append(got, scanner.Text())
+		if _, err := ParseMessage(scanner.Bytes(), Logplex); err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", scanner.Bytes(), err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Unexpected error scanning Logplex frames: %s", err)
+	} else if strings.Join(got, "|") != strings.Join(frames, "|") {
+		t.Fatalf("Expected SplitLogplexFrames to return frames %q, but got %q", frames, got)
+	}
+}
+
+func TestSplitLogplexFramesErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected error
+	}{
+		{"14 <0>1 - - - - -x", errOctetCount},
+		{"<0>1 - - - - -", errOctetCount},
+		{"15 <0>1 - - - - -", io.ErrUnexpectedEOF},
+		{"14", io.ErrUnexpectedEOF},
+	}
+
+	for _, test := range tests {
+		scanner := bufio.NewScanner(bytes.NewReader([]byte(test.Input)))
+		scanner.Split(SplitLogplexFrames)
+		for scanner.Scan() {
+		}
+		if err := scanner.Err(); err != test.Expected {
+			t.Fatalf("Expected scanning %q to return error %v, but got %v",
+				test.Input, test.Expected, err)
+		}
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected map[string]string
+	}{
+		{`at=info`, map[string]string{"at": "info"}},
+		{` a=1  b= c="x \"y\""`, map[string]string{"a": "1", "b": "", "c": `x "y"`}},
+		{`msg="a=b"`, map[string]string{"msg": "a=b"}},
+		{``, nil},
+		{`   `, nil},
+		{`plain text`, nil},
+		{`a=1 text`, nil},
+		{`=1`, nil},
+		{`a=b=c`, nil},
+		{`a="b`, nil},
+		{`a="b"c`, nil},
+		{`a="\q"`, nil},
+		{`"a"=b`, nil},
+		{`a=b"`, nil},
+	}
+
+	for _, test := range tests {
+		got := parseLogfmt(test.Input)
+		if len(got) != len(test.Expected) || (got == nil) != (test.Expected == nil) {
+			t.Fatalf("Expected parseLogfmt(%q) to return %v, but got %v", test.Input, test.Expected, got)
+		}
+		for key, value := range test.Expected {
+			if got[key] != value {
+				t.Fatalf("Expected parseLogfmt(%q) to return %v, but got %v", test.Input, test.Expected, got)
+			}
+		}
+	}
+}
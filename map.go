@@ -0,0 +1,321 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Map returns the message as a map, using the following keys:
+//
+//	priority    Priority
+//	facility    string, the name (see Facility.String), or uint8 if the name isn't unique
+//	severity    string, the name (see Severity.String), or uint8 if invalid
+//	version     uint
+//	timestamp   time.Time
+//	hostname    string
+//	appname     string
+//	process_id  string
+//	message_id  string
+//	data        map[string]map[string]string, a copy of Data
+//	raw_data    string
+//	message     string
+//	utf8        bool
+//
+// Fields with a zero value are omitted, like in Message.MarshalJSON. See
+// FlatMap to flatten the structured data and FromMap for the reverse.
+func (msg *Message) Map() map[string]interface{} {
+	m := msg.headerMap()
+	if msg.Data != nil {
+		data := make(map[string]map[string]string, len(msg.Data))
+		for dataID, params := range msg.Data {
+			data[dataID] = make(map[string]string, len(params))
+			for name, value := range params {
+				data[dataID][name] = value
+			}
+		}
+		m["data"] = data
+	}
+	return m
+}
+
+// FlatMap is like Map, but the structured data params are flattened into
+// string values with keys "data", the data-ID and the name joined by sep, e.g.
+// "data.request.status" with sep ".". See FromFlatMap for the reverse.
+func (msg *Message) FlatMap(sep string) map[string]interface{} {
+	m := msg.headerMap()
+	for dataID, params := range msg.Data {
+		for name, value := range params {
+			m["data"+sep+dataID+sep+name] = value
+		}
+	}
+	return m
+}
+
+// headerMap returns a map with all fields of the message, except Data.
+func (msg *Message) headerMap() map[string]interface{} {
+	m := make(map[string]interface{})
+	if msg.Priority != 0 {
+		m["priority"] = msg.Priority
+	}
+	if msg.Facility != 0 {
+		f, ok := facilityByName(msg.Facility.String())
+		m["facility"] = mapLevel(msg.Facility.String(), uint8(msg.Facility), ok && f == msg.Facility)
+	}
+	if msg.Severity != 0 {
+		m["severity"] = mapLevel(msg.Severity.String(), uint8(msg.Severity), msg.Severity.IsValid())
+	}
+	if msg.Version != 0 {
+		m["version"] = msg.Version
+	}
+	if !msg.Timestamp.IsZero() {
+		m["timestamp"] = msg.Timestamp
+	}
+
+	values := []struct{ key, value string }{
+		{"hostname", msg.Hostname},
+		{"appname", msg.Appname},
+		{"process_id", msg.ProcessID},
+		{"message_id", msg.MessageID},
+		{"raw_data", msg.RawData},
+		{"message", msg.Message},
+	}
+	for _, v := range values {
+		if v.value != "" {
+			m[v.key] = v.value
+		}
+	}
+	if msg.UTF8 {
+		m["utf8"] = true
+	}
+	return m
+}
+
+// mapLevel returns the name of a facility or severity, or the number if the
+// name can't be used.
+func mapLevel(name string, n uint8, useName bool) interface{} {
+	if useName {
+		return name
+	}
+	return n
+}
+
+// FromMap creates a message from a map using the keys described in
+// Message.Map. Missing keys are left as zero value. The facility and severity
+// can be either a name, compared case insensitively, or a number. The data can
+// be a map[string]map[string]string or map[string]interface{} with
+// map[string]interface{} or map[string]string values. Unknown keys are
+// ignored.
+func FromMap(m map[string]interface{}) (*Message, error) {
+	return fromMap(m, "")
+}
+
+// FromFlatMap is like FromMap, but the structured data is read from the keys
+// created by Message.FlatMap with the separator sep.
+func FromFlatMap(m map[string]interface{}, sep string) (*Message, error) {
+	return fromMap(m, sep)
+}
+
+// fromMap implements FromMap and FromFlatMap, reading flattened data keys if
+// sep isn't empty.
+func fromMap(m map[string]interface{}, sep string) (*Message, error) {
+	msg := &Message{}
+	for key, value := range m {
+		var err error
+		switch key {
+		case "priority":
+			var n uint8
+			n, err = mapUint8(key, value)
+			msg.Priority = Priority(n)
+		case "facility":
+			var n uint8
+			n, err = mapLevelValue(key, value, func(name string) (uint8, bool) {
+				f, ok := facilityByName(name)
+				return uint8(f), ok
+			})
+			msg.Facility = Facility(n)
+		case "severity":
+			var n uint8
+			n, err = mapLevelValue(key, value, func(name string) (uint8, bool) {
+				s, ok := severityByName(name)
+				return uint8(s), ok
+			})
+			msg.Severity = Severity(n)
+		case "version":
+			var n uint64
+			n, err = mapUint(key, value, 64)
+			msg.Version = uint(n)
+		case "timestamp":
+			var ok bool
+			if msg.Timestamp, ok = value.(time.Time); !ok {
+				err = mapError(key, "time.Time")
+			}
+		case "hostname":
+			msg.Hostname, err = mapString(key, value)
+		case "appname":
+			msg.Appname, err = mapString(key, value)
+		case "process_id":
+			msg.ProcessID, err = mapString(key, value)
+		case "message_id":
+			msg.MessageID, err = mapString(key, value)
+		case "raw_data":
+			msg.RawData, err = mapString(key, value)
+		case "message":
+			msg.Message, err = mapString(key, value)
+		case "utf8":
+			var ok bool
+			if msg.UTF8, ok = value.(bool); !ok {
+				err = mapError(key, "bool")
+			}
+		case "data":
+			if sep == "" {
+				msg.Data, err = mapData(value)
+			}
+		default:
+			if sep == "" || !strings.HasPrefix(key, "data"+sep) {
+				continue
+			}
+			dataID, name, ok := strings.Cut(key[len("data"+sep):], sep)
+			if !ok {
+				continue
+			}
+
+			var s string
+			if s, err = mapString(key, value); err == nil {
+				if msg.Data == nil {
+					msg.Data = make(map[string]map[string]string)
+				}
+				if msg.Data[dataID] == nil {
+					msg.Data[dataID] = make(map[string]string)
+				}
+				msg.Data[dataID][name] = s
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// mapData converts the data value of a map.
+func mapData(value interface{}) (map[string]map[string]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case map[string]map[string]string:
+		data := make(map[string]map[string]string, len(v))
+		for dataID, params := range v {
+			data[dataID] = make(map[string]string, len(params))
+			for name, value := range params {
+				data[dataID][name] = value
+			}
+		}
+		return data, nil
+	case map[string]interface{}:
+		data := make(map[string]map[string]string, len(v))
+		for dataID, params := range v {
+			data[dataID] = make(map[string]string)
+			switch params := params.(type) {
+			case map[string]string:
+				for name, value := range params {
+					data[dataID][name] = value
+				}
+			case map[string]interface{}:
+				for name, value := range params {
+					s, err := mapString("data."+dataID+"."+name, value)
+					if err != nil {
+						return nil, err
+					}
+					data[dataID][name] = s
+				}
+			default:
+				return nil, mapError("data."+dataID, "map")
+			}
+		}
+		return data, nil
+	}
+	return nil, mapError("data", "map")
+}
+
+// mapLevelValue converts a facility or severity, either as name or number.
+func mapLevelValue(key string, value interface{}, byName func(string) (uint8, bool)) (uint8, error) {
+	if name, ok := value.(string); ok {
+		if n, ok := byName(name); ok {
+			return n, nil
+		}
+		if n, err := strconv.ParseUint(name, 10, 8); err == nil {
+			return uint8(n), nil
+		}
+		return 0, errors.New("syslog: unknown " + key + " in map: " + name)
+	}
+	return mapUint8(key, value)
+}
+
+// mapUint8 converts a number to uint8.
+func mapUint8(key string, value interface{}) (uint8, error) {
+	n, err := mapUint(key, value, 8)
+	return uint8(n), err
+}
+
+// mapUint converts a number to an unsigned integer of the given size. All
+// integer types, Facility, Severity, Priority and float64 (as used by
+// encoding/json) without fraction are accepted.
+func mapUint(key string, value interface{}, bitSize int) (uint64, error) {
+	var n uint64
+	switch v := value.(type) {
+	case int:
+		n = uint64(v)
+		if v < 0 {
+			return 0, mapError(key, "positive number")
+		}
+	case int64:
+		n = uint64(v)
+		if v < 0 {
+			return 0, mapError(key, "positive number")
+		}
+	case uint:
+		n = uint64(v)
+	case uint8:
+		n = uint64(v)
+	case uint64:
+		n = v
+	case Priority:
+		n = uint64(v)
+	case Facility:
+		n = uint64(v)
+	case Severity:
+		n = uint64(v)
+	case float64:
+		n = uint64(v)
+		if v < 0 || float64(n) != v {
+			return 0, mapError(key, "positive integer")
+		}
+	default:
+		return 0, mapError(key, "number")
+	}
+
+	if bitSize < 64 && n >= 1<<bitSize {
+		return 0, errors.New("syslog: invalid map field " + key + ": " +
+			strconv.FormatUint(n, 10) + " out of range")
+	}
+	return n, nil
+}
+
+// mapString converts a string.
+func mapString(key string, value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return "", mapError(key, "string")
+}
+
+// mapError returns an error for a field with an unexpected type.
+func mapError(key, expected string) error {
+	return errors.New("syslog: invalid map field " + key + ": expected a " + expected)
+}
@@ -0,0 +1,165 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMessageMap(t *testing.T) {
+	t.Parallel()
+
+	msg, err := ParseMessage(regularInputRFC5424, RFC5424)
+	if err != nil {
+		t.Fatalf("Unexpected error ParseMessage(%q): %s", regularInputRFC5424, err)
+	}
+
+	expected := map[string]interface{}{
+		"priority":   CalculatePriority(Local7, Debug),
+		"facility":   "Local 7",
+		"severity":   "Debug",
+		"version":    uint(10),
+		"timestamp":  msg.Timestamp,
+		"hostname":   "hostname",
+		"appname":    "appname",
+		"process_id": "procid",
+		"message_id": "msgid",
+		"data":       map[string]map[string]string{"data": {"name": "value"}},
+		"message":    "message",
+	}
+	if got := msg.Map(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected msg.Map() to return %v, but got %v", expected, got)
+	}
+
+	// The data must be a copy.
+	msg.Map()["data"].(map[string]map[string]string)["data"]["name"] = "changed"
+	if msg.Data["data"]["name"] != "value" {
+		t.Fatal("Expected msg.Map() to copy the data")
+	}
+
+	delete(expected, "data")
+	expected["data.data.name"] = "value"
+	if got := msg.FlatMap("."); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected msg.FlatMap(\".\") to return %v, but got %v", expected, got)
+	}
+
+	if got := (&Message{}).Map(); len(got) != 0 {
+		t.Fatalf("Expected msg.Map() to return an empty map, but got %v", got)
+	}
+	if got := (&Message{Facility: SecurityAuthorization2}).Map(); got["facility"] != uint8(SecurityAuthorization2) {
+		t.Fatalf("Expected msg.Map() to return the facility as number, but got %v", got["facility"])
+	}
+}
+
+func TestMessageMapRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input  []byte
+		Format format
+	}{
+		{regularInputRFC5424, RFC5424},
+		{regularInputNginxAccess, NginxAccess},
+		{regularInputNginxError, NginxError},
+		{regularInputCEF, CEF},
+		{[]byte("<191>1 - - - - - [data name=\"value\"] \xef\xbb\xbfmessage"), RFC5424Lazy},
+	}
+
+	for _, test := range tests {
+		msg, err := ParseMessage(test.Input, test.Format)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		}
+		msg.dataPos = 0 // Not included in the map.
+
+		got, err := FromMap(msg.Map())
+		if err != nil {
+			t.Fatalf("Unexpected error FromMap(): %s", err)
+		} else if !messagesAreEqual(got, msg) {
+			t.Fatalf("Expected FromMap(msg.Map()) to return %#v, but got %#v", msg, got)
+		}
+
+		for _, sep := range []string{".", "_", "/"} {
+			got, err = FromFlatMap(msg.FlatMap(sep), sep)
+			if err != nil {
+				t.Fatalf("Unexpected error FromFlatMap(): %s", err)
+			} else if !messagesAreEqual(got, msg) {
+				t.Fatalf("Expected FromFlatMap(msg.FlatMap(%q), %q) to return %#v, but got %#v",
+					sep, sep, msg, got)
+			}
+		}
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	t.Parallel()
+
+	timestamp := time.Date(2015, 9, 30, 23, 10, 11, 0, locationCEST)
+	tests := []struct {
+		Input    map[string]interface{}
+		Expected *Message
+	}{
+		{map[string]interface{}{}, &Message{}},
+		{map[string]interface{}{"unknown": 1, "data.data.name": "value"}, &Message{}},
+		{
+			map[string]interface{}{"severity": 4, "facility": "local 7", "priority": float64(188)},
+			&Message{Priority: 188, Facility: Local7, Severity: Warning},
+		},
+		{
+			map[string]interface{}{"severity": "4", "facility": uint8(23), "version": int64(1)},
+			&Message{Facility: Local7, Severity: Warning, Version: 1},
+		},
+		{
+			map[string]interface{}{
+				"timestamp": timestamp,
+				"utf8":      true,
+				"data": map[string]interface{}{
+					"data":  map[string]interface{}{"name": "value"},
+					"data2": map[string]string{"name": "value"},
+				},
+			},
+			&Message{
+				Timestamp: timestamp,
+				UTF8:      true,
+				Data: map[string]map[string]string{
+					"data":  {"name": "value"},
+					"data2": {"name": "value"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := FromMap(test.Input)
+		if err != nil {
+			t.Fatalf("Unexpected error FromMap(%v): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected FromMap(%v) to return %#v, but got %#v", test.Input, test.Expected, got)
+		}
+	}
+
+	errorTests := []struct {
+		Input    map[string]interface{}
+		Expected string
+	}{
+		{map[string]interface{}{"hostname": 1}, "syslog: invalid map field hostname: expected a string"},
+		{map[string]interface{}{"severity": "unknown"}, "syslog: unknown severity in map: unknown"},
+		{map[string]interface{}{"severity": -1}, "syslog: invalid map field severity: expected a positive number"},
+		{map[string]interface{}{"facility": 256}, "syslog: invalid map field facility: 256 out of range"},
+		{map[string]interface{}{"priority": 1.5}, "syslog: invalid map field priority: expected a positive integer"},
+		{map[string]interface{}{"timestamp": "now"}, "syslog: invalid map field timestamp: expected a time.Time"},
+		{map[string]interface{}{"data": "data"}, "syslog: invalid map field data: expected a map"},
+		{map[string]interface{}{"data": map[string]interface{}{"data": 1}}, "syslog: invalid map field data.data: expected a map"},
+	}
+
+	for _, test := range errorTests {
+		_, err := FromMap(test.Input)
+		if err == nil || err.Error() != test.Expected {
+			t.Fatalf("Expected FromMap(%v) to return error %q, but got %v", test.Input, test.Expected, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+)
+
+// Severities of the MySQL levels, the same mapping MySQL uses when logging to
+// syslog itself.
+var mysqlLevels = map[string]Severity{
+	"error":   Error,
+	"warning": Warning,
+	"note":    Informational,
+	"system":  Informational,
+}
+
+// Layouts of the timestamps of MariaDB and MySQL 5.6 and older, MySQL 5.7 and
+// newer use RFC 3339.
+const (
+	mariaDBTimestampLayout = "2006-01-02 15:04:05"
+	mysql56TimestampLayout = "060102 15:04:05"
+)
+
+// ParseMySQLError parses a MySQL, or MariaDB, error log line, e.g.
+// "2015-10-13T12:31:40.123456Z 12 [Warning] [MY-010055] [Server] IP address
+// '1.2.3.4' could not be resolved". The timestamp replaces Timestamp, the
+// optional thread id is stored as ProcessID and the level replaces the
+// severity, like parseNginxLevel. The optional error code, e.g. "MY-010055",
+// is stored as MessageID and the subsystem that follows it in
+// Data["mysql"]["subsystem"]. The remainder is stored as Message.
+func parseMySQLError(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+	buf.position = buf.length
+
+	if len(b) == 0 {
+		return io.EOF
+	}
+	timestamp, i, ok := parseMySQLTimestamp(b, buf.cfg.Location())
+	if !ok {
+		return newFormatError(startPos, ErrBadTimestamp, "invalid MySQL timestamp")
+	}
+	msg.Timestamp = timestamp
+
+	// The thread id is missing in MySQL 5.6 and older.
+	i = skipSpaces(b, i)
+	if j := skipDigits(b, i); j != i {
+		if j-i > maxProcessIDLength {
+			return newFormatError(startPos+i, ErrFieldTooLong, "thread id too long")
+		}
+		msg.ProcessID = string(b[i:j])
+		i = skipSpaces(b, j)
+	}
+
+	level, i, err := apacheBlock(b, i, startPos)
+	if err != nil {
+		return err
+	}
+	severity, ok := mysqlLevels[strings.ToLower(string(level))]
+	if !ok {
+		levelPos := startPos + i - len(level) - 1
+		return newFormatError(levelPos, nil, "unknown MySQL level '"+escapeSnippet(level)+"'")
+	} else if !buf.cfg.PrioritySeverity() {
+		msg.Severity = severity
+		if msg.Priority.IsValid() {
+			msg.Priority = CalculatePriority(msg.Facility, severity)
+		}
+	}
+
+	// MySQL 8.0 added the error code and subsystem, e.g. "[MY-010055] [Server]".
+	if code, j, err := apacheBlock(b, skipSpaces(b, i), startPos); err == nil && bytes.HasPrefix(code, []byte("MY-")) {
+		if len(code) > maxMessageIDLength {
+			return newFormatError(startPos+j-len(code)-1, ErrFieldTooLong, "error code too long")
+		}
+		msg.MessageID = string(code)
+		i = j
+		if subsystem, j, err := apacheBlock(b, skipSpaces(b, i), startPos); err == nil {
+			msg.Data = map[string]map[string]string{"mysql": {"subsystem": string(subsystem)}}
+			i = j
+		}
+	}
+
+	msg.Message = string(bytes.TrimSpace(b[i:]))
+	return nil
+}
+
+// parseMySQLTimestamp parses the timestamp at the start of b, of MySQL 5.7 and
+// newer, e.g. "2015-10-13T12:31:40.123456Z", MariaDB, e.g. "2015-10-13
+// 12:31:40", or MySQL 5.6 and older, e.g. "151013 12:31:40" or "151013
+// 2:31:40". It returns the index after the timestamp.
+func parseMySQLTimestamp(b []byte, location *time.Location) (time.Time, int, bool) {
+	end := bytes.IndexByte(b, spaceByte)
+	if end == -1 {
+		end = len(b)
+	}
+
+	var layout string
+	switch date := b[:end]; {
+	case bytes.IndexByte(date, 'T') != -1:
+		timestamp, err := time.Parse(time.RFC3339Nano, string(date))
+		return timestamp, end, err == nil
+	case len(date) == len("2006-01-02"):
+		layout = mariaDBTimestampLayout
+	case len(date) == len("060102"):
+		layout = mysql56TimestampLayout
+	default:
+		return time.Time{}, end, false
+	}
+
+	// MySQL 5.6 pads the hour with a space, e.g. "151013  2:31:40".
+	start := skipSpaces(b, end)
+	timeEnd := bytes.IndexByte(b[start:], spaceByte)
+	if timeEnd == -1 {
+		timeEnd = len(b) - start
+	}
+	value := string(b[:end]) + " " + string(b[start:start+timeEnd])
+	timestamp, err := time.ParseInLocation(layout, value, location)
+	return timestamp, start + timeEnd, err == nil
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageMySQLError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputMySQLError),
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+			},
+		},
+		{
+			// MySQL 8.0.
+			string(regularInputMySQLError),
+			&Message{
+				Priority:  CalculatePriority(System, Warning),
+				Facility:  System,
+				Severity:  Warning,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 123456000, time.UTC),
+				Hostname:  "hostname",
+				Appname:   "mysqld",
+				ProcessID: "12",
+				MessageID: "MY-010055",
+				Data:      map[string]map[string]string{"mysql": {"subsystem": "Server"}},
+				Message:   "IP address '1.2.3.4' could not be resolved: Name or service not known",
+			},
+		},
+		{
+			// MySQL 8.0, system level with thread id 0.
+			`<30>Oct 13 12:31:40 hostname mysqld: 2015-10-13T12:31:40.000001Z 0 [System] [MY-010931] [Server] /usr/sbin/mysqld: ready for connections. Version: '8.0.21'  socket: '/var/run/mysqld/mysqld.sock'  port: 3306  MySQL Community Server - GPL.`,
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 1000, time.UTC),
+				Hostname:  "hostname",
+				Appname:   "mysqld",
+				ProcessID: "0",
+				MessageID: "MY-010931",
+				Data:      map[string]map[string]string{"mysql": {"subsystem": "Server"}},
+				Message:   "/usr/sbin/mysqld: ready for connections. Version: '8.0.21'  socket: '/var/run/mysqld/mysqld.sock'  port: 3306  MySQL Community Server - GPL.",
+			},
+		},
+		{
+			// MySQL 5.7, with a time zone offset.
+			`<27>Oct 13 12:31:40 hostname mysqld: 2015-10-13T12:31:40.123456+02:00 7 [ERROR] Incorrect definition of table mysql.db: expected column 'User' at position 2 to have type char(32)`,
+			&Message{
+				Priority:  CalculatePriority(System, Error),
+				Facility:  System,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 123456000, time.FixedZone("", 2*60*60)),
+				Hostname:  "hostname",
+				Appname:   "mysqld",
+				ProcessID: "7",
+				Message:   "Incorrect definition of table mysql.db: expected column 'User' at position 2 to have type char(32)",
+			},
+		},
+		{
+			// MariaDB.
+			`<30>Oct 13 12:31:40 hostname mariadbd[812]: 2015-10-13 12:31:40 0 [Note] InnoDB: Buffer pool(s) load completed at 151013 12:31:40`,
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "mariadbd",
+				ProcessID: "0",
+				Message:   "InnoDB: Buffer pool(s) load completed at 151013 12:31:40",
+			},
+		},
+		{
+			// MySQL 5.6.
+			`<28>Oct 13 12:31:40 hostname mysqld: 151013 12:31:40 [Warning] IP address '1.2.3.4' could not be resolved: Name or service not known`,
+			&Message{
+				Priority:  CalculatePriority(System, Warning),
+				Facility:  System,
+				Severity:  Warning,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "mysqld",
+				Message:   "IP address '1.2.3.4' could not be resolved: Name or service not known",
+			},
+		},
+		{
+			// MySQL 5.6, with a space padded hour.
+			`<27>Oct 13 12:31:40 hostname mysqld: 151013  2:31:40 [ERROR] /usr/sbin/mysqld: unknown option '--skip-locking'`,
+			&Message{
+				Priority:  CalculatePriority(System, Error),
+				Facility:  System,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 13, 2, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "mysqld",
+				Message:   "/usr/sbin/mysqld: unknown option '--skip-locking'",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), MySQLError)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, MySQLError) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageMySQLErrorErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`<27>Jan  1 01:01:01 h a: `,
+			"syslog: MySQLError: format incorrect at byte 25: unexpected end of message"},
+		{`<27>Jan  1 01:01:01 h a: 2015-10-13T12:31 1 [Note] msg`,
+			"syslog: MySQLError: format incorrect at byte 26: invalid MySQL timestamp"},
+		{`<27>Jan  1 01:01:01 h a: 15101 12:31:40 [Note] msg`,
+			"syslog: MySQLError: format incorrect at byte 26: invalid MySQL timestamp"},
+		{`<27>Jan  1 01:01:01 h a: 151013 12:31:40`,
+			"syslog: MySQLError: format incorrect at byte 40: unexpected end of message"},
+		{`<27>Jan  1 01:01:01 h a: 151013 12:31:40 Note msg`,
+			"syslog: MySQLError: format incorrect at byte 42: expected byte '[', but got 'N'"},
+		{`<27>Jan  1 01:01:01 h a: 151013 12:31:40 [Info] msg`,
+			"syslog: MySQLError: format incorrect at byte 43: unknown MySQL level 'Info'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), MySQLError)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"strings"
+)
+
+// ParseNetfilter parses the message of a netfilter (iptables) kernel log line,
+// e.g. "[12345.678] IPTABLES-DROP: IN=eth0 OUT= SRC=1.2.3.4 DST=5.6.7.8 PROTO=TCP
+// SPT=51515 DPT=22 SYN". The optional kernel timestamp is skipped. The
+// optional log prefix is stored as MessageID, without a trailing colon, and
+// the fields, starting at IN=, in Data["netfilter"], see netfilterData. If the
+// message has no fields it's stored as Message.
+func parseNetfilter(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+	buf.position = buf.length
+
+	_, b = kernelUptime(b)
+	i := netfilterFieldsIndex(b)
+	if i == -1 {
+		msg.Message = string(b)
+		return nil
+	}
+
+	prefix := strings.TrimSuffix(string(bytes.TrimSpace(b[:i])), ":")
+	if len(prefix) > maxMessageIDLength {
+		return newFormatError(startPos, ErrFieldTooLong, "log prefix too long")
+	}
+	msg.MessageID = prefix
+	msg.Data = map[string]map[string]string{"netfilter": netfilterData(b[i:])}
+	return nil
+}
+
+// netfilterFieldsIndex returns the index of the first field, IN=, in b, or -1
+// if b doesn't contain it.
+func netfilterFieldsIndex(b []byte) int {
+	for i := 0; i < len(b); {
+		j := bytes.Index(b[i:], []byte("IN="))
+		if j == -1 {
+			return -1
+		} else if i+j == 0 || b[i+j-1] == spaceByte {
+			return i + j
+		}
+		i += j + 1
+	}
+	return -1
+}
+
+// netfilterData returns the space separated KEY=VALUE fields in b. Flags
+// without a value, e.g. SYN, have the value "1" and empty values, e.g. OUT=,
+// are kept. The fields of the packet that caused an ICMP error, enclosed in
+// brackets, are skipped.
+func netfilterData(b []byte) map[string]string {
+	data := make(map[string]string, 24)
+	depth := 0
+	for _, field := range strings.Fields(string(b)) {
+		if strings.HasPrefix(field, "[") {
+			depth++
+		}
+		if depth == 0 {
+			if key, value, ok := strings.Cut(field, "="); ok {
+				data[key] = value
+			} else {
+				data[field] = "1"
+			}
+		}
+		if depth > 0 && strings.HasSuffix(field, "]") {
+			depth--
+		}
+	}
+	return data
+}
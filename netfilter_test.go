@@ -0,0 +1,198 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseMessageNetfilter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputNetfilter),
+			&Message{
+				Priority:  CalculatePriority(Kernel, Warning),
+				Facility:  Kernel,
+				Severity:  Warning,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+				Data:      map[string]map[string]string{"netfilter": {"IN": ""}},
+			},
+		},
+		{
+			string(regularInputNetfilter),
+			&Message{
+				Priority:  CalculatePriority(Kernel, Warning),
+				Facility:  Kernel,
+				Severity:  Warning,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "kernel",
+				MessageID: "IPTABLES-DROP",
+				Data: map[string]map[string]string{
+					"netfilter": {
+						"IN":     "eth0",
+						"OUT":    "",
+						"MAC":    "00:11:22:33:44:55:66:77:88:99:aa:bb:08:00",
+						"SRC":    "1.2.3.4",
+						"DST":    "5.6.7.8",
+						"LEN":    "60",
+						"TOS":    "0x00",
+						"PREC":   "0x00",
+						"TTL":    "52",
+						"ID":     "54321",
+						"DF":     "1",
+						"PROTO":  "TCP",
+						"SPT":    "51515",
+						"DPT":    "22",
+						"WINDOW": "29200",
+						"RES":    "0x00",
+						"SYN":    "1",
+						"URGP":   "0",
+					},
+				},
+			},
+		},
+		{
+			// UDP, without the kernel timestamp.
+			`<4>Oct 13 12:31:40 hostname kernel: [UFW BLOCK] IN=eth0 OUT= MAC=01:00:5e:00:00:fb SRC=10.0.0.5 DST=224.0.0.251 LEN=73 TOS=0x00 PREC=0x00 TTL=255 ID=0 DF PROTO=UDP SPT=5353 DPT=5353 LEN=53`,
+			&Message{
+				Priority:  CalculatePriority(Kernel, Warning),
+				Facility:  Kernel,
+				Severity:  Warning,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "kernel",
+				MessageID: "[UFW BLOCK]",
+				Data: map[string]map[string]string{
+					"netfilter": {
+						"IN":    "eth0",
+						"OUT":   "",
+						"MAC":   "01:00:5e:00:00:fb",
+						"SRC":   "10.0.0.5",
+						"DST":   "224.0.0.251",
+						"LEN":   "53",
+						"TOS":   "0x00",
+						"PREC":  "0x00",
+						"TTL":   "255",
+						"ID":    "0",
+						"DF":    "1",
+						"PROTO": "UDP",
+						"SPT":   "5353",
+						"DPT":   "5353",
+					},
+				},
+			},
+		},
+		{
+			// ICMP error, without a log prefix.
+			`<4>Oct 13 12:31:40 hostname kernel: [ 1234.567890] IN= OUT=eth0 SRC=5.6.7.8 DST=1.2.3.4 LEN=88 TOS=0x00 PREC=0xC0 TTL=64 ID=1 PROTO=ICMP TYPE=3 CODE=3 [SRC=1.2.3.4 DST=5.6.7.8 LEN=60 TOS=0x00 PREC=0x00 TTL=52 ID=2 PROTO=UDP SPT=53 DPT=33434 LEN=40 ]`,
+			&Message{
+				Priority:  CalculatePriority(Kernel, Warning),
+				Facility:  Kernel,
+				Severity:  Warning,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "kernel",
+				Data: map[string]map[string]string{
+					"netfilter": {
+						"IN":    "",
+						"OUT":   "eth0",
+						"SRC":   "5.6.7.8",
+						"DST":   "1.2.3.4",
+						"LEN":   "88",
+						"TOS":   "0x00",
+						"PREC":  "0xC0",
+						"TTL":   "64",
+						"ID":    "1",
+						"PROTO": "ICMP",
+						"TYPE":  "3",
+						"CODE":  "3",
+					},
+				},
+			},
+		},
+		{
+			// IPv6.
+			`<4>Oct 13 12:31:40 hostname kernel: [12345.678] ip6tables-drop: IN=eth0 OUT= MAC=33:33:00:00:00:01 SRC=fe80:0000:0000:0000:0211:22ff:fe33:4455 DST=ff02:0000:0000:0000:0000:0000:0000:0001 LEN=64 TC=0 HOPLIMIT=255 FLOWLBL=0 PROTO=ICMPv6 TYPE=134 CODE=0`,
+			&Message{
+				Priority:  CalculatePriority(Kernel, Warning),
+				Facility:  Kernel,
+				Severity:  Warning,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "kernel",
+				MessageID: "ip6tables-drop",
+				Data: map[string]map[string]string{
+					"netfilter": {
+						"IN":       "eth0",
+						"OUT":      "",
+						"MAC":      "33:33:00:00:00:01",
+						"SRC":      "fe80:0000:0000:0000:0211:22ff:fe33:4455",
+						"DST":      "ff02:0000:0000:0000:0000:0000:0000:0001",
+						"LEN":      "64",
+						"TC":       "0",
+						"HOPLIMIT": "255",
+						"FLOWLBL":  "0",
+						"PROTO":    "ICMPv6",
+						"TYPE":     "134",
+						"CODE":     "0",
+					},
+				},
+			},
+		},
+		{
+			// Not a netfilter message.
+			`<6>Oct 13 12:31:40 hostname kernel: [    0.000000] Linux version 4.2.0 (LOGIN=root)`,
+			&Message{
+				Priority:  CalculatePriority(Kernel, Informational),
+				Facility:  Kernel,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "kernel",
+				Message:   "Linux version 4.2.0 (LOGIN=root)",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Netfilter)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Netfilter) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageNetfilterErrors(t *testing.T) {
+	t.Parallel()
+
+	input := `<4>Oct 13 12:31:40 hostname kernel: ` + generateString("P", maxMessageIDLength+1) + ` IN=eth0`
+	expected := "syslog: Netfilter: format incorrect at byte 37: log prefix too long"
+	_, err := ParseMessage([]byte(input), Netfilter)
+	formatErr, ok := err.(*FormatError)
+	if !ok {
+		t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v", input, err)
+	}
+
+	formatErr.Snippet = nil
+	if got := formatErr.Error(); got != expected {
+		t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q", input, expected, got)
+	} else if !errors.Is(err, ErrFieldTooLong) {
+		t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %v",
+			input, ErrFieldTooLong, err)
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import "time"
+
+// Option configures a Parser, see NewParser.
+type Option func(*config)
+
+// Config is the configuration of a Parser, created by applying the Options.
+type config struct {
+	lint     bool
+	strict   bool
+	rawMsg   bool
+	dupes    DuplicatePolicy
+	location *time.Location
+	year     int
+	now      func() time.Time
+
+	prioritySeverity bool
+	bestEffort       bool
+	lenientSpace     bool
+
+	maxLength int // Maximum length of the message, 0 for unlimited.
+	maxParams int // Maximum number of params per data element, 0 for unlimited.
+
+	internSize int
+	interned   map[string]string // Created by NewParser, if internSize > 0.
+}
+
+// DuplicatePolicy determines what happens with duplicate structured data
+// elements and params, see WithDuplicates.
+type DuplicatePolicy uint8
+
+// Available duplicate policies.
+const (
+	// LastWins replaces a duplicate element or param with the last one. This
+	// is the default, unless the strict mode is used.
+	LastWins DuplicatePolicy = iota
+
+	// RejectDuplicates returns an error with kind ErrDuplicate for a duplicate
+	// element or param. This is the default in strict mode.
+	RejectDuplicates
+
+	// CollectDuplicates merges the params of duplicate elements and collects
+	// the values of duplicate params, see Message.ParamValues. Message.Data
+	// holds the last value of a param.
+	CollectDuplicates
+)
+
+// Used by ParseMessage.
+var defaultConfig = &config{}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.internSize > 0 {
+		cfg.interned = make(map[string]string, cfg.internSize)
+	}
+	return cfg
+}
+
+// WithLint makes NewParser check the format using Lint, panicking if any
+// problems are found.
+func WithLint() Option {
+	return func(cfg *config) {
+		cfg.lint = true
+	}
+}
+
+// WithStrict makes the Parser reject messages that don't strictly follow the
+// RFC, which are accepted by default. For example a version starting with a
+// zero, a timestamp with "-00:00" as timezone, header fields and structured
+// data ids and param names with bytes outside of the printable US-ASCII range,
+// or a message starting with the BOM that isn't valid UTF-8.
+func WithStrict() Option {
+	return func(cfg *config) {
+		cfg.strict = true
+	}
+}
+
+// WithRawMessage makes the Parser keep the whitespace around the message,
+// which is trimmed by default. The BOM is still removed, see Message.UTF8.
+func WithRawMessage() Option {
+	return func(cfg *config) {
+		cfg.rawMsg = true
+	}
+}
+
+// WithDuplicates sets the policy for duplicate structured data elements and
+// params. Defaults to LastWins, or RejectDuplicates in strict mode.
+func WithDuplicates(policy DuplicatePolicy) Option {
+	return func(cfg *config) {
+		cfg.dupes = policy
+	}
+}
+
+// WithMaxMessageLength sets the maximum length of a message in bytes. Longer
+// messages aren't parsed, instead an error with kind ErrMessageTooLong is
+// returned. Defaults to unlimited.
+func WithMaxMessageLength(maxLength int) Option {
+	return func(cfg *config) {
+		cfg.maxLength = maxLength
+	}
+}
+
+// WithMaxParams sets the maximum number of params in a single structured data
+// element. If a element has more params an error with kind ErrTooManyParams is
+// returned. Defaults to unlimited.
+func WithMaxParams(maxParams int) Option {
+	return func(cfg *config) {
+		cfg.maxParams = maxParams
+	}
+}
+
+// WithLocation sets the location used for timestamps that don't include a
+// timezone, e.g. those in the Nginx formats. Defaults to time.Local.
+func WithLocation(location *time.Location) Option {
+	return func(cfg *config) {
+		cfg.location = location
+	}
+}
+
+// WithYear sets the year used for timestamps that don't include a year, e.g.
+// those in the Nginx formats. By default the year is inferred from the current
+// time: the current year, unless that makes the timestamp more than 48 hours in
+// the future, in which case it's the previous year (or the next year for a
+// timestamp at the start of January parsed at the end of December).
+func WithYear(year int) Option {
+	return func(cfg *config) {
+		cfg.year = year
+	}
+}
+
+// WithClock sets the function used to get the current time, which is used to
+// infer the year of timestamps that don't include one, see WithYear. Defaults
+// to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(cfg *config) {
+		cfg.now = now
+	}
+}
+
+// WithPrioritySeverity makes the Parser use the severity from the priority,
+// ignoring the level formats such as NginxError include in the message. By
+// default the level in the message is used, as that is what the application
+// logged.
+func WithPrioritySeverity() Option {
+	return func(cfg *config) {
+		cfg.prioritySeverity = true
+	}
+}
+
+// WithInterning makes the Parser reuse previously allocated strings for
+// values that repeat often, such as the hostname, appname, process id, message
+// id and structured data ids and param names. At most maxEntries strings are
+// kept, once full no new strings are added.
+//
+// Note: the strings are kept per Parser, which makes the Parser unsafe for
+// concurrent use.
+func WithInterning(maxEntries int) Option {
+	return func(cfg *config) {
+		cfg.internSize = maxEntries
+	}
+}
+
+// WithBestEffort makes the Parser parse as much of the message as possible,
+// instead of returning an error. If a part of the message can't be parsed the
+// error is recorded, see Message.Errors, the fields set by the failing part
+// are reset and parsing continues at the next space. If no space remains, or
+// the message (MSG) couldn't be parsed, the remainder is stored as Message. A
+// Parser using this option never returns an error.
+func WithBestEffort() Option {
+	return func(cfg *config) {
+		cfg.bestEffort = true
+	}
+}
+
+// WithLenientWhitespace makes the Parser accept multiple spaces and tabs
+// between the fields of the header, e.g. "<34>1  2015-10-13T12:31:40Z\thost",
+// as written by some embedded devices. This is ignored in strict mode, which
+// only accepts a single space.
+func WithLenientWhitespace() Option {
+	return func(cfg *config) {
+		cfg.lenientSpace = true
+	}
+}
+
+// Strict returns whether or not the strict mode is enabled.
+func (cfg *config) Strict() bool {
+	return cfg != nil && cfg.strict
+}
+
+// BestEffort returns whether or not the best-effort mode is enabled.
+func (cfg *config) BestEffort() bool {
+	return cfg != nil && cfg.bestEffort
+}
+
+// LenientWhitespace returns whether or not multiple spaces and tabs are
+// accepted between fields, never in strict mode.
+func (cfg *config) LenientWhitespace() bool {
+	return cfg != nil && cfg.lenientSpace && !cfg.strict
+}
+
+// RawMessage returns whether or not the whitespace around the message should
+// be kept.
+func (cfg *config) RawMessage() bool {
+	return cfg != nil && cfg.rawMsg
+}
+
+// Duplicates returns the policy for duplicate structured data.
+func (cfg *config) Duplicates() DuplicatePolicy {
+	if cfg == nil {
+		return LastWins
+	} else if cfg.dupes == LastWins && cfg.strict {
+		return RejectDuplicates
+	}
+	return cfg.dupes
+}
+
+// TooLong returns whether or not a message of length n is too long.
+func (cfg *config) TooLong(n int) bool {
+	return cfg != nil && cfg.maxLength > 0 && n > cfg.maxLength
+}
+
+// TooManyParams returns whether or not n params in a single data element are
+// too many.
+func (cfg *config) TooManyParams(n int) bool {
+	return cfg != nil && cfg.maxParams > 0 && n > cfg.maxParams
+}
+
+// Location returns the location to use for timestamps without a timezone.
+func (cfg *config) Location() *time.Location {
+	if cfg == nil || cfg.location == nil {
+		return time.Local
+	}
+	return cfg.location
+}
+
+// Year returns the year to use for timestamps without a year, the year of Now
+// if it's not set.
+func (cfg *config) Year() int {
+	if cfg == nil || cfg.year == 0 {
+		return cfg.Now().Year()
+	}
+	return cfg.year
+}
+
+// HasYear returns whether or not the year is set explicitly.
+func (cfg *config) HasYear() bool {
+	return cfg != nil && cfg.year != 0
+}
+
+// Now returns the current time.
+func (cfg *config) Now() time.Time {
+	if cfg == nil || cfg.now == nil {
+		return time.Now()
+	}
+	return cfg.now()
+}
+
+// PrioritySeverity returns whether or not the severity from the priority
+// should be preferred over the level in the message.
+func (cfg *config) PrioritySeverity() bool {
+	return cfg != nil && cfg.prioritySeverity
+}
+
+// Intern returns the bytes as string, reusing a previously allocated string if
+// interning is enabled.
+func (cfg *config) intern(b []byte) string {
+	if cfg == nil || cfg.interned == nil {
+		return string(b)
+	}
+
+	// Doesn't allocate, see https://golang.org/issue/3512.
+	if s, ok := cfg.interned[string(b)]; ok {
+		return s
+	}
+
+	s := string(b)
+	if len(cfg.interned) < cfg.internSize {
+		cfg.interned[s] = s
+	}
+	return s
+}
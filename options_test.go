@@ -0,0 +1,621 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestWithLocationAndYear(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Options  []Option
+		Expected time.Time
+	}{
+		{nil, inferredDate(1, 1, 1, 1, 1, time.Local)},
+		{[]Option{WithLocation(time.UTC)}, inferredDate(1, 1, 1, 1, 1, time.UTC)},
+		{[]Option{WithYear(2015)}, time.Date(2015, 1, 1, 1, 1, 1, 0, time.Local)},
+		{
+			[]Option{WithLocation(locationCEST), WithYear(2015)},
+			time.Date(2015, 1, 1, 1, 1, 1, 0, locationCEST),
+		},
+	}
+
+	for _, test := range tests {
+		parse := NewParser(NginxAccess, test.Options...)
+		msg, err := parse(minimumInputNginxAccess)
+		if err != nil {
+			t.Fatalf("Unexpected error parse(%q): %s", minimumInputNginxAccess, err)
+		}
+
+		if !msg.Timestamp.Equal(test.Expected) {
+			t.Fatalf("Expected parse(%q) to return timestamp %s, but got %s",
+				minimumInputNginxAccess, test.Expected, msg.Timestamp)
+		}
+	}
+}
+
+func TestNginxFormatsWith(t *testing.T) {
+	t.Parallel()
+
+	const (
+		accessInput = `<190>Oct 13 12:31:40 hostname nginx: [request status="200"]`
+		errorInput  = `<187>Oct 13 12:31:40 hostname nginx: 2014/10/13 01:31:40 [error] 1187#1187: msg, client: 192.168.1.255`
+	)
+
+	tests := []struct {
+		Options        []Option
+		ParserOptions  []Option
+		ExpectedAccess time.Time
+		ExpectedError  time.Time
+	}{
+		{
+			[]Option{WithLocation(locationCEST), WithYear(2015)},
+			nil,
+			time.Date(2015, 10, 13, 12, 31, 40, 0, locationCEST),
+			time.Date(2014, 10, 13, 1, 31, 40, 0, locationCEST),
+		},
+		{
+			[]Option{WithLocation(time.UTC), WithYear(2015)},
+			nil,
+			time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+			time.Date(2014, 10, 13, 1, 31, 40, 0, time.UTC),
+		},
+		{
+			// Options of the format take precedence.
+			[]Option{WithLocation(time.UTC)},
+			[]Option{WithLocation(locationCEST), WithYear(2015)},
+			time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+			time.Date(2014, 10, 13, 1, 31, 40, 0, time.UTC),
+		},
+		{
+			nil,
+			[]Option{WithLocation(locationCEST), WithYear(2015)},
+			time.Date(2015, 10, 13, 12, 31, 40, 0, locationCEST),
+			time.Date(2014, 10, 13, 1, 31, 40, 0, locationCEST),
+		},
+	}
+
+	for _, test := range tests {
+		formats := []struct {
+			Name     string
+			Format   format
+			Input    string
+			Expected time.Time
+		}{
+			{"NginxAccessWith", NginxAccessWith(test.Options...), accessInput, test.ExpectedAccess},
+			{"NginxErrorWith", NginxErrorWith(test.Options...), errorInput, test.ExpectedError},
+		}
+
+		for _, f := range formats {
+			msg, err := NewParser(f.Format, test.ParserOptions...)([]byte(f.Input))
+			if err != nil {
+				t.Fatalf("Unexpected error parsing %q with %s: %s", f.Input, f.Name, err)
+			}
+
+			if !msg.Timestamp.Equal(f.Expected) || msg.Timestamp.Location() != f.Expected.Location() {
+				t.Fatalf("Expected parsing %q with %s to return timestamp %s, but got %s",
+					f.Input, f.Name, f.Expected, msg.Timestamp)
+			}
+		}
+	}
+
+	// The header timestamp of the error format, if the embedded one can't be
+	// parsed.
+	input := `<187>Oct 13 12:31:40 hostname nginx: - [error] 1187#1187: msg`
+	msg, err := ParseMessage([]byte(input), NginxErrorWith(WithLocation(time.UTC), WithYear(2015)))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %q with NginxErrorWith: %s", input, err)
+	} else if expected := time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC); !msg.Timestamp.Equal(expected) {
+		t.Fatalf("Expected parsing %q with NginxErrorWith to return timestamp %s, but got %s",
+			input, expected, msg.Timestamp)
+	}
+
+	if problems := Lint(NginxErrorWith(WithYear(2015))); len(problems) != 0 {
+		t.Fatalf("Expected Lint(NginxErrorWith()) to return no problems, but got %v", problems)
+	}
+
+	// The default formats must not be changed.
+	msg, err = ParseMessage([]byte(accessInput), NginxAccess)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %q with NginxAccess: %s", accessInput, err)
+	} else if msg.Timestamp.Location() != time.Local {
+		t.Fatalf("Expected parsing %q with NginxAccess to return timestamp in %s, but got %s",
+			accessInput, time.Local, msg.Timestamp.Location())
+	}
+}
+
+func TestWithPrioritySeverity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Options  []Option
+		Expected Severity
+	}{
+		{nil, Error},
+		{[]Option{WithPrioritySeverity()}, Critical},
+	}
+
+	for _, test := range tests {
+		parse := NewParser(NginxError, test.Options...)
+		msg, err := parse(regularInputNginxError)
+		if err != nil {
+			t.Fatalf("Unexpected error parse(%q): %s", regularInputNginxError, err)
+		}
+
+		if msg.Severity != test.Expected || msg.Priority != CalculatePriority(Local7, test.Expected) {
+			t.Fatalf("Expected parse(%q) to return severity %s, but got %s (priority %d)",
+				regularInputNginxError, test.Expected, msg.Severity, msg.Priority)
+		}
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Now      time.Time
+		Input    string
+		Expected time.Time
+	}{
+		{time.Date(2015, 10, 13, 12, 0, 0, 0, time.UTC), "Oct 13 12:31:40", time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC)},
+		{time.Date(2015, 10, 13, 12, 0, 0, 0, time.UTC), "Oct 15 11:59:59", time.Date(2015, 10, 15, 11, 59, 59, 0, time.UTC)},
+		{time.Date(2015, 10, 13, 12, 0, 0, 0, time.UTC), "Oct 15 12:00:01", time.Date(2014, 10, 15, 12, 0, 1, 0, time.UTC)},
+		{time.Date(2015, 10, 13, 12, 0, 0, 0, time.UTC), "Jan  1 00:00:00", time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)},
+
+		// Year boundaries, a message of just before the new year processed on
+		// the first day and the other way around.
+		{time.Date(2016, 1, 1, 0, 0, 10, 0, time.UTC), "Dec 31 23:59:59", time.Date(2015, 12, 31, 23, 59, 59, 0, time.UTC)},
+		{time.Date(2016, 1, 1, 12, 0, 0, 0, time.UTC), "Jan  1 11:00:00", time.Date(2016, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{time.Date(2015, 12, 31, 23, 59, 59, 0, time.UTC), "Jan  1 00:00:10", time.Date(2016, 1, 1, 0, 0, 10, 0, time.UTC)},
+		{time.Date(2015, 12, 31, 23, 59, 59, 0, time.UTC), "Dec 31 23:59:58", time.Date(2015, 12, 31, 23, 59, 58, 0, time.UTC)},
+
+		// Leap days.
+		{time.Date(2016, 2, 29, 12, 0, 0, 0, time.UTC), "Feb 29 11:00:00", time.Date(2016, 2, 29, 11, 0, 0, 0, time.UTC)},
+		{time.Date(2016, 2, 28, 12, 0, 0, 0, time.UTC), "Feb 29 11:00:00", time.Date(2016, 2, 29, 11, 0, 0, 0, time.UTC)},
+		{time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC), "Feb 29 11:00:00", time.Date(2016, 2, 29, 11, 0, 0, 0, time.UTC)},
+		{time.Date(2015, 3, 1, 12, 0, 0, 0, time.UTC), "Feb 29 11:00:00", time.Date(2012, 2, 29, 11, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		now := test.Now
+		parse := NewParser(NginxAccess, WithLocation(time.UTC), WithClock(func() time.Time { return now }))
+		input := []byte("<190>" + test.Input + " h a: [request]")
+		msg, err := parse(input)
+		if err != nil {
+			t.Fatalf("Unexpected error parse(%q): %s", input, err)
+		}
+
+		if !msg.Timestamp.Equal(test.Expected) {
+			t.Fatalf("Expected parse(%q) at %s to return timestamp %s, but got %s",
+				input, test.Now, test.Expected, msg.Timestamp)
+		}
+	}
+
+	// The year set with WithYear is used as is.
+	parse := NewParser(NginxAccess, WithLocation(time.UTC), WithYear(2015),
+		WithClock(func() time.Time { return time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC) }))
+	input := []byte("<190>Dec 31 23:59:59 h a: [request]")
+	msg, err := parse(input)
+	if err != nil {
+		t.Fatalf("Unexpected error parse(%q): %s", input, err)
+	} else if expected := time.Date(2015, 12, 31, 23, 59, 59, 0, time.UTC); !msg.Timestamp.Equal(expected) {
+		t.Fatalf("Expected parse(%q) to return timestamp %s, but got %s",
+			input, expected, msg.Timestamp)
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	t.Parallel()
+
+	var cfg *config
+	if got := cfg.Location(); got != time.Local {
+		t.Fatalf("Expected the default location to be %s, but got %s", time.Local, got)
+	}
+	if got, expected := cfg.Year(), time.Now().Year(); got != expected {
+		t.Fatalf("Expected the default year to be %d, but got %d", expected, got)
+	}
+
+	cfg = newConfig(nil)
+	if got := cfg.Location(); got != time.Local {
+		t.Fatalf("Expected the default location to be %s, but got %s", time.Local, got)
+	}
+}
+
+func TestWithInterning(t *testing.T) {
+	t.Parallel()
+
+	parse := NewParser(RFC5424, WithInterning(3))
+	msg1, err := parse(regularInputRFC5424)
+	if err != nil {
+		t.Fatalf("Unexpected error parse(%q): %s", regularInputRFC5424, err)
+	}
+	msg2, err := parse(regularInputRFC5424)
+	if err != nil {
+		t.Fatalf("Unexpected error parse(%q): %s", regularInputRFC5424, err)
+	}
+
+	// The first three values should be interned, the message id not.
+	tests := []struct {
+		Name           string
+		Got1, Got2     string
+		ExpectedShared bool
+	}{
+		{"Hostname", msg1.Hostname, msg2.Hostname, true},
+		{"Appname", msg1.Appname, msg2.Appname, true},
+		{"ProcessID", msg1.ProcessID, msg2.ProcessID, true},
+		{"MessageID", msg1.MessageID, msg2.MessageID, false},
+	}
+
+	for _, test := range tests {
+		if test.Got1 != test.Got2 {
+			t.Fatalf("Expected %s to be %q, but got %q", test.Name, test.Got1, test.Got2)
+		}
+
+		shared := stringData(test.Got1) == stringData(test.Got2)
+		if shared != test.ExpectedShared {
+			t.Fatalf("Expected %s to be shared: %t, but got %t",
+				test.Name, test.ExpectedShared, shared)
+		}
+	}
+
+	// Another parser shouldn't share the strings.
+	msg3, err := NewParser(RFC5424, WithInterning(3))(regularInputRFC5424)
+	if err != nil {
+		t.Fatalf("Unexpected error parse(%q): %s", regularInputRFC5424, err)
+	}
+	if stringData(msg1.Hostname) == stringData(msg3.Hostname) {
+		t.Fatal("Expected interned strings to not be shared between parsers")
+	}
+}
+
+func stringData(s string) *byte {
+	return unsafe.StringData(s)
+}
+
+func TestWithMaxMessageLength(t *testing.T) {
+	t.Parallel()
+
+	parse := NewParser(RFC5424, WithMaxMessageLength(len(regularInputRFC5424)))
+	if _, err := parse(regularInputRFC5424); err != nil {
+		t.Fatalf("Unexpected error parse(%q): %s", regularInputRFC5424, err)
+	}
+
+	input := append(append([]byte{}, regularInputRFC5424...), '!')
+	msg, err := parse(input)
+	if !errors.Is(err, ErrMessageTooLong) {
+		t.Fatalf("Expected parse(%q) to return %q, but got %v", input, ErrMessageTooLong, err)
+	} else if msg == nil || !reflect.DeepEqual(*msg, Message{}) {
+		t.Fatalf("Expected parse(%q) to return an empty message, but got %#v", input, msg)
+	}
+
+	formatErr, ok := err.(*FormatError)
+	if !ok {
+		t.Fatalf("Expected parse(%q) to return a *FormatError, but got %#v", input, err)
+	} else if formatErr.Format != "RFC5424" || formatErr.Snippet == nil {
+		t.Fatalf("Expected parse(%q) to return an error with the format name and a snippet, "+
+			"but got %q", input, err)
+	}
+
+	// Unlimited by default.
+	if _, err := NewParser(RFC5424)(input); err != nil {
+		t.Fatalf("Unexpected error parse(%q): %s", input, err)
+	}
+}
+
+func TestParseMessageOpts(t *testing.T) {
+	t.Parallel()
+
+	msg, err := ParseMessageOpts(minimumInputNginxAccess, NginxAccess, WithLocation(locationCEST), WithYear(2015))
+	if err != nil {
+		t.Fatalf("Unexpected error ParseMessageOpts(%q): %s", minimumInputNginxAccess, err)
+	} else if expected := time.Date(2015, 1, 1, 1, 1, 1, 0, locationCEST); !msg.Timestamp.Equal(expected) {
+		t.Fatalf("Expected ParseMessageOpts(%q) to return timestamp %s, but got %s",
+			minimumInputNginxAccess, expected, msg.Timestamp)
+	}
+
+	_, err = ParseMessageOpts(regularInputRFC5424, RFC5424, WithMaxMessageLength(10))
+	if !errors.Is(err, ErrMessageTooLong) {
+		t.Fatalf("Expected ParseMessageOpts(%q) to return %q, but got %v",
+			regularInputRFC5424, ErrMessageTooLong, err)
+	}
+
+	_, err = ParseMessageOpts(regularInputRFC5424, Format{calculateFacility, parsePriority}, WithLint())
+	var invalidErr InvalidFormatError
+	if !errors.As(err, &invalidErr) {
+		t.Fatalf("Expected ParseMessageOpts with WithLint to return an InvalidFormatError, but got %v", err)
+	}
+}
+
+func TestParseMessageOptsAllocs(t *testing.T) {
+	// Without options it shouldn't allocate more than ParseMessage.
+	expected := testing.AllocsPerRun(100, func() { ParseMessage(regularInputRFC5424, RFC5424) })
+	if got := testing.AllocsPerRun(100, func() { ParseMessageOpts(regularInputRFC5424, RFC5424) }); got != expected {
+		t.Fatalf("Expected ParseMessageOpts without options to allocate %v times, but got %v",
+			expected, got)
+	}
+}
+
+func TestParserConfigsDontLeak(t *testing.T) {
+	t.Parallel()
+
+	input := minimumInputNginxAccess
+	amsterdam := NewParser(NginxAccess, WithLocation(locationCEST), WithYear(2015))
+	utc := NewParser(NginxAccess, WithLocation(time.UTC), WithYear(2015), WithMaxMessageLength(len(input)-1))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if _, err := utc(input); !errors.Is(err, ErrMessageTooLong) {
+				t.Errorf("Expected parse(%q) to return %q, but got %v", input, ErrMessageTooLong, err)
+				return
+			}
+		}
+	}()
+
+	expected := time.Date(2015, 1, 1, 1, 1, 1, 0, locationCEST)
+	for i := 0; i < 100; i++ {
+		msg, err := amsterdam(input)
+		if err != nil {
+			t.Fatalf("Unexpected error parse(%q): %s", input, err)
+		} else if !msg.Timestamp.Equal(expected) || msg.Timestamp.Location() != locationCEST {
+			t.Fatalf("Expected parse(%q) to return timestamp %s, but got %s",
+				input, expected, msg.Timestamp)
+		}
+	}
+	<-done
+
+	// Nor into ParseMessage.
+	msg, err := ParseMessage(input, NginxAccess)
+	if err != nil {
+		t.Fatalf("Unexpected error ParseMessage(%q): %s", input, err)
+	} else if msg.Timestamp.Location() != time.Local {
+		t.Fatalf("Expected ParseMessage(%q) to use the local location, but got %s",
+			input, msg.Timestamp.Location())
+	}
+}
+
+func TestWithBestEffort(t *testing.T) {
+	t.Parallel()
+
+	parse := NewParser(RFC5424, WithBestEffort())
+	tests := []struct {
+		Input          string
+		Expected       *Message
+		ExpectedErrors []error
+	}{
+		{
+			"<34>1 2015-13-45T99:00:00Z hostname appname - - - message",
+			&Message{
+				Priority: 34,
+				Facility: SecurityAuthorization,
+				Severity: Critical,
+				Version:  1,
+				Hostname: "hostname",
+				Appname:  "appname",
+				Message:  "message",
+			},
+			[]error{ErrBadTimestamp},
+		},
+		{
+			"<34>1 2015-10-13T12:31:40Z hostname " + generateString("appname", maxAppNameLength+1) + " 1187 - - message",
+			&Message{
+				Priority:  34,
+				Facility:  SecurityAuthorization,
+				Severity:  Critical,
+				Version:   1,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Hostname:  "hostname",
+				ProcessID: "1187",
+				Message:   "message",
+			},
+			[]error{ErrFieldTooLong},
+		},
+		{
+			`<34>1 - hostname appname - - [data name="value" message`,
+			&Message{
+				Priority: 34,
+				Facility: SecurityAuthorization,
+				Severity: Critical,
+				Version:  1,
+				Hostname: "hostname",
+				Appname:  "appname",
+				Message:  `name="value" message`,
+			},
+			[]error{ErrTruncated},
+		},
+		{
+			"<34>x - hostname",
+			&Message{
+				Priority: 34,
+				Facility: SecurityAuthorization,
+				Severity: Critical,
+				Hostname: "hostname",
+			},
+			[]error{nil, ErrTruncated},
+		},
+		{"garbage", &Message{Message: "garbage"}, []error{nil}},
+	}
+
+	for _, test := range tests {
+		msg, err := parse([]byte(test.Input))
+		if err != nil {
+			t.Fatalf("Unexpected error parse(%q): %s", test.Input, err)
+		}
+
+		errs := msg.Errors()
+		if len(errs) != len(test.ExpectedErrors) {
+			t.Fatalf("Expected parse(%q) to record %d errors, but got %v",
+				test.Input, len(test.ExpectedErrors), errs)
+		}
+		for i, err := range errs {
+			var formatErr *FormatError
+			if !errors.As(err, &formatErr) || (test.ExpectedErrors[i] != nil && !errors.Is(err, test.ExpectedErrors[i])) {
+				t.Fatalf("Expected parse(%q) to record a *FormatError with kind %v, but got %#v",
+					test.Input, test.ExpectedErrors[i], err)
+			}
+		}
+
+		msg.errs = nil
+		if !messagesAreEqual(msg, test.Expected) {
+			t.Fatalf("Expected parse(%q) to return Message %#v, but got %#v",
+				test.Input, test.Expected, msg)
+		}
+	}
+
+	// A message that's too long is still not parsed.
+	msg, err := NewParser(RFC5424, WithBestEffort(), WithMaxMessageLength(5))(regularInputRFC5424)
+	if err != nil {
+		t.Fatalf("Unexpected error parse(%q): %s", regularInputRFC5424, err)
+	} else if errs := msg.Errors(); len(errs) != 1 || !errors.Is(errs[0], ErrMessageTooLong) {
+		t.Fatalf("Expected parse(%q) to record %q, but got %v", regularInputRFC5424,
+			ErrMessageTooLong, errs)
+	}
+}
+
+func TestWithLenientWhitespace(t *testing.T) {
+	t.Parallel()
+
+	expected := &Message{
+		Priority:  34,
+		Facility:  SecurityAuthorization,
+		Severity:  Critical,
+		Version:   1,
+		Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+		Hostname:  "hostname",
+		Appname:   "appname",
+		ProcessID: "1187",
+		Data:      map[string]map[string]string{"data": {"name": "value"}},
+		Message:   "message",
+	}
+
+	tests := []struct {
+		Input         string
+		ExpectedError string
+	}{
+		{"<34>1 2015-10-13T12:31:40Z hostname appname 1187 - [data name=\"value\"] message", ""},
+		{"<34>1  2015-10-13T12:31:40Z  hostname  appname  1187  -  [data name=\"value\"]  message",
+			"syslog: RFC5424: format incorrect at byte 7: timestamp is not following an accepted format"},
+		{"<34>1\t2015-10-13T12:31:40Z\thostname\tappname\t1187\t-\t[data name=\"value\"]\tmessage",
+			"syslog: RFC5424: format incorrect at byte 5: version too long"},
+		{"<34>1 \t2015-10-13T12:31:40Z \t hostname\t\tappname 1187\t - [data name=\"value\"] \t message",
+			"syslog: RFC5424: format incorrect at byte 7: timestamp is not following an accepted format"},
+		{"<34>1 2015-10-13T12:31:40Z  hostname appname 1187 - [data name=\"value\"] message",
+			"syslog: RFC5424: format incorrect at byte 28: expected hostname, but got ' '"},
+	}
+
+	lenient := NewParser(RFC5424, WithLenientWhitespace())
+	strict := NewParser(RFC5424, WithLenientWhitespace(), WithStrict())
+	for _, test := range tests {
+		msg, err := lenient([]byte(test.Input))
+		if err != nil {
+			t.Fatalf("Unexpected error parse(%q) with WithLenientWhitespace: %s", test.Input, err)
+		} else if !messagesAreEqual(msg, expected) {
+			t.Fatalf("Expected parse(%q) with WithLenientWhitespace to return Message %#v, but got %#v",
+				test.Input, expected, msg)
+		}
+
+		// The default and strict modes only accept a single space.
+		for _, parse := range []Parser{NewParser(RFC5424), strict} {
+			_, err := parse([]byte(test.Input))
+			if test.ExpectedError == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error parse(%q): %s", test.Input, err)
+				}
+				continue
+			}
+
+			formatErr, ok := err.(*FormatError)
+			if !ok {
+				t.Fatalf("Expected parse(%q) to return a *FormatError, but got %#v", test.Input, err)
+			}
+			formatErr.Snippet = nil
+			if got := formatErr.Error(); got != test.ExpectedError {
+				t.Fatalf("Expected parse(%q) to return error %q, but got %q",
+					test.Input, test.ExpectedError, got)
+			}
+		}
+	}
+}
+
+func TestWithLenientWhitespaceNginxError(t *testing.T) {
+	t.Parallel()
+
+	expected, err := ParseMessage(regularInputNginxError, NginxError)
+	if err != nil {
+		t.Fatalf("Unexpected error ParseMessage(%q): %s", regularInputNginxError, err)
+	}
+
+	input := bytes.Replace(regularInputNginxError, []byte(" [Error]"), []byte(" \t [Error]"), 1)
+	if _, err := ParseMessage(input, NginxError); err == nil {
+		t.Fatalf("Expected ParseMessage(%q) to return an error, but got nil", input)
+	}
+
+	msg, err := NewParser(NginxError, WithLenientWhitespace())(input)
+	if err != nil {
+		t.Fatalf("Unexpected error parse(%q) with WithLenientWhitespace: %s", input, err)
+	} else if !messagesAreEqual(msg, expected) {
+		t.Fatalf("Expected parse(%q) with WithLenientWhitespace to return Message %#v, but got %#v",
+			input, expected, msg)
+	}
+}
+
+func TestWithLenientWhitespaceDiscardByte(t *testing.T) {
+	t.Parallel()
+
+	input := []byte("<1> \thostname")
+	lenient := NewParser(Format{ParsePriority(), DiscardSpace(), ParseHostname()}, WithLenientWhitespace())
+	if msg, err := lenient(input); err != nil {
+		t.Fatalf("Unexpected error parse(%q) with DiscardSpace: %s", input, err)
+	} else if msg.Hostname != "hostname" {
+		t.Fatalf("Expected parse(%q) with DiscardSpace to return hostname %q, but got %q",
+			input, "hostname", msg.Hostname)
+	}
+
+	// DiscardByte always discards a single byte.
+	strict := NewParser(Format{ParsePriority(), DiscardByte(' '), ParseHostname()}, WithLenientWhitespace())
+	if _, err := strict(input); err == nil {
+		t.Fatalf("Expected parse(%q) with DiscardByte(' ') to return an error, but got nil", input)
+	}
+}
+
+func TestWithMaxParams(t *testing.T) {
+	t.Parallel()
+
+	parse := NewParser(RFC5424, WithMaxParams(2))
+	tests := []struct {
+		Input    string
+		Expected error
+	}{
+		{`<191>1 - - - - - [a x="1" y="2"][b x="1" y="2"]`, nil},
+		{`<191>1 - - - - - [a x="1" y="2" z="3"]`,
+			&FormatError{Pos: 33, Msg: "data element a has too many params", Err: ErrTooManyParams, Format: "RFC5424"}},
+	}
+
+	for _, test := range tests {
+		_, err := parse([]byte(test.Input))
+		if test.Expected == nil {
+			if err != nil {
+				t.Fatalf("Unexpected error parse(%q): %s", test.Input, err)
+			}
+			continue
+		}
+
+		if !errors.Is(err, ErrTooManyParams) {
+			t.Fatalf("Expected parse(%q) to return %q, but got %v", test.Input, ErrTooManyParams, err)
+		}
+		err.(*FormatError).Snippet = nil
+		if got, expected := err.Error(), test.Expected.Error(); got != expected {
+			t.Fatalf("Expected parse(%q) to return error %q, but got %q", test.Input, expected, got)
+		}
+	}
+}
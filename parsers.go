@@ -6,16 +6,19 @@ package syslog
 
 import (
 	"bytes"
-	"fmt"
 	"io"
+	"math"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 )
 
 const (
 	maxPriorityLength  = 3
-	maxVersionLength   = 2
+	maxVersionLength   = 3
 	maxHostnameLength  = 255
 	maxAppNameLength   = 48
 	maxProcessIDLength = 128
@@ -23,6 +26,9 @@ const (
 	maxDataIDLength    = 32
 	maxDataParamLength = 32
 
+	// Maximum number of digits of the fractional seconds of a timestamp.
+	maxTimestampFraction = 6
+
 	spaceByte     byte = ' '
 	nilValueByte  byte = '-'
 	equalByte     byte = '='
@@ -39,9 +45,23 @@ const (
 )
 
 // Threat as constant.
-var bom = []byte{239, 187, 191}
+var (
+	bom             = []byte{239, 187, 191}
+	unknownTimezone = []byte("-00:00")
+)
+
+// ParseFunc parses a part of the message, starting at the current position of
+// the Buffer, into the Message. It returns io.EOF if the message ends before
+// the part, which ParseMessage reports as a FormatError with kind
+// ErrTruncated, and a *FormatError if the part doesn't follow the format.
+//
+// The ParseFuncs of this package are returned by functions named after them,
+// e.g. ParseHostname, to combine them with custom ParseFuncs into a custom
+// Format.
+type ParseFunc func(*Buffer, *Message) error
 
-type parseFunc func(*buffer, *Message) error
+// parseFunc is the internal name of ParseFunc.
+type parseFunc = ParseFunc
 
 func parsePriority(buf *buffer, msg *Message) error {
 	if err := checkByte(buf, priorityStart); err != nil {
@@ -55,22 +75,31 @@ func parsePriority(buf *buffer, msg *Message) error {
 		if pos > buf.Pos() {
 			pos = buf.Pos()
 		}
-		return newFormatError(pos, "priority not closed")
+		return newFormatError(pos, ErrBadPriority, "priority not closed")
 	} else if err != nil {
 		return err
 	} else if len(priorityBytes) > maxPriorityLength+1 { // Closing tag included.
-		return newFormatError(startPos+maxPriorityLength, "priority too long")
+		return newFormatError(startPos+maxPriorityLength, ErrBadPriority, "priority too long")
 	}
 
 	priorityBytes = priorityBytes[:len(priorityBytes)-1]
 	if len(priorityBytes) == 0 {
-		return newFormatError(startPos, "priority can't be empty")
+		return newFormatError(startPos, ErrBadPriority, "priority can't be empty")
 	}
 
-	priority, err := strconv.Atoi(string(priorityBytes))
-	if err != nil {
-		return newFormatError(startPos, "priority not a number: "+
+	priority, ok := parseDigits(priorityBytes)
+	if !ok {
+		return newFormatError(startPos, ErrBadPriority, "priority not a number: "+
 			string(priorityBytes))
+	} else if priority > math.MaxUint8 {
+		// Can't be represented by Priority.
+		return newFormatError(startPos, ErrBadPriority, "priority out of range")
+	} else if buf.cfg.Strict() {
+		if len(priorityBytes) > 1 && priorityBytes[0] == '0' {
+			return newFormatError(startPos, ErrBadPriority, "priority has leading zeros")
+		} else if !Priority(priority).IsValid() {
+			return newFormatError(startPos, ErrBadPriority, "priority out of range")
+		}
 	}
 
 	msg.Priority = Priority(priority)
@@ -78,46 +107,74 @@ func parsePriority(buf *buffer, msg *Message) error {
 }
 
 func parseVersion(buf *buffer, msg *Message) error {
-	versionBytes, err := buf.Peek(maxVersionLength)
+	startPos := buf.Pos()
+	versionBytes, err := buf.Peek(maxVersionLength + 1) // Including the space.
 	if err != nil && err != io.EOF {
 		return err
 	}
-	l := len(versionBytes)
 
-	// Version can be between 0 and 2 digits long.
-	if l == 0 || (l >= 1 && versionBytes[0] == spaceByte) {
+	// Version can be between 0 and 3 digits long, followed by a space.
+	if i := indexSeparator(buf, versionBytes); i != -1 {
+		versionBytes = versionBytes[:i]
+	}
+	l := len(versionBytes)
+	if l == 0 {
 		return nil
-	} else if l == 2 && versionBytes[1] == spaceByte {
-		versionBytes = versionBytes[:1]
-		l = len(versionBytes)
+	} else if l > maxVersionLength {
+		return newFormatError(startPos, ErrFieldTooLong, "version too long")
 	}
 
-	version, err := strconv.ParseUint(string(versionBytes), 10, 0)
-	if err != nil {
-		return newFormatError(buf.Pos(), "version not a number: "+
+	version, ok := parseDigits(versionBytes)
+	if !ok {
+		return newFormatError(startPos, nil, "version not a number: "+
 			string(versionBytes))
+	} else if buf.cfg.Strict() && versionBytes[0] == '0' {
+		return newFormatError(startPos, nil, "version must start with a non-zero "+
+			"digit: "+string(versionBytes))
 	}
 
-	if n := buf.Discard(l); n != l {
-		return io.EOF
-	}
-
-	msg.Version = uint(version)
+	buf.Discard(l)
+	msg.Version = version
 	return nil
 }
 
+// ParseDigits parses the bytes as a decimal number, it returns false if any of
+// the bytes is not a digit. It doesn't check for overflows, so it should only
+// be used on short numbers.
+func parseDigits(b []byte) (uint, bool) {
+	var n uint
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + uint(c-'0')
+	}
+	return n, true
+}
+
+// ParseTimestamp parses a timestamp using the first of the given formats that
+// matches, in the location of the configuration, see WithLocation. If no
+// formats are given parseTimestampNoFormats is returned, which always returns
+// an error, use Lint to detect it.
 func parseTimestamp(formats ...string) parseFunc {
+	return parseTimestampIn(nil, formats...)
+}
+
+// ParseTimestampIn is parseTimestamp, but it uses loc for timestamps without a
+// timezone, regardless of the configuration. If loc is nil the location of the
+// configuration is used.
+func parseTimestampIn(loc *time.Location, formats ...string) parseFunc {
 	if len(formats) == 0 {
-		panic("syslog: no formats supplied to parseTimestamp")
+		return parseTimestampNoFormats
 	}
 
-	return func(buf *buffer, msg *Message) error {
+	return setStage(func(buf *buffer, msg *Message) error {
 		if nextIsNilValue(buf) {
 			return nil
 		}
 
 		for _, format := range formats {
-			timestamp, err := parseTimestampf(buf, format)
+			timestamp, err := parseTimestampf(buf, format, loc)
 			if err != nil {
 				continue
 			}
@@ -126,25 +183,104 @@ func parseTimestamp(formats ...string) parseFunc {
 		}
 
 		// todo: improve the error message, include the given formats.
-		return newFormatError(buf.Pos(), "timestamp is not following an accepted format")
+		return newFormatError(buf.Pos(), ErrBadTimestamp, "timestamp is not following an accepted format")
+	}, stage{name: "ParseTimestamp", flags: stageTimestamp})
+}
+
+// CheckTimestamp checks, in strict mode, if the timestamp follows RFC 5424,
+// before it's parsed by parseTimestamp. It rejects the timestamps accepted by
+// time.Parse that RFC 5424 doesn't allow: a lowercase 't' or 'z', more than 6
+// digits for the fractional seconds and "-00:00" as timezone.
+func checkTimestamp(buf *buffer, msg *Message) error {
+	if !buf.cfg.Strict() {
+		return nil
+	}
+
+	timestamp := buf.bytes[buf.position:buf.length]
+	if i := bytes.IndexByte(timestamp, spaceByte); i != -1 {
+		timestamp = timestamp[:i]
+	}
+
+	startPos := buf.Pos()
+	for i := 0; i < len(timestamp); i++ {
+		switch timestamp[i] {
+		case 't':
+			return newFormatError(startPos+i, ErrBadTimestamp,
+				"timestamp must use an uppercase 'T'")
+		case 'z':
+			return newFormatError(startPos+i, ErrBadTimestamp,
+				"timestamp must use an uppercase 'Z'")
+		case '.':
+			digits := 0
+			for i++; i < len(timestamp) && '0' <= timestamp[i] && timestamp[i] <= '9'; i++ {
+				if digits++; digits > maxTimestampFraction {
+					return newFormatError(startPos+i, ErrBadTimestamp,
+						"timestamp fractional seconds longer than 6 digits")
+				}
+			}
+			i--
+		}
 	}
+
+	if bytes.HasSuffix(timestamp, unknownTimezone) {
+		return newFormatError(startPos+len(timestamp)-len(unknownTimezone),
+			ErrBadTimestamp, "timestamp can't use -00:00 as timezone")
+	}
+	return nil
 }
 
-func parseTimestampf(buf *buffer, format string) (time.Time, error) {
-	timeBytes, err := buf.Peek(len(format))
+// parseBSDTimestamp parses either a RFC 3339 timestamp, as written by e.g.
+// Busybox, or a RFC 3164 timestamp, e.g. "Oct 13 12:31:40", of which the year
+// is inferred, see nginxFixTimestamp.
+func parseBSDTimestamp(buf *buffer, msg *Message) error {
+	if timestamp, err := parseTimestampf(buf, time.RFC3339Nano, nil); err == nil {
+		msg.Timestamp = timestamp
+		return nil
+	}
+
+	timestamp, err := parseTimestampf(buf, "Jan _2 15:04:05", nil)
 	if err != nil {
-		return time.Time{}, err
+		return newFormatError(buf.Pos(), ErrBadTimestamp, "timestamp is not following an accepted format")
+	}
+	msg.Timestamp = timestamp
+	return nginxFixTimestamp(buf, msg)
+}
+
+func parseTimestampNoFormats(buf *buffer, msg *Message) error {
+	return newFormatError(buf.Pos(), nil, "no timestamp formats supplied")
+}
+
+// ParseTimestampf parses a timestamp with the given format. If the format
+// doesn't contain a space, e.g. time.RFC3339, the timestamp can be of variable
+// length and ends at the next space. Otherwise it must be as long as the format.
+// If loc is nil the location of the configuration is used.
+func parseTimestampf(buf *buffer, format string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = buf.cfg.Location()
 	}
 
-	timestamp, err := time.ParseInLocation(format, string(timeBytes), time.Now().Location())
+	var timeBytes []byte
+	if strings.IndexByte(format, spaceByte) == -1 {
+		timeBytes = buf.bytes[buf.position:buf.length]
+		if i := indexSeparator(buf, timeBytes); i != -1 {
+			timeBytes = timeBytes[:i]
+		} else if len(timeBytes) == 0 {
+			return time.Time{}, io.EOF
+		}
+	} else {
+		var err error
+		if timeBytes, err = buf.Peek(len(format)); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	timestamp, err := time.ParseInLocation(format, string(timeBytes), loc)
 	if err != nil {
 		return time.Time{}, err
 	}
 
-	if n := buf.Discard(len(format)); n != len(format) {
-		return time.Time{}, io.EOF
-	}
-	return timestamp, err
+	buf.Discard(len(timeBytes))
+	return timestamp, nil
 }
 
 func parseHostname(buf *buffer, msg *Message) error {
@@ -157,6 +293,24 @@ func parseHostname(buf *buffer, msg *Message) error {
 	return nil
 }
 
+// parseOptionalHostname parses the hostname, followed by a space, unless the
+// next value is a tag ending with a colon, e.g. "app:" or "app[123]:", as
+// written by syslog daemons that don't include the hostname.
+func parseOptionalHostname(buf *buffer, msg *Message) error {
+	b := buf.bytes[buf.position:buf.length]
+	if i := indexSeparator(buf, b); i != -1 {
+		b = b[:i]
+	}
+	if len(b) != 0 && b[len(b)-1] == ':' {
+		return nil
+	}
+
+	if err := parseHostname(buf, msg); err != nil {
+		return err
+	}
+	return discardSpace(buf, msg)
+}
+
 func parseAppname(buf *buffer, msg *Message) error {
 	appname, err := parseSingleValue(buf, "appname", true, maxAppNameLength)
 	if err != nil {
@@ -167,6 +321,32 @@ func parseAppname(buf *buffer, msg *Message) error {
 	return nil
 }
 
+// ParseTag parses a BSD style tag, "appname[pid]:", into Appname and
+// ProcessID. Both the pid and the colon are optional, e.g. "appname:" and
+// "appname" are valid tags as well.
+func parseTag(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	tag, err := parseSingleValue(buf, "tag", true, maxAppNameLength+maxProcessIDLength+3)
+	if err != nil {
+		return err
+	}
+
+	appname, processID := strings.TrimSuffix(tag, ":"), ""
+	if i := strings.IndexByte(appname, '['); i != -1 && strings.HasSuffix(appname, "]") {
+		appname, processID = appname[:i], appname[i+1:len(appname)-1]
+	}
+
+	if len(appname) > maxAppNameLength {
+		return newFormatError(startPos, ErrFieldTooLong, "appname too long")
+	} else if len(processID) > maxProcessIDLength {
+		return newFormatError(startPos+len(appname)+1, ErrFieldTooLong, "processID too long")
+	}
+
+	msg.Appname = appname
+	msg.ProcessID = processID
+	return nil
+}
+
 func parseProcessID(buf *buffer, msg *Message) error {
 	processID, err := parseSingleValue(buf, "processID", true, maxProcessIDLength)
 	if err != nil {
@@ -188,105 +368,377 @@ func parseMessageID(buf *buffer, msg *Message) error {
 }
 
 func parseData(buf *buffer, msg *Message) error {
+	return parseDataValues(buf, msg, parseDataElements)
+}
+
+// ParseNginxAccessData is parseData for Nginx access logs, which decodes the
+// escapes Nginx uses in the values, see parseNginxParamValue, and allows
+// whitespace between the elements, e.g. "[request a="1"] [upstream b="2"]".
+func parseNginxAccessData(buf *buffer, msg *Message) error {
+	return parseDataValues(buf, msg, parseNginxDataElements)
+}
+
+// ParseDataValues parses the structured data, using parseElements to parse
+// the elements, see parseDataElement.
+func parseDataValues(buf *buffer, msg *Message, parseElements parseFunc) error {
 	if nextIsNilValue(buf) {
 		return nil
-	} else if err := checkByte(buf, dataStart); err != nil {
+	}
+
+	// Data is only set if all structured data is valid.
+	previous := msg.Data
+	msg.Data = map[string]map[string]string{}
+	if err := parseElements(buf, msg); err != nil {
+		msg.Data = previous
 		return err
 	}
 
-	var data = map[string]map[string]string{}
-	for {
-		dataID, err := parseSingleValue(buf, "data-ID", false, maxDataIDLength)
+	// The structured data must be followed by a space, or the end of the
+	// message.
+	if b, err := buf.Peek(1); err == nil && indexSeparator(buf, b) != 0 {
+		msg.Data = previous
+		return newUnexpectedByteError(buf.Pos(), b[0], spaceByte, dataStart)
+	}
+	return nil
+}
+
+// The element parsers used by parseData and parseNginxAccessData.
+var (
+	parseDataElements = many(func(buf *buffer, msg *Message) error {
+		return parseDataElement(buf, msg, parseParamValue, false)
+	}, 1)
+	parseNginxDataElements = many(func(buf *buffer, msg *Message) error {
+		return parseDataElement(buf, msg, parseNginxParamValue, true)
+	}, 1)
+)
+
+// ParseDataElement parses a single structured data element, e.g.
+// `[id name="value"]`, into Message.Data, using parseValue to parse the param
+// values. It doesn't consume anything if the next byte doesn't start an
+// element.
+//
+// If nginx is true whitespace after the element is discarded, if it's followed
+// by another element, and "-" values are dropped, Nginx logs those for unset
+// variables. Otherwise "-" is a regular value, a qouted value is never the
+// NILVALUE.
+func parseDataElement(buf *buffer, msg *Message, parseValue func(*buffer) (string, error), nginx bool) error {
+	pos := buf.Pos()
+	if b, err := buf.Peek(1); err != nil {
+		return err
+	} else if b[0] != dataStart {
+		return newUnexpectedByteError(pos, b[0], dataStart)
+	}
+	buf.Discard(1)
+
+	policy := buf.cfg.Duplicates()
+	idPos := buf.Pos()
+	dataID, err := parseDataName(buf, "data-ID", maxDataIDLength, spaceByte, dataEnd)
+	if err != nil {
+		return err
+	}
+
+	params, ok := msg.Data[dataID]
+	if ok && policy == RejectDuplicates {
+		return newFormatError(idPos, ErrDuplicate, "duplicate data element "+dataID)
+	} else if !ok || policy == LastWins {
+		params = map[string]string{}
+		msg.Data[dataID] = params
+	}
+
+	for n := 1; ; n++ {
+		pos := buf.Pos()
+		if c, err := buf.ReadByte(); err != nil {
+			return err
+		} else if c == dataEnd {
+			break
+		} else if c != spaceByte {
+			return newUnexpectedByteError(pos, c, dataEnd, spaceByte)
+		}
+
+		namePos := buf.Pos()
+		paramName, err := parseParamName(buf)
 		if err != nil {
 			return err
+		} else if buf.cfg.TooManyParams(n) {
+			return newFormatError(namePos, ErrTooManyParams, "data element "+
+				dataID+" has too many params")
 		}
-		buf.ReadByte() // Read next space.
 
-		data[dataID] = map[string]string{}
-		for {
-			paramName, err := parseParamName(buf)
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				return err
-			}
+		paramValue, err := parseValue(buf)
+		if err != nil {
+			return err
+		}
 
-			paramValue, err := parseParamValue(buf)
-			if err != nil {
-				return err
+		if previous, ok := params[paramName]; ok {
+			if policy == RejectDuplicates {
+				return newFormatError(namePos, ErrDuplicate, "duplicate data param "+
+					paramName+" in data element "+dataID)
+			} else if policy == CollectDuplicates {
+				msg.addRepeated(dataID, paramName, previous, paramValue)
 			}
+		}
 
-			if paramValue != nilValue {
-				data[dataID][paramName] = paramValue
-			}
+		if !nginx || paramValue != nilValue {
+			params[paramName] = paramValue
+		}
+	}
 
-			if c, err := buf.ReadByte(); err != nil {
-				return err
-			} else if c == dataEnd {
-				break
-			} else if c != spaceByte {
-				return newFormatError(buf.Pos(), "expected byte '"+string(dataEnd)+
-					"' or '"+string(spaceByte)+"', but got '"+string(c)+"'")
-			}
+	if nginx {
+		b := buf.bytes[buf.position:buf.length]
+		if i := indexNonSpace(b); i < len(b) && b[i] == dataStart {
+			buf.position += i
 		}
+	}
+	return nil
+}
+
+// ParseRawData captures the structured data into RawData, without parsing it
+// into Data, see Message.ParsedData.
+func parseRawData(buf *buffer, msg *Message) error {
+	if nextIsNilValue(buf) {
+		return nil
+	}
+
+	startPos, start := buf.Pos(), buf.position
+	if err := checkByte(buf, dataStart); err != nil {
+		return err
+	}
 
-		if c, err := buf.ReadByte(); err != nil && err != io.EOF {
+	// Only look for the end of the last element, making sure we don't stop on a
+	// `]` inside a param value.
+	var qouted, escaped bool
+	for {
+		c, err := buf.ReadByte()
+		if err != nil {
 			return err
-		} else if err == io.EOF {
-			break
-		} else if c == spaceByte {
-			buf.UnreadByte()
-			break
-		} else if c != dataStart {
-			return newFormatError(buf.Pos(), "expected byte '"+string(spaceByte)+
-				"' or '"+string(dataEnd)+"', but got '"+string(c)+"'")
 		}
-	}
 
-	msg.Data = data
-	return nil
+		switch {
+		case escaped:
+			escaped = false
+		case qouted && c == escapeByte:
+			escaped = true
+		case c == qouteByte:
+			qouted = !qouted
+		case !qouted && c == dataEnd:
+			if next, err := buf.Peek(1); err != nil || next[0] != dataStart {
+				msg.RawData = string(buf.bytes[start:buf.position])
+				msg.dataPos = startPos
+				msg.dataCfg = buf.cfg
+				return nil
+			}
+		}
+	}
 }
 
 func parseParamName(buf *buffer) (string, error) {
-	nameBytes, err := buf.ReadSlice(equalByte)
+	name, err := parseDataName(buf, "data param name", maxDataParamLength, equalByte)
 	if err != nil {
 		return "", err
 	}
-	nameBytes = nameBytes[:len(nameBytes)-1]
+	buf.Discard(1) // Equal sign.
+	return name, nil
+}
+
+// ParseDataName parses a structured data name, i.e. a data-ID or param name.
+// It ends before the first byte that isn't allowed in a name, a space, '=',
+// ']' or '"', which must be one of the given end bytes.
+func parseDataName(buf *buffer, name string, maxLength int, ends ...byte) (string, error) {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+
+	i := 0
+	for i < len(b) && !isDataNameEnd(b[i]) {
+		i++
+	}
 
-	if len(nameBytes) > maxDataParamLength {
-		return "", newFormatError(buf.Pos()-len(nameBytes),
-			"data param name too long")
+	if i == len(b) {
+		buf.position = buf.length
+		return "", io.EOF
+	} else if bytes.IndexByte(ends, b[i]) == -1 {
+		return "", newUnexpectedByteError(startPos+i, b[i], ends...)
+	} else if i == 0 {
+		return "", newFormatError(startPos, nil, name+" can't be empty")
+	} else if i > maxLength {
+		return "", newFormatError(startPos, ErrFieldTooLong, name+" too long")
+	} else if err := checkPrintASCII(buf, b[:i], startPos, name); err != nil {
+		return "", err
 	}
 
-	return string(nameBytes), nil
+	buf.position += i
+	return buf.cfg.intern(b[:i]), nil
+}
+
+func isDataNameEnd(c byte) bool {
+	return c == spaceByte || c == equalByte || c == dataEnd || c == qouteByte
 }
 
+// ParseParamValue parses a qouted param value, translating the escaped
+// characters (\", \\ and \]). Unknown escapes are left intact.
 func parseParamValue(buf *buffer) (string, error) {
+	startPos := buf.Pos()
 	if err := checkByte(buf, qouteByte); err != nil {
 		return "", err
 	}
 
-	// todo: test with unescaped and escaped characters: '"', '\' and ']'.
-	paramValue, err := buf.ReadSlice(qouteByte)
-	if err != nil && err != io.EOF {
+	b, start, escaped := buf.bytes, buf.position, 0
+	for i := start; i < buf.length; i++ {
+		if c := b[i]; c == escapeByte && i+1 < buf.length && isParamEscape(b[i+1]) {
+			escaped++
+			i++
+		} else if c == qouteByte {
+			buf.position = i + 1
+			return unescape(b[start:i], escaped, isParamEscape), nil
+		}
+	}
+
+	buf.position = buf.length
+	return "", newFormatError(startPos, ErrTruncated, "param value not closed")
+}
+
+// isParamEscape checks if the byte is escaped inside a param value.
+func isParamEscape(c byte) bool {
+	return c == qouteByte || c == escapeByte || c == dataEnd
+}
+
+// ParseNginxParamValue parses a qouted param value of a Nginx access log. A
+// backslash escapes any byte, Nginx escapes qoutes either as \x22, or as \"
+// with escape=json. The \xHH escapes, the escapes of parseParamValue and those
+// of JSON strings (\n, \uXXXX, etc.) are translated, unknown escapes are left
+// intact.
+func parseNginxParamValue(buf *buffer) (string, error) {
+	startPos := buf.Pos()
+	if err := checkByte(buf, qouteByte); err != nil {
 		return "", err
 	}
 
-	return string(paramValue[:len(paramValue)-1]), nil
+	b, start, escaped := buf.bytes, buf.position, false
+	for i := start; i < buf.length; i++ {
+		if c := b[i]; c == escapeByte && i+1 < buf.length {
+			escaped = true
+			i++
+		} else if c == qouteByte {
+			buf.position = i + 1
+			if !escaped {
+				return string(b[start:i]), nil
+			}
+			return unescapeNginx(b[start:i]), nil
+		}
+	}
+
+	buf.position = buf.length
+	return "", newFormatError(startPos, ErrTruncated, "param value not closed")
+}
+
+// Bytes translated from a single character escape in Nginx values.
+var nginxEscapes = map[byte]byte{
+	qouteByte:  qouteByte,
+	escapeByte: escapeByte,
+	dataEnd:    dataEnd,
+	'/':        '/',
+	'b':        '\b',
+	'f':        '\f',
+	'n':        '\n',
+	'r':        '\r',
+	't':        '\t',
 }
 
-// ParseMsg reads the remainding bytes and trims an options BOM.
+// unescapeNginx translates the escapes in a Nginx value, see
+// parseNginxParamValue.
+func unescapeNginx(value []byte) string {
+	unescaped := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != escapeByte || i+1 == len(value) {
+			unescaped = append(unescaped, c)
+			continue
+		}
+
+		if e, ok := nginxEscapes[value[i+1]]; ok {
+			unescaped = append(unescaped, e)
+			i++
+		} else if n, ok := parseHex(value[i+2:], 2); ok && value[i+1] == 'x' {
+			unescaped = append(unescaped, byte(n))
+			i += 3
+		} else if n, ok := parseHex(value[i+2:], 4); ok && value[i+1] == 'u' {
+			unescaped = utf8.AppendRune(unescaped, rune(n))
+			i += 5
+		} else {
+			unescaped = append(unescaped, c)
+		}
+	}
+	return string(unescaped)
+}
+
+// parseHex parses the first n bytes of b as hexadecimal number, returning
+// false if b is too short or not a hexadecimal number.
+func parseHex(b []byte, n int) (uint, bool) {
+	if len(b) < n {
+		return 0, false
+	}
+
+	var value uint
+	for _, c := range b[:n] {
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c -= 'a' - 10
+		case c >= 'A' && c <= 'F':
+			c -= 'A' - 10
+		default:
+			return 0, false
+		}
+		value = value<<4 | uint(c)
+	}
+	return value, true
+}
+
+// ParseMsg reads the remainding bytes and trims an options BOM. If the BOM is
+// present Message.UTF8 is set and in strict mode the message must be valid
+// UTF-8. The whitespace around the message is trimmed, unless the raw message
+// option is used.
 func parseMsg(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
 	messageBytes := buf.ReadAll()
-	messageBytes = bytes.TrimSpace(messageBytes)
-	messageBytes = bytes.TrimPrefix(messageBytes, bom)
-	messageBytes = bytes.TrimSpace(messageBytes)
+	trim := !buf.cfg.RawMessage()
+
+	// Used to determine the position of invalid UTF-8, trimming from the start
+	// reduces the capacity.
+	startCap := cap(messageBytes)
+
+	if trim {
+		messageBytes = bytes.TrimLeftFunc(messageBytes, unicode.IsSpace)
+	}
+	messageBytes, msg.UTF8 = bytes.CutPrefix(messageBytes, bom)
+	if trim {
+		messageBytes = bytes.TrimSpace(messageBytes)
+	}
+
+	if msg.UTF8 && buf.cfg.Strict() {
+		if i := invalidUTF8(messageBytes); i != -1 {
+			pos := startPos + startCap - cap(messageBytes) + i
+			return newFormatError(pos, nil, "message contains invalid UTF-8")
+		}
+	}
+
 	msg.Message = string(messageBytes)
 	return nil
 }
 
+// InvalidUTF8 returns the index of the first invalid UTF-8 sequence in b, or
+// -1 if b is valid UTF-8.
+func invalidUTF8(b []byte) int {
+	for i := 0; i < len(b); {
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
 // Discard discard the number of given bytes.
 func discard(n int) parseFunc {
 	return func(buf *buffer, msg *Message) error {
@@ -300,9 +752,39 @@ func discard(n int) parseFunc {
 // DiscardByte check if the next byte is the given byte and then discards it.
 // It returns an error if the next byte is not the given byte.
 func discardByte(c byte) parseFunc {
-	return func(buf *buffer, msg *Message) error {
+	fn := func(buf *buffer, msg *Message) error {
 		return checkByte(buf, c)
 	}
+	if c == spaceByte {
+		setStage(fn, stage{name: "DiscardByte(' ')",
+			flags: stageDiscardsSpace | stageConsumesSpace})
+	}
+	return fn
+}
+
+// Literal checks if the next bytes are exactly s and then discards them. If
+// they're not it returns an error at the first byte that differs, quoting both
+// s and the actual bytes. If the message ends before s it returns an error
+// with kind ErrTruncated, including the number of missing bytes.
+func literal(s string) parseFunc {
+	return func(buf *buffer, msg *Message) error {
+		startPos := buf.Pos()
+		b, err := buf.Peek(len(s))
+		for i := 0; i < len(b); i++ {
+			if b[i] != s[i] {
+				return newFormatError(startPos+i, nil, "expected '"+escapeSnippet([]byte(s))+
+					"', but got '"+escapeSnippet(b)+"'")
+			}
+		}
+		buf.Discard(len(b))
+
+		if err != nil {
+			missing := len(s) - len(b)
+			return newFormatError(buf.Pos(), ErrTruncated, "unexpected end of message, missing "+
+				strconv.Itoa(missing)+" bytes of '"+escapeSnippet([]byte(s))+"'")
+		}
+		return nil
+	}
 }
 
 // DiscardUntil discard all bytes until the given byte is found. If the bytes is
@@ -310,46 +792,251 @@ func discardByte(c byte) parseFunc {
 //
 // Note: the discarded bytes include the given byte.
 func discardUntil(c byte) parseFunc {
-	return func(buf *buffer, msg *Message) error {
+	fn := func(buf *buffer, msg *Message) error {
 		_, err := buf.ReadSlice(c)
 		return err
 	}
+	if c == spaceByte {
+		setStage(fn, stage{name: "DiscardUntil(' ')", flags: stageConsumesSpace})
+	}
+	return fn
+}
+
+// DiscardTo discards all bytes up to, but not including, c. It returns io.EOF
+// if c is not found.
+func discardTo(c byte) parseFunc {
+	return func(buf *buffer, msg *Message) error {
+		b, err := buf.PeekUntil(c)
+		buf.Discard(len(b))
+		return err
+	}
+}
+
+// Capture reads the value up to the delim byte, or the end of the message,
+// and stores it in Message.Data[dataID][param]. The delim byte is not
+// consumed. Duplicate params are handled like duplicate structured data
+// params, see WithDuplicates.
+func capture(dataID, param string, delim byte) parseFunc {
+	return func(buf *buffer, msg *Message) error {
+		startPos := buf.Pos()
+		b := buf.bytes[buf.position:buf.length]
+		if len(b) == 0 {
+			return io.EOF
+		}
+		end := bytes.IndexByte(b, delim)
+		if end == -1 {
+			end = len(b)
+		}
+		buf.position += end
+		return setCaptured(buf, msg, dataID, param, string(b[:end]), startPos)
+	}
+}
+
+// CaptureThrough is like capture, but also consumes the delim byte, which
+// must be present.
+func captureThrough(dataID, param string, delim byte) parseFunc {
+	fn := func(buf *buffer, msg *Message) error {
+		startPos := buf.Pos()
+		b, err := buf.ReadSlice(delim)
+		if err != nil {
+			return err
+		}
+		return setCaptured(buf, msg, dataID, param, string(b[:len(b)-1]), startPos)
+	}
+	if delim == spaceByte {
+		setStage(fn, stage{name: "CaptureThrough", flags: stageConsumesSpace})
+	}
+	return fn
+}
+
+// CaptureQouted reads a qouted value, using the escaping rules of RFC 5424 (\",
+// \\ and \]), and stores it in Message.Data[dataID][param], see capture.
+func captureQouted(dataID, param string) parseFunc {
+	return func(buf *buffer, msg *Message) error {
+		startPos := buf.Pos()
+		value, err := parseParamValue(buf)
+		if err != nil {
+			return err
+		}
+		return setCaptured(buf, msg, dataID, param, value, startPos)
+	}
 }
 
-// Shortcut for checkByte with a space.
+// setCaptured stores the captured value in Message.Data[dataID][param],
+// creating the maps if needed.
+func setCaptured(buf *buffer, msg *Message, dataID, param, value string, pos int) error {
+	if msg.Data == nil {
+		msg.Data = map[string]map[string]string{}
+	}
+	params := msg.Data[dataID]
+	if params == nil {
+		params = map[string]string{}
+		msg.Data[dataID] = params
+	}
+
+	if previous, ok := params[param]; ok {
+		if policy := buf.cfg.Duplicates(); policy == RejectDuplicates {
+			return newFormatError(pos, ErrDuplicate, "duplicate data param "+param+
+				" in data element "+dataID)
+		} else if policy == CollectDuplicates {
+			msg.addRepeated(dataID, param, previous, value)
+		}
+	}
+	params[param] = value
+	return nil
+}
+
+// Regex matches the regexp against the start of the remainder of the message
+// and consumes the match. The matched text and the submatches, see
+// regexp.Regexp.FindStringSubmatch, are passed to assign, which sets the
+// fields of the message. Unmatched submatches are empty.
+func regex(re *regexp.Regexp, assign func(match []string, msg *Message) error) parseFunc {
+	// Anchor the regexp, so it doesn't search the entire message.
+	anchored := regexp.MustCompile(`^(?:` + re.String() + `)`)
+	return func(buf *buffer, msg *Message) error {
+		b := buf.bytes[buf.position:buf.length]
+		indices := anchored.FindSubmatchIndex(b)
+		if indices == nil {
+			return newFormatError(buf.Pos(), nil, "expected a match of regexp "+re.String())
+		}
+
+		match := make([]string, len(indices)/2)
+		for i := range match {
+			if start := indices[2*i]; start != -1 {
+				match[i] = string(b[start:indices[2*i+1]])
+			}
+		}
+		if err := assign(match, msg); err != nil {
+			return err
+		}
+		buf.position += indices[1]
+		return nil
+	}
+}
+
+// RegexData is like regex, but stores the named submatches in
+// Message.Data[dataID], using the name of the submatch as param name.
+// Unmatched submatches are not stored.
+func regexData(re *regexp.Regexp, dataID string) parseFunc {
+	names := re.SubexpNames()
+	anchored := regexp.MustCompile(`^(?:` + re.String() + `)`)
+	return func(buf *buffer, msg *Message) error {
+		startPos := buf.Pos()
+		b := buf.bytes[buf.position:buf.length]
+		indices := anchored.FindSubmatchIndex(b)
+		if indices == nil {
+			return newFormatError(startPos, nil, "expected a match of regexp "+re.String())
+		}
+
+		for i, name := range names {
+			if name == "" || indices[2*i] == -1 {
+				continue
+			}
+			value := string(b[indices[2*i]:indices[2*i+1]])
+			if err := setCaptured(buf, msg, dataID, name, value, startPos+indices[2*i]); err != nil {
+				return err
+			}
+		}
+		buf.position += indices[1]
+		return nil
+	}
+}
+
+// Shortcut for checkByte with a space. With WithLenientWhitespace it skips
+// all spaces and tabs instead, requiring at least one.
 func discardSpace(buf *buffer, msg *Message) error {
+	if buf.cfg.LenientWhitespace() {
+		return skipBlanks(buf, 1)
+	}
 	return checkByte(buf, spaceByte)
 }
 
+// SkipWhitespace skips all spaces and tabs, returning an error if less than
+// min are skipped.
+func skipWhitespace(min int) parseFunc {
+	return setStage(func(buf *buffer, msg *Message) error {
+		return skipBlanks(buf, min)
+	}, stage{name: "SkipWhitespace", flags: stageConsumesSpace})
+}
+
+// skipBlanks skips all spaces and tabs, returning an error if less than min
+// are skipped.
+func skipBlanks(buf *buffer, min int) error {
+	b := buf.bytes[buf.position:buf.length]
+	n := 0
+	for n < len(b) && (b[n] == spaceByte || b[n] == '\t') {
+		n++
+	}
+	buf.position += n
+
+	if n >= min {
+		return nil
+	} else if n == len(b) {
+		return io.EOF
+	}
+	return newUnexpectedByteError(buf.Pos(), b[n], spaceByte)
+}
+
+// IndexSeparator returns the index of the first space in b, which separates
+// the fields of the message, or -1 if there is none. With
+// WithLenientWhitespace a tab is a separator as well.
+func indexSeparator(buf *buffer, b []byte) int {
+	if !buf.cfg.LenientWhitespace() {
+		return bytes.IndexByte(b, spaceByte)
+	}
+	for i, c := range b {
+		if c == spaceByte || c == '\t' {
+			return i
+		}
+	}
+	return -1
+}
+
 func parseSingleValue(buf *buffer, name string, allowNilValue bool, maxLength int) (string, error) {
 	if allowNilValue && nextIsNilValue(buf) {
 		return "", nil
 	}
 
-	value, err := buf.ReadSlice(spaceByte)
-	l := len(value)
-	if (err != nil && err != io.EOF) || (err == io.EOF && l == 0) {
-		return "", err
+	startPos := buf.Pos()
+	value := buf.bytes[buf.position:buf.length]
+	if i := indexSeparator(buf, value); i == 0 {
+		// Fields are separated by a single space, an empty field means there
+		// were multiple. With WithLenientWhitespace those are already skipped.
+		return "", newFormatError(startPos, nil, "expected "+name+", but got '"+
+			escapeSnippet(value[:1])+"'")
+	} else if i != -1 {
+		value = value[:i]
+	} else if len(value) == 0 {
+		return "", io.EOF
 	}
 
-	if err != io.EOF {
-		// Space is included.
-		maxLength++
+	if len(value) > maxLength {
+		return "", newFormatError(startPos, ErrFieldTooLong, name+" too long")
 	}
-	if l > maxLength {
-		return "", newFormatError(buf.Pos()-l+1, name+" too long")
+	buf.Discard(len(value))
+
+	if err := checkPrintASCII(buf, value, startPos, name); err != nil {
+		return "", err
 	}
 
-	// todo: this is really a temporary workaround because parseData uses this in
-	// Data-ID. In case of no (empty) data it will be "[Data-ID]", so the value at
-	// this point will be "Data-ID]", and we need to unread "]". But I'm not sure
-	// this is the best solution.
-	if b := value[l-1]; b == spaceByte || b == dataEnd {
-		value = value[:l-1]
-		buf.UnreadByte()
+	return buf.cfg.intern(value), nil
+}
+
+// CheckPrintASCII checks, in strict mode, if the value of the field only
+// contains printable US-ASCII characters (%d33-126), as required by RFC 5424.
+// Pos is the position of the value in the message.
+func checkPrintASCII(buf *buffer, value []byte, pos int, name string) error {
+	if !buf.cfg.Strict() {
+		return nil
 	}
 
-	return string(value), nil
+	for i, c := range value {
+		if !isPrintASCII(c) {
+			return newFormatError(pos+i, nil, name+" contains invalid byte '"+
+				escapeSnippet([]byte{c})+"'")
+		}
+	}
+	return nil
 }
 
 func checkByte(buf *buffer, expected byte) error {
@@ -358,8 +1045,7 @@ func checkByte(buf *buffer, expected byte) error {
 	if err != nil {
 		return err
 	} else if c != expected {
-		return newFormatError(startPos, "expected byte '"+string(expected)+
-			"', but got '"+string(c)+"'")
+		return newUnexpectedByteError(startPos, c, expected)
 	}
 	return nil
 }
@@ -367,132 +1053,341 @@ func checkByte(buf *buffer, expected byte) error {
 // NextIsNilValue checks if the next byte is a nil value byte. If this function
 // return true, the byte will be read. If it returns false it doesn't read the
 // byte.
-// If the reader returns an error this function returns false, with the
-// expectation that the next read will return the same error.
+// If the buffer is completely read this function returns false, with the
+// expectation that the next read will return io.EOF.
 func nextIsNilValue(buf *buffer) bool {
-	b, err := buf.ReadByte()
-	if err == nil && b == nilValueByte {
-		return true
+	if b, err := buf.Peek(1); err != nil || b[0] != nilValueByte {
+		return false
 	}
+	buf.Discard(1)
+	return true
+}
 
-	if err != io.EOF {
-		buf.UnreadByte()
+// Layout of the timestamp Nginx includes in the message of error logs.
+const nginxTimestampLayout = "2006/01/02 15:04:05"
+
+// ParseNginxTimestamp parses the timestamp Nginx includes in the message of
+// error logs, e.g. "2015/10/13 01:31:40". It replaces the timestamp from the
+// syslog header, as it includes the year and is the time Nginx logged the
+// error. If it can't be parsed the timestamp from the header is kept and the
+// bytes up to the level, e.g. " [error]", are skipped.
+func parseNginxTimestamp(buf *buffer, msg *Message) error {
+	timestamp, err := parseTimestampf(buf, nginxTimestampLayout, nil)
+	if err == nil {
+		msg.Timestamp = timestamp
+		return nil
 	}
-	return false
+
+	i := bytes.Index(buf.bytes[buf.position:buf.length], []byte(" ["))
+	if i == -1 {
+		buf.position = buf.length
+		return io.EOF
+	}
+	buf.position += i
+	return nil
 }
 
-func parseNginxMsg(buf *buffer, msg *Message) error {
-	msgBytes, err := buf.ReadSlice(commaByte)
+// ParseNginxLevel parses the level of a Nginx error log, e.g. "error]" (the
+// opening bracket is already read), see ParseSeverityName. The level replaces
+// the severity from the priority, unless WithPrioritySeverity is used.
+func parseNginxLevel(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b, err := buf.ReadSlice(']')
 	if err != nil {
-		//		return err
+		return err
+	}
+
+	level := b[:len(b)-1]
+	severity, err := ParseSeverityName(string(level))
+	if err != nil {
+		return newFormatError(startPos, nil, "unknown Nginx level '"+escapeSnippet(level)+"'")
+	}
+
+	if !buf.cfg.PrioritySeverity() {
+		msg.Severity = severity
+		if msg.Priority.IsValid() {
+			msg.Priority = CalculatePriority(msg.Facility, severity)
+		}
+	}
+	return nil
+}
+
+// ParseNginxProcessID parses the optional "pid#tid: " token at the start of
+// the message of a Nginx error log into ProcessID, e.g. "1187#1187". If the
+// token isn't there nothing is read.
+func parseNginxProcessID(buf *buffer, msg *Message) error {
+	b := buf.bytes[buf.position:buf.length]
+	i := skipDigits(b, 0)
+	if i == 0 || i >= len(b) || b[i] != '#' {
+		return nil
+	}
+	end := skipDigits(b, i+1)
+	if end == i+1 || end >= len(b) || b[end] != colonByte || end > maxProcessIDLength {
+		return nil
+	}
+
+	msg.ProcessID = string(b[:end])
+	end++ // Colon.
+	if end < len(b) && b[end] == spaceByte {
+		end++
+	}
+	buf.position += end
+	return nil
+}
+
+// skipDigits returns the index of the first non-digit byte in b, starting at
+// i.
+func skipDigits(b []byte, i int) int {
+	for i < len(b) && b[i] >= '0' && b[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+// ParseNginxConnection parses the optional connection id "*46 " that follows
+// the pid#tid in Nginx error logs into Data["data"]["connection"], e.g. "46".
+// If the id isn't there nothing is read.
+func parseNginxConnection(buf *buffer, msg *Message) error {
+	b := buf.bytes[buf.position:buf.length]
+	if len(b) == 0 || b[0] != '*' {
+		return nil
+	}
+	end := skipDigits(b, 1)
+	if end == 1 || (end < len(b) && b[end] != spaceByte) {
+		return nil
+	}
+
+	nginxData(msg)["connection"] = string(b[1:end])
+	if end < len(b) {
+		end++ // Space.
+	}
+	buf.position += end
+	return nil
+}
+
+// nginxData returns the data element of Nginx error logs, Data["data"],
+// creating it if needed.
+func nginxData(msg *Message) map[string]string {
+	data, ok := msg.Data["data"]
+	if !ok {
+		data = map[string]string{}
+		if msg.Data == nil {
+			msg.Data = map[string]map[string]string{}
+		}
+		msg.Data["data"] = data
+	}
+	return data
+}
+
+// ParseNginxMsg parses the free-text message of a Nginx error log, which ends
+// at the comma before the first "key:" of the data, see nginxMsgEnd. Commas in
+// the message itself are kept. If the message isn't followed by data, e.g. in
+// startup messages, the remainder is used as message.
+func parseNginxMsg(buf *buffer, msg *Message) error {
+	b := buf.bytes[buf.position:buf.length]
+	end := nginxMsgEnd(b, true)
+	if end == -1 {
+		end = nginxMsgEnd(b, false)
+	}
+
+	if end == -1 {
+		msg.Message = string(bytes.TrimSpace(b))
+		buf.position = buf.length
+		return nil
+	}
+
+	msg.Message = string(bytes.TrimSpace(b[:end]))
+	buf.position += end + 1
+	return nil
+}
+
+// NginxMsgEnd returns the index of the first comma in b that is followed by a
+// data key and a colon, e.g. ", client:" or `, "server":`, or -1 if there is
+// none. If qoutes is true commas inside qouted strings are skipped.
+func nginxMsgEnd(b []byte, qoutes bool) int {
+	var inQoutes bool
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case qoutes && c == escapeByte:
+			i++
+		case qoutes && c == qouteByte:
+			inQoutes = !inQoutes
+		case c == commaByte && !inQoutes && isNginxKey(b[i+1:]):
+			return i
+		}
+	}
+	return -1
+}
+
+// isNginxKey checks if b starts with a, optionally qouted, data key followed
+// by a colon and a space (or the end of b), like Nginx formats them. Spaces
+// before and after the key are allowed.
+func isNginxKey(b []byte) bool {
+	i := 0
+	for i < len(b) && isSpace(b[i]) {
+		i++
+	}
 
+	if i < len(b) && b[i] == qouteByte {
+		end := bytes.IndexByte(b[i+1:], qouteByte)
+		if end <= 0 {
+			return false
+		}
+		i += end + 2
 	} else {
-		msgBytes = msgBytes[:len(msgBytes)-1]
+		start := i
+		for i < len(b) && isNginxKeyByte(b[i]) {
+			i++
+		}
+		if i == start {
+			return false
+		}
+	}
 
+	for i < len(b) && isSpace(b[i]) {
+		i++
 	}
+	return i < len(b) && b[i] == colonByte && (i+1 == len(b) || isSpace(b[i+1]))
+}
 
-	msgBytes = bytes.TrimSpace(msgBytes)
-	msg.Message = string(msgBytes)
-	return err
+// isNginxKeyByte checks if the byte is allowed in an unqouted data key.
+func isNginxKeyByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
 }
 
 func parseNginxData(buf *buffer, msg *Message) error {
-	var data = map[string]string{}
+	var data = nginxData(msg)
 
 	for {
-		startPos := buf.Pos()
-
 		key, err := getValue(buf, colonByte, false)
 		if err != nil {
-			if err == io.EOF {
-				return err
-			}
-			return newFormatError(startPos, err.Error())
+			return err
 		}
 
-		value, err := getValue(buf, commaByte, true)
+		var value string
+		if key == "upstream" {
+			value, err = getNginxUpstream(buf)
+		} else {
+			value, err = getValue(buf, commaByte, true)
+		}
 		if err != nil && err != io.EOF {
-			return newFormatError(startPos, err.Error())
+			return err
 		}
 
-		data[string(key)] = string(value)
+		data[key] = value
 
 		if err == io.EOF {
 			break
 		}
 	}
-
-	msg.Data = map[string]map[string]string{
-		"data": data,
-	}
 	return nil
 }
 
-// IF allowEOF is true it won't return io.EOF as an error, but see it as the end
-// of the value.
-func getValue(buf *buffer, end byte, allowEOF bool) ([]byte, error) {
-	var started, isQouted, qoutedClosed bool
-	var value []byte
-	var er error
-
-	for {
-		c, err := buf.ReadByte()
-		if err != nil {
-			if allowEOF && err == io.EOF {
-				er = err
-				break
-			}
+// GetNginxUpstream gets the value of the upstream data key, e.g.
+// "http://10.0.0.5:8080/path". Qouted values are read using getValue, but
+// unqouted values, unlike other values, only end at a comma followed by the
+// next key, as the URL itself may contain commas and colons.
+func getNginxUpstream(buf *buffer) (string, error) {
+	b := buf.bytes[buf.position:buf.length]
+	start := skipSpaces(b, 0)
+	if start < len(b) && b[start] == qouteByte {
+		return getValue(buf, commaByte, true)
+	}
 
-			return []byte{}, err
-		}
+	end := nginxMsgEnd(b[start:], false)
+	if end == -1 {
+		buf.position = buf.length
+		return string(bytes.TrimSpace(b[start:])), io.EOF
+	}
+	buf.position += start + end + 1
+	return string(bytes.TrimSpace(b[start : start+end])), nil
+}
 
-		if !started {
-			if isSpace(c) {
-				continue
-			}
+// GetValue gets a single, optionally qouted, value ending with the given end
+// byte. Leading spaces are skipped and for unqouted values trailing spaces are
+// trimmed. Escaped qoutes (\") are replaced with a qoute. If allowEOF is true
+// it won't return io.EOF as an error, but see it as the end of the value. The
+// value is sliced from the buffer, only the returned string is allocated.
+func getValue(buf *buffer, end byte, allowEOF bool) (string, error) {
+	b, i := buf.bytes, buf.position
+	for i < buf.length && isSpace(b[i]) {
+		i++
+	}
 
-			if c == qouteByte {
-				isQouted = true
-			} else {
-				value = append(value, c)
-			}
+	var isQouted bool
+	if i < buf.length && b[i] == qouteByte {
+		isQouted = true
+		i++
+	}
 
-			started = true
-			continue
+	// Find the end of the value, counting the escaped qoutes along the way.
+	start, escaped := i, 0
+	for ; i < buf.length; i++ {
+		if c := b[i]; c == escapeByte && i+1 < buf.length && b[i+1] == qouteByte {
+			escaped++
+			i++
+		} else if (isQouted && c == qouteByte) || (!isQouted && c == end) {
+			break
 		}
+	}
+	value := b[start:i]
 
-		if qoutedClosed {
-			if isSpace(c) {
-				continue
-			} else if c != end {
-				return []byte{}, fmt.Errorf("unexpected %s after closed qoute", string(c))
-			}
+	if isQouted && i < buf.length {
+		// Skip the closing qoute and any space after it.
+		for i++; i < buf.length && isSpace(b[i]); i++ {
 		}
 
-		if c == qouteByte {
-			if isQouted && value[len(value)-1] != escapeByte {
-				qoutedClosed = true
-				continue
-			} else if value[len(value)-1] == escapeByte {
-				// replace the escape byte with the byte being escaped.
-				value[len(value)-1] = c
-				continue
-			}
+		if i < buf.length && b[i] != end {
+			pos := buf.base + i + 1
+			buf.position = i + 1
+			return "", newUnexpectedByteError(pos, b[i], end)
 		}
+	} else if !isQouted {
+		value = bytes.TrimRightFunc(value, unicode.IsSpace)
+	}
 
-		if c == end && (!isQouted || qoutedClosed) {
-			break
+	if i >= buf.length {
+		buf.position = buf.length
+		if !allowEOF {
+			return "", io.EOF
 		}
+		return unescape(value, escaped, isQoute), io.EOF
+	}
+
+	buf.position = i + 1 // Skip the end byte.
+	return unescape(value, escaped, isQoute), nil
+}
 
-		value = append(value, c)
+// Unescape removes the escape byte in front of the given number of escaped
+// bytes, for which isEscape returns true, in the value.
+func unescape(value []byte, escaped int, isEscape func(byte) bool) string {
+	if escaped == 0 {
+		return string(value)
 	}
 
-	if !isQouted {
-		value = bytes.TrimRightFunc(value, unicode.IsSpace)
+	unescaped := make([]byte, 0, len(value)-escaped)
+	for i := 0; i < len(value); i++ {
+		if value[i] == escapeByte && i+1 < len(value) && isEscape(value[i+1]) {
+			i++
+		}
+		unescaped = append(unescaped, value[i])
 	}
+	return string(unescaped)
+}
 
-	// todo: trim left space.
-	return value, er
+// isQoute checks if the byte is a qoute.
+func isQoute(c byte) bool {
+	return c == qouteByte
+}
+
+// indexNonSpace returns the index of the first byte in b that isn't
+// whitespace, see isSpace, or len(b) if there is none.
+func indexNonSpace(b []byte) int {
+	i := 0
+	for i < len(b) && isSpace(b[i]) {
+		i++
+	}
+	return i
 }
 
 func isSpace(c byte) bool {
@@ -1,10 +1,11 @@
 package syslog
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
-	"runtime"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -27,12 +28,23 @@ func TestParsePriority(t *testing.T) {
 		{"<191>", &Message{Priority: 191}, nil, ""},
 
 		{"", nil, io.EOF, ""},
-		{"!", nil, newFormatError(1, "expected byte '<', but got '!'"), ""},
-		{"<1923", nil, newFormatError(5, "priority not closed"), ""},
-		{"<19", nil, newFormatError(3, "priority not closed"), ""},
-		{"<1923>", nil, newFormatError(5, "priority too long"), ""},
-		{"<>", nil, newFormatError(2, "priority can't be empty"), ""},
-		{"<abc>", nil, newFormatError(2, "priority not a number: abc"), ""},
+		{"!", nil, newFormatError(1, nil, "expected byte '<', but got '!'"), ""},
+		{"<1923", nil, newFormatError(5, nil, "priority not closed"), ""},
+		{"<19", nil, newFormatError(3, nil, "priority not closed"), ""},
+		{"<1923>", nil, newFormatError(5, nil, "priority too long"), ""},
+		{"<>", nil, newFormatError(2, nil, "priority can't be empty"), ""},
+		{"<abc>", nil, newFormatError(2, nil, "priority not a number: abc"), ""},
+		{"<1a>", nil, newFormatError(2, nil, "priority not a number: 1a"), ""},
+		{"<-1>", nil, newFormatError(2, nil, "priority not a number: -1"), ""},
+
+		{"<256>", nil, newFormatError(2, ErrBadPriority, "priority out of range"), ""},
+		{"<999>", nil, newFormatError(2, ErrBadPriority, "priority out of range"), ""},
+
+		// Only rejected in strict mode.
+		{"<00>", &Message{Priority: 0}, nil, ""},
+		{"<01>", &Message{Priority: 1}, nil, ""},
+		{"<192>", &Message{Priority: 192}, nil, ""},
+		{"<255>", &Message{Priority: 255}, nil, ""},
 	}
 
 	if err := testParseFunc(parsePriority, tests); err != nil {
@@ -40,6 +52,65 @@ func TestParsePriority(t *testing.T) {
 	}
 }
 
+func TestParsePriorityStrict(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"<0>", &Message{Priority: 0}, nil, ""},
+		{"<10>", &Message{Priority: 10}, nil, ""},
+		{"<191>", &Message{Priority: 191}, nil, ""},
+
+		{"<00>", nil, newFormatError(2, ErrBadPriority, "priority has leading zeros"), ""},
+		{"<01>", nil, newFormatError(2, ErrBadPriority, "priority has leading zeros"), ""},
+		{"<007>", nil, newFormatError(2, ErrBadPriority, "priority has leading zeros"), ""},
+		{"<192>", nil, newFormatError(2, ErrBadPriority, "priority out of range"), ""},
+		{"<999>", nil, newFormatError(2, ErrBadPriority, "priority out of range"), ""},
+	}
+
+	cfg := newConfig([]Option{WithStrict()})
+	if err := testParseFuncConfig(parsePriority, cfg, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCalculateInvalidPriority(t *testing.T) {
+	t.Parallel()
+
+	msg := &Message{Priority: 192}
+	calculateFacility(nil, msg)
+	calculateSeverity(nil, msg)
+	if msg.Facility != 0 || msg.Severity != 0 {
+		t.Fatalf("Expected an invalid priority to not set the facility and "+
+			"severity, but got %s and %s", msg.Facility, msg.Severity)
+	}
+}
+
+func TestParseFuncErrorKinds(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Fn       parseFunc
+		Input    string
+		Expected error
+	}{
+		{parsePriority, "<1923>", ErrBadPriority},
+		{parsePriority, "<abc>", ErrBadPriority},
+		{parseTimestamp(time.RFC3339), "abc", ErrBadTimestamp},
+		{parseHostname, generateString("hostname", maxHostnameLength+1), ErrFieldTooLong},
+		{parseAppname, generateString("appname", maxAppNameLength+1), ErrFieldTooLong},
+		{parseProcessID, generateString("processID", maxProcessIDLength+1), ErrFieldTooLong},
+		{parseMessageID, generateString("messageID", maxMessageIDLength+1), ErrFieldTooLong},
+	}
+
+	for _, test := range tests {
+		err := test.Fn(newBuffer([]byte(test.Input)), &Message{})
+		if !errors.Is(err, test.Expected) {
+			t.Fatalf("Expected %s(%q) to return error %q, but got %v",
+				getFuncName(test.Fn), test.Input, test.Expected, err)
+		}
+	}
+}
+
 func TestParseVersion(t *testing.T) {
 	t.Parallel()
 
@@ -49,9 +120,20 @@ func TestParseVersion(t *testing.T) {
 		{"1", &Message{Version: 1}, nil, ""},
 		{"10", &Message{Version: 10}, nil, ""},
 		{"99", &Message{Version: 99}, nil, ""},
-
-		{"a", nil, newFormatError(1, "version not a number: a"), ""},
-		{"ab", nil, newFormatError(1, "version not a number: ab"), ""},
+		{"100", &Message{Version: 100}, nil, ""},
+		{"999", &Message{Version: 999}, nil, ""},
+		{"1 -", &Message{Version: 1}, nil, " -"},
+		{"10 -", &Message{Version: 10}, nil, " -"},
+		{"100 -", &Message{Version: 100}, nil, " -"},
+		{"012", &Message{Version: 12}, nil, ""},
+		{" -", &Message{}, nil, " -"},
+
+		{"a", nil, newFormatError(1, nil, "version not a number: a"), ""},
+		{"ab", nil, newFormatError(1, nil, "version not a number: ab"), ""},
+		{"1a", nil, newFormatError(1, nil, "version not a number: 1a"), ""},
+		{"+1", nil, newFormatError(1, nil, "version not a number: +1"), ""},
+		{"1000", nil, newFormatError(1, ErrFieldTooLong, "version too long"), ""},
+		{"1000 -", nil, newFormatError(1, ErrFieldTooLong, "version too long"), ""},
 	}
 
 	if err := testParseFunc(parseVersion, tests); err != nil {
@@ -59,6 +141,23 @@ func TestParseVersion(t *testing.T) {
 	}
 }
 
+func TestParseVersionStrict(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"1", &Message{Version: 1}, nil, ""},
+		{"100 -", &Message{Version: 100}, nil, " -"},
+
+		{"0", nil, newFormatError(1, nil, "version must start with a non-zero digit: 0"), ""},
+		{"012", nil, newFormatError(1, nil, "version must start with a non-zero digit: 012"), ""},
+	}
+
+	cfg := newConfig([]Option{WithStrict()})
+	if err := testParseFuncConfig(parseVersion, cfg, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestParseTimestamp(t *testing.T) {
 	t.Parallel()
 
@@ -67,37 +166,109 @@ func TestParseTimestamp(t *testing.T) {
 		{"2015-10-18T17:05:55+00:00", &Message{Timestamp: time.Date(2015, 10, 18, 17, 5, 55, 0, time.UTC)}, nil, ""},
 		{"2015-10-18T17:05:55+02:00", &Message{Timestamp: time.Date(2015, 10, 18, 17, 5, 55, 0, locationCEST)}, nil, ""},
 		{"2015-10-18T17:05:55.956934919+02:00", &Message{Timestamp: time.Date(2015, 10, 18, 17, 5, 55, 956934919, locationCEST)}, nil, ""},
-
-		{"a", nil, newFormatError(1, "timestamp is not following an accepted format"), ""},
-		{"abc", nil, newFormatError(1, "timestamp is not following an accepted format"), ""},
+		{"2015-10-18T17:05:55Z", &Message{Timestamp: time.Date(2015, 10, 18, 17, 5, 55, 0, time.UTC)}, nil, ""},
+		{"2015-10-18T17:05:55Z host", &Message{Timestamp: time.Date(2015, 10, 18, 17, 5, 55, 0, time.UTC)}, nil, " host"},
+		{"2015-10-18T17:05:55.1Z", &Message{Timestamp: time.Date(2015, 10, 18, 17, 5, 55, 100000000, time.UTC)}, nil, ""},
+		{"2015-10-18T17:05:55.123Z", &Message{Timestamp: time.Date(2015, 10, 18, 17, 5, 55, 123000000, time.UTC)}, nil, ""},
+		{"2015-10-18T17:05:55.123456+02:00 host", &Message{Timestamp: time.Date(2015, 10, 18, 17, 5, 55, 123456000, locationCEST)}, nil, " host"},
+		{"2015-10-18T17:05:55.123456789Z", &Message{Timestamp: time.Date(2015, 10, 18, 17, 5, 55, 123456789, time.UTC)}, nil, ""},
+
+		{"a", nil, newFormatError(1, nil, "timestamp is not following an accepted format"), ""},
+		{"abc", nil, newFormatError(1, nil, "timestamp is not following an accepted format"), ""},
+		{"2015-10-18T17:05:55+02:00host", nil, newFormatError(1, nil, "timestamp is not following an accepted format"), ""},
 	}
 
 	if err := testParseFunc(parseTimestamp(time.RFC3339, time.RFC3339Nano), tests); err != nil {
 		t.Fatal(err)
 	}
+
+	// Formats with spaces have a fixed length.
+	tests = []ParseFuncTest{
+		{"Oct  5 12:05:15 host", &Message{Timestamp: time.Date(0, 10, 5, 12, 5, 15, 0, time.UTC)}, nil, " host"},
+		{"Oct 15 12:05:15", &Message{Timestamp: time.Date(0, 10, 15, 12, 5, 15, 0, time.UTC)}, nil, ""},
+
+		{"Oct 15 12:05", nil, newFormatError(1, nil, "timestamp is not following an accepted format"), ""},
+	}
+
+	cfg := newConfig([]Option{WithLocation(time.UTC)})
+	if err := testParseFuncConfig(parseTimestamp("Jan _2 15:04:05"), cfg, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckTimestamp(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"-", &Message{}, nil, "-"},
+		{"2015-10-18T17:05:55Z", &Message{}, nil, "2015-10-18T17:05:55Z"},
+		{"2015-10-18T17:05:55+00:00 host", &Message{}, nil, "2015-10-18T17:05:55+00:00 host"},
+		{"2015-10-18T17:05:55.123456Z", &Message{}, nil, "2015-10-18T17:05:55.123456Z"},
+		{"2015-10-18T17:05:55.1-01:00", &Message{}, nil, "2015-10-18T17:05:55.1-01:00"},
+
+		{"2015-10-18t17:05:55Z", nil, newFormatError(11, ErrBadTimestamp, "timestamp must use an uppercase 'T'"), ""},
+		{"2015-10-18T17:05:55z", nil, newFormatError(20, ErrBadTimestamp, "timestamp must use an uppercase 'Z'"), ""},
+		{"2015-10-18T17:05:55.1234567Z", nil, newFormatError(27, ErrBadTimestamp, "timestamp fractional seconds longer than 6 digits"), ""},
+		{"2015-10-18T17:05:55-00:00 host", nil, newFormatError(20, ErrBadTimestamp, "timestamp can't use -00:00 as timezone"), ""},
+	}
+
+	cfg := newConfig([]Option{WithStrict()})
+	if err := testParseFuncConfig(checkTimestamp, cfg, tests); err != nil {
+		t.Fatal(err)
+	}
+
+	// Doesn't check anything in lenient mode.
+	lenient := []ParseFuncTest{
+		{"2015-10-18T17:05:55-00:00", &Message{}, nil, "2015-10-18T17:05:55-00:00"},
+		{"2015-10-18t17:05:55.1234567z", &Message{}, nil, "2015-10-18t17:05:55.1234567z"},
+	}
+	if err := testParseFunc(checkTimestamp, lenient); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestParseTimestampNoTimestamps(t *testing.T) {
 	t.Parallel()
 
-	defer func() {
-		recv := recover()
-		if recv == nil {
-			t.Fatal("Expected parseTimestamp() to panic, but it didn't")
-		}
-		expected := "syslog: no formats supplied to parseTimestamp"
-		got, ok := recv.(string)
-		if !ok {
-			t.Fatalf("Unexpected panic: %v", recv)
-		}
+	tests := []ParseFuncTest{
+		{"", nil, newFormatError(1, nil, "no timestamp formats supplied"), ""},
+		{"2015-10-18T17:05:55+00:00", nil, newFormatError(1, nil, "no timestamp formats supplied"), ""},
+	}
+
+	if err := testParseFunc(parseTimestamp(), tests); err != nil {
+		t.Fatal(err)
+	}
+}
 
-		if got != expected {
-			t.Fatalf("Expected parseTimestamp() to panic with message %s, but got %s",
-				expected, got)
+func TestParseTimestampIn(t *testing.T) {
+	t.Parallel()
+
+	const input = "Oct 13 12:31:40 message"
+	parse := func(loc *time.Location, opts ...Option) time.Time {
+		format := Format{ParseTimestampIn(loc, "Jan _2 15:04:05"), InferYear()}
+		msg, err := NewParser(format, append(opts, WithYear(2015))...)([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %q: %s", input, err)
 		}
-	}()
+		return msg.Timestamp
+	}
 
-	parseTimestamp()
+	utc, amsterdam := parse(time.UTC), parse(locationCEST)
+	if expected := time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC); !utc.Equal(expected) {
+		t.Fatalf("Expected the timestamp in UTC to be %s, but got %s", expected, utc)
+	} else if got := utc.Sub(amsterdam); got != 2*time.Hour {
+		t.Fatalf("Expected the timestamp in Europe/Amsterdam to be 2 hours before "+
+			"the one in UTC, but got a difference of %s", got)
+	}
+
+	// The location of the configuration is ignored, unless no location is given.
+	if got := parse(locationCEST, WithLocation(time.UTC)); !got.Equal(amsterdam) {
+		t.Fatalf("Expected the timestamp to be %s, ignoring WithLocation, but got %s",
+			amsterdam, got)
+	} else if got := parse(nil, WithLocation(locationCEST)); !got.Equal(amsterdam) {
+		t.Fatalf("Expected the timestamp to be %s, using WithLocation, but got %s",
+			amsterdam, got)
+	}
 }
 
 func TestParseHostname(t *testing.T) {
@@ -110,7 +281,7 @@ func TestParseHostname(t *testing.T) {
 		{"host", &Message{Hostname: "host"}, nil, ""},
 		{"hostname ", &Message{Hostname: "hostname"}, nil, " "},
 
-		{generateString("hostname", maxHostnameLength+1), nil, newFormatError(1, "hostname too long"), ""},
+		{generateString("hostname", maxHostnameLength+1), nil, newFormatError(1, nil, "hostname too long"), ""},
 	}
 
 	if err := testParseFunc(parseHostname, tests); err != nil {
@@ -118,6 +289,57 @@ func TestParseHostname(t *testing.T) {
 	}
 }
 
+func TestParseSingleValueStrict(t *testing.T) {
+	t.Parallel()
+
+	cfg := newConfig([]Option{WithStrict()})
+	tests := []struct {
+		Fn    parseFunc
+		Tests []ParseFuncTest
+	}{
+		{parseHostname, []ParseFuncTest{
+			{"-", &Message{}, nil, ""},
+			{"hostname ", &Message{Hostname: "hostname"}, nil, " "},
+
+			{"host\tname", nil, newFormatError(5, nil, `hostname contains invalid byte '\t'`), ""},
+			{"host\u00a0name", nil, newFormatError(5, nil, `hostname contains invalid byte '\xc2'`), ""},
+			{"host\x00", nil, newFormatError(5, nil, `hostname contains invalid byte '\x00'`), ""},
+			{"hóst", nil, newFormatError(2, nil, `hostname contains invalid byte '\xc3'`), ""},
+		}},
+		{parseAppname, []ParseFuncTest{
+			{"app\x7f ", nil, newFormatError(4, nil, `appname contains invalid byte '\x7f'`), ""},
+		}},
+		{parseProcessID, []ParseFuncTest{
+			{"1\r2", nil, newFormatError(2, nil, `processID contains invalid byte '\r'`), ""},
+		}},
+		{parseMessageID, []ParseFuncTest{
+			{"msg\vid", nil, newFormatError(4, nil, `messageID contains invalid byte '\x0b'`), ""},
+		}},
+		{parseData, []ParseFuncTest{
+			{`[id a="b"]`, &Message{Data: map[string]map[string]string{"id": {"a": "b"}}}, nil, ""},
+
+			{"[id\x01 a=\"b\"]", nil, newFormatError(4, nil, `data-ID contains invalid byte '\x01'`), ""},
+			{"[id a\tb=\"c\"]", nil, newFormatError(6, nil, `data param name contains invalid byte '\t'`), ""},
+			{`[id é="c"]`, nil, newFormatError(5, nil, `data param name contains invalid byte '\xc3'`), ""},
+		}},
+	}
+
+	for _, test := range tests {
+		if err := testParseFuncConfig(test.Fn, cfg, test.Tests); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Lenient mode accepts the same values.
+	lenient := []ParseFuncTest{
+		{"host\tname", &Message{Hostname: "host\tname"}, nil, ""},
+		{"hóst", &Message{Hostname: "hóst"}, nil, ""},
+	}
+	if err := testParseFunc(parseHostname, lenient); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestParseAppname(t *testing.T) {
 	t.Parallel()
 
@@ -128,7 +350,7 @@ func TestParseAppname(t *testing.T) {
 		{"app", &Message{Appname: "app"}, nil, ""},
 		{"appname ", &Message{Appname: "appname"}, nil, " "},
 
-		{generateString("appname", maxAppNameLength+1), nil, newFormatError(1, "appname too long"), ""},
+		{generateString("appname", maxAppNameLength+1), nil, newFormatError(1, nil, "appname too long"), ""},
 	}
 
 	if err := testParseFunc(parseAppname, tests); err != nil {
@@ -136,6 +358,31 @@ func TestParseAppname(t *testing.T) {
 	}
 }
 
+func TestParseTag(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, io.EOF, ""},
+		{"-", &Message{}, nil, ""},
+		{"nginx", &Message{Appname: "nginx"}, nil, ""},
+		{"nginx:", &Message{Appname: "nginx"}, nil, ""},
+		{"nginx: msg", &Message{Appname: "nginx"}, nil, " msg"},
+		{"haproxy[123]: msg", &Message{Appname: "haproxy", ProcessID: "123"}, nil, " msg"},
+		{"myapp[123] msg", &Message{Appname: "myapp", ProcessID: "123"}, nil, " msg"},
+		{"[123]:", &Message{ProcessID: "123"}, nil, ""},
+		{"app[]:", &Message{Appname: "app"}, nil, ""},
+		{"app[1", &Message{Appname: "app[1"}, nil, ""},
+		{"app]:", &Message{Appname: "app]"}, nil, ""},
+
+		{generateString("appname", maxAppNameLength+1) + ":", nil, newFormatError(1, nil, "appname too long"), ""},
+		{"a[" + generateString("pid", maxProcessIDLength+1) + "]:", nil, newFormatError(3, nil, "processID too long"), ""},
+	}
+
+	if err := testParseFunc(parseTag, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestParseProcessID(t *testing.T) {
 	t.Parallel()
 
@@ -146,7 +393,7 @@ func TestParseProcessID(t *testing.T) {
 		{"procId", &Message{ProcessID: "procId"}, nil, ""},
 		{"processID ", &Message{ProcessID: "processID"}, nil, " "},
 
-		{generateString("processID", maxHostnameLength+1), nil, newFormatError(1, "processID too long"), ""},
+		{generateString("processID", maxHostnameLength+1), nil, newFormatError(1, nil, "processID too long"), ""},
 	}
 
 	if err := testParseFunc(parseProcessID, tests); err != nil {
@@ -164,7 +411,7 @@ func TestParseMessageID(t *testing.T) {
 		{"msgID", &Message{MessageID: "msgID"}, nil, ""},
 		{"messageID ", &Message{MessageID: "messageID"}, nil, " "},
 
-		{generateString("messageID", maxHostnameLength+1), nil, newFormatError(1, "messageID too long"), ""},
+		{generateString("messageID", maxHostnameLength+1), nil, newFormatError(1, nil, "messageID too long"), ""},
 	}
 
 	if err := testParseFunc(parseMessageID, tests); err != nil {
@@ -181,6 +428,27 @@ func TestParseData(t *testing.T) {
 		{`[dataID]`, &Message{Data: map[string]map[string]string{"dataID": {}}}, nil, ""},
 		{`[dataID dataName="dataValue"]`, &Message{Data: map[string]map[string]string{"dataID": {"dataName": "dataValue"}}}, nil, ""},
 		{`[dataID dataName="dataValue" dataName2="dataValue2"]`, &Message{Data: map[string]map[string]string{"dataID": {"dataName": "dataValue", "dataName2": "dataValue2"}}}, nil, ""},
+		{`[id a="\"q\""]`, &Message{Data: map[string]map[string]string{"id": {"a": `"q"`}}}, nil, ""},
+		{`[id a="a\\b"]`, &Message{Data: map[string]map[string]string{"id": {"a": `a\b`}}}, nil, ""},
+		{`[id a="[\]" b="c"]`, &Message{Data: map[string]map[string]string{"id": {"a": "[]", "b": "c"}}}, nil, ""},
+		{`[id a="a\\\\b"]`, &Message{Data: map[string]map[string]string{"id": {"a": `a\\b`}}}, nil, ""},
+		{`[id a="a\\\""]`, &Message{Data: map[string]map[string]string{"id": {"a": `a\"`}}}, nil, ""},
+		{`[id a="a\nb\x"]`, &Message{Data: map[string]map[string]string{"id": {"a": `a\nb\x`}}}, nil, ""},
+		{`[id path="C:\\logs\"x\"" b="c"]`, &Message{Data: map[string]map[string]string{"id": {"path": `C:\logs"x"`, "b": "c"}}}, nil, ""},
+
+		{`[dataID][dataID2 name="value"] msg`, &Message{Data: map[string]map[string]string{"dataID": {}, "dataID2": {"name": "value"}}}, nil, " msg"},
+		{`[dataID] msg`, &Message{Data: map[string]map[string]string{"dataID": {}}}, nil, " msg"},
+
+		{`[dataID`, nil, io.EOF, ""},
+		{`[dataID name`, nil, io.EOF, ""},
+		{`[dataID name="value" `, nil, io.EOF, ""},
+		{`[]`, nil, newFormatError(2, nil, "data-ID can't be empty"), ""},
+		{`[dataID=`, nil, newFormatError(8, nil, "expected byte ' ' or ']', but got '='"), ""},
+		{`[dataID name]`, nil, newFormatError(13, nil, "expected byte '=', but got ']'"), ""},
+		{`[dataID name="value" ]`, nil, newFormatError(22, nil, "expected byte '=', but got ']'"), ""},
+		{`[dataID name="value"][`, nil, io.EOF, ""},
+		{`[id a="abc`, nil, newFormatError(7, ErrTruncated, "param value not closed"), ""},
+		{`[id a="abc\"]`, nil, newFormatError(7, ErrTruncated, "param value not closed"), ""},
 	}
 
 	if err := testParseFunc(parseData, tests); err != nil {
@@ -188,6 +456,80 @@ func TestParseData(t *testing.T) {
 	}
 }
 
+func TestParseDataDuplicates(t *testing.T) {
+	t.Parallel()
+
+	const (
+		duplicateElements = `[a x="1"][a x="2"]`
+		duplicateParams   = `[a x="1" x="2"]`
+		mergedElements    = `[a x="1" y="2"][a x="3"]`
+	)
+
+	tests := []struct {
+		Policy DuplicatePolicy
+		Tests  []ParseFuncTest
+	}{
+		{LastWins, []ParseFuncTest{
+			{duplicateElements, &Message{Data: map[string]map[string]string{"a": {"x": "2"}}}, nil, ""},
+			{duplicateParams, &Message{Data: map[string]map[string]string{"a": {"x": "2"}}}, nil, ""},
+			{mergedElements, &Message{Data: map[string]map[string]string{"a": {"x": "3"}}}, nil, ""},
+		}},
+		{RejectDuplicates, []ParseFuncTest{
+			{duplicateElements, nil, newFormatError(11, ErrDuplicate, "duplicate data element a"), ""},
+			{duplicateParams, nil, newFormatError(10, ErrDuplicate, "duplicate data param x in data element a"), ""},
+		}},
+		{CollectDuplicates, []ParseFuncTest{
+			{duplicateElements, &Message{
+				Data:     map[string]map[string]string{"a": {"x": "2"}},
+				repeated: map[string]map[string][]string{"a": {"x": {"1", "2"}}},
+			}, nil, ""},
+			{duplicateParams, &Message{
+				Data:     map[string]map[string]string{"a": {"x": "2"}},
+				repeated: map[string]map[string][]string{"a": {"x": {"1", "2"}}},
+			}, nil, ""},
+			{mergedElements, &Message{
+				Data:     map[string]map[string]string{"a": {"x": "3", "y": "2"}},
+				repeated: map[string]map[string][]string{"a": {"x": {"1", "3"}}},
+			}, nil, ""},
+		}},
+	}
+
+	for _, test := range tests {
+		cfg := newConfig([]Option{WithDuplicates(test.Policy)})
+		if err := testParseFuncConfig(parseData, cfg, test.Tests); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Strict mode rejects duplicates by default.
+	cfg := newConfig([]Option{WithStrict()})
+	if err := testParseFuncConfig(parseData, cfg, tests[1].Tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseRawData(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, io.EOF, ""},
+		{"-", &Message{}, nil, ""},
+		{"- msg", &Message{}, nil, " msg"},
+		{`[dataID]`, &Message{RawData: `[dataID]`, dataPos: 1}, nil, ""},
+		{`[dataID] msg`, &Message{RawData: `[dataID]`, dataPos: 1}, nil, " msg"},
+		{`[dataID name="value"][dataID2 name="]"] msg`, &Message{RawData: `[dataID name="value"][dataID2 name="]"]`, dataPos: 1}, nil, " msg"},
+		{`[dataID name="\"]"]`, &Message{RawData: `[dataID name="\"]"]`, dataPos: 1}, nil, ""},
+
+		{`[dataID name="value"`, nil, io.EOF, ""},
+		{`[dataID name="]`, nil, io.EOF, ""},
+		{`dataID]`, nil, newFormatError(1, nil, "expected byte '[', but got 'd'"), ""},
+	}
+
+	if err := testParseFunc(parseRawData, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestParseMsg(t *testing.T) {
 	t.Parallel()
 
@@ -196,9 +538,12 @@ func TestParseMsg(t *testing.T) {
 		{"m", &Message{Message: "m"}, nil, ""},
 		{"msg", &Message{Message: "msg"}, nil, ""},
 		{" message ", &Message{Message: "message"}, nil, ""},
-		{string(bom) + " message ", &Message{Message: "message"}, nil, ""},
+		{string(bom) + " message ", &Message{Message: "message", UTF8: true}, nil, ""},
 		{" \t\t message \t\t ", &Message{Message: "message"}, nil, ""},
-		{" \t\t " + string(bom) + "message \t\t ", &Message{Message: "message"}, nil, ""},
+		{" \t\t " + string(bom) + "message \t\t ", &Message{Message: "message", UTF8: true}, nil, ""},
+		{string(bom) + "héllo wörld", &Message{Message: "héllo wörld", UTF8: true}, nil, ""},
+		{string(bom) + "a\xffb", &Message{Message: "a\xffb", UTF8: true}, nil, ""},
+		{"\x00\x01\xfe\xff", &Message{Message: "\x00\x01\xfe\xff"}, nil, ""},
 	}
 
 	if err := testParseFunc(parseMsg, tests); err != nil {
@@ -206,6 +551,40 @@ func TestParseMsg(t *testing.T) {
 	}
 }
 
+func TestParseMsgStrict(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{string(bom) + "héllo wörld", &Message{Message: "héllo wörld", UTF8: true}, nil, ""},
+		{"\x00\x01\xfe\xff", &Message{Message: "\x00\x01\xfe\xff"}, nil, ""},
+
+		{string(bom) + "a\xffb", nil, newFormatError(5, nil, "message contains invalid UTF-8"), ""},
+		{" " + string(bom) + " h\xc3", nil, newFormatError(7, nil, "message contains invalid UTF-8"), ""},
+	}
+
+	cfg := newConfig([]Option{WithStrict()})
+	if err := testParseFuncConfig(parseMsg, cfg, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseMsgRawMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, nil, ""},
+		{" message ", &Message{Message: " message "}, nil, ""},
+		{"\t\tmessage \r\n", &Message{Message: "\t\tmessage \r\n"}, nil, ""},
+		{string(bom) + " message ", &Message{Message: " message ", UTF8: true}, nil, ""},
+		{" " + string(bom) + "message", &Message{Message: " " + string(bom) + "message"}, nil, ""},
+	}
+
+	cfg := newConfig([]Option{WithRawMessage()})
+	if err := testParseFuncConfig(parseMsg, cfg, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestDiscard(t *testing.T) {
 	t.Parallel()
 
@@ -229,8 +608,8 @@ func TestDiscardByte(t *testing.T) {
 		{"a", &Message{}, nil, ""},
 		{"abc", &Message{}, nil, "bc"},
 
-		{"bc", &Message{}, newFormatError(1, "expected byte 'a', but got 'b'"), ""},
-		{"cba", &Message{}, newFormatError(1, "expected byte 'a', but got 'c'"), ""},
+		{"bc", &Message{}, newFormatError(1, nil, "expected byte 'a', but got 'b'"), ""},
+		{"cba", &Message{}, newFormatError(1, nil, "expected byte 'a', but got 'c'"), ""},
 	}
 
 	if err := testParseFunc(discardByte('a'), tests); err != nil {
@@ -238,6 +617,299 @@ func TestDiscardByte(t *testing.T) {
 	}
 }
 
+func TestLiteral(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, newFormatError(1, ErrTruncated, "unexpected end of message, missing 7 bytes of 'nginx: '"), ""},
+		{"ngi", &Message{}, newFormatError(3, ErrTruncated, "unexpected end of message, missing 4 bytes of 'nginx: '"), ""},
+		{"nginx: ", &Message{}, nil, ""},
+		{"nginx: abc", &Message{}, nil, "abc"},
+
+		{"nginx; abc", &Message{}, newFormatError(6, nil, "expected 'nginx: ', but got 'nginx; '"), ""},
+		{"ngx", &Message{}, newFormatError(3, nil, "expected 'nginx: ', but got 'ngx'"), ""},
+		{"ngin\x00: ", &Message{}, newFormatError(5, nil, "expected 'nginx: ', but got 'ngin\\x00: '"), ""},
+	}
+
+	if err := testParseFunc(literal("nginx: "), tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, io.EOF, ""},
+		{"value", &Message{Data: map[string]map[string]string{"id": {"name": "value"}}}, nil, ""},
+		{"value rest", &Message{Data: map[string]map[string]string{"id": {"name": "value"}}}, nil, " rest"},
+		{" rest", &Message{Data: map[string]map[string]string{"id": {"name": ""}}}, nil, " rest"},
+	}
+
+	if err := testParseFunc(capture("id", "name", ' '), tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCaptureThrough(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, io.EOF, ""},
+		{"value", &Message{}, io.EOF, ""},
+		{"value|", &Message{Data: map[string]map[string]string{"id": {"name": "value"}}}, nil, ""},
+		{"value|rest", &Message{Data: map[string]map[string]string{"id": {"name": "value"}}}, nil, "rest"},
+	}
+
+	if err := testParseFunc(captureThrough("id", "name", '|'), tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCaptureQouted(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, io.EOF, ""},
+		{`"value`, &Message{}, newFormatError(1, ErrTruncated, "param value not closed"), ""},
+		{`value"`, &Message{}, newFormatError(1, nil, "expected byte '\"', but got 'v'"), ""},
+		{`"value" rest`, &Message{Data: map[string]map[string]string{"id": {"name": "value"}}}, nil, " rest"},
+		{`"a \"b\" \] \\ \n"`, &Message{Data: map[string]map[string]string{"id": {"name": `a "b" ] \ \n`}}}, nil, ""},
+	}
+
+	if err := testParseFunc(captureQouted("id", "name"), tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCaptureFormat(t *testing.T) {
+	t.Parallel()
+
+	// Format: <34>web1 GET /index.html "Mozilla/5.0 \"X11\"" 200.
+	format := Format{
+		ParsePriority(),
+		ParseHostname(),
+		DiscardSpace(),
+		CaptureThrough("request", "method", ' '),
+		Capture("request", "path", ' '),
+		DiscardSpace(),
+		CaptureQuoted("request", "user_agent"),
+		DiscardSpace(),
+		Capture("response", "status", ' '),
+	}
+
+	input := []byte(`<34>web1 GET /index.html "Mozilla/5.0 \"X11\"" 200`)
+	expected := &Message{
+		Priority: 34,
+		Hostname: "web1",
+		Data: map[string]map[string]string{
+			"request": {
+				"method":     "GET",
+				"path":       "/index.html",
+				"user_agent": `Mozilla/5.0 "X11"`,
+			},
+			"response": {"status": "200"},
+		},
+	}
+
+	got, err := ParseMessage(input, format)
+	if err != nil {
+		t.Fatalf("Unexpected error ParseMessage(%q): %s", input, err)
+	} else if !messagesAreEqual(got, expected) {
+		t.Fatalf("Expected ParseMessage(%q) to return Message %#v, but got %#v",
+			input, expected, got)
+	}
+
+	input = []byte(`<34>web1 GET / "-" 200 200`)
+	_, err = NewParser(append(format, DiscardSpace(), Capture("response", "status", ' ')),
+		WithDuplicates(RejectDuplicates))(input)
+	if !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("Expected parsing %q with RejectDuplicates to return an ErrDuplicate error, but got %v",
+			input, err)
+	}
+}
+
+func TestRegex(t *testing.T) {
+	t.Parallel()
+
+	re := regexp.MustCompile(`(\w+)=(\d+)?`)
+	assign := func(match []string, msg *Message) error {
+		if match[2] == "" {
+			return errors.New("missing value")
+		}
+		msg.MessageID, msg.ProcessID = match[1], match[2]
+		return nil
+	}
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, newFormatError(1, nil, "expected a match of regexp (\\w+)=(\\d+)?"), ""},
+		{"id=123", &Message{MessageID: "id", ProcessID: "123"}, nil, ""},
+		{"id=123 rest", &Message{MessageID: "id", ProcessID: "123"}, nil, " rest"},
+		{"id=", &Message{}, errors.New("missing value"), ""},
+		{" id=123", &Message{}, newFormatError(1, nil, "expected a match of regexp (\\w+)=(\\d+)?"), ""},
+	}
+
+	if err := testParseFunc(regex(re, assign), tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegexData(t *testing.T) {
+	t.Parallel()
+
+	re := regexp.MustCompile(`user=(?P<user>\w+)( uid=(?P<uid>\d+))?(\.)`)
+	tests := []ParseFuncTest{
+		{"", &Message{}, newFormatError(1, nil, "expected a match of regexp user=(?P<user>\\w+)( uid=(?P<uid>\\d+))?(\\.)"), ""},
+		{"user=root.", &Message{Data: map[string]map[string]string{"id": {"user": "root"}}}, nil, ""},
+		{"user=root uid=0. rest", &Message{Data: map[string]map[string]string{"id": {"user": "root", "uid": "0"}}}, nil, " rest"},
+	}
+
+	if err := testParseFunc(regexData(re, "id"), tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAlt(t *testing.T) {
+	t.Parallel()
+
+	fn := alt(
+		format{literal("GET "), capture("request", "path", ' '), literal(" HTTP/1.1")},
+		format{literal("GET "), parseMsg},
+		format{literal("HEAD"), end},
+	)
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, newFormatError(1, ErrTruncated, "unexpected end of message, missing 4 bytes of 'HEAD'"), ""},
+		{"GET /index.html HTTP/1.1", &Message{Data: map[string]map[string]string{
+			"request": {"path": "/index.html"},
+		}}, nil, ""},
+		{"GET /index.html HTTP/1.1 rest", &Message{Data: map[string]map[string]string{
+			"request": {"path": "/index.html"},
+		}}, nil, " rest"},
+		// Rolls back the first candidate, after it parsed the path.
+		{"GET /index.html", &Message{Message: "/index.html"}, nil, ""},
+		{"GET /index.html HTTP/2", &Message{Message: "/index.html HTTP/2"}, nil, ""},
+		{"HEAD", &Message{}, nil, ""},
+		// Error of the last candidate.
+		{"HEAD /", &Message{}, newFormatError(5, nil, "expected end of message"), ""},
+		{"POST /", &Message{}, newFormatError(1, nil, "expected 'HEAD', but got 'POST'"), ""},
+	}
+
+	if err := testParseFunc(fn, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAltTruncated(t *testing.T) {
+	t.Parallel()
+
+	fn := alt(format{literal("ab"), discardByte('c')})
+	tests := []ParseFuncTest{
+		{"ab", &Message{}, newFormatError(2, ErrTruncated, "unexpected end of message"), ""},
+	}
+
+	if err := testParseFunc(fn, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnd(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, nil, ""},
+		{"a", &Message{}, newFormatError(1, nil, "expected end of message"), ""},
+	}
+
+	if err := testParseFunc(end, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMany(t *testing.T) {
+	t.Parallel()
+
+	// Parses elements like "a=1;".
+	element := func(buf *buffer, msg *Message) error {
+		if b, err := buf.Peek(1); err != nil {
+			return err
+		} else if b[0] < 'a' || b[0] > 'z' {
+			return newFormatError(buf.Pos(), nil, "expected a name")
+		}
+		return captureThrough("elements", string(buf.bytes[buf.position]), ';')(buf, msg)
+	}
+	fn := many(element, 2)
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, io.EOF, ""},
+		{"a=1;", &Message{}, io.EOF, ""},
+		{"a=1;b=2;", &Message{Data: map[string]map[string]string{
+			"elements": {"a": "a=1", "b": "b=2"},
+		}}, nil, ""},
+		{"a=1;b=2;c=3; rest", &Message{Data: map[string]map[string]string{
+			"elements": {"a": "a=1", "b": "b=2", "c": "c=3"},
+		}}, nil, " rest"},
+		{"a=1; rest", &Message{}, newFormatError(5, nil, "expected a name"), ""},
+		// Fails after consuming part of the third element.
+		{"a=1;b=2;c=3", &Message{}, io.EOF, ""},
+	}
+
+	if err := testParseFunc(fn, tests); err != nil {
+		t.Fatal(err)
+	}
+
+	// Succeeds without consuming anything.
+	nothing := func(*buffer, *Message) error { return nil }
+	if err := testParseFunc(many(nothing, 1), []ParseFuncTest{
+		{"", &Message{}, nil, ""},
+		{"a", &Message{}, nil, "a"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := testParseFunc(many(nothing, 2), []ParseFuncTest{
+		{"", &Message{}, newFormatError(1, nil, "expected at least 2 elements, but got 1"), ""},
+		{"a", &Message{}, newFormatError(1, nil, "expected at least 2 elements, but got 1"), ""},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOptionalIf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pred  func(*buffer) bool
+		tests []ParseFuncTest
+	}{
+		{nextIs(' '), []ParseFuncTest{
+			{"", &Message{}, nil, ""},
+			{"x", &Message{}, nil, "x"},
+			{" x", &Message{Message: "x"}, nil, ""},
+		}},
+		{remaining(2), []ParseFuncTest{
+			{"", &Message{}, nil, ""},
+			{" ", &Message{}, nil, " "},
+			{"x", &Message{}, nil, "x"},
+			{" x", &Message{Message: "x"}, nil, ""},
+			{"xy", &Message{}, newFormatError(1, nil, "expected byte ' ', but got 'x'"), ""},
+		}},
+		{nonBlank, []ParseFuncTest{
+			{"", &Message{}, nil, ""},
+			{" ", &Message{}, nil, " "},
+			{" \t ", &Message{}, nil, " \t "},
+			{"x", &Message{}, newFormatError(1, nil, "expected byte ' ', but got 'x'"), ""},
+			{" x", &Message{Message: "x"}, nil, ""},
+			{"  x ", &Message{Message: "x"}, nil, ""},
+		}},
+	}
+
+	for _, test := range tests {
+		if err := testParseFunc(optionalIf(test.pred, discardSpace, parseMsg), test.tests); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func TestDiscardUntil(t *testing.T) {
 	t.Parallel()
 
@@ -254,6 +926,40 @@ func TestDiscardUntil(t *testing.T) {
 	}
 }
 
+func TestSkipWhitespace(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, io.EOF, ""},
+		{" ", &Message{}, io.EOF, ""},
+		{"  ", &Message{}, nil, ""},
+		{" \tabc", &Message{}, nil, "abc"},
+		{"\t\t\t abc ", &Message{}, nil, "abc "},
+		{" abc", &Message{}, newFormatError(2, nil, "expected byte ' ', but got 'a'"), ""},
+		{"abc", &Message{}, newFormatError(1, nil, "expected byte ' ', but got 'a'"), ""},
+	}
+
+	if err := testParseFunc(skipWhitespace(2), tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscardTo(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, io.EOF, ""},
+		{"bcdef", &Message{}, io.EOF, ""},
+		{"a", &Message{}, nil, "a"},
+		{"bca", &Message{}, nil, "a"},
+		{"bcabc", &Message{}, nil, "abc"},
+	}
+
+	if err := testParseFunc(discardTo('a'), tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestDiscardSpace(t *testing.T) {
 	t.Parallel()
 
@@ -262,8 +968,8 @@ func TestDiscardSpace(t *testing.T) {
 		{" ", &Message{}, nil, ""},
 		{" abc", &Message{}, nil, "abc"},
 
-		{"bc", &Message{}, newFormatError(1, "expected byte ' ', but got 'b'"), ""},
-		{"cb ", &Message{}, newFormatError(1, "expected byte ' ', but got 'c'"), ""},
+		{"bc", &Message{}, newFormatError(1, nil, "expected byte ' ', but got 'b'"), ""},
+		{"cb ", &Message{}, newFormatError(1, nil, "expected byte ' ', but got 'c'"), ""},
 	}
 
 	if err := testParseFunc(discardSpace, tests); err != nil {
@@ -271,14 +977,233 @@ func TestDiscardSpace(t *testing.T) {
 	}
 }
 
-func TestParseNginxMsg(t *testing.T) {
+func TestParseNginxParamValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input            string
+		Expected         string
+		ExpectedError    error
+		ExpectedLeftover string
+	}{
+		{`""`, "", nil, ""},
+		{`"value" rest`, "value", nil, " rest"},
+		{`"a \x22b\x22"`, `a "b"`, nil, ""},
+		{`"\x5C\x5c"`, `\\`, nil, ""},
+		{`"a \"b\""`, `a "b"`, nil, ""},
+		{`"\\ \] \/"`, `\ ] /`, nil, ""},
+		{`"\b\f\n\r\t"`, "\b\f\n\r\t", nil, ""},
+		{`"é€"`, "é€", nil, ""},
+		{`"\\x22"`, `\x22`, nil, ""},
+
+		// Unknown or incomplete escapes are left intact.
+		{`"\a \x2 \xZZ \u12"`, `\a \x2 \xZZ \u12`, nil, ""},
+
+		{``, "", io.EOF, ""},
+		{`value"`, "", newFormatError(1, nil, "expected byte '\"', but got 'v'"), ""},
+		{`"value`, "", newFormatError(1, ErrTruncated, "param value not closed"), ""},
+		{`"value\"`, "", newFormatError(1, ErrTruncated, "param value not closed"), ""},
+	}
+
+	for _, test := range tests {
+		buf := newBuffer([]byte(test.Input))
+		got, err := parseNginxParamValue(buf)
+		if test.ExpectedError != nil {
+			if err == nil || err.Error() != test.ExpectedError.Error() {
+				t.Fatalf("Expected parseNginxParamValue(%q) to return error %q, but got %v",
+					test.Input, test.ExpectedError, err)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("Unexpected error parseNginxParamValue(%q): %s", test.Input, err)
+		}
+
+		if got != test.Expected {
+			t.Fatalf("Expected parseNginxParamValue(%q) to return %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+		if leftover := string(buf.ReadAll()); leftover != test.ExpectedLeftover {
+			t.Fatalf("Expected leftover bytes to be %q, but got %q",
+				test.ExpectedLeftover, leftover)
+		}
+	}
+}
+
+func TestParseNginxTimestamp(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"2015/10/13 01:31:40", &Message{Timestamp: time.Date(2015, 10, 13, 1, 31, 40, 0, time.Local)}, nil, ""},
+		{"2015/10/13 01:31:40 [error]", &Message{Timestamp: time.Date(2015, 10, 13, 1, 31, 40, 0, time.Local)}, nil, " [error]"},
+
+		// Not parsed, skipped up to the level.
+		{"2015/10/13 1:31:40 [error]", &Message{}, nil, " [error]"},
+		{"13/10/2015 [error]", &Message{}, nil, " [error]"},
+		{" [error]", &Message{}, nil, " [error]"},
+
+		{"", &Message{}, io.EOF, ""},
+		{"2015/10/13", &Message{}, io.EOF, ""},
+	}
+
+	if err := testParseFunc(parseNginxTimestamp, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseBSDTimestamp(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"2015-10-13T12:31:40Z", &Message{Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC)}, nil, ""},
+		{"2015-10-13T12:31:40.5+02:00 host", &Message{Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 5e8, time.FixedZone("", 2*60*60))}, nil, " host"},
+		{"Oct 13 12:31:40 host", &Message{Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local)}, nil, " host"},
+		{"Oct  5 12:31:40", &Message{Timestamp: inferredDate(10, 5, 12, 31, 40, time.Local)}, nil, ""},
+
+		{"", &Message{}, newFormatError(1, ErrBadTimestamp, "timestamp is not following an accepted format"), ""},
+		{"2015-10-13 host", &Message{}, newFormatError(1, ErrBadTimestamp, "timestamp is not following an accepted format"), "2015-10-13 host"},
+		{"Oct 13 host", &Message{}, newFormatError(1, ErrBadTimestamp, "timestamp is not following an accepted format"), "Oct 13 host"},
+	}
+
+	if err := testParseFunc(parseBSDTimestamp, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseOptionalHostname(t *testing.T) {
 	t.Parallel()
 
 	tests := []ParseFuncTest{
+		{"hostname app: msg", &Message{Hostname: "hostname"}, nil, "app: msg"},
+		{"hostname app msg", &Message{Hostname: "hostname"}, nil, "app msg"},
+		{"::1 app: msg", &Message{Hostname: "::1"}, nil, "app: msg"},
+		{"app: msg", &Message{}, nil, "app: msg"},
+		{"app[123]: msg", &Message{}, nil, "app[123]: msg"},
+		{"app:", &Message{}, nil, "app:"},
+
 		{"", &Message{}, io.EOF, ""},
-		{"msg", &Message{}, io.EOF, ""},
-		{"msg,", &Message{Message: "msg"}, nil, ""},
-		{" message ,", &Message{Message: "message"}, nil, ""},
+		{"hostname", &Message{Hostname: "hostname"}, io.EOF, ""},
+	}
+
+	if err := testParseFunc(parseOptionalHostname, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseNginxLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"emerg]", &Message{Priority: 0, Severity: Emergency}, nil, ""},
+		{"alert]", &Message{Priority: 1, Severity: Alert}, nil, ""},
+		{"crit] msg", &Message{Priority: 2, Severity: Critical}, nil, " msg"},
+		{"error]", &Message{Priority: 3, Severity: Error}, nil, ""},
+		{"warn]", &Message{Priority: 4, Severity: Warning}, nil, ""},
+		{"notice]", &Message{Priority: 5, Severity: Notice}, nil, ""},
+		{"info]", &Message{Priority: 6, Severity: Informational}, nil, ""},
+		{"debug]", &Message{Priority: 7, Severity: Debug}, nil, ""},
+		{"Error]", &Message{Priority: 3, Severity: Error}, nil, ""},
+		{"Informational]", &Message{Priority: 6, Severity: Informational}, nil, ""},
+
+		{"", &Message{}, io.EOF, ""},
+		{"error", &Message{}, io.EOF, ""},
+		{"]", &Message{}, newFormatError(1, nil, "unknown Nginx level ''"), ""},
+		{"fatal]", &Message{}, newFormatError(1, nil, "unknown Nginx level 'fatal'"), ""},
+		{"err or]", &Message{}, newFormatError(1, nil, "unknown Nginx level 'err or'"), ""},
+	}
+
+	if err := testParseFunc(parseNginxLevel, tests); err != nil {
+		t.Fatal(err)
+	}
+
+	// The level is still checked, but the severity isn't changed.
+	tests = []ParseFuncTest{
+		{"error]", &Message{}, nil, ""},
+		{"fatal]", &Message{}, newFormatError(1, nil, "unknown Nginx level 'fatal'"), ""},
+	}
+
+	cfg := newConfig([]Option{WithPrioritySeverity()})
+	if err := testParseFuncConfig(parseNginxLevel, cfg, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseNginxProcessID(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, nil, ""},
+		{"1#1:", &Message{ProcessID: "1#1"}, nil, ""},
+		{"1187#1187: *46 msg", &Message{ProcessID: "1187#1187"}, nil, "*46 msg"},
+		{"12#3:msg", &Message{ProcessID: "12#3"}, nil, "msg"},
+
+		{"msg", &Message{}, nil, "msg"},
+		{"1187 msg", &Message{}, nil, "1187 msg"},
+		{"1187#", &Message{}, nil, "1187#"},
+		{"1187#1187", &Message{}, nil, "1187#1187"},
+		{"1187#1187 msg", &Message{}, nil, "1187#1187 msg"},
+		{"#1187: msg", &Message{}, nil, "#1187: msg"},
+		{"1187#: msg", &Message{}, nil, "1187#: msg"},
+	}
+
+	if err := testParseFunc(parseNginxProcessID, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseNginxConnection(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, nil, ""},
+		{"*1", &Message{Data: map[string]map[string]string{"data": {"connection": "1"}}}, nil, ""},
+		{"*46 msg", &Message{Data: map[string]map[string]string{"data": {"connection": "46"}}}, nil, "msg"},
+
+		{"msg", &Message{}, nil, "msg"},
+		{"*", &Message{}, nil, "*"},
+		{"* msg", &Message{}, nil, "* msg"},
+		{"*46msg", &Message{}, nil, "*46msg"},
+		{"46 msg", &Message{}, nil, "46 msg"},
+	}
+
+	if err := testParseFunc(parseNginxConnection, tests); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNginxData(t *testing.T) {
+	t.Parallel()
+
+	msg := &Message{Data: map[string]map[string]string{"other": {"name": "value"}}}
+	nginxData(msg)["connection"] = "46"
+	expected := map[string]map[string]string{
+		"other": {"name": "value"},
+		"data":  {"connection": "46"},
+	}
+	if !reflect.DeepEqual(msg.Data, expected) {
+		t.Fatalf("Expected nginxData to keep the other data elements %v, but got %v",
+			expected, msg.Data)
+	}
+}
+
+func TestParseNginxMsg(t *testing.T) {
+	t.Parallel()
+
+	tests := []ParseFuncTest{
+		{"", &Message{}, nil, ""},
+		{"msg", &Message{Message: "msg"}, nil, ""},
+		{" msg ", &Message{Message: "msg"}, nil, ""},
+		{"msg,", &Message{Message: "msg,"}, nil, ""},
+		{"msg, a: a", &Message{Message: "msg"}, nil, " a: a"},
+		{" message , a: a", &Message{Message: "message"}, nil, " a: a"},
+		{"a, b, c: c", &Message{Message: "a, b"}, nil, " c: c"},
+		{"a (1: b), c_d : c", &Message{Message: "a (1: b)"}, nil, " c_d : c"},
+		{"a, b:c, d: d", &Message{Message: "a, b:c"}, nil, " d: d"},
+		{`a "b, c: d", e: e`, &Message{Message: `a "b, c: d"`}, nil, " e: e"},
+		{`a "b\", c: d", e: e`, &Message{Message: `a "b\", c: d"`}, nil, " e: e"},
+		{`a "b, "c": c`, &Message{Message: `a "b`}, nil, ` "c": c`},
+		{`a, "b c": c`, &Message{Message: "a"}, nil, ` "b c": c`},
+		{`a, "": c`, &Message{Message: `a, "": c`}, nil, ""},
+		{"a, b c: c", &Message{Message: "a, b c: c"}, nil, ""},
 	}
 
 	if err := testParseFunc(parseNginxMsg, tests); err != nil {
@@ -298,9 +1223,19 @@ func TestParseNginxData(t *testing.T) {
 		{`"a b": "a b", abc: "a b c" `, &Message{Data: map[string]map[string]string{"data": {"a b": "a b", "abc": "a b c"}}}, nil, ""},
 		{`"a:b": "c,b"`, &Message{Data: map[string]map[string]string{"data": {"a:b": "c,b"}}}, nil, ""},
 		{`a: a, d: "\"d\""`, &Message{Data: map[string]map[string]string{"data": {"a": "a", "d": `"d"`}}}, nil, ""},
+		{`a: "", b: ""`, &Message{Data: map[string]map[string]string{"data": {"a": "", "b": ""}}}, nil, ""},
+		{`a: "a\\b", b: a\"b`, &Message{Data: map[string]map[string]string{"data": {"a": `a\\b`, "b": `a"b`}}}, nil, ""},
+		{`a: "a, b" , b: "b" `, &Message{Data: map[string]map[string]string{"data": {"a": "a, b", "b": "b"}}}, nil, ""},
+
+		{`upstream: "http://a:8080/b?c=1,d:2", e: e`, &Message{Data: map[string]map[string]string{"data": {"upstream": "http://a:8080/b?c=1,d:2", "e": "e"}}}, nil, ""},
+		{`upstream: http://a:8080/b?c=1,d:2, e: e`, &Message{Data: map[string]map[string]string{"data": {"upstream": "http://a:8080/b?c=1,d:2", "e": "e"}}}, nil, ""},
+		{`upstream: http://a/b?c=1, d=2`, &Message{Data: map[string]map[string]string{"data": {"upstream": "http://a/b?c=1, d=2"}}}, nil, ""},
+		{`upstream:`, &Message{Data: map[string]map[string]string{"data": {"upstream": ""}}}, nil, ""},
 
 		{"", &Message{}, io.EOF, ""},
 		{"a: a, b", &Message{}, io.EOF, ""},
+		{`a: "a" b`, &Message{}, newFormatError(8, nil, "expected byte ',', but got 'b'"), ""},
+		{`"a" b: a`, &Message{}, newFormatError(5, nil, "expected byte ':', but got 'b'"), ""},
 	}
 
 	if err := testParseFunc(parseNginxData, tests); err != nil {
@@ -309,8 +1244,14 @@ func TestParseNginxData(t *testing.T) {
 }
 
 func testParseFunc(fn parseFunc, tests []ParseFuncTest) error {
+	return testParseFuncConfig(fn, nil, tests)
+}
+
+// testParseFuncConfig is testParseFunc with the given configuration.
+func testParseFuncConfig(fn parseFunc, cfg *config, tests []ParseFuncTest) error {
 	for _, test := range tests {
 		buf := newBuffer([]byte(test.Input))
+		buf.cfg = cfg
 		gotMsg := Message{}
 		gotErr := fn(buf, &gotMsg)
 
@@ -346,7 +1287,5 @@ func testParseFunc(fn parseFunc, tests []ParseFuncTest) error {
 }
 
 func getFuncName(fn parseFunc) string {
-	prt := reflect.ValueOf(fn).Pointer()
-	fullName := runtime.FuncForPC(prt).Name()
-	return strings.TrimPrefix(fullName, "github.com/Thomasdezeeuw/syslog.")
+	return strings.TrimPrefix(funcName(fn), "syslog.")
 }
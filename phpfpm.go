@@ -0,0 +1,168 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+// Layout of the timestamp of php-fpm logs, the milliseconds are optional when
+// parsing.
+const phpfpmTimestampLayout = "02-Jan-2006 15:04:05"
+
+// Severities of the php-fpm levels.
+var phpfpmLevels = map[string]Severity{
+	"DEBUG":   Debug,
+	"NOTICE":  Notice,
+	"WARNING": Warning,
+	"ERROR":   Error,
+	"ALERT":   Alert,
+}
+
+// ParsePHPFPM parses a php-fpm log line, e.g. `[13-Oct-2015 12:31:40]
+// WARNING: [pool www] child 1187 said into stderr: "PHP message: ..."`. The
+// optional timestamp replaces Timestamp and the optional level, either
+// "WARNING:" or, when logging to syslog, "[WARNING]", replaces the severity,
+// like parseNginxLevel. The optional pool is stored in Data["phpfpm"]["pool"]
+// and the pid of the child, e.g. "child 1187" or "pid 1187" in slowlog
+// entries, as ProcessID. The remainder is stored as Message, for stderr output
+// of a child without the outer qoutes.
+//
+// Lines without timestamp, level and pool, e.g. the lines of a stack trace in
+// the slowlog, are stored as Message with Data["phpfpm"]["continuation"] set
+// to "true", see PHPFPMReassembler.
+func parsePHPFPM(buf *buffer, msg *Message) error {
+	b := buf.bytes[buf.position:buf.length]
+	buf.position = buf.length
+
+	data := map[string]string{}
+	i := 0
+	if block, end, ok := phpfpmBlock(b, i); ok {
+		timestamp, err := time.ParseInLocation(phpfpmTimestampLayout, string(block), buf.cfg.Location())
+		if err == nil {
+			msg.Timestamp = timestamp
+			i = skipSpaces(b, end)
+		}
+	}
+
+	if level, end, ok := phpfpmLevel(b, i); ok {
+		if !buf.cfg.PrioritySeverity() {
+			msg.Severity = phpfpmLevels[level]
+			if msg.Priority.IsValid() {
+				msg.Priority = CalculatePriority(msg.Facility, msg.Severity)
+			}
+		}
+		i = skipSpaces(b, end)
+	}
+
+	if block, end, ok := phpfpmBlock(b, i); ok && bytes.HasPrefix(block, []byte("pool ")) {
+		data["pool"] = string(block[len("pool "):])
+		i = skipSpaces(b, end)
+	}
+
+	if i == 0 {
+		msg.Message = string(b)
+		msg.Data = map[string]map[string]string{"phpfpm": {"continuation": "true"}}
+		return nil
+	}
+
+	message := string(bytes.TrimSpace(b[i:]))
+	for _, prefix := range [...]string{"child ", "pid "} {
+		if !strings.HasPrefix(message, prefix) {
+			continue
+		}
+		end := len(prefix) + skipDigits([]byte(message[len(prefix):]), 0)
+		if end != len(prefix) && end-len(prefix) <= maxProcessIDLength {
+			msg.ProcessID = message[len(prefix):end]
+		}
+		break
+	}
+
+	// Output of a child, e.g. `child 1187 said into stderr: "PHP message:
+	// ..."`, possibly followed by ", pipe is closed".
+	if _, output, ok := strings.Cut(message, ` said into stderr: "`); ok {
+		if end := strings.LastIndexByte(output, '"'); end != -1 {
+			output = output[:end]
+		}
+		message = output
+	}
+	msg.Message = message
+	if len(data) != 0 {
+		msg.Data = map[string]map[string]string{"phpfpm": data}
+	}
+	return nil
+}
+
+// phpfpmBlock returns the content of the bracketed block starting at index i
+// of b and the index after the block.
+func phpfpmBlock(b []byte, i int) ([]byte, int, bool) {
+	if i >= len(b) || b[i] != '[' {
+		return nil, i, false
+	}
+	end := bytes.IndexByte(b[i:], ']')
+	if end == -1 {
+		return nil, i, false
+	}
+	return b[i+1 : i+end], i + end + 1, true
+}
+
+// phpfpmLevel returns the level at index i of b, e.g. "WARNING:" or
+// "[WARNING]", and the index after it.
+func phpfpmLevel(b []byte, i int) (string, int, bool) {
+	if block, end, ok := phpfpmBlock(b, i); ok {
+		_, ok := phpfpmLevels[string(block)]
+		return string(block), end, ok
+	}
+
+	end := bytes.IndexByte(b[i:], ':')
+	if end == -1 {
+		return "", i, false
+	}
+	level := string(b[i : i+end])
+	_, ok := phpfpmLevels[level]
+	return level, i + end + 1, ok
+}
+
+// PHPFPMReassembler merges the continuation lines of php-fpm logs, e.g. the
+// stack trace of a slowlog entry, into the message that precedes them. It
+// expects the messages in the order they're logged, parsed with the PHPFPM
+// format. The Message of continuation lines is appended to the Message of the
+// preceding line, separated by a newline.
+//
+// The zero value is ready to use. It's not safe for concurrent use.
+type PHPFPMReassembler struct {
+	pending *Message
+}
+
+// Add adds the message and returns the message that is complete, if any.
+// Because a message can always be continued it's only complete once the next
+// message is added, see Flush. Continuation lines without a preceding message
+// are kept as a message of their own.
+func (r *PHPFPMReassembler) Add(msg *Message) []*Message {
+	if r.pending != nil && msg.Data["phpfpm"]["continuation"] == "true" {
+		r.pending.Message += "\n" + msg.Message
+		return nil
+	}
+
+	var done []*Message
+	if r.pending != nil {
+		done = []*Message{r.pending}
+	}
+	r.pending = msg
+	return done
+}
+
+// Flush returns the message that is waiting for continuation lines, if any,
+// and resets the reassembler.
+func (r *PHPFPMReassembler) Flush() []*Message {
+	if r.pending == nil {
+		return nil
+	}
+	msg := r.pending
+	r.pending = nil
+	return []*Message{msg}
+}
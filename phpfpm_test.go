@@ -0,0 +1,171 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessagePHPFPM(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputPHPFPM),
+			&Message{
+				Priority:  CalculatePriority(System, Warning),
+				Facility:  System,
+				Severity:  Warning,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+				Data:      map[string]map[string]string{"phpfpm": {"continuation": "true"}},
+			},
+		},
+		{
+			// Stderr output of a child.
+			string(regularInputPHPFPM),
+			&Message{
+				Priority:  CalculatePriority(System, Warning),
+				Facility:  System,
+				Severity:  Warning,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "php-fpm",
+				ProcessID: "1187",
+				Data:      map[string]map[string]string{"phpfpm": {"pool": "www"}},
+				Message:   "PHP message: PHP Warning:  Undefined variable $name in /var/www/index.php on line 3",
+			},
+		},
+		{
+			// Without a pool.
+			`<30>Oct 13 12:31:40 hostname php-fpm[1186]: [13-Oct-2015 12:31:40] NOTICE: fpm is running, pid 1186`,
+			&Message{
+				Priority:  CalculatePriority(System, Notice),
+				Facility:  System,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "php-fpm",
+				ProcessID: "1186",
+				Message:   "fpm is running, pid 1186",
+			},
+		},
+		{
+			// Child exiting, with milliseconds.
+			`<30>Oct 13 12:31:40 hostname php-fpm: [13-Oct-2015 12:31:40.250] ERROR: [pool api] child 2048 exited on signal 11 (SIGSEGV) after 12.5 seconds from start`,
+			&Message{
+				Priority:  CalculatePriority(System, Error),
+				Facility:  System,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 250000000, time.Local),
+				Hostname:  "hostname",
+				Appname:   "php-fpm",
+				ProcessID: "2048",
+				Data:      map[string]map[string]string{"phpfpm": {"pool": "api"}},
+				Message:   "child 2048 exited on signal 11 (SIGSEGV) after 12.5 seconds from start",
+			},
+		},
+		{
+			// Logged to syslog by php-fpm itself, the stderr pipe closed.
+			`<28>Oct 13 12:31:40 hostname php-fpm[1186]: [WARNING] [pool www] child 1187 said into stderr: "Exiting", pipe is closed`,
+			&Message{
+				Priority:  CalculatePriority(System, Warning),
+				Facility:  System,
+				Severity:  Warning,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "php-fpm",
+				ProcessID: "1187",
+				Data:      map[string]map[string]string{"phpfpm": {"pool": "www"}},
+				Message:   "Exiting",
+			},
+		},
+		{
+			// Slowlog entry, without a level.
+			`<28>Oct 13 12:31:40 hostname php-fpm: [13-Oct-2015 12:31:40]  [pool www] pid 1187`,
+			&Message{
+				Priority:  CalculatePriority(System, Warning),
+				Facility:  System,
+				Severity:  Warning,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "php-fpm",
+				ProcessID: "1187",
+				Data:      map[string]map[string]string{"phpfpm": {"pool": "www"}},
+				Message:   "pid 1187",
+			},
+		},
+		{
+			// Continuation line of the slowlog.
+			`<28>Oct 13 12:31:40 hostname php-fpm: [0x00007f2b3c013e40] sleep() /var/www/index.php:3`,
+			&Message{
+				Priority:  CalculatePriority(System, Warning),
+				Facility:  System,
+				Severity:  Warning,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "php-fpm",
+				Data:      map[string]map[string]string{"phpfpm": {"continuation": "true"}},
+				Message:   "[0x00007f2b3c013e40] sleep() /var/www/index.php:3",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), PHPFPM)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, PHPFPM) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestPHPFPMReassembler(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		`<28>Oct 13 12:31:40 h php-fpm: script_filename = /var/www/orphan.php`,
+		`<28>Oct 13 12:31:40 h php-fpm: [13-Oct-2015 12:31:40]  [pool www] pid 1187`,
+		`<28>Oct 13 12:31:40 h php-fpm: script_filename = /var/www/index.php`,
+		`<28>Oct 13 12:31:40 h php-fpm: [0x00007f2b3c013e40] sleep() /var/www/index.php:3`,
+		`<28>Oct 13 12:31:40 h php-fpm: [13-Oct-2015 12:31:41] NOTICE: ready to handle connections`,
+	}
+	expected := []string{
+		"script_filename = /var/www/orphan.php",
+		"pid 1187\nscript_filename = /var/www/index.php\n[0x00007f2b3c013e40] sleep() /var/www/index.php:3",
+		"ready to handle connections",
+	}
+
+	var r Reassembler = &PHPFPMReassembler{}
+	var got []*Message
+	for _, input := range inputs {
+		msg, err := ParseMessage([]byte(input), PHPFPM)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", input, err)
+		}
+		got = append(got, r.Add(msg)...)
+	}
+	got = append(got, r.Flush()...)
+
+	if len(got) != len(expected) {
+		t.Fatalf("Expected PHPFPMReassembler to return %d messages, but got %d",
+			len(expected), len(got))
+	}
+	for i, msg := range got {
+		if msg.Message != expected[i] {
+			t.Fatalf("Expected message %d to be %q, but got %q", i, expected[i], msg.Message)
+		}
+	}
+	if msgs := r.Flush(); len(msgs) != 0 {
+		t.Fatalf("Expected Flush to return no messages after flushing, but got %d", len(msgs))
+	}
+}
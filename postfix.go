@@ -0,0 +1,119 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import "strings"
+
+// ParsePostfix parses the message of a Postfix log line, e.g.
+// "3F2A41C0A5: to=<a@b>, relay=mx[1.2.3.4]:25, status=sent (250 OK)". The
+// sub-program of the appname, e.g. "smtpd" in "postfix/smtpd", is stored in
+// Data["postfix"]["process"]. The optional queue ID is stored as MessageID. If
+// the remainder is a comma-separated list of key=value attributes they're
+// stored in Data["postfix"], otherwise the remainder is stored as Message.
+func parsePostfix(buf *buffer, msg *Message) error {
+	b := buf.bytes[buf.position:buf.length]
+	buf.position = buf.length
+
+	data := map[string]string{}
+	if appname, process, ok := strings.Cut(msg.Appname, "/"); ok && process != "" {
+		msg.Appname = appname
+		data["process"] = process
+	}
+
+	if i := postfixQueueIDLength(b); i != 0 {
+		msg.MessageID = string(b[:i])
+		b = b[i+2:]
+	}
+
+	if !parsePostfixAttributes(b, data) {
+		msg.Message = string(b)
+	}
+	if len(data) != 0 {
+		msg.Data = map[string]map[string]string{"postfix": data}
+	}
+	return nil
+}
+
+// PostfixQueueIDLength returns the length of the queue ID at the start of b,
+// followed by ": ", or 0 if b doesn't start with a queue ID. Both short (hex)
+// and long queue IDs are alphanumeric, to not mistake words like "warning" for
+// a queue ID it must contain a digit or an upper case letter.
+func postfixQueueIDLength(b []byte) int {
+	var i int
+	upper := false
+	for ; i < len(b) && i <= maxMessageIDLength; i++ {
+		c := b[i]
+		if c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' {
+			upper = true
+		} else if c < 'a' || c > 'z' {
+			break
+		}
+	}
+
+	if !upper || i > maxMessageIDLength || i+1 >= len(b) || b[i] != ':' || b[i+1] != spaceByte {
+		return 0
+	}
+	return i
+}
+
+// ParsePostfixAttributes parses the comma-separated key=value attributes in b
+// into data, e.g. "to=<a@b>, status=sent (250 2.0.0 OK, id=1)". Values end
+// at a comma followed by the next key, commas inside parentheses don't end a
+// value, which keeps the details of the status attached to it. It returns
+// false, without modifying data, if b isn't a list of attributes.
+func parsePostfixAttributes(b []byte, data map[string]string) bool {
+	if postfixKeyLength(b) == 0 {
+		return false
+	}
+
+	attributes := make(map[string]string, 8)
+	for len(b) != 0 {
+		n := postfixKeyLength(b)
+		key := string(b[:n])
+		b = b[n+1:]
+
+		end, depth := len(b), 0
+		for i := 0; i < len(b); i++ {
+			switch b[i] {
+			case '(':
+				depth++
+			case ')':
+				if depth > 0 {
+					depth--
+				}
+			case ',':
+				if depth == 0 && i+1 < len(b) && b[i+1] == spaceByte && postfixKeyLength(b[i+2:]) != 0 {
+					end = i
+				}
+			}
+			if end != len(b) {
+				break
+			}
+		}
+
+		attributes[key] = string(b[:end])
+		if b = b[end:]; len(b) != 0 {
+			b = b[2:] // ", ".
+		}
+	}
+
+	for key, value := range attributes {
+		data[key] = value
+	}
+	return true
+}
+
+// PostfixKeyLength returns the length of the attribute key at the start of b,
+// which must be followed by '=', or 0 if b doesn't start with a key.
+func postfixKeyLength(b []byte) int {
+	for i := 0; i < len(b); i++ {
+		if b[i] == equalByte {
+			return i
+		} else if !isNginxKeyByte(b[i]) && b[i] != '-' {
+			return 0
+		}
+	}
+	return 0
+}
@@ -0,0 +1,215 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessagePostfix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputPostfix),
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+			},
+		},
+		{
+			string(regularInputPostfix),
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postfix",
+				ProcessID: "1234",
+				MessageID: "3F2A41C0A5",
+				Data: map[string]map[string]string{
+					"postfix": {
+						"process": "smtp",
+						"to":      "<a@example.com>",
+						"relay":   "mx.example.com[1.2.3.4]:25",
+						"delay":   "0.5",
+						"dsn":     "2.0.0",
+						"status":  "sent (250 2.0.0 OK, queued as 4B1C2D)",
+					},
+				},
+			},
+		},
+		{
+			`<22>Oct 13 12:31:40 hostname postfix/smtpd[1234]: ABC123DEF: client=relay.example.com[1.2.3.4]`,
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postfix",
+				ProcessID: "1234",
+				MessageID: "ABC123DEF",
+				Data: map[string]map[string]string{
+					"postfix": {
+						"process": "smtpd",
+						"client":  "relay.example.com[1.2.3.4]",
+					},
+				},
+			},
+		},
+		{
+			// Trailing detail and a long queue ID.
+			`<22>Oct 13 12:31:40 hostname postfix/qmgr[1235]: 4VqM1Q0Lsmz9sVN: from=<a@example.com>, size=1234, nrcpt=1 (queue active)`,
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postfix",
+				ProcessID: "1235",
+				MessageID: "4VqM1Q0Lsmz9sVN",
+				Data: map[string]map[string]string{
+					"postfix": {
+						"process": "qmgr",
+						"from":    "<a@example.com>",
+						"size":    "1234",
+						"nrcpt":   "1 (queue active)",
+					},
+				},
+			},
+		},
+		{
+			// No attributes.
+			`<22>Oct 13 12:31:40 hostname postfix/qmgr[1235]: 3F2A41C0A5: removed`,
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postfix",
+				ProcessID: "1235",
+				MessageID: "3F2A41C0A5",
+				Data:      map[string]map[string]string{"postfix": {"process": "qmgr"}},
+				Message:   "removed",
+			},
+		},
+		{
+			// No queue ID.
+			`<22>Oct 13 12:31:40 hostname postfix/smtpd[1234]: connect from unknown[1.2.3.4]`,
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postfix",
+				ProcessID: "1234",
+				Data:      map[string]map[string]string{"postfix": {"process": "smtpd"}},
+				Message:   "connect from unknown[1.2.3.4]",
+			},
+		},
+		{
+			// Not a queue ID.
+			`<20>Oct 13 12:31:40 hostname postfix/smtpd[1234]: warning: hostname example.com does not resolve to address 1.2.3.4`,
+			&Message{
+				Priority:  CalculatePriority(Mail, Warning),
+				Facility:  Mail,
+				Severity:  Warning,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postfix",
+				ProcessID: "1234",
+				Data:      map[string]map[string]string{"postfix": {"process": "smtpd"}},
+				Message:   "warning: hostname example.com does not resolve to address 1.2.3.4",
+			},
+		},
+		{
+			`<22>Oct 13 12:31:40 hostname postfix/smtpd[1234]: NOQUEUE: reject: RCPT from unknown[1.2.3.4]: 554 5.7.1 Relay access denied; from=<a@b> to=<c@d>`,
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postfix",
+				ProcessID: "1234",
+				MessageID: "NOQUEUE",
+				Data:      map[string]map[string]string{"postfix": {"process": "smtpd"}},
+				Message:   "reject: RCPT from unknown[1.2.3.4]: 554 5.7.1 Relay access denied; from=<a@b> to=<c@d>",
+			},
+		},
+		{
+			// Multiple instance tag and a value with a comma.
+			`<22>Oct 13 12:31:40 hostname postfix-out/cleanup[1236]: 3F2A41C0A5: message-id=<a,b@example.com>, resent-message-id=<c@example.com>`,
+			&Message{
+				Priority:  CalculatePriority(Mail, Informational),
+				Facility:  Mail,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postfix-out",
+				ProcessID: "1236",
+				MessageID: "3F2A41C0A5",
+				Data: map[string]map[string]string{
+					"postfix": {
+						"process":           "cleanup",
+						"message-id":        "<a,b@example.com>",
+						"resent-message-id": "<c@example.com>",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Postfix)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Postfix) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestPostfixQueueIDLength(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected int
+	}{
+		{"", 0},
+		{"ABC123: ", 6},
+		{"NOQUEUE: x", 7},
+		{"4VqM1Q0Lsmz9sVN: x", 15},
+		{"warning: x", 0},
+		{"ABC123:x", 0},
+		{"ABC123:", 0},
+		{"ABC-123: x", 0},
+		{": x", 0},
+		{generateString("A", maxMessageIDLength) + ": x", maxMessageIDLength},
+		{generateString("A", maxMessageIDLength+1) + ": x", 0},
+	}
+
+	for _, test := range tests {
+		if got := postfixQueueIDLength([]byte(test.Input)); got != test.Expected {
+			t.Fatalf("Expected postfixQueueIDLength(%q) to return %d, but got %d",
+				test.Input, test.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Severities of the PostgreSQL levels.
+var postgresLevels = map[string]Severity{
+	"DEBUG1":  Debug,
+	"DEBUG2":  Debug,
+	"DEBUG3":  Debug,
+	"DEBUG4":  Debug,
+	"DEBUG5":  Debug,
+	"INFO":    Informational,
+	"LOG":     Informational,
+	"NOTICE":  Notice,
+	"WARNING": Warning,
+	"ERROR":   Error,
+	"FATAL":   Critical,
+	"PANIC":   Alert,
+}
+
+// isPostgresDetail checks if level is the level of an additional line of a
+// message, e.g. DETAIL, which doesn't change the severity.
+func isPostgresDetail(level string) bool {
+	switch level {
+	case "DETAIL", "HINT", "QUERY", "CONTEXT", "STATEMENT", "LOCATION":
+		return true
+	default:
+		return false
+	}
+}
+
+// ParsePostgres parses the message of a PostgreSQL log line, e.g. "[3-1]
+// LOG:  statement: SELECT 1". The line and chunk number of the marker, e.g.
+// 3 and 1 in "[3-1]", are stored in Data["postgres"]["line"] and
+// Data["postgres"]["chunk"]. The first chunk has a level, e.g. "LOG", which
+// is stored in Data["postgres"]["level"] and replaces the severity, like
+// parseNginxLevel. The log_line_prefix before the level, if any, is stored in
+// Data["postgres"]["prefix"]. The text after the level, or the entire text of
+// continuation chunks, is stored as Message.
+func parsePostgres(buf *buffer, msg *Message) error {
+	s := string(buf.bytes[buf.position:buf.length])
+	buf.position = buf.length
+
+	data := map[string]string{}
+	if line, chunk, rest, ok := postgresMarker(s); ok {
+		data["line"] = line
+		data["chunk"] = chunk
+		s = rest
+		if chunk != "1" {
+			msg.Message = s
+			msg.Data = map[string]map[string]string{"postgres": data}
+			return nil
+		}
+	}
+
+	msg.Message = s
+	if prefix, level, message, ok := postgresLevel(s); ok {
+		data["level"] = level
+		if prefix != "" {
+			data["prefix"] = prefix
+		}
+		msg.Message = message
+
+		if severity, ok := postgresLevels[level]; ok && !buf.cfg.PrioritySeverity() {
+			msg.Severity = severity
+			if msg.Priority.IsValid() {
+				msg.Priority = CalculatePriority(msg.Facility, severity)
+			}
+		}
+	}
+
+	if len(data) != 0 {
+		msg.Data = map[string]map[string]string{"postgres": data}
+	}
+	return nil
+}
+
+// postgresMarker returns the line and chunk number of the marker at the start
+// of s, e.g. "[3-1] ", and the text after it. It returns false if s doesn't
+// start with a marker.
+func postgresMarker(s string) (line, chunk, rest string, ok bool) {
+	if len(s) == 0 || s[0] != '[' {
+		return "", "", s, false
+	}
+	end := strings.IndexByte(s, ']')
+	if end == -1 {
+		return "", "", s, false
+	}
+	line, chunk, ok = strings.Cut(s[1:end], "-")
+	if !ok || !isDigits(line) || !isDigits(chunk) {
+		return "", "", s, false
+	}
+	rest = s[end+1:]
+	if len(rest) != 0 && rest[0] == spaceByte {
+		rest = rest[1:]
+	}
+	return line, chunk, rest, true
+}
+
+// postgresLevel finds the level in s, e.g. "LOG" in "user=app LOG:  text",
+// and returns the prefix before it and the text after it.
+func postgresLevel(s string) (prefix, level, message string, ok bool) {
+	for i := 0; i < len(s); {
+		j := strings.Index(s[i:], ":  ")
+		if j == -1 {
+			break
+		}
+		end := i + j
+		start := strings.LastIndexByte(s[:end], spaceByte) + 1
+		level = s[start:end]
+		if _, ok := postgresLevels[level]; ok || isPostgresDetail(level) {
+			return strings.TrimSpace(s[:start]), level, s[end+3:], true
+		}
+		i = end + 1
+	}
+	return "", "", s, false
+}
+
+// isDigits checks if s is a non-empty string of only digits.
+func isDigits(s string) bool {
+	return s != "" && skipDigits([]byte(s), 0) == len(s)
+}
+
+// Reassembler merges messages that were split over multiple log lines back into
+// a single message, see PostgresReassembler and PHPFPMReassembler. Messages
+// are added in the order they're logged, Add returns the messages that are
+// complete and Flush returns the remaining messages, e.g. once the input ends.
+type Reassembler interface {
+	Add(msg *Message) []*Message
+	Flush() []*Message
+}
+
+// PostgresReassembler merges the messages of a single PostgreSQL log line, that
+// PostgreSQL splits into chunks when logging to syslog, back into a single
+// message. It expects the messages in the order they're logged, parsed with
+// the Postgres format. The chunks of different processes may be interleaved.
+//
+// The text of the continuation chunks is appended to the Message of the first
+// chunk. If a chunk is missing, or received out of order, the chunks received
+// so far are returned as a message with Data["postgres"]["incomplete"] set to
+// "true".
+//
+// The zero value is ready to use. It's not safe for concurrent use.
+type PostgresReassembler struct {
+	pending map[string]*postgresPending // By ProcessID.
+	order   []string                    // ProcessIDs in pending, oldest first.
+}
+
+// postgresPending is a message of which not all chunks may be received yet.
+type postgresPending struct {
+	msg   *Message
+	line  string
+	chunk int
+}
+
+// Add adds the message and returns the messages that are complete, if any.
+// Because PostgreSQL doesn't mark the last chunk a message is only complete
+// once the next log line of the same process is added, see Flush. Messages
+// without a marker are returned immediately.
+func (r *PostgresReassembler) Add(msg *Message) []*Message {
+	data := msg.Data["postgres"]
+	chunk, err := strconv.Atoi(data["chunk"])
+	if err != nil {
+		return append(r.remove(msg.ProcessID), msg)
+	}
+
+	line := data["line"]
+	p, ok := r.pending[msg.ProcessID]
+	if ok && chunk != 1 && p.line == line && p.chunk+1 == chunk {
+		p.msg.Message += msg.Message
+		p.chunk = chunk
+		return nil
+	}
+
+	done := r.remove(msg.ProcessID)
+	if chunk != 1 {
+		// Continuation of a line of which we missed one or more chunks.
+		markPostgresIncomplete(msg)
+		if ok && p.line == line {
+			markPostgresIncomplete(p.msg)
+		}
+	}
+
+	if r.pending == nil {
+		r.pending = map[string]*postgresPending{}
+	}
+	r.pending[msg.ProcessID] = &postgresPending{msg: msg, line: line, chunk: chunk}
+	r.order = append(r.order, msg.ProcessID)
+	return done
+}
+
+// Flush returns all messages that are waiting for more chunks, in the order
+// they were added, and resets the reassembler.
+func (r *PostgresReassembler) Flush() []*Message {
+	msgs := make([]*Message, 0, len(r.order))
+	for _, processID := range r.order {
+		msgs = append(msgs, r.pending[processID].msg)
+	}
+	r.pending, r.order = nil, nil
+	return msgs
+}
+
+// remove removes the pending message of the process, returning it if any.
+func (r *PostgresReassembler) remove(processID string) []*Message {
+	p, ok := r.pending[processID]
+	if !ok {
+		return nil
+	}
+
+	delete(r.pending, processID)
+	for i, id := range r.order {
+		if id == processID {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return []*Message{p.msg}
+}
+
+// markPostgresIncomplete marks msg as missing one or more chunks.
+func markPostgresIncomplete(msg *Message) {
+	if msg.Data == nil {
+		msg.Data = map[string]map[string]string{}
+	}
+	if msg.Data["postgres"] == nil {
+		msg.Data["postgres"] = map[string]string{}
+	}
+	msg.Data["postgres"]["incomplete"] = "true"
+}
@@ -0,0 +1,224 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessagePostgres(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputPostgres),
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+			},
+		},
+		{
+			string(regularInputPostgres),
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postgres",
+				ProcessID: "1234",
+				Data: map[string]map[string]string{
+					"postgres": {
+						"line":   "3",
+						"chunk":  "1",
+						"level":  "LOG",
+						"prefix": "user=app,db=prod",
+					},
+				},
+				Message: "duration: 1.234 ms  statement: SELECT id, name FROM users WHERE id = 1",
+			},
+		},
+		{
+			// Error, without a prefix.
+			`<132>Oct 13 12:31:40 hostname postgres[1234]: [7-1] ERROR:  relation "userz" does not exist at character 15`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Error),
+				Facility:  Local0,
+				Severity:  Error,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postgres",
+				ProcessID: "1234",
+				Data: map[string]map[string]string{
+					"postgres": {
+						"line":  "7",
+						"chunk": "1",
+						"level": "ERROR",
+					},
+				},
+				Message: `relation "userz" does not exist at character 15`,
+			},
+		},
+		{
+			// Detail lines don't change the severity.
+			`<132>Oct 13 12:31:40 hostname postgres[1234]: [8-1] STATEMENT:  SELECT * FROM userz`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Warning),
+				Facility:  Local0,
+				Severity:  Warning,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postgres",
+				ProcessID: "1234",
+				Data: map[string]map[string]string{
+					"postgres": {
+						"line":  "8",
+						"chunk": "1",
+						"level": "STATEMENT",
+					},
+				},
+				Message: "SELECT * FROM userz",
+			},
+		},
+		{
+			// Continuation chunk.
+			`<134>Oct 13 12:31:40 hostname postgres[1234]: [3-2] #011FROM users`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postgres",
+				ProcessID: "1234",
+				Data: map[string]map[string]string{
+					"postgres": {
+						"line":  "3",
+						"chunk": "2",
+					},
+				},
+				Message: "#011FROM users",
+			},
+		},
+		{
+			// Without sequence numbers.
+			`<130>Oct 13 12:31:40 hostname postgres[1234]: FATAL:  password authentication failed for user "app"`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Critical),
+				Facility:  Local0,
+				Severity:  Critical,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "postgres",
+				ProcessID: "1234",
+				Data:      map[string]map[string]string{"postgres": {"level": "FATAL"}},
+				Message:   `password authentication failed for user "app"`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Postgres)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Postgres) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestPostgresReassembler(t *testing.T) {
+	t.Parallel()
+
+	type expected struct {
+		Message    string
+		Incomplete bool
+	}
+
+	tests := []struct {
+		Name     string
+		Inputs   []string
+		Expected []expected
+	}{
+		{"complete", []string{
+			`<134>Oct 13 12:31:40 h postgres[1]: [3-1] LOG:  statement: SELECT id,`,
+			`<134>Oct 13 12:31:40 h postgres[1]: [3-2]  name FROM users`,
+			`<134>Oct 13 12:31:40 h postgres[1]: [3-3]  WHERE id = 1`,
+			`<134>Oct 13 12:31:40 h postgres[1]: [4-1] LOG:  duration: 1 ms`,
+		}, []expected{
+			{"statement: SELECT id, name FROM users WHERE id = 1", false},
+			{"duration: 1 ms", false},
+		}},
+		{"interleaved processes", []string{
+			`<134>Oct 13 12:31:40 h postgres[1]: [3-1] LOG:  statement: SELECT 1`,
+			`<134>Oct 13 12:31:40 h postgres[2]: [9-1] LOG:  statement: SELECT`,
+			`<134>Oct 13 12:31:40 h postgres[1]: [3-2] 1`,
+			`<134>Oct 13 12:31:40 h postgres[2]: [9-2]  2`,
+		}, []expected{
+			{"statement: SELECT 11", false},
+			{"statement: SELECT 2", false},
+		}},
+		{"missing chunk", []string{
+			`<134>Oct 13 12:31:40 h postgres[1]: [3-1] LOG:  statement: SELECT`,
+			`<134>Oct 13 12:31:40 h postgres[1]: [3-3]  WHERE id = 1`,
+			`<134>Oct 13 12:31:40 h postgres[1]: [4-1] LOG:  duration: 1 ms`,
+		}, []expected{
+			{"statement: SELECT", true},
+			{" WHERE id = 1", true},
+			{"duration: 1 ms", false},
+		}},
+		{"missing first chunk", []string{
+			`<134>Oct 13 12:31:40 h postgres[1]: [3-1] LOG:  statement: SELECT 1`,
+			`<134>Oct 13 12:31:40 h postgres[1]: [4-2]  FROM users`,
+		}, []expected{
+			{"statement: SELECT 1", false},
+			{" FROM users", true},
+		}},
+		{"without markers", []string{
+			`<134>Oct 13 12:31:40 h postgres[1]: [3-1] LOG:  statement: SELECT 1`,
+			`<134>Oct 13 12:31:40 h postgres[1]: LOG:  duration: 1 ms`,
+			`<134>Oct 13 12:31:40 h postgres[2]: LOG:  checkpoint starting: time`,
+		}, []expected{
+			{"statement: SELECT 1", false},
+			{"duration: 1 ms", false},
+			{"checkpoint starting: time", false},
+		}},
+	}
+
+	for _, test := range tests {
+		var r PostgresReassembler
+		var got []*Message
+		for _, input := range test.Inputs {
+			msg, err := ParseMessage([]byte(input), Postgres)
+			if err != nil {
+				t.Fatalf("Unexpected error ParseMessage(%q): %s", input, err)
+			}
+			got = append(got, r.Add(msg)...)
+		}
+		got = append(got, r.Flush()...)
+
+		if len(got) != len(test.Expected) {
+			t.Fatalf("Expected PostgresReassembler to return %d messages for %s, but got %d",
+				len(test.Expected), test.Name, len(got))
+		}
+		for i, msg := range got {
+			want := test.Expected[i]
+			incomplete := msg.Data["postgres"]["incomplete"] == "true"
+			if msg.Message != want.Message || incomplete != want.Incomplete {
+				t.Fatalf("Expected message %d of %s to be %q (incomplete: %t), but got %q (incomplete: %t)",
+					i, test.Name, want.Message, want.Incomplete, msg.Message, incomplete)
+			}
+		}
+	}
+}
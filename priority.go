@@ -4,6 +4,12 @@
 
 package syslog
 
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
 const (
 	multiplier  = 8
 	maxFacility = 23
@@ -108,6 +114,49 @@ func (severity Severity) String() string {
 	return severityNames[severityIndices[severity]:severityIndices[severity+1]]
 }
 
+// Keyword returns the short keyword of the severity used by syslog.conf, e.g.
+// "err" for Error and "crit" for Critical.
+func (severity Severity) Keyword() string {
+	if !severity.IsValid() {
+		return "invalid"
+	}
+	return severityKeywords[severity]
+}
+
+// Keywords of the severities, used in syslog.conf.
+var severityKeywords = [...]string{
+	Emergency:     "emerg",
+	Alert:         "alert",
+	Critical:      "crit",
+	Error:         "err",
+	Warning:       "warning",
+	Notice:        "notice",
+	Informational: "info",
+	Debug:         "debug",
+}
+
+// Deprecated keywords of syslog.conf, which are still in use.
+var severityAliases = map[string]Severity{
+	"panic": Emergency,
+	"warn":  Warning,
+}
+
+// ParseSeverityName returns the severity with the given name, either the name
+// returned by Severity.String, e.g. "Warning", or the keyword returned by
+// Severity.Keyword, e.g. "warning". The deprecated keywords "panic" and "warn"
+// are accepted as well. The name is case-insensitive.
+func ParseSeverityName(name string) (Severity, error) {
+	for severity := Emergency; severity.IsValid(); severity++ {
+		if strings.EqualFold(name, severity.String()) || strings.EqualFold(name, severity.Keyword()) {
+			return severity, nil
+		}
+	}
+	if severity, ok := severityAliases[strings.ToLower(name)]; ok {
+		return severity, nil
+	}
+	return 0, errors.New("syslog: invalid severity name: " + strconv.Quote(name))
+}
+
 // Available severity levels, taken from RFC 5424.
 const (
 	Emergency     Severity = iota // Emergency: system is unusable.
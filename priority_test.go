@@ -152,3 +152,92 @@ func TestSeverityString(t *testing.T) {
 		}
 	}
 }
+
+func TestSeverityKeyword(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Severity Severity
+		Expected string
+	}{
+		{Emergency, "emerg"},
+		{Alert, "alert"},
+		{Critical, "crit"},
+		{Error, "err"},
+		{Warning, "warning"},
+		{Notice, "notice"},
+		{Informational, "info"},
+		{Debug, "debug"},
+		{Severity(9), "invalid"},
+	}
+
+	for _, test := range tests {
+		got := test.Severity.Keyword()
+		if got != test.Expected {
+			t.Fatalf("Expected %#v.Keyword() to return %s, but got %s",
+				test.Severity, test.Expected, got)
+		}
+	}
+}
+
+func TestParseSeverityName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Expected Severity
+	}{
+		{"Emergency", Emergency},
+		{"emergency", Emergency},
+		{"emerg", Emergency},
+		{"EMERG", Emergency},
+		{"panic", Emergency},
+		{"Panic", Emergency},
+		{"Alert", Alert},
+		{"alert", Alert},
+		{"Critical", Critical},
+		{"critical", Critical},
+		{"crit", Critical},
+		{"Error", Error},
+		{"error", Error},
+		{"err", Error},
+		{"ERR", Error},
+		{"Warning", Warning},
+		{"warning", Warning},
+		{"warn", Warning},
+		{"WARN", Warning},
+		{"Notice", Notice},
+		{"notice", Notice},
+		{"Informational", Informational},
+		{"informational", Informational},
+		{"info", Informational},
+		{"Debug", Debug},
+		{"debug", Debug},
+	}
+
+	for _, test := range tests {
+		got, err := ParseSeverityName(test.Name)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseSeverityName(%q): %s", test.Name, err)
+		} else if got != test.Expected {
+			t.Fatalf("Expected ParseSeverityName(%q) to return %s, but got %s",
+				test.Name, test.Expected, got)
+		}
+	}
+
+	for _, name := range []string{"", "Invalid", "invalid", "fatal", "warn ", "e", "none", "*"} {
+		if got, err := ParseSeverityName(name); err == nil {
+			t.Fatalf("Expected ParseSeverityName(%q) to return an error, but got %s", name, got)
+		}
+	}
+
+	// Every severity can be parsed from its String and Keyword.
+	for severity := Emergency; severity.IsValid(); severity++ {
+		for _, name := range []string{severity.String(), severity.Keyword()} {
+			if got, err := ParseSeverityName(name); err != nil || got != severity {
+				t.Fatalf("Expected ParseSeverityName(%q) to return %s, but got %s, %v",
+					name, severity, got, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"io"
+	"time"
+)
+
+// Layouts of the timestamp of Redis logs, before 3.0 the year was missing.
+// The milliseconds are optional when parsing.
+const (
+	redisTimestampLayout    = "_2 Jan 2006 15:04:05"
+	redisOldTimestampLayout = "_2 Jan 15:04:05"
+)
+
+// Roles of the process logging a Redis log line.
+var redisRoles = map[byte]string{
+	'M': "master",
+	'S': "replica",
+	'C': "child",
+	'X': "sentinel",
+}
+
+// Severities of the Redis level symbols.
+var redisLevels = map[byte]Severity{
+	'.': Debug,
+	'-': Informational, // Verbose.
+	'*': Notice,
+	'#': Warning,
+}
+
+// ParseRedis parses a Redis log line, e.g. "1187:M 13 Oct 2015 12:31:40.123 *
+// Background saving started by pid 1200", or before 3.0 "[1187] 13 Oct
+// 12:31:40.123 * ...". The pid is stored as ProcessID and the role, e.g.
+// "master" for M, in Data["redis"]["role"]. The timestamp replaces Timestamp,
+// the year of timestamps without one is added like nginxFixTimestamp. The
+// level symbol replaces the severity, like parseNginxLevel, and the remainder
+// is stored as Message.
+func parseRedis(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+	buf.position = buf.length
+
+	if len(b) == 0 {
+		return io.EOF
+	}
+
+	var i, tokens int
+	var layout string
+	if b[0] == '[' {
+		end := bytes.IndexByte(b, ']')
+		if end == -1 {
+			return io.EOF
+		} else if !isDigits(string(b[1:end])) {
+			return newFormatError(startPos+1, nil, "invalid Redis pid")
+		}
+		msg.ProcessID = string(b[1:end])
+		i, tokens, layout = end+1, 3, redisOldTimestampLayout
+	} else {
+		end := skipDigits(b, 0)
+		if end == 0 {
+			return newFormatError(startPos, nil, "invalid Redis pid")
+		} else if end+1 >= len(b) {
+			return io.EOF
+		} else if b[end] != ':' {
+			return newUnexpectedByteError(startPos+end, b[end], ':')
+		}
+		role, ok := redisRoles[b[end+1]]
+		if !ok {
+			return newFormatError(startPos+end+1, nil, "unknown Redis role '"+escapeSnippet(b[end+1:end+2])+"'")
+		}
+		msg.ProcessID = string(b[:end])
+		msg.Data = map[string]map[string]string{"redis": {"role": role}}
+		i, tokens, layout = end+2, 4, redisTimestampLayout
+	}
+
+	if len(msg.ProcessID) > maxProcessIDLength {
+		return newFormatError(startPos, ErrFieldTooLong, "pid too long")
+	} else if i >= len(b) {
+		return io.EOF
+	} else if b[i] != spaceByte {
+		return newUnexpectedByteError(startPos+i, b[i], spaceByte)
+	}
+	i++
+
+	// The timestamp consists of multiple space separated tokens, e.g. "13 Oct
+	// 2015 12:31:40.123".
+	end := i
+	for n := 0; n < tokens; n++ {
+		if n != 0 {
+			end++
+		}
+		if end >= len(b) {
+			return io.EOF
+		}
+		next := bytes.IndexByte(b[end:], spaceByte)
+		if next == -1 {
+			return io.EOF
+		}
+		end += next
+	}
+	timestamp, err := time.ParseInLocation(layout, string(b[i:end]), buf.cfg.Location())
+	if err != nil {
+		return newFormatError(startPos+i, ErrBadTimestamp, "invalid Redis timestamp")
+	}
+	msg.Timestamp = timestamp
+	if layout == redisOldTimestampLayout {
+		nginxFixTimestamp(buf, msg) // Adds the year.
+	}
+
+	// The level symbol, e.g. "*".
+	if end+1 >= len(b) {
+		return io.EOF
+	}
+	severity, ok := redisLevels[b[end+1]]
+	if !ok {
+		return newFormatError(startPos+end+1, nil, "unknown Redis level '"+escapeSnippet(b[end+1:end+2])+"'")
+	} else if !buf.cfg.PrioritySeverity() {
+		msg.Severity = severity
+		if msg.Priority.IsValid() {
+			msg.Priority = CalculatePriority(msg.Facility, severity)
+		}
+	}
+
+	if end+2 < len(b) {
+		if b[end+2] != spaceByte {
+			return newUnexpectedByteError(startPos+end+2, b[end+2], spaceByte)
+		}
+		msg.Message = string(b[end+3:])
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageRedis(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputRedis),
+			&Message{
+				Priority:  CalculatePriority(System, Notice),
+				Facility:  System,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+				ProcessID: "1",
+				Data:      map[string]map[string]string{"redis": {"role": "master"}},
+			},
+		},
+		{
+			string(regularInputRedis),
+			&Message{
+				Priority:  CalculatePriority(System, Notice),
+				Facility:  System,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 123000000, time.Local),
+				Hostname:  "hostname",
+				Appname:   "redis",
+				ProcessID: "1187",
+				Data:      map[string]map[string]string{"redis": {"role": "child"}},
+				Message:   "DB saved on disk",
+			},
+		},
+		{
+			// Replica, warning.
+			`<30>Oct 13 12:31:40 hostname redis[1187]: 1187:S 13 Oct 2015 12:31:40.001 # Error condition on socket for SYNC: Connection refused`,
+			&Message{
+				Priority:  CalculatePriority(System, Warning),
+				Facility:  System,
+				Severity:  Warning,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 1000000, time.Local),
+				Hostname:  "hostname",
+				Appname:   "redis",
+				ProcessID: "1187",
+				Data:      map[string]map[string]string{"redis": {"role": "replica"}},
+				Message:   "Error condition on socket for SYNC: Connection refused",
+			},
+		},
+		{
+			// Sentinel.
+			`<30>Oct 13 12:31:40 hostname redis-sentinel: 26409:X 13 Oct 2015 12:31:40.500 # +sdown master mymaster 127.0.0.1 6379`,
+			&Message{
+				Priority:  CalculatePriority(System, Warning),
+				Facility:  System,
+				Severity:  Warning,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 500000000, time.Local),
+				Hostname:  "hostname",
+				Appname:   "redis-sentinel",
+				ProcessID: "26409",
+				Data:      map[string]map[string]string{"redis": {"role": "sentinel"}},
+				Message:   "+sdown master mymaster 127.0.0.1 6379",
+			},
+		},
+		{
+			// Before Redis 3.0, without the role and the year.
+			`<30>Oct 13 12:31:40 hostname redis: [1187] 03 Oct 12:31:40.123 - Accepted 127.0.0.1:52314`,
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 3, 12, 31, 40, time.Local).Add(123 * time.Millisecond),
+				Hostname:  "hostname",
+				Appname:   "redis",
+				ProcessID: "1187",
+				Message:   "Accepted 127.0.0.1:52314",
+			},
+		},
+		{
+			// Before Redis 2.6, without the milliseconds.
+			`<30>Oct 13 12:31:40 hostname redis: [1187] 13 Oct 12:31:40 . Client closed connection`,
+			&Message{
+				Priority:  CalculatePriority(System, Debug),
+				Facility:  System,
+				Severity:  Debug,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "redis",
+				ProcessID: "1187",
+				Message:   "Client closed connection",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Redis)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Redis) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageRedisErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`<30>Jan  1 01:01:01 h a: `,
+			"syslog: Redis: format incorrect at byte 25: unexpected end of message"},
+		{`<30>Jan  1 01:01:01 h a: M 1 Jan 2015 01:01:01 *`,
+			"syslog: Redis: format incorrect at byte 26: invalid Redis pid"},
+		{`<30>Jan  1 01:01:01 h a: [a] 1 Jan 01:01:01 *`,
+			"syslog: Redis: format incorrect at byte 27: invalid Redis pid"},
+		{`<30>Jan  1 01:01:01 h a: 1-M 1 Jan 2015 01:01:01 *`,
+			"syslog: Redis: format incorrect at byte 27: expected byte ':', but got '-'"},
+		{`<30>Jan  1 01:01:01 h a: 1:R 1 Jan 2015 01:01:01 *`,
+			"syslog: Redis: format incorrect at byte 28: unknown Redis role 'R'"},
+		{`<30>Jan  1 01:01:01 h a: 1:M 1 Jan 2015`,
+			"syslog: Redis: format incorrect at byte 39: unexpected end of message"},
+		{`<30>Jan  1 01:01:01 h a: 1:M 1 Jan 15 01:01:01 *`,
+			"syslog: Redis: format incorrect at byte 30: invalid Redis timestamp"},
+		{`<30>Jan  1 01:01:01 h a: 1:M 1 Jan 2015 01:01:01 ! msg`,
+			"syslog: Redis: format incorrect at byte 50: unknown Redis level '!'"},
+		{`<30>Jan  1 01:01:01 h a: 1:M 1 Jan 2015 01:01:01 *msg`,
+			"syslog: Redis: format incorrect at byte 51: expected byte ' ', but got 'm'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), Redis)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// builtinFormats are the formats of this package, registered by their name
+// and named after their variable, see Format.String.
+var builtinFormats = [...]struct {
+	name, display string
+	format        format
+}{
+	{"rfc5424", "RFC5424", RFC5424},
+	{"rfc5424-lazy", "RFC5424Lazy", RFC5424Lazy},
+	{"rfc3164", "RFC3164", RFC3164},
+	{"nginx-access", "NginxAccess", NginxAccess},
+	{"nginx-error", "NginxError", NginxError},
+	{"cef", "CEF", CEF},
+	{"leef", "LEEF", LEEF},
+	{"apache-access", "ApacheAccess", ApacheAccess},
+	{"apache-error", "ApacheError", ApacheError},
+	{"postfix", "Postfix", Postfix},
+	{"sshd", "SSHD", SSHD},
+	{"sudo", "Sudo", Sudo},
+	{"cron", "Cron", Cron},
+	{"netfilter", "Netfilter", Netfilter},
+	{"dmesg", "Dmesg", Dmesg},
+	{"journal-json", "JournalJSON", JournalJSON},
+	{"docker", "Docker", Docker},
+	{"logplex", "Logplex", Logplex},
+	{"fortigate", "FortiGate", FortiGate},
+	{"filterlog", "Filterlog", Filterlog},
+	{"dovecot", "Dovecot", Dovecot},
+	{"postgres", "Postgres", Postgres},
+	{"mysql-error", "MySQLError", MySQLError},
+	{"redis", "Redis", Redis},
+	{"php-fpm", "PHPFPM", PHPFPM},
+	{"gunicorn-access", "GunicornAccess", GunicornAccess},
+	{"uwsgi-access", "UWSGIAccess", UWSGIAccess},
+	{"envoy-access", "EnvoyAccess", EnvoyAccess},
+	{"alb-access", "ALBAccess", ALBAccess},
+	{"elb-access", "ELBAccess", ELBAccess},
+	{"audit", "Audit", Audit},
+	{"fail2ban", "Fail2ban", Fail2ban},
+	{"keepalived", "Keepalived", Keepalived},
+}
+
+// registry holds the formats by name, see RegisterFormat, and the names of
+// the formats, see Format.String.
+var registry = newRegistry()
+
+type formatRegistry struct {
+	sync.RWMutex
+	formats map[string]format
+	// Map[formatKey]string, copied and replaced on every change while holding
+	// the lock, so the names can be read without locking.
+	names atomic.Value
+}
+
+func newRegistry() *formatRegistry {
+	r := &formatRegistry{formats: make(map[string]format, len(builtinFormats))}
+	names := make(map[formatKey]string, len(builtinFormats))
+	for _, f := range builtinFormats {
+		r.formats[f.name] = f.format
+		names[keyOf(f.format)] = f.display
+	}
+	r.names.Store(names)
+	return r
+}
+
+// nameOf returns the name of the format, or an empty string if it's unnamed.
+func (r *formatRegistry) nameOf(f Format) string {
+	return r.names.Load().(map[formatKey]string)[keyOf(f)]
+}
+
+// setName sets the name of the format, unless it already has one. The caller
+// must hold the write lock.
+func (r *formatRegistry) setName(f Format, name string) {
+	key := keyOf(f)
+	names := r.names.Load().(map[formatKey]string)
+	if key == (formatKey{}) || names[key] != "" {
+		return
+	}
+
+	updated := make(map[formatKey]string, len(names)+1)
+	for k, v := range names {
+		updated[k] = v
+	}
+	updated[key] = name
+	r.names.Store(updated)
+}
+
+// formatKey identifies a format by its backing array, two formats with the
+// same key are the same format.
+type formatKey struct {
+	first  *ParseFunc
+	length int
+}
+
+func keyOf(f Format) formatKey {
+	if len(f) == 0 {
+		return formatKey{}
+	}
+	return formatKey{&f[0], len(f)}
+}
+
+// RegisterFormat registers the format under the given name, so it can be
+// retrieved with GetFormat and used with NewParserNamed. The name is also used
+// as name of the format, see Format.String, unless it already has one. All
+// formats of this package are registered using their name in lowercase, with
+// dashes between words, e.g. "rfc5424" for RFC5424 and "nginx-access" for
+// NginxAccess.
+//
+// It panics if a format with the same name is already registered. It's
+// intended to be called from an init function, it's safe to call GetFormat
+// concurrently with it though.
+func RegisterFormat(name string, f Format) {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.formats[name]; ok {
+		panic("syslog: format " + name + " is already registered")
+	}
+	registry.formats[name] = f
+	registry.setName(f, name)
+}
+
+// nameDerived gives the format derived from parent, e.g. by NginxAccessWith,
+// the name of parent.
+func nameDerived(f, parent Format) {
+	if name := parent.name(); name != "" {
+		registry.Lock()
+		registry.setName(f, name)
+		registry.Unlock()
+	}
+}
+
+// GetFormat returns the format registered under the given name, see
+// RegisterFormat.
+func GetFormat(name string) (Format, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	f, ok := registry.formats[name]
+	return f, ok
+}
+
+// Formats returns the names of all registered formats, sorted.
+func Formats() []string {
+	registry.RLock()
+	names := make([]string, 0, len(registry.formats))
+	for name := range registry.formats {
+		names = append(names, name)
+	}
+	registry.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// String returns the name of the format, e.g. "RFC5424" for RFC5424, or the
+// name it's registered under using RegisterFormat. Formats are identified by
+// their backing array, so a copy or modified format isn't named, the formats
+// returned by NginxAccessWith and NginxErrorWith are named after the format
+// they're based on. It returns "unnamed format" for a format without name.
+func (f Format) String() string {
+	if name := f.name(); name != "" {
+		return name
+	}
+	return "unnamed format"
+}
+
+// name returns the name of the format, or an empty string if it's unnamed.
+func (f Format) name() string {
+	return registry.nameOf(f)
+}
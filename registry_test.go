@@ -0,0 +1,185 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Expected format
+	}{
+		{"rfc5424", RFC5424},
+		{"nginx-access", NginxAccess},
+		{"nginx-error", NginxError},
+		{"php-fpm", PHPFPM},
+		{"keepalived", Keepalived},
+	}
+
+	for _, test := range tests {
+		got, ok := GetFormat(test.Name)
+		if !ok {
+			t.Fatalf("Expected GetFormat(%q) to return a format", test.Name)
+		} else if !formatsAreEqual(got, test.Expected) {
+			t.Fatalf("Expected GetFormat(%q) to return the %s format", test.Name, test.Name)
+		}
+	}
+
+	if _, ok := GetFormat("unknown"); ok {
+		t.Fatal("Expected GetFormat(unknown) to return false")
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	t.Parallel()
+
+	f := format{parseMsg}
+	RegisterFormat("test-register", f)
+	if got, ok := GetFormat("test-register"); !ok || !formatsAreEqual(got, f) {
+		t.Fatal("Expected GetFormat to return the registered format")
+	}
+
+	names := Formats()
+	if !sort.StringsAreSorted(names) {
+		t.Fatalf("Expected Formats to return sorted names, but got %q", names)
+	} else if i := sort.SearchStrings(names, "test-register"); i == len(names) || names[i] != "test-register" {
+		t.Fatalf("Expected Formats to contain the registered format, but got %q", names)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("Expected RegisterFormat to panic with a duplicate name")
+		}
+	}()
+	RegisterFormat("rfc5424", f)
+}
+
+func TestRegisterFormatConcurrent(t *testing.T) {
+	t.Parallel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				GetFormat("rfc5424")
+				Formats()
+			}
+		}()
+	}
+	RegisterFormat("test-concurrent", format{parseMsg})
+	wg.Wait()
+}
+
+func TestNewParserNamed(t *testing.T) {
+	t.Parallel()
+
+	parse, err := NewParserNamed("rfc5424", WithMaxMessageLength(100))
+	if err != nil {
+		t.Fatalf("Unexpected error NewParserNamed(rfc5424): %s", err)
+	}
+	input := []byte("<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 - message")
+	got, err := parse(input)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %q: %s", input, err)
+	}
+	expected, _ := ParseMessage(input, RFC5424)
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected parsing %q to return Message %#v, but got %#v", input, expected, got)
+	}
+
+	if _, err := NewParserNamed("unknown"); err == nil || err.Error() != `syslog: unknown format "unknown"` {
+		t.Fatalf("Expected NewParserNamed(unknown) to return an error, but got %v", err)
+	}
+}
+
+// formatsAreEqual checks if both formats have the same parseFuncs.
+func formatsAreEqual(a, b format) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if funcPointer(a[i]) != funcPointer(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFormatString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Format   Format
+		Expected string
+	}{
+		{RFC5424, "RFC5424"},
+		{RFC5424Lazy, "RFC5424Lazy"},
+		{NginxAccess, "NginxAccess"},
+		{PHPFPM, "PHPFPM"},
+		{Keepalived, "Keepalived"},
+		{NginxAccessWith(WithYear(2015)), "NginxAccess"},
+		{NginxErrorWith(WithLocation(time.UTC)), "NginxError"},
+		{RFC5424[:3], "unnamed format"},
+		{Format{parseMsg}, "unnamed format"},
+		{nil, "unnamed format"},
+	}
+
+	for _, test := range tests {
+		if got := test.Format.String(); got != test.Expected {
+			t.Fatalf("Expected the name of the format to be %q, but got %q",
+				test.Expected, got)
+		}
+	}
+
+	// All built-in formats must have a unique name.
+	names := map[string]bool{}
+	for _, f := range builtinFormats {
+		if name := f.format.String(); name != f.display || names[name] {
+			t.Fatalf("Expected the %s format to be named %q, but got %q", f.name, f.display, name)
+		}
+		names[f.display] = true
+	}
+
+	f := Format{parseMsg}
+	RegisterFormat("test-string", f)
+	if got, expected := f.String(), "test-string"; got != expected {
+		t.Fatalf("Expected the name of the registered format to be %q, but got %q",
+			expected, got)
+	}
+}
+
+func TestFormatParser(t *testing.T) {
+	t.Parallel()
+
+	parser := NewFormatParser(RFC3164)
+	if got, expected := parser.Format(), "RFC3164"; got != expected {
+		t.Fatalf("Expected FormatParser.Format() to return %q, but got %q", expected, got)
+	}
+
+	_, err := parser.Parse([]byte("<1923>"))
+	var formatErr *FormatError
+	if !errors.As(err, &formatErr) {
+		t.Fatalf("Expected FormatParser.Parse to return a *FormatError, but got %#v", err)
+	} else if formatErr.Format != "RFC3164" {
+		t.Fatalf("Expected the error to include the format name %q, but got %q",
+			"RFC3164", formatErr.Format)
+	}
+
+	formatErr.Snippet = nil
+	if got, expected := err.Error(), "syslog: RFC3164: format incorrect at byte 5: priority too long"; got != expected {
+		t.Fatalf("Expected FormatParser.Parse to return error %q, but got %q", expected, got)
+	}
+}
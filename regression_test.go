@@ -0,0 +1,247 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"math/rand"
+	"testing"
+)
+
+var allFormats = map[string]format{
+	"RFC5424":        RFC5424,
+	"RFC5424Lazy":    RFC5424Lazy,
+	"NginxAccess":    NginxAccess,
+	"NginxError":     NginxError,
+	"CEF":            CEF,
+	"LEEF":           LEEF,
+	"ApacheAccess":   ApacheAccess,
+	"ApacheError":    ApacheError,
+	"Postfix":        Postfix,
+	"SSHD":           SSHD,
+	"Sudo":           Sudo,
+	"Cron":           Cron,
+	"Netfilter":      Netfilter,
+	"Dmesg":          Dmesg,
+	"JournalJSON":    JournalJSON,
+	"Docker":         Docker,
+	"Logplex":        Logplex,
+	"RFC3164":        RFC3164,
+	"FortiGate":      FortiGate,
+	"Filterlog":      Filterlog,
+	"Dovecot":        Dovecot,
+	"Postgres":       Postgres,
+	"MySQLError":     MySQLError,
+	"Redis":          Redis,
+	"PHPFPM":         PHPFPM,
+	"GunicornAccess": GunicornAccess,
+	"UWSGIAccess":    UWSGIAccess,
+	"EnvoyAccess":    EnvoyAccess,
+	"JSONCaddy":      caddyJSON,
+	"JSONTraefik":    traefikJSON,
+	"ALBAccess":      ALBAccess,
+	"ELBAccess":      ELBAccess,
+	"Audit":          Audit,
+	"Fail2ban":       Fail2ban,
+	"Keepalived":     Keepalived,
+}
+
+var regressionInputs = [][]byte{
+	minimumInputRFC5424,
+	regularInputRFC5424,
+	minimumInputNginxAccess,
+	regularInputNginxAccess,
+	minimumInputNginxError,
+	regularInputNginxError,
+	minimumInputCEF,
+	regularInputCEF,
+	minimumInputLEEF,
+	regularInputLEEF,
+	minimumInputApacheAccess,
+	regularInputApacheAccess,
+	minimumInputApacheError,
+	regularInputApacheError,
+	minimumInputPostfix,
+	regularInputPostfix,
+	minimumInputSSHD,
+	regularInputSSHD,
+	minimumInputSudo,
+	regularInputSudo,
+	minimumInputCron,
+	regularInputCron,
+	minimumInputNetfilter,
+	regularInputNetfilter,
+	minimumInputDmesg,
+	regularInputDmesg,
+	minimumInputJournalJSON,
+	regularInputJournalJSON,
+	minimumInputDocker,
+	regularInputDocker,
+	minimumInputLogplex,
+	regularInputLogplex,
+	minimumInputRFC3164,
+	regularInputRFC3164,
+	minimumInputFortiGate,
+	regularInputFortiGate,
+	minimumInputFilterlog,
+	regularInputFilterlog,
+	minimumInputDovecot,
+	regularInputDovecot,
+	minimumInputPostgres,
+	regularInputPostgres,
+	minimumInputMySQLError,
+	regularInputMySQLError,
+	minimumInputRedis,
+	regularInputRedis,
+	minimumInputPHPFPM,
+	regularInputPHPFPM,
+	minimumInputGunicornAccess,
+	regularInputGunicornAccess,
+	minimumInputUWSGIAccess,
+	regularInputUWSGIAccess,
+	minimumInputEnvoyAccess,
+	regularInputEnvoyAccess,
+	minimumInputJSONCaddy,
+	regularInputJSONCaddy,
+	minimumInputJSONTraefik,
+	regularInputJSONTraefik,
+	minimumInputALBAccess,
+	regularInputALBAccess,
+	minimumInputELBAccess,
+	regularInputELBAccess,
+	minimumInputAudit,
+	regularInputAudit,
+	minimumInputFail2ban,
+	regularInputFail2ban,
+	minimumInputKeepalived,
+	regularInputKeepalived,
+	[]byte(`<191>1 2015-09-30T23:10:11.123Z h a p m [d n="v\\" x="\]"][e][f y="\"z\""] ` + "\xef\xbb\xbfmsg"),
+}
+
+// parseNoPanic parses the input, including the lazy structured data, and
+// reports a panic as an error.
+func parseNoPanic(t *testing.T, name string, input []byte, format format) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("Unexpected panic ParseMessage(%q, %s): %v", input, name, r)
+		}
+	}()
+
+	msg, err := ParseMessage(input, format)
+	if err == nil {
+		_, err = msg.ParsedData()
+	}
+	return err
+}
+
+func TestParseMessageTruncatedNoPanic(t *testing.T) {
+	t.Parallel()
+
+	for name, format := range allFormats {
+		for _, input := range regressionInputs {
+			for i := 0; i <= len(input); i++ {
+				parseNoPanic(t, name, input[:i], format)
+			}
+		}
+	}
+}
+
+func TestParseMessageTruncatedData(t *testing.T) {
+	t.Parallel()
+
+	// These used to be accepted, even though the structured data is incomplete.
+	tests := []string{
+		`<0>1 - - - - - [d`,
+		`<0>1 - - - - - [d `,
+		`<0>1 - - - - - [d n`,
+		`<0>1 - - - - - [d n="v" `,
+		`<0>1 - - - - - [d n="v" x`,
+		`<0>1 - - - - - [d n="v"][e`,
+		`<0>1 - - - - - [d n]`,
+		`<0>1 - - - - - [d n][e x="1"]`,
+		`<0>1 - - - - - []`,
+		`<0>1 - - - - - [d n="v" ]`,
+	}
+
+	for _, input := range tests {
+		for _, name := range []string{"RFC5424", "RFC5424Lazy"} {
+			if err := parseNoPanic(t, name, []byte(input), allFormats[name]); err == nil {
+				t.Fatalf("Expected ParseMessage(%q, %s) to return an error, but got nil",
+					input, name)
+			}
+		}
+	}
+}
+
+func TestParseMessageArbitraryBytesNoPanic(t *testing.T) {
+	t.Parallel()
+
+	// Special bytes used by the formats, more likely to find problems than
+	// random bytes.
+	special := []byte("<>[]=\"\\ -:,\x00\xff")
+	rng := rand.New(rand.NewSource(1))
+
+	for name, format := range allFormats {
+		for _, input := range regressionInputs {
+			for n := 0; n < 200; n++ {
+				mutated := append([]byte{}, input...)
+				for m := rng.Intn(4) + 1; m > 0; m-- {
+					i := rng.Intn(len(mutated))
+					if rng.Intn(2) == 0 {
+						mutated[i] = special[rng.Intn(len(special))]
+					} else {
+						mutated[i] = byte(rng.Intn(256))
+					}
+				}
+				parseNoPanic(t, name, mutated, format)
+			}
+		}
+	}
+}
+
+func FuzzParseMessage(f *testing.F) {
+	for _, input := range regressionInputs {
+		f.Add(input)
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		for name, format := range allFormats {
+			parseNoPanic(t, name, input, format)
+		}
+	})
+}
+
+func TestParseMessageBestEffort(t *testing.T) {
+	t.Parallel()
+
+	for name, format := range allFormats {
+		parse := NewParser(format, WithBestEffort())
+		for _, input := range regressionInputs {
+			expected, err := ParseMessage(input, format)
+			got, gotErr := parse(input)
+			if gotErr != nil {
+				t.Fatalf("Unexpected error parse(%q, %s) with WithBestEffort: %s", input, name, gotErr)
+			} else if err != nil {
+				continue
+			}
+
+			// Messages that can be parsed must be the same in both modes.
+			if errs := got.Errors(); len(errs) != 0 {
+				t.Fatalf("Unexpected errors parse(%q, %s) with WithBestEffort: %v", input, name, errs)
+			} else if !messagesAreEqual(got, expected) {
+				t.Fatalf("Expected parse(%q, %s) with WithBestEffort to return Message %#v, but got %#v",
+					input, name, expected, got)
+			}
+		}
+
+		// It never fails.
+		for _, input := range regressionInputs {
+			for i := 0; i <= len(input); i++ {
+				if _, err := parse(input[:i]); err != nil {
+					t.Fatalf("Unexpected error parse(%q, %s) with WithBestEffort: %s", input[:i], name, err)
+				}
+			}
+		}
+	}
+}
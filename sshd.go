@@ -0,0 +1,124 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import "strings"
+
+// ParseSSHD parses the message of an OpenSSH sshd log line. The complete line
+// is stored as Message, lines about authentication, e.g. "Failed password for
+// invalid user admin from 203.0.113.7 port 52314 ssh2", are also parsed into
+// Data["ssh"], see sshdData.
+func parseSSHD(buf *buffer, msg *Message) error {
+	msg.Message = string(buf.bytes[buf.position:buf.length])
+	buf.position = buf.length
+
+	if data := sshdData(msg.Message); data != nil {
+		msg.Data = map[string]map[string]string{"ssh": data}
+	}
+	return nil
+}
+
+// sshdData returns the fields of the sshd message, or nil if the message isn't
+// recognised. The recognised messages are:
+//
+//	Accepted publickey for root from 10.0.0.1 port 22 ssh2: ED25519 SHA256:...
+//	Failed password for invalid user admin from 203.0.113.7 port 52314 ssh2
+//	Invalid user admin from 203.0.113.7 port 52314
+//	Disconnected from invalid user admin 203.0.113.7 port 52314 [preauth]
+//
+// The returned fields are "result", the first word in lower case, and
+// "method", "user", "invalid_user" ("true"), "source_ip", "port" and
+// "key_fingerprint", if present.
+func sshdData(message string) map[string]string {
+	words := strings.Fields(message)
+	if len(words) == 0 {
+		return nil
+	}
+
+	data := map[string]string{"result": strings.ToLower(words[0])}
+	switch words[0] {
+	case "Accepted", "Failed":
+		// Accepted <method> for [invalid user ]<user> from <ip> port <port> ssh2[: <type> <fingerprint>]
+		if len(words) < 3 || words[2] != "for" {
+			return nil
+		}
+		data["method"] = words[1]
+		words = sshdUser(words[3:], data)
+		if len(words) < 2 || words[0] != "from" {
+			return nil
+		}
+		words = sshdSource(words[1:], data)
+		if len(words) == 3 && words[0] == "ssh2:" {
+			data["key_fingerprint"] = words[2]
+		}
+	case "Invalid":
+		// Invalid user <user> from <ip>[ port <port>]
+		if len(words) < 4 || words[1] != "user" {
+			return nil
+		}
+		data["invalid_user"] = "true"
+		words = words[2:]
+		if words[0] != "from" {
+			data["user"] = words[0]
+			words = words[1:]
+		}
+		if len(words) < 2 || words[0] != "from" {
+			return nil
+		}
+		sshdSource(words[1:], data)
+	case "Disconnected":
+		// Disconnected from [invalid |authenticating ]user <user> <ip> port <port>[ [preauth]]
+		// Disconnected from <ip> port <port>[ [preauth]]
+		if len(words) < 3 || words[1] != "from" {
+			return nil
+		}
+		words = words[2:]
+		if words[0] == "invalid" || words[0] == "authenticating" {
+			if words[0] == "invalid" {
+				data["invalid_user"] = "true"
+			}
+			words = words[1:]
+		}
+		if len(words) >= 3 && words[0] == "user" {
+			data["user"] = words[1]
+			words = words[2:]
+		} else if data["invalid_user"] != "" || len(words) < 3 {
+			return nil
+		}
+		sshdSource(words, data)
+	default:
+		return nil
+	}
+	return data
+}
+
+// sshdUser parses "[invalid user ]<user>" into data, returning the remaining
+// words.
+func sshdUser(words []string, data map[string]string) []string {
+	if len(words) > 2 && words[0] == "invalid" && words[1] == "user" {
+		data["invalid_user"] = "true"
+		words = words[2:]
+	}
+	if len(words) != 0 && words[0] != "from" {
+		data["user"] = words[0]
+		words = words[1:]
+	}
+	return words
+}
+
+// sshdSource parses "<ip>[ port <port>]" into data, returning the remaining
+// words.
+func sshdSource(words []string, data map[string]string) []string {
+	if len(words) == 0 {
+		return words
+	}
+	data["source_ip"] = words[0]
+	words = words[1:]
+	if len(words) >= 2 && words[0] == "port" {
+		data["port"] = words[1]
+		words = words[2:]
+	}
+	return words
+}
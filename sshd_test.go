@@ -0,0 +1,135 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageSSHD(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputSSHD),
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization, Informational),
+				Facility:  SecurityAuthorization,
+				Severity:  Informational,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+			},
+		},
+		{
+			string(regularInputSSHD),
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization, Informational),
+				Facility:  SecurityAuthorization,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "sshd",
+				ProcessID: "4711",
+				Data: map[string]map[string]string{
+					"ssh": {
+						"result":          "accepted",
+						"method":          "publickey",
+						"user":            "root",
+						"source_ip":       "10.0.0.1",
+						"port":            "22",
+						"key_fingerprint": "SHA256:Vh2KwMj2g6lKs7IdhEw0aC0ZzL2YrQ1gHdDb3n2Rz4s",
+					},
+				},
+				Message: "Accepted publickey for root from 10.0.0.1 port 22 ssh2: ED25519 SHA256:Vh2KwMj2g6lKs7IdhEw0aC0ZzL2YrQ1gHdDb3n2Rz4s",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), SSHD)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, SSHD) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestSSHDData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected map[string]string
+	}{
+		{"Accepted password for frank from 2001:db8::1 port 50022 ssh2", map[string]string{
+			"result": "accepted", "method": "password", "user": "frank",
+			"source_ip": "2001:db8::1", "port": "50022",
+		}},
+		{"Failed password for invalid user admin from 203.0.113.7 port 52314 ssh2", map[string]string{
+			"result": "failed", "method": "password", "user": "admin", "invalid_user": "true",
+			"source_ip": "203.0.113.7", "port": "52314",
+		}},
+		{"Failed publickey for root from 203.0.113.7 port 52314 ssh2: RSA SHA256:abc", map[string]string{
+			"result": "failed", "method": "publickey", "user": "root",
+			"source_ip": "203.0.113.7", "port": "52314", "key_fingerprint": "SHA256:abc",
+		}},
+		{"Failed keyboard-interactive/pam for invalid user  from 203.0.113.7 port 52314 ssh2", map[string]string{
+			"result": "failed", "method": "keyboard-interactive/pam", "invalid_user": "true",
+			"source_ip": "203.0.113.7", "port": "52314",
+		}},
+		{"Invalid user admin from 203.0.113.7 port 52314", map[string]string{
+			"result": "invalid", "user": "admin", "invalid_user": "true",
+			"source_ip": "203.0.113.7", "port": "52314",
+		}},
+		{"Invalid user admin from 203.0.113.7", map[string]string{
+			"result": "invalid", "user": "admin", "invalid_user": "true",
+			"source_ip": "203.0.113.7",
+		}},
+		{"Disconnected from invalid user admin 203.0.113.7 port 52314 [preauth]", map[string]string{
+			"result": "disconnected", "user": "admin", "invalid_user": "true",
+			"source_ip": "203.0.113.7", "port": "52314",
+		}},
+		{"Disconnected from authenticating user root 203.0.113.7 port 52314 [preauth]", map[string]string{
+			"result": "disconnected", "user": "root",
+			"source_ip": "203.0.113.7", "port": "52314",
+		}},
+		{"Disconnected from user frank 10.0.0.1 port 22", map[string]string{
+			"result": "disconnected", "user": "frank",
+			"source_ip": "10.0.0.1", "port": "22",
+		}},
+		{"Disconnected from 10.0.0.1 port 22 [preauth]", map[string]string{
+			"result": "disconnected", "source_ip": "10.0.0.1", "port": "22",
+		}},
+		{"", nil},
+		{"Server listening on 0.0.0.0 port 22.", nil},
+		{"Received disconnect from 10.0.0.1 port 22:11: disconnected by user", nil},
+		{"pam_unix(sshd:session): session opened for user frank by (uid=0)", nil},
+		{"Accepted password", nil},
+		{"Failed password for root", nil},
+		{"Invalid user", nil},
+		{"Invalid user admin", nil},
+		{"Disconnected from", nil},
+		{"Disconnected from invalid user admin", nil},
+	}
+
+	for _, test := range tests {
+		got := sshdData(test.Input)
+		if len(got) != len(test.Expected) || (got == nil) != (test.Expected == nil) {
+			t.Fatalf("Expected sshdData(%q) to return %v, but got %v", test.Input, test.Expected, got)
+		}
+		for key, value := range test.Expected {
+			if got[key] != value {
+				t.Fatalf("Expected sshdData(%q) to return %v, but got %v", test.Input, test.Expected, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import "strings"
+
+// Separators used by sudo, between the user and the pairs, and between the
+// pairs.
+const (
+	sudoUserSeparator = " : "
+	sudoSeparator     = " ; "
+)
+
+// ParseSudo parses the message of a sudo log line. The complete line is
+// stored as Message, lines about running a command, e.g. "alice : TTY=pts/0 ;
+// PWD=/home/alice ; USER=root ; COMMAND=/bin/ls", are also parsed into
+// Data["sudo"], see sudoData. If the command was denied the severity is
+// raised to Alert, like parseNginxLevel it respects WithPrioritySeverity.
+func parseSudo(buf *buffer, msg *Message) error {
+	b := buf.bytes[buf.position:buf.length]
+	msg.Message = string(b[skipSpaces(b, 0):])
+	buf.position = buf.length
+
+	data := sudoData(msg.Message)
+	if data == nil {
+		return nil
+	}
+	msg.Data = map[string]map[string]string{"sudo": data}
+
+	if _, denied := data["reason"]; denied && msg.Severity > Alert && !buf.cfg.PrioritySeverity() {
+		msg.Severity = Alert
+		if msg.Priority.IsValid() {
+			msg.Priority = CalculatePriority(msg.Facility, Alert)
+		}
+	}
+	return nil
+}
+
+// sudoData returns the fields of the sudo message, or nil if the message isn't
+// recognised. The invoking user is returned as "user", the KEY=VALUE pairs
+// with their key unchanged, e.g. "TTY" and "USER". COMMAND is always the last
+// pair and is returned completely, even if it contains the separator. Text
+// before the first pair, e.g. "user NOT in sudoers", is returned as "reason".
+func sudoData(message string) map[string]string {
+	user, pairs, ok := strings.Cut(message, sudoUserSeparator)
+	if !ok || user == "" || strings.IndexByte(user, spaceByte) != -1 {
+		return nil
+	}
+
+	data := map[string]string{"user": user}
+	for pairs != "" {
+		var pair string
+		if strings.HasPrefix(pairs, "COMMAND=") {
+			pair, pairs = pairs, ""
+		} else {
+			pair, pairs, _ = strings.Cut(pairs, sudoSeparator)
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || !isSudoKey(key) {
+			if len(data) != 1 {
+				return nil
+			}
+			data["reason"] = pair
+			continue
+		}
+		data[key] = value
+	}
+
+	if len(data) == 1 {
+		return nil
+	}
+	return data
+}
+
+// isSudoKey checks if key is a key used by sudo, which are in upper case, e.g.
+// TTY.
+func isSudoKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; (c < 'A' || c > 'Z') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageSudo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputSudo),
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization2, Notice),
+				Facility:  SecurityAuthorization2,
+				Severity:  Notice,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+			},
+		},
+		{
+			string(regularInputSudo),
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization2, Notice),
+				Facility:  SecurityAuthorization2,
+				Severity:  Notice,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "sudo",
+				Data: map[string]map[string]string{
+					"sudo": {
+						"user":    "alice",
+						"TTY":     "pts/0",
+						"PWD":     "/home/alice",
+						"USER":    "root",
+						"COMMAND": "/bin/systemctl restart nginx",
+					},
+				},
+				Message: "alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/bin/systemctl restart nginx",
+			},
+		},
+		{
+			// Denied.
+			`<85>Oct 13 12:31:40 hostname sudo:      bob : user NOT in sudoers ; TTY=pts/1 ; PWD=/home/bob ; USER=root ; COMMAND=/usr/bin/id`,
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization2, Alert),
+				Facility:  SecurityAuthorization2,
+				Severity:  Alert,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "sudo",
+				Data: map[string]map[string]string{
+					"sudo": {
+						"user":    "bob",
+						"reason":  "user NOT in sudoers",
+						"TTY":     "pts/1",
+						"PWD":     "/home/bob",
+						"USER":    "root",
+						"COMMAND": "/usr/bin/id",
+					},
+				},
+				Message: "bob : user NOT in sudoers ; TTY=pts/1 ; PWD=/home/bob ; USER=root ; COMMAND=/usr/bin/id",
+			},
+		},
+		{
+			// Denied, already logged with a higher severity.
+			`<80>Oct 13 12:31:40 hostname sudo: bob : 3 incorrect password attempts ; TTY=pts/1 ; PWD=/home/bob ; USER=root ; COMMAND=/usr/bin/id`,
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization2, Emergency),
+				Facility:  SecurityAuthorization2,
+				Severity:  Emergency,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "sudo",
+				Data: map[string]map[string]string{
+					"sudo": {
+						"user":    "bob",
+						"reason":  "3 incorrect password attempts",
+						"TTY":     "pts/1",
+						"PWD":     "/home/bob",
+						"USER":    "root",
+						"COMMAND": "/usr/bin/id",
+					},
+				},
+				Message: "bob : 3 incorrect password attempts ; TTY=pts/1 ; PWD=/home/bob ; USER=root ; COMMAND=/usr/bin/id",
+			},
+		},
+		{
+			// Command containing the separator.
+			`<85>Oct 13 12:31:40 hostname sudo: alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; ENV=LANG=C ; COMMAND=/bin/sh -c 'echo a ; echo b'`,
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization2, Notice),
+				Facility:  SecurityAuthorization2,
+				Severity:  Notice,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "sudo",
+				Data: map[string]map[string]string{
+					"sudo": {
+						"user":    "alice",
+						"TTY":     "pts/0",
+						"PWD":     "/home/alice",
+						"USER":    "root",
+						"ENV":     "LANG=C",
+						"COMMAND": "/bin/sh -c 'echo a ; echo b'",
+					},
+				},
+				Message: "alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; ENV=LANG=C ; COMMAND=/bin/sh -c 'echo a ; echo b'",
+			},
+		},
+		{
+			// Not about a command.
+			`<86>Oct 13 12:31:40 hostname sudo: pam_unix(sudo:session): session opened for user root by alice(uid=0)`,
+			&Message{
+				Priority:  CalculatePriority(SecurityAuthorization2, Informational),
+				Facility:  SecurityAuthorization2,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "sudo",
+				Message:   "pam_unix(sudo:session): session opened for user root by alice(uid=0)",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), Sudo)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, Sudo) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageSudoPrioritySeverity(t *testing.T) {
+	t.Parallel()
+
+	const input = `<85>Oct 13 12:31:40 hostname sudo: bob : user NOT in sudoers ; TTY=pts/1 ; USER=root ; COMMAND=/usr/bin/id`
+	msg, err := NewParser(Sudo, WithPrioritySeverity())([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error ParseMessage(%q): %s", input, err)
+	} else if msg.Severity != Notice || msg.Priority != CalculatePriority(SecurityAuthorization2, Notice) {
+		t.Fatalf("Expected ParseMessage(%q) with WithPrioritySeverity to keep severity %s, but got %s",
+			input, Notice, msg.Severity)
+	}
+}
+
+func TestSudoData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected map[string]string
+	}{
+		{"alice : TTY=pts/0 ; COMMAND=/bin/ls", map[string]string{
+			"user": "alice", "TTY": "pts/0", "COMMAND": "/bin/ls",
+		}},
+		{"alice : command not allowed", map[string]string{
+			"user": "alice", "reason": "command not allowed",
+		}},
+		{"alice : COMMAND=a ; b ; TTY=c", map[string]string{
+			"user": "alice", "COMMAND": "a ; b ; TTY=c",
+		}},
+		{"", nil},
+		{"alice", nil},
+		{"alice : ", nil},
+		{" : TTY=pts/0", nil},
+		{"a b : TTY=pts/0", nil},
+		{"alice : TTY=pts/0 ; not a pair", nil},
+		{"alice : =pts/0", map[string]string{"user": "alice", "reason": "=pts/0"}},
+	}
+
+	for _, test := range tests {
+		got := sudoData(test.Input)
+		if len(got) != len(test.Expected) || (got == nil) != (test.Expected == nil) {
+			t.Fatalf("Expected sudoData(%q) to return %v, but got %v", test.Input, test.Expected, got)
+		}
+		for key, value := range test.Expected {
+			if got[key] != value {
+				t.Fatalf("Expected sudoData(%q) to return %v, but got %v", test.Input, test.Expected, got)
+			}
+		}
+	}
+}
@@ -2,13 +2,19 @@
 //
 // Licensed under the MIT license that can be found in the LICENSE file.
 
-// Package syslog is a package to parse syslog logs. It has formats for RFC5424
-// and Nginx access and error logs
+// Package syslog is a package to parse syslog logs. It has formats for RFC5424,
+// RFC3164, Nginx and Apache access and error logs, Gunicorn, uWSGI, Envoy and
+// AWS load balancer access logs, JSON access logs, e.g. of Caddy and Traefik,
+// CEF, LEEF, kernel messages, Linux audit records, the systemd journal (JSON),
+// Docker's syslog log driver, Heroku Logplex drains, FortiGate firewalls, the
+// pfSense and OPNsense filterlog and the logs of Postfix, Dovecot, fail2ban,
+// PostgreSQL, MySQL, Redis, php-fpm, keepalived, OpenSSH, sudo, cron and
+// netfilter.
 package syslog
 
 import (
+	"bytes"
 	"errors"
-	"fmt"
 	"io"
 	"sort"
 	"strconv"
@@ -16,10 +22,10 @@ import (
 	"time"
 )
 
-// todo: only allow PRINTUSASCII, currently not checked: %d33-126.
-
 // Message represents a single syslog message.
 type Message struct {
+	// Priority of the message. If zero it's calculated from Facility and
+	// Severity when formatting the message, the parser sets all three.
 	Priority  Priority
 	Facility  Facility
 	Severity  Severity
@@ -29,55 +35,162 @@ type Message struct {
 	Appname   string
 	ProcessID string
 	MessageID string
-	Data      map[string]map[string]string
-	Message   string
+
+	// Data holds the structured data, mapping data-IDs to their params. It's
+	// nil if the message has no structured data (the nil value "-"), and
+	// non-nil otherwise, even for an element without params. When formatting a
+	// nil and empty map are the same, see HasStructuredData.
+	Data map[string]map[string]string
+
+	Message string
+
+	// UTF8 is true if the message started with the UTF-8 BOM, which indicates
+	// the message is encoded in UTF-8. The BOM is not included in Message.
+	UTF8 bool
+
+	// RawData holds the unparsed structured data, it's only set by formats
+	// that parse the structured data lazily, e.g. RFC5424Lazy. See ParsedData.
+	RawData string
+
+	dataPos int     // Position of RawData in the original message.
+	dataErr error   // Error returned by parsing RawData.
+	dataCfg *config // Configuration of the parser that set RawData.
+	// Name of the format that set RawData, see Format.String.
+	dataFormat string
+
+	// All values of duplicate params, only set with CollectDuplicates.
+	repeated map[string]map[string][]string
+
+	errs []error // Errors of the parts that couldn't be parsed, see Errors.
 }
 
-// String formats the message in a RFC5424 format.
-func (msg *Message) String() string {
-	return string(msg.Bytes())
+// priority returns the priority to format, calculated from the facility and
+// severity if Priority is not set.
+func (msg *Message) priority() Priority {
+	if msg.Priority == 0 && (msg.Facility != 0 || msg.Severity != 0) {
+		return CalculatePriority(msg.Facility, msg.Severity)
+	}
+	return msg.Priority
 }
 
-// Bytes formats the message in a RFC5424 format.
-func (msg *Message) Bytes() []byte {
-	var b []byte
+// HasStructuredData returns true if the message has at least one structured
+// data element, either in Data or RawData.
+func (msg *Message) HasStructuredData() bool {
+	return len(msg.Data) != 0 || msg.RawData != ""
+}
+
+// ParamValues returns all values of the param in the structured data element,
+// in the order they appeared in the message. Multiple values are only kept
+// when parsing with the CollectDuplicates policy, otherwise it returns at most
+// one value.
+func (msg *Message) ParamValues(dataID, name string) []string {
+	if values, ok := msg.repeated[dataID][name]; ok {
+		return values
+	} else if value, ok := msg.Data[dataID][name]; ok {
+		return []string{value}
+	}
+	return nil
+}
 
-	// Format priority: <pri>, e.g. <0>, <191>
-	b = append(b, priorityStart)
-	b = strconv.AppendUint(b, uint64(msg.Priority), 10)
-	b = append(b, priorityEnd)
+// Errors returns the errors of the parts of the message that couldn't be
+// parsed, in the order they occurred. It's only set when parsing with
+// WithBestEffort, the errors are those ParseMessage would have returned.
+func (msg *Message) Errors() []error {
+	return msg.errs
+}
 
-	// Add optional version and a space, e.g. 1, 10
-	if msg.Version != 0 {
-		b = strconv.AppendUint(b, uint64(msg.Version), 10)
+// addRepeated adds the value of a duplicate param, previous is the value it
+// had so far.
+func (msg *Message) addRepeated(dataID, name, previous, value string) {
+	if msg.repeated == nil {
+		msg.repeated = map[string]map[string][]string{}
+	}
+	if msg.repeated[dataID] == nil {
+		msg.repeated[dataID] = map[string][]string{}
 	}
-	b = append(b, spaceByte)
 
-	// Add values, with a nil value for a zero value.
-	b = addTimestamp(b, msg.Timestamp)
-	b = addValue(b, msg.Hostname)
-	b = addValue(b, msg.Appname)
-	b = addValue(b, msg.ProcessID)
-	b = addValue(b, msg.MessageID)
+	values, ok := msg.repeated[dataID][name]
+	if !ok {
+		values = []string{previous}
+	}
+	msg.repeated[dataID][name] = append(values, value)
+}
 
-	b = addData(b, msg.Data)
+// ParsedData returns the structured data of the message. If the message was
+// parsed with a lazy format, e.g. RFC5424Lazy, the structured data is parsed
+// from RawData on the first call and stored in Data, returning the same error
+// the parser would have returned, using the same options. The result is
+// cached, so calling it again doesn't parse the data again.
+//
+// Note: because of the caching this is not safe for concurrent use.
+func (msg *Message) ParsedData() (map[string]map[string]string, error) {
+	if msg.Data != nil || msg.RawData == "" || msg.dataErr != nil {
+		return msg.Data, msg.dataErr
+	}
 
-	if msg.Message != "" {
-		b = append(b, spaceByte)
-		b = append(b, msg.Message...)
+	cfg := msg.dataCfg
+	if cfg == nil {
+		cfg = defaultConfig
 	}
+	buf := getBuffer([]byte(msg.RawData), cfg)
+	defer putBuffer(buf)
+	buf.base = msg.dataPos - 1
 
-	return b
+	if err := parseData(buf, msg); err != nil {
+		if err == io.EOF {
+			err = newFormatError(buf.Pos(), ErrTruncated, "unexpected end of message")
+		}
+		if formatErr, ok := err.(*FormatError); ok {
+			formatErr.Format = msg.dataFormat
+		}
+		msg.dataErr = err
+	}
+	return msg.Data, msg.dataErr
 }
 
-func addTimestamp(b []byte, t time.Time) []byte {
-	if t.IsZero() {
-		b = append(b, nilValueByte)
-	} else {
-		b = t.AppendFormat(b, time.RFC3339Nano)
+// String formats the message in a RFC5424 format.
+func (msg *Message) String() string {
+	return string(msg.Bytes())
+}
+
+// Bytes formats the message in a RFC5424 format, see Encoder for more
+// options.
+func (msg *Message) Bytes() []byte {
+	return defaultEncoder.Bytes(msg)
+}
+
+// WriteTo writes the message in a RFC5424 format to w, see Encoder.WriteTo.
+// It implements io.WriterTo.
+func (msg *Message) WriteTo(w io.Writer) (int64, error) {
+	return defaultEncoder.WriteTo(w, msg)
+}
+
+// BytesRFC3164 formats the message in the older BSD format, see
+// Encoder.BytesRFC3164.
+func (msg *Message) BytesRFC3164() []byte {
+	return defaultEncoder.BytesRFC3164(msg)
+}
+
+// AppendFormat is like Bytes, but appends the formatted message to b and
+// returns the extended buffer. It doesn't allocate if b has enough capacity.
+func (msg *Message) AppendFormat(b []byte) []byte {
+	return defaultEncoder.AppendFormat(b, msg)
+}
+
+// MarshalText implements encoding.TextMarshaler, using the RFC5424 format.
+func (msg *Message) MarshalText() ([]byte, error) {
+	return msg.Bytes(), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing the text using
+// the RFC5424 format.
+func (msg *Message) UnmarshalText(text []byte) error {
+	parsed, err := ParseMessage(text, RFC5424)
+	if err != nil {
+		return err
 	}
-	b = append(b, spaceByte)
-	return b
+	*msg = *parsed
+	return nil
 }
 
 // addValue adds a value and a space to the given bytes. If the value is empty
@@ -100,19 +213,20 @@ func addData(b []byte, data map[string]map[string]string) []byte {
 		return b
 	}
 
-	for _, dataID := range getSortedMapMapKeys(data) {
+	var dataIDs, names [maxSortedKeys]string
+	for _, dataID := range getSortedMapMapKeys(data, dataIDs[:0]) {
 		params := data[dataID]
 
 		b = append(b, dataStart)
 		b = append(b, dataID...)
 
 		// Add name and value in the following format: ` name="value"`
-		for _, name := range getSortedMapKeys(params) {
+		for _, name := range getSortedMapKeys(params, names[:0]) {
 			value := params[name]
 			b = append(b, spaceByte)
 			b = append(b, name...)
 			b = append(b, equalByte)
-			b = strconv.AppendQuote(b, value)
+			b = appendParamValue(b, value)
 		}
 
 		b = append(b, dataEnd)
@@ -121,56 +235,205 @@ func addData(b []byte, data map[string]map[string]string) []byte {
 	return b
 }
 
-func getSortedMapKeys(m map[string]string) []string {
-	var keys = make([]string, 0, len(m))
+// appendParamValue appends the qouted param value, escaping the characters
+// required by RFC 5424 (", \ and ]). All other bytes are appended unchanged.
+func appendParamValue(b []byte, value string) []byte {
+	b = append(b, qouteByte)
+	for i := 0; i < len(value); i++ {
+		if isParamEscape(value[i]) {
+			b = append(b, escapeByte)
+		}
+		b = append(b, value[i])
+	}
+	return append(b, qouteByte)
+}
+
+// Maximum number of keys that getSortedMapKeys and getSortedMapMapKeys can
+// sort without allocating, if the given keys are backed by an array this size.
+const maxSortedKeys = 16
+
+// getSortedMapKeys appends the keys of the map to keys and sorts them.
+func getSortedMapKeys(m map[string]string, keys []string) []string {
 	for key := range m {
 		keys = append(keys, key)
 	}
-	sort.Strings(keys)
+	sortStrings(keys)
 	return keys
 }
 
-func getSortedMapMapKeys(m map[string]map[string]string) []string {
-	var keys = make([]string, 0, len(m))
+// getSortedMapMapKeys appends the keys of the map to keys and sorts them.
+func getSortedMapMapKeys(m map[string]map[string]string, keys []string) []string {
 	for key := range m {
 		keys = append(keys, key)
 	}
-	sort.Strings(keys)
+	sortStrings(keys)
 	return keys
 }
 
-// ParseMessage parses a single syslog log.
-func ParseMessage(b []byte, format format) (*Message, error) {
-	buf := newBuffer(b)
+// sortStrings sorts the strings, without allocating for a small number of
+// strings.
+func sortStrings(s []string) {
+	if len(s) > maxSortedKeys {
+		sort.Strings(s)
+		return
+	}
+
+	// Insertion sort.
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && s[j] < s[j-1]; j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
+	}
+}
+
+// ParseMessage parses a single syslog log. If the message doesn't follow the
+// format a *FormatError is returned, if the message ended before the format
+// was completely parsed its kind is ErrTruncated.
+//
+// If an error is returned the message is still returned, but it's incomplete.
+// It holds all the fields parsed before the error occurred, which can be
+// useful for debugging. It never panics, regardless of the input.
+func ParseMessage(b []byte, format Format) (*Message, error) {
+	return parseMessage(b, format, defaultConfig)
+}
+
+// ParseMessageOpts is like ParseMessage, but configured with the given
+// options, see NewParser. Without options it's the same as ParseMessage and
+// doesn't allocate a configuration. With WithLint the format is checked using
+// Lint, returning an InvalidFormatError if any problems are found. Use
+// NewParser to parse multiple messages with the same options.
+func ParseMessageOpts(b []byte, format Format, opts ...Option) (*Message, error) {
+	if len(opts) == 0 {
+		return parseMessage(b, format, defaultConfig)
+	}
+
+	cfg := newConfig(opts)
+	if cfg.lint {
+		if problems := Lint(format); len(problems) != 0 {
+			return &Message{}, InvalidFormatError(problems)
+		}
+	}
+	return parseMessage(b, format, cfg)
+}
+
+func parseMessage(b []byte, format format, cfg *config) (*Message, error) {
+	msg, _, err := parseStages(b, format, cfg)
+	return msg, err
+}
 
-	var msg Message
+// parseStages parses the message, it also returns the index of the stage of
+// the format that failed, or -1 if no stage failed.
+func parseStages(b []byte, format format, cfg *config) (*Message, int, error) {
+	if cfg.TooLong(len(b)) {
+		err := &FormatError{Pos: cfg.maxLength + 1, Err: ErrMessageTooLong,
+			Msg: "message longer than " + strconv.Itoa(cfg.maxLength) + " bytes"}
+		err.setSnippet(b)
+		err.Format = format.name()
+		if cfg.BestEffort() {
+			return &Message{errs: []error{err}}, -1, nil
+		}
+		return &Message{}, -1, err
+	}
+
+	buf := getBuffer(b, cfg)
+	defer putBuffer(buf)
+
+	var msg, saved Message
 	for i, parseFunc := range format {
+		mark := buf.Mark()
+		if cfg.BestEffort() {
+			saved = msg
+		}
+
 		if err := parseFunc(buf, &msg); err != nil {
 			if err == io.EOF {
-				if i >= 16 {
-					break
-				}
-				err = io.ErrUnexpectedEOF
+				err = newFormatError(buf.Pos(), ErrTruncated, "unexpected end of message")
+			}
+			if formatErr, ok := err.(*FormatError); ok {
+				formatErr.setSnippet(b)
+				formatErr.Format = format.name()
+			}
+			if !cfg.BestEffort() {
+				return &msg, i, err
+			}
+
+			msg = saved
+			msg.errs = append(msg.errs, err)
+			if !resync(buf, mark) {
+				break
 			}
-			fmt.Printf("error in func:%d\n %v", i, err.Error())
-			return nil, err
 		}
 	}
 
-	return &msg, nil
+	if len(msg.errs) != 0 && msg.Message == "" {
+		// Don't lose the part of the message that couldn't be parsed.
+		msg.Message = string(bytes.TrimSpace(buf.ReadAll()))
+	}
+	if msg.RawData != "" {
+		msg.dataFormat = format.name()
+	}
+	return &msg, -1, nil
 }
 
-func newFormatError(column int, msg string) error {
-	return errors.New("syslog: format incorrect: " + msg +
-		", at column " + strconv.Itoa(column))
+// resync restores the buffer to mark and moves it to the next space, after a
+// part of the message failed to parse in best-effort mode. It returns false,
+// leaving the buffer at mark, if no space remains.
+func resync(buf *buffer, mark int) bool {
+	buf.Restore(mark)
+	b, err := buf.PeekUntil(spaceByte)
+	if err != nil {
+		return false
+	}
+	buf.Discard(len(b))
+	return true
 }
 
 // Parser parses a single syslog log, with an already defined format.
 type Parser func([]byte) (*Message, error)
 
-// NewParser creates a new parser with the given format.
-func NewParser(format format) Parser {
+// NewParser creates a new parser with the given format, configured with the
+// given options. The returned Parser is safe for concurrent use, unless noted
+// otherwise by one of the options.
+func NewParser(format Format, opts ...Option) Parser {
+	cfg := newConfig(opts)
+	if cfg.lint {
+		if problems := Lint(format); len(problems) != 0 {
+			panic("syslog: format incorrect, " + problems[0].String())
+		}
+	}
+
 	return func(b []byte) (*Message, error) {
-		return ParseMessage(b, format)
+		return parseMessage(b, format, cfg)
+	}
+}
+
+// NewParserNamed is like NewParser, but uses the format registered under the
+// given name, see RegisterFormat. It returns an error if no format is
+// registered under the name.
+func NewParserNamed(name string, opts ...Option) (Parser, error) {
+	format, ok := GetFormat(name)
+	if !ok {
+		return nil, errors.New("syslog: unknown format " + strconv.Quote(name))
 	}
+	return NewParser(format, opts...), nil
+}
+
+// FormatParser is a Parser that also holds the name of its format, see
+// NewFormatParser.
+type FormatParser struct {
+	// Parse parses a single syslog log, see Parser.
+	Parse  Parser
+	format string
+}
+
+// NewFormatParser is like NewParser, but returns a FormatParser, which
+// includes the name of the format, e.g. for diagnostics when using multiple
+// formats.
+func NewFormatParser(format Format, opts ...Option) FormatParser {
+	return FormatParser{Parse: NewParser(format, opts...), format: format.String()}
+}
+
+// Format returns the name of the format of the parser, see Format.String.
+func (p FormatParser) Format() string {
+	return p.format
 }
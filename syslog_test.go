@@ -5,8 +5,11 @@
 package syslog
 
 import (
+	"encoding"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -39,45 +42,364 @@ var (
 	longInputNginxAccess    = []byte(fmt.Sprintf(`<190>Dec 31 23:59:59 %s nginx: [request %s=%q %s=%q]`,
 		longHostname, longParamName, longParamValue, longParamName2, longParamValue2))
 
-	minimumInputNginxError = []byte("<184>Jan  1 01:01:01 h a: 0001/01/01 01:01:01 [Emergency] m, c: c, s: s, r: r, h: h")
-	regularInputNginxError = []byte(`<186>Jan  1 01:01:01 hostname nginx: 0001/01/01 01:01:01 [Error] message, client: 192.168.1.255, server: localhost, request: "GET / HTTP/1.1", host: "192.168.1.254"`)
+	minimumInputNginxError = []byte("<184>Jan  1 01:01:01 h a: 2015/01/01 01:01:01 [Emergency] m, c: c, s: s, r: r, h: h")
+	regularInputNginxError = []byte(`<186>Jan  1 01:01:01 hostname nginx: 2015/01/01 01:01:01 [Error] message, client: 192.168.1.255, server: localhost, request: "GET / HTTP/1.1", host: "192.168.1.254"`)
 	longInputNginxError    = []byte(fmt.Sprintf(`<191>Dec 31 23:59:59 %s nginx: 2015/12/31 23:59:59 [Debug] %s, client: %s, server: %s, request: %q, host: %q`,
 		longHostname, longMessage, longClient, longServer, longRequest, longHost))
 
+	minimumInputCEF = []byte("<134>Jan  1 01:01:01 h CEF:0|v|p|1|s|n|0|")
+	regularInputCEF = []byte(`<134>Oct 13 12:31:40 dsm CEF:0|Trend Micro|Deep Security Agent|10.0.0|4000000|Eicar_test_file|6|` +
+		`cn1=1 cn1Label=Host ID dvchost=hostname filePath=C:\\Users\\trend\\Desktop\\eicar.exe act=Delete msg=Realtime`)
+
+	minimumInputLEEF = []byte("<13>Jan  1 01:01:01 h LEEF:1.0|v|p|1|e|")
+	regularInputLEEF = []byte("<13>Oct 13 12:31:40 host LEEF:2.0|Lancope|StealthWatch|1.0|41|^|" +
+		"src=192.0.2.0^dst=172.50.123.1^sev=5^cat=anomaly^srcPort=81^dstPort=21^usrName=joe.black")
+
+	minimumInputApacheAccess = []byte(`<134>Jan  1 01:01:01 h a: 1 - - [01/Jan/2015:01:01:01 +0000] "" 200 -`)
+	regularInputApacheAccess = []byte(`<134>Oct 13 12:31:40 hostname apache: 192.168.1.255 - frank [13/Oct/2015:12:31:40 +0200] "GET /index.html HTTP/1.1" 200 2326 "http://example.com/start.html" "Mozilla/5.0 (X11; Linux x86_64)"`)
+
+	minimumInputApacheError = []byte(`<131>Jan  1 01:01:01 h a: [error]`)
+	regularInputApacheError = []byte(`<131>Oct 13 12:31:40 hostname apache: [Tue Oct 13 12:31:40.123456 2015] [core:error] [pid 1187:tid 140] [client 1.2.3.4:5678] AH00126: Invalid URI in request`)
+
+	minimumInputPostfix = []byte(`<22>Jan  1 01:01:01 h a: `)
+	regularInputPostfix = []byte(`<22>Oct 13 12:31:40 hostname postfix/smtp[1234]: 3F2A41C0A5: to=<a@example.com>, relay=mx.example.com[1.2.3.4]:25, delay=0.5, dsn=2.0.0, status=sent (250 2.0.0 OK, queued as 4B1C2D)`)
+
+	minimumInputSSHD = []byte(`<38>Jan  1 01:01:01 h a: `)
+	regularInputSSHD = []byte(`<38>Oct 13 12:31:40 hostname sshd[4711]: Accepted publickey for root from 10.0.0.1 port 22 ssh2: ED25519 SHA256:Vh2KwMj2g6lKs7IdhEw0aC0ZzL2YrQ1gHdDb3n2Rz4s`)
+
+	minimumInputSudo = []byte(`<85>Jan  1 01:01:01 h a:`)
+	regularInputSudo = []byte(`<85>Oct 13 12:31:40 hostname sudo:    alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/bin/systemctl restart nginx`)
+
+	minimumInputCron = []byte(`<78>Jan  1 01:01:01 h a: `)
+	regularInputCron = []byte(`<78>Oct 13 12:31:40 hostname CRON[1234]: (root) CMD (run-parts /etc/cron.hourly)`)
+
+	minimumInputNetfilter = []byte(`<4>Jan  1 01:01:01 h a: IN=`)
+	regularInputNetfilter = []byte(`<4>Oct 13 12:31:40 hostname kernel: [12345.678] IPTABLES-DROP: IN=eth0 OUT= MAC=00:11:22:33:44:55:66:77:88:99:aa:bb:08:00 SRC=1.2.3.4 DST=5.6.7.8 LEN=60 TOS=0x00 PREC=0x00 TTL=52 ID=54321 DF PROTO=TCP SPT=51515 DPT=22 WINDOW=29200 RES=0x00 SYN URGP=0`)
+
+	minimumInputDmesg = []byte(`<6>`)
+	regularInputDmesg = []byte(`<6>[ 8940.406075] usb 1-1: new high-speed USB device number 2 using xhci_hcd`)
+
+	minimumInputJournalJSON = []byte(`{}`)
+	regularInputJournalJSON = []byte(`{"__CURSOR":"s=6d1a;i=1","__REALTIME_TIMESTAMP":"1444739500123456","__MONOTONIC_TIMESTAMP":"8940406075","_BOOT_ID":"5b3d7f1e","PRIORITY":"3","SYSLOG_FACILITY":"3","SYSLOG_IDENTIFIER":"systemd","_PID":"1","_COMM":"systemd","_HOSTNAME":"hostname","_SYSTEMD_UNIT":"init.scope","MESSAGE_ID":"be02cf6855d2428ba40df7e9d022f03d","UNIT":"nginx.service","MESSAGE":"Failed to start A high performance web server."}`)
+
+	minimumInputDocker = []byte(`<30>Jan  1 01:01:01 h a:`)
+	regularInputDocker = []byte(`<30>1 2015-10-13T12:31:40.123456+02:00 hostname docker/web 1234 docker/web - GET / HTTP/1.1 200`)
+
+	minimumInputLogplex = []byte(`14 <0>1 - - - - -`)
+	regularInputLogplex = []byte(`176 <158>1 2012-11-30T06:45:29.123456+00:00 host heroku router - at=info method=GET path="/" host=example.herokuapp.com fwd="1.2.3.4" dyno=web.1 connect=1ms service=18ms status=200`)
+
+	minimumInputRFC3164 = []byte(`<30>Jan  1 01:01:01 a:`)
+	regularInputRFC3164 = []byte(`<30>Oct 13 12:31:40 hostname app[123]: message`)
+
+	minimumInputFortiGate = []byte(`<189>a=`)
+	regularInputFortiGate = []byte(`<189>date=2015-10-13 time=12:31:40 devname="FGT60E" devid="FGT60E4Q16000000" logid="0000000013" type="traffic" subtype="forward" level="notice" vd="root" srcip=192.168.1.10 srcport=51515 srcintf="internal" dstip=93.184.216.34 dstport=443 dstintf="wan1" proto=6 action="accept" policyid=1 service="HTTPS" sentbyte=1234 rcvdbyte=5678`)
+
+	minimumInputFilterlog = []byte(`<134>Jan  1 01:01:01 h a: ,,,,,,,,6,,,,,,,,`)
+	regularInputFilterlog = []byte(`<134>Oct 13 12:31:40 hostname filterlog[12345]: 5,,,1000000103,igb0,match,block,in,4,0x0,,64,12345,0,DF,6,tcp,60,192.168.1.10,10.0.0.1,51515,22,0,S,123456789,,64240,,mss;sackOK;TS;nop;wscale`)
+
+	minimumInputDovecot = []byte(`<22>Jan  1 01:01:01 h a: `)
+	regularInputDovecot = []byte(`<22>Oct 13 12:31:40 hostname dovecot: imap-login: Login: user=<alice>, method=PLAIN, rip=1.2.3.4, lip=10.0.0.1, mpid=1234, TLS, session=<Lx9a2b3cQeAKAAAB>`)
+
+	minimumInputPostgres = []byte(`<134>Jan  1 01:01:01 h a: `)
+	regularInputPostgres = []byte(`<134>Oct 13 12:31:40 hostname postgres[1234]: [3-1] user=app,db=prod LOG:  duration: 1.234 ms  statement: SELECT id, name FROM users WHERE id = 1`)
+
+	minimumInputMySQLError = []byte(`<27>Jan  1 01:01:01 h a: 150101 1:01:01 [Note]`)
+	regularInputMySQLError = []byte(`<27>Oct 13 12:31:40 hostname mysqld: 2015-10-13T12:31:40.123456Z 12 [Warning] [MY-010055] [Server] IP address '1.2.3.4' could not be resolved: Name or service not known`)
+
+	minimumInputRedis = []byte(`<30>Jan  1 01:01:01 h a: 1:M 1 Jan 2015 01:01:01 *`)
+	regularInputRedis = []byte(`<30>Oct 13 12:31:40 hostname redis: 1187:C 13 Oct 2015 12:31:40.123 * DB saved on disk`)
+
+	minimumInputPHPFPM = []byte(`<28>Jan  1 01:01:01 h a: `)
+	regularInputPHPFPM = []byte(`<28>Oct 13 12:31:40 hostname php-fpm: [13-Oct-2015 12:31:40] WARNING: [pool www] child 1187 said into stderr: "PHP message: PHP Warning:  Undefined variable $name in /var/www/index.php on line 3"`)
+
+	minimumInputGunicornAccess = []byte(`<134>Jan  1 01:01:01 h a: 1 - - [01/Jan/2015:01:01:01 +0000] "" 200 -`)
+	regularInputGunicornAccess = []byte(`<134>Oct 13 12:31:40 hostname gunicorn.access: 2001:db8::1 - - [13/Oct/2015:12:31:40 +0200] "GET /api?page=2 HTTP/1.1" 200 123 "-" "python-requests/2.7.0" 4567`)
+
+	minimumInputUWSGIAccess = []byte(`<134>Jan  1 01:01:01 h a: [] 1 () {} [Thu Jan  1 01:01:01 2015] GET / => generated 0 bytes in 0 msecs (HTTP/1.1 200)`)
+	regularInputUWSGIAccess = []byte(`<134>Oct 13 12:31:40 hostname uwsgi[1186]: [pid: 1187|app: 0|req: 1/1] 2001:db8::1 () {34 vars in 512 bytes} [Tue Oct 13 12:31:40 2015] GET /api => generated 123 bytes in 12 msecs (HTTP/1.1 200) 2 headers in 79 bytes (1 switches on core 0)`)
+
+	minimumInputEnvoyAccess = []byte(`<134>Jan  1 01:01:01 h a: [2015-01-01T01:01:01Z] "-" 0 - 0 0 0 - "-" "-" "-" "-" "-"`)
+	regularInputEnvoyAccess = []byte(`<134>Oct 13 12:31:40 hostname envoy[1]: [2015-10-13T12:31:40.123Z] "GET /api/users?page=2 HTTP/1.1" 200 - 0 1234 5 4 "10.0.0.1" "curl/7.43.0" "5f1d6a84-7b5c-4f0c-9b1f-1c2d3e4f5a6b" "api.example.com" "10.0.0.5:8080"`)
+
+	minimumInputJSONCaddy = []byte(`{}`)
+	regularInputJSONCaddy = []byte(`{"level":"info","ts":1444739500.1234567,"logger":"http.log.access.log0","msg":"handled request","request":{"remote_ip":"2001:db8::1","remote_port":"51515","proto":"HTTP/2.0","method":"GET","host":"example.com","uri":"/api?page=2","headers":{"User-Agent":["curl/7.43.0"],"Accept":["*/*"]},"tls":{"resumed":false,"version":772,"server_name":"example.com"}},"bytes_read":0,"user_id":"","duration":0.003027,"size":1234,"status":200,"resp_headers":{"Server":["Caddy"],"Content-Type":["application/json"]}}`)
+
+	minimumInputJSONTraefik = []byte(`{"time":"2015-01-01T01:01:01Z"}`)
+	regularInputJSONTraefik = []byte(`{"ClientAddr":"10.0.0.1:51515","ClientHost":"10.0.0.1","ClientPort":"51515","ClientUsername":"-","DownstreamContentSize":1234,"DownstreamStatus":200,"Duration":3027000,"OriginContentSize":1234,"OriginDuration":2500000,"OriginStatus":200,"Overhead":527000,"RequestAddr":"example.com","RequestContentSize":0,"RequestCount":42,"RequestHost":"example.com","RequestMethod":"GET","RequestPath":"/api?page=2","RequestPort":"-","RequestProtocol":"HTTP/1.1","RequestScheme":"https","RetryAttempts":0,"RouterName":"api@docker","ServiceAddr":"172.17.0.3:8080","ServiceName":"api@docker","ServiceURL":{"Scheme":"http","Host":"172.17.0.3:8080"},"StartLocal":"2015-10-13T14:31:40.123456789+02:00","StartUTC":"2015-10-13T12:31:40.123456789Z","TLSCipher":"TLS_AES_128_GCM_SHA256","TLSVersion":"1.3","entryPointName":"websecure","level":"info","msg":"","time":"2015-10-13T12:31:40Z"}`)
+
+	minimumInputALBAccess = []byte(`http 2015-01-01T01:01:01Z - - - 0 0 0 0 - 0 0 "- - - " "-" - - - "-"`)
+	regularInputALBAccess = []byte(`https 2015-10-13T12:31:40.123456Z app/my-loadbalancer/50dc6c495c0c9188 192.168.131.39:2817 10.0.0.1:80 0.086 0.048 0.037 200 200 0 57 "GET https://www.example.com:443/ HTTP/1.1" "curl/7.46.0" ECDHE-RSA-AES128-GCM-SHA256 TLSv1.2 arn:aws:elasticloadbalancing:us-east-2:123456789012:targetgroup/my-targets/73e2d6bc24d8a067 "Root=1-58337281-1d84f3d73c47ec4e58577259" "www.example.com" "arn:aws:acm:us-east-2:123456789012:certificate/12345678-1234-1234-1234-123456789012" 1 2015-10-13T12:31:40.037000Z "authenticate,forward" "-" "-" "10.0.0.1:80" "200" "-" "-" TID_1234abcd5678ef90`)
+
+	minimumInputELBAccess = []byte(`2015-01-01T01:01:01Z - - - 0 0 0 0 - 0 0 "- - - "`)
+	regularInputELBAccess = []byte(`2015-10-13T12:31:40.123456Z my-loadbalancer 192.168.131.39:2817 10.0.0.1:80 0.000073 0.001048 0.000057 200 200 0 29 "GET https://www.example.com:443/ HTTP/1.1" "curl/7.38.0" DHE-RSA-AES128-SHA TLSv1.2`)
+
+	minimumInputAudit = []byte(`<0>Jan  1 01:01:01 h a: type=A audit(1420074061:1):`)
+	regularInputAudit = []byte(`<86>Oct 13 12:31:40 hostname audit[1187]: type=SYSCALL msg=audit(1444733500.123:456): arch=c000003e syscall=59 success=yes exit=0 a0=55d5c4f0a2b8 a1=55d5c4f0a2e0 a2=55d5c4f0a300 a3=0 items=2 ppid=1186 pid=1187 auid=1000 uid=0 gid=0 euid=0 suid=0 fsuid=0 egid=0 sgid=0 fsgid=0 tty=pts0 ses=3 comm="ls" exe="/usr/bin/ls" subj=unconfined key="exec-log"`)
+
+	minimumInputFail2ban = []byte(`<0>Jan  1 01:01:01 h a: info`)
+	regularInputFail2ban = []byte(`<38>Oct 13 12:31:40 hostname fail2ban.actions        [1187]: NOTICE  [sshd] Ban 203.0.113.7`)
+
+	minimumInputKeepalived = []byte(`<0>Jan  1 01:01:01 h a: `)
+	regularInputKeepalived = []byte(`<30>Oct 13 12:31:40 hostname Keepalived_vrrp[1187]: VRRP_Instance(VI_1) Transition to MASTER STATE`)
+
 	locationCEST, _ = time.LoadLocation("Europe/Amsterdam")
 	locationLINT, _ = time.LoadLocation("Pacific/Kiritimati")
 )
 
+// RFC5424 inputs and the expected parsed messages.
+var rfc5424Tests = []struct {
+	Input    string
+	Expected *Message
+}{
+	{string(minimumInputRFC5424), &Message{}},
+	{
+		string(regularInputRFC5424),
+		&Message{
+			Priority:  CalculatePriority(Local7, Debug),
+			Facility:  Local7,
+			Severity:  Debug,
+			Version:   10,
+			Timestamp: time.Date(2015, 9, 30, 23, 10, 11, 0, locationCEST),
+			Hostname:  "hostname",
+			Appname:   "appname",
+			ProcessID: "procid",
+			MessageID: "msgid",
+			Data: map[string]map[string]string{
+				"data": {
+					"name": "value",
+				},
+			},
+			Message: "message",
+		},
+	},
+	{
+		`<191>10 2015-09-30T23:10:11+02:00 hostname appname procid msgid [data]`,
+		&Message{
+			Priority:  CalculatePriority(Local7, Debug),
+			Facility:  Local7,
+			Severity:  Debug,
+			Version:   10,
+			Timestamp: time.Date(2015, 9, 30, 23, 10, 11, 0, locationCEST),
+			Hostname:  "hostname",
+			Appname:   "appname",
+			ProcessID: "procid",
+			MessageID: "msgid",
+			Data: map[string]map[string]string{
+				"data": {},
+			},
+		},
+	},
+	{
+		`<191>1 - - - - - - x`,
+		&Message{
+			Priority: CalculatePriority(Local7, Debug),
+			Facility: Local7,
+			Severity: Debug,
+			Version:  1,
+			Message:  "x",
+		},
+	},
+	{
+		`<191>1 - - - - - - `,
+		&Message{
+			Priority: CalculatePriority(Local7, Debug),
+			Facility: Local7,
+			Severity: Debug,
+			Version:  1,
+		},
+	},
+	{
+		"<191>1 - - - - - [data] \t ",
+		&Message{
+			Priority: CalculatePriority(Local7, Debug),
+			Facility: Local7,
+			Severity: Debug,
+			Version:  1,
+			Data:     map[string]map[string]string{"data": {}},
+		},
+	},
+	{
+		`<191>1 2015-09-30T23:10:11Z hostname - - - -`,
+		&Message{
+			Priority:  CalculatePriority(Local7, Debug),
+			Facility:  Local7,
+			Severity:  Debug,
+			Version:   1,
+			Timestamp: time.Date(2015, 9, 30, 23, 10, 11, 0, time.UTC),
+			Hostname:  "hostname",
+		},
+	},
+	{
+		`<191>1 2015-09-30T23:10:11.123+02:00 hostname - - - -`,
+		&Message{
+			Priority:  CalculatePriority(Local7, Debug),
+			Facility:  Local7,
+			Severity:  Debug,
+			Version:   1,
+			Timestamp: time.Date(2015, 9, 30, 23, 10, 11, 123000000, locationCEST),
+			Hostname:  "hostname",
+		},
+	},
+	{
+		`<9>1 2000-01-01T01:01:01+00:00 h a p m [d n="v"] m`,
+		&Message{
+			Priority:  CalculatePriority(UserLevel, Alert),
+			Facility:  UserLevel,
+			Severity:  Alert,
+			Version:   1,
+			Timestamp: time.Date(2000, 1, 1, 1, 1, 1, 0, time.UTC),
+			Hostname:  "h",
+			Appname:   "a",
+			ProcessID: "p",
+			MessageID: "m",
+			Data: map[string]map[string]string{
+				"d": {
+					"n": "v",
+				},
+			},
+			Message: "m",
+		},
+	},
+	{
+		string(longInputRFC5424),
+		&Message{
+			Priority:  CalculatePriority(Local7, Debug),
+			Facility:  Local7,
+			Severity:  Debug,
+			Version:   99,
+			Timestamp: time.Date(3000, 12, 31, 23, 59, 59, 999999999, locationLINT),
+			Hostname:  longHostname,
+			Appname:   longAppname,
+			ProcessID: longProcID,
+			MessageID: longMsgID,
+			Data: map[string]map[string]string{
+				longDataID: {
+					longParamName: longParamValue,
+				},
+				longDataID2: {
+					longParamName:  longParamValue,
+					longParamName2: longParamValue2,
+				},
+			},
+			Message: longMessage,
+		},
+	},
+}
+
 func TestParseMessageRFC5424(t *testing.T) {
 	t.Parallel()
 
+	for _, test := range rfc5424Tests {
+		got, err := ParseMessage([]byte(test.Input), RFC5424)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q, RFC5424): %s",
+				test.Input, err.Error())
+		}
+
+		if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, RFC5424) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageRFC5424Lazy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input        string
+		ExpectedData map[string]map[string]string
+		ExpectedErr  error
+	}{
+		{string(minimumInputRFC5424), nil, nil},
+		{string(regularInputRFC5424), map[string]map[string]string{"data": {"name": "value"}}, nil},
+		{`<191>1 - - - - - [data]`, map[string]map[string]string{"data": {}}, nil},
+		{`<191>1 - - - - - [data name="value"][data2 name2="value2"] msg`, map[string]map[string]string{
+			"data":  {"name": "value"},
+			"data2": {"name2": "value2"},
+		}, nil},
+		{`<191>1 - - - - - [data name="value"x] msg`, nil, &FormatError{Pos: 36, Format: "RFC5424Lazy",
+			Msg: "expected byte ']' or ' ', but got 'x'"}},
+	}
+
+	for _, test := range tests {
+		expected, err := ParseMessage([]byte(test.Input), RFC5424)
+		if err != nil && test.ExpectedErr == nil {
+			t.Fatalf("Unexpected error ParseMessage(%q, RFC5424): %s", test.Input, err)
+		}
+
+		msg, err := ParseMessage([]byte(test.Input), RFC5424Lazy)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q, RFC5424Lazy): %s", test.Input, err)
+		} else if msg.Data != nil {
+			t.Fatalf("Expected ParseMessage(%q, RFC5424Lazy) to not parse the data, "+
+				"but got %v", test.Input, msg.Data)
+		}
+
+		for i := 0; i < 2; i++ { // Second time is cached.
+			data, err := msg.ParsedData()
+			if test.ExpectedErr != nil {
+				if err == nil || err.Error() != test.ExpectedErr.Error() {
+					t.Fatalf("Expected msg.ParsedData() to return error %q, but got %v",
+						test.ExpectedErr, err)
+				}
+				continue
+			} else if err != nil {
+				t.Fatalf("Unexpected error msg.ParsedData(): %s", err)
+			}
+
+			if !reflect.DeepEqual(data, test.ExpectedData) {
+				t.Fatalf("Expected msg.ParsedData() to return %v, but got %v",
+					test.ExpectedData, data)
+			}
+		}
+
+		if test.ExpectedErr != nil {
+			continue
+		}
+
+		// Apart from the raw data the messages should be equal.
+		msg.RawData, msg.dataPos = "", 0
+		if !messagesAreEqual(msg, expected) {
+			t.Fatalf("Expected ParseMessage(%q, RFC5424Lazy) to return Message %#v, but got %#v",
+				test.Input, expected, msg)
+		}
+	}
+}
+
+func TestParsedDataOptions(t *testing.T) {
+	t.Parallel()
+
+	const input = `<191>1 - - - - - [d a="1" b="2"][d a="3"] msg`
+	opts := []Option{WithMaxParams(1), WithStrict()}
+	_, expected := NewParser(RFC5424, opts...)([]byte(input))
+	formatErr, ok := expected.(*FormatError)
+	if !ok {
+		t.Fatalf("Expected parse(%q, RFC5424) to return a format error, but got %v", input, expected)
+	}
+	// ParsedData doesn't have the entire message.
+	formatErr.Format, formatErr.Snippet = "RFC5424Lazy", nil
+
+	msg, err := NewParser(RFC5424Lazy, opts...)([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error parse(%q, RFC5424Lazy): %s", input, err)
+	}
+	if _, err := msg.ParsedData(); err == nil || err.Error() != expected.Error() {
+		t.Fatalf("Expected msg.ParsedData() to return error %q, but got %v", expected, err)
+	}
+}
+
+func TestParseMessagePartial(t *testing.T) {
+	t.Parallel()
+
 	tests := []struct {
 		Input    string
 		Expected *Message
 	}{
-		{string(minimumInputRFC5424), &Message{}},
-		{
-			string(regularInputRFC5424),
-			&Message{
-				Priority:  CalculatePriority(Local7, Debug),
-				Facility:  Local7,
-				Severity:  Debug,
-				Version:   10,
-				Timestamp: time.Date(2015, 9, 30, 23, 10, 11, 0, locationCEST),
-				Hostname:  "hostname",
-				Appname:   "appname",
-				ProcessID: "procid",
-				MessageID: "msgid",
-				Data: map[string]map[string]string{
-					"data": {
-						"name": "value",
-					},
-				},
-				Message: "message",
-			},
-		},
+		{"", &Message{}},
+		{"<1923>", &Message{}},
 		{
-			`<191>10 2015-09-30T23:10:11+02:00 hostname appname procid msgid [data]`,
+			`<191>10 2015-09-30T23:10:11+02:00 hostname appname procid msgid [data name="value"x] message`,
 			&Message{
 				Priority:  CalculatePriority(Local7, Debug),
 				Facility:  Local7,
@@ -88,66 +410,28 @@ func TestParseMessageRFC5424(t *testing.T) {
 				Appname:   "appname",
 				ProcessID: "procid",
 				MessageID: "msgid",
-				Data: map[string]map[string]string{
-					"data": {},
-				},
-			},
-		},
-		{
-			`<9>1 2000-01-01T01:01:01+00:00 h a p m [d n="v"] m`,
-			&Message{
-				Priority:  CalculatePriority(UserLevel, Alert),
-				Facility:  UserLevel,
-				Severity:  Alert,
-				Version:   1,
-				Timestamp: time.Date(2000, 1, 1, 1, 1, 1, 0, time.UTC),
-				Hostname:  "h",
-				Appname:   "a",
-				ProcessID: "p",
-				MessageID: "m",
-				Data: map[string]map[string]string{
-					"d": {
-						"n": "v",
-					},
-				},
-				Message: "m",
 			},
 		},
 		{
-			string(longInputRFC5424),
+			"<191>10 2015-09-30 hostname appname procid msgid - message",
 			&Message{
-				Priority:  CalculatePriority(Local7, Debug),
-				Facility:  Local7,
-				Severity:  Debug,
-				Version:   99,
-				Timestamp: time.Date(3000, 12, 31, 23, 59, 59, 999999999, locationLINT),
-				Hostname:  longHostname,
-				Appname:   longAppname,
-				ProcessID: longProcID,
-				MessageID: longMsgID,
-				Data: map[string]map[string]string{
-					longDataID: {
-						longParamName: longParamValue,
-					},
-					longDataID2: {
-						longParamName:  longParamValue,
-						longParamName2: longParamValue2,
-					},
-				},
-				Message: longMessage,
+				Priority: CalculatePriority(Local7, Debug),
+				Facility: Local7,
+				Severity: Debug,
+				Version:  10,
 			},
 		},
 	}
 
 	for _, test := range tests {
 		got, err := ParseMessage([]byte(test.Input), RFC5424)
-		if err != nil {
-			t.Fatalf("Unexpected error ParseMessage(%q, RFC5424): %s",
-				test.Input, err.Error())
+		if err == nil {
+			t.Fatalf("Expected ParseMessage(%q, RFC5424) to return an error, but got nil",
+				test.Input)
 		}
 
 		if !messagesAreEqual(got, test.Expected) {
-			t.Fatalf("Expected ParseMessage(%q, RFC5424) to return Message %#v, but got %#v",
+			t.Fatalf("Expected ParseMessage(%q, RFC5424) to return incomplete Message %#v, but got %#v",
 				test.Input, test.Expected, got)
 		}
 	}
@@ -156,8 +440,6 @@ func TestParseMessageRFC5424(t *testing.T) {
 func TestParseMessageNginxAccess(t *testing.T) {
 	t.Parallel()
 
-	var now = time.Now()
-
 	tests := []struct {
 		Input    string
 		Expected *Message
@@ -168,7 +450,7 @@ func TestParseMessageNginxAccess(t *testing.T) {
 				Priority:  CalculatePriority(Local7, Informational),
 				Facility:  Local7,
 				Severity:  Informational,
-				Timestamp: time.Date(now.Year(), 1, 1, 1, 1, 1, 0, now.Location()),
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
 				Hostname:  "h",
 				Appname:   "a",
 				Data: map[string]map[string]string{
@@ -182,7 +464,7 @@ func TestParseMessageNginxAccess(t *testing.T) {
 				Priority:  CalculatePriority(Local7, Informational),
 				Facility:  Local7,
 				Severity:  Informational,
-				Timestamp: time.Date(now.Year(), 1, 1, 1, 1, 1, 0, now.Location()),
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
 				Hostname:  "hostname",
 				Appname:   "nginx",
 				Data: map[string]map[string]string{
@@ -202,7 +484,7 @@ func TestParseMessageNginxAccess(t *testing.T) {
 				Priority:  CalculatePriority(Local7, Informational),
 				Facility:  Local7,
 				Severity:  Informational,
-				Timestamp: time.Date(now.Year(), 10, 5, 12, 05, 15, 0, now.Location()),
+				Timestamp: inferredDate(10, 5, 12, 05, 15, time.Local),
 				Hostname:  "hostname",
 				Appname:   "nginx",
 				Data: map[string]map[string]string{
@@ -226,7 +508,7 @@ func TestParseMessageNginxAccess(t *testing.T) {
 				Priority:  CalculatePriority(Local7, Informational),
 				Facility:  Local7,
 				Severity:  Informational,
-				Timestamp: time.Date(now.Year(), 10, 13, 10, 06, 04, 0, now.Location()),
+				Timestamp: inferredDate(10, 13, 10, 06, 04, time.Local),
 				Hostname:  "hostname",
 				Appname:   "nginx",
 				Data: map[string]map[string]string{
@@ -255,7 +537,7 @@ func TestParseMessageNginxAccess(t *testing.T) {
 				Priority:  CalculatePriority(Local7, Informational),
 				Facility:  Local7,
 				Severity:  Informational,
-				Timestamp: time.Date(now.Year(), 10, 13, 17, 55, 29, 0, now.Location()),
+				Timestamp: inferredDate(10, 13, 17, 55, 29, time.Local),
 				Hostname:  "hostname",
 				Appname:   "nginx",
 				Data: map[string]map[string]string{
@@ -285,7 +567,7 @@ func TestParseMessageNginxAccess(t *testing.T) {
 				Priority:  CalculatePriority(Local7, Informational),
 				Facility:  Local7,
 				Severity:  Informational,
-				Timestamp: time.Date(now.Year(), 12, 31, 23, 59, 59, 0, now.Location()),
+				Timestamp: inferredDate(12, 31, 23, 59, 59, time.Local),
 				Hostname:  longHostname,
 				Appname:   "nginx",
 				Data: map[string]map[string]string{
@@ -296,6 +578,103 @@ func TestParseMessageNginxAccess(t *testing.T) {
 				},
 			},
 		},
+		{
+			`<190>Oct  5 12:05:15 hostname nginx: [request http_user_agent="Mozilla/5.0 \x22Quoted\x22 (X11; Linux)" http_referer="https://example.com/a b?q=\x22c d\x22" status="200"]`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Informational),
+				Facility:  Local7,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 5, 12, 05, 15, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				Data: map[string]map[string]string{
+					"request": {
+						"http_user_agent": `Mozilla/5.0 "Quoted" (X11; Linux)`,
+						"http_referer":    `https://example.com/a b?q="c d"`,
+						"status":          "200",
+					},
+				},
+			},
+		},
+		{
+			// escape=json.
+			`<190>Oct  5 12:05:15 hostname nginx: [request http_user_agent="say \"hi\"\n\tbye\\ \/ é \]" request_body="{\"a\":[1]}" status="200"]`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Informational),
+				Facility:  Local7,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 5, 12, 05, 15, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				Data: map[string]map[string]string{
+					"request": {
+						"http_user_agent": "say \"hi\"\n\tbye\\ / é ]",
+						"request_body":    `{"a":[1]}`,
+						"status":          "200",
+					},
+				},
+			},
+		},
+		{
+			`<190>Oct  5 12:05:15 hostname nginx[1187]: [request status="200"]`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Informational),
+				Facility:  Local7,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 5, 12, 05, 15, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187",
+				Data: map[string]map[string]string{
+					"request": {
+						"status": "200",
+					},
+				},
+			},
+		},
+		{
+			`<190>Oct  5 12:05:15 hostname nginx: [request request_time="0.010" status="200"][upstream upstream_addr="10.0.0.5:8080" upstream_response_time="0.008"]`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Informational),
+				Facility:  Local7,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 5, 12, 05, 15, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				Data: map[string]map[string]string{
+					"request": {
+						"request_time": "0.010",
+						"status":       "200",
+					},
+					"upstream": {
+						"upstream_addr":          "10.0.0.5:8080",
+						"upstream_response_time": "0.008",
+					},
+				},
+			},
+		},
+		{
+			`<190>Oct  5 12:05:15 hostname nginx: [request status="200"] [upstream upstream_status="200"]	 [cache upstream_cache_status="HIT"] `,
+			&Message{
+				Priority:  CalculatePriority(Local7, Informational),
+				Facility:  Local7,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 5, 12, 05, 15, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				Data: map[string]map[string]string{
+					"request": {
+						"status": "200",
+					},
+					"upstream": {
+						"upstream_status": "200",
+					},
+					"cache": {
+						"upstream_cache_status": "HIT",
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -315,8 +694,6 @@ func TestParseMessageNginxAccess(t *testing.T) {
 func TestParseMessageNginxError(t *testing.T) {
 	t.Parallel()
 
-	var now = time.Now()
-
 	tests := []struct {
 		Input    string
 		Expected *Message
@@ -327,7 +704,7 @@ func TestParseMessageNginxError(t *testing.T) {
 				Priority:  CalculatePriority(Local7, Emergency),
 				Facility:  Local7,
 				Severity:  Emergency,
-				Timestamp: time.Date(now.Year(), 1, 1, 1, 1, 1, 0, now.Location()),
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.Local),
 				Hostname:  "h",
 				Appname:   "a",
 				Message:   `m`,
@@ -344,10 +721,10 @@ func TestParseMessageNginxError(t *testing.T) {
 		{
 			string(regularInputNginxError),
 			&Message{
-				Priority:  CalculatePriority(Local7, Critical),
+				Priority:  CalculatePriority(Local7, Error),
 				Facility:  Local7,
-				Severity:  Critical,
-				Timestamp: time.Date(now.Year(), 1, 1, 1, 1, 1, 0, now.Location()),
+				Severity:  Error,
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.Local),
 				Hostname:  "hostname",
 				Appname:   "nginx",
 				Message:   `message`,
@@ -367,7 +744,7 @@ func TestParseMessageNginxError(t *testing.T) {
 				Priority:  CalculatePriority(Local7, Debug),
 				Facility:  Local7,
 				Severity:  Debug,
-				Timestamp: time.Date(now.Year(), 12, 31, 23, 59, 59, 0, now.Location()),
+				Timestamp: time.Date(2015, 12, 31, 23, 59, 59, 0, time.Local),
 				Hostname:  longHostname,
 				Appname:   "nginx",
 				Message:   longMessage,
@@ -387,148 +764,571 @@ func TestParseMessageNginxError(t *testing.T) {
 				Priority:  CalculatePriority(Local7, Error),
 				Facility:  Local7,
 				Severity:  Error,
-				Timestamp: time.Date(now.Year(), 10, 13, 12, 31, 40, 0, now.Location()),
+				Timestamp: time.Date(2015, 10, 13, 1, 31, 40, 0, time.Local),
 				Hostname:  "hostname",
 				Appname:   "nginx",
-				Message:   `1187#1187: *46 open() "/usr/share/nginx/html/test" failed (2: No such file or directory)`,
+				ProcessID: "1187#1187",
+				Message:   `open() "/usr/share/nginx/html/test" failed (2: No such file or directory)`,
 				Data: map[string]map[string]string{
 					"data": {
-						"client":  "192.168.1.255",
-						"server":  "localhost",
-						"request": "GET /test HTTP/1.1",
-						"host":    "192.168.1.254",
+						"connection": "46",
+						"client":     "192.168.1.255",
+						"server":     "localhost",
+						"request":    "GET /test HTTP/1.1",
+						"host":       "192.168.1.254",
 					},
 				},
 			},
 		},
-	}
-
-	for _, test := range tests {
-		got, err := ParseMessage([]byte(test.Input), NginxError)
-		if err != nil {
-			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err.Error())
-		}
-
-		if !messagesAreEqual(got, test.Expected) {
-			t.Fatalf("Expected ParseMessage(%q, NginxError) to return Message %#v, but got %#v",
-				test.Input, test.Expected, got)
-		}
-	}
-}
-
-func TestParser(t *testing.T) {
-	t.Parallel()
-
-	tests := []struct {
-		Input    string
-		Expected *Message
-	}{
-		{string(minimumInputRFC5424), &Message{}},
 		{
-			`<191>10 2015-09-30T23:10:11+00:00 hostname appname procid msgid [data]`,
+			`<187>Oct 13 12:31:40 hostname nginx: 2015/10/13 01:31:40 [error] 1187#1187: *46 upstream timed out (110: Connection timed out) while reading response header from upstream, client: 192.168.1.255, server: localhost, request: "GET / HTTP/1.1", upstream: "http://127.0.0.1:8080/", host: "192.168.1.254"`,
 			&Message{
-				Priority:  CalculatePriority(Local7, Debug),
+				Priority:  CalculatePriority(Local7, Error),
 				Facility:  Local7,
-				Severity:  Debug,
-				Version:   10,
-				Timestamp: time.Date(2015, 9, 30, 23, 10, 11, 0, time.UTC),
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 13, 1, 31, 40, 0, time.Local),
 				Hostname:  "hostname",
-				Appname:   "appname",
-				ProcessID: "procid",
-				MessageID: "msgid",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   `upstream timed out (110: Connection timed out) while reading response header from upstream`,
 				Data: map[string]map[string]string{
-					"data": {},
+					"data": {
+						"connection": "46",
+						"client":     "192.168.1.255",
+						"server":     "localhost",
+						"request":    "GET / HTTP/1.1",
+						"upstream":   "http://127.0.0.1:8080/",
+						"host":       "192.168.1.254",
+					},
 				},
 			},
 		},
-	}
-
-	parse := NewParser(RFC5424)
-
-	for _, test := range tests {
-		got, err := parse([]byte(test.Input))
+		{
+			`<187>Oct 13 12:31:40 hostname nginx: 2015/10/13 01:31:40 [emerg] 1187#1187: invalid host in upstream "a,b", first, second, client: 192.168.1.255, server: localhost`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Emergency),
+				Facility:  Local7,
+				Severity:  Emergency,
+				Timestamp: time.Date(2015, 10, 13, 1, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   `invalid host in upstream "a,b", first, second`,
+				Data: map[string]map[string]string{
+					"data": {
+						"client": "192.168.1.255",
+						"server": "localhost",
+					},
+				},
+			},
+		},
+		{
+			`<187>Oct 13 12:31:40 hostname nginx: 2015/10/13 01:31:40 [error] 1187#1187: *46 "/var/www/a, b: c/index.html" is not found (2: No such file or directory), client: 192.168.1.255, server: localhost`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Error),
+				Facility:  Local7,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 13, 1, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   `"/var/www/a, b: c/index.html" is not found (2: No such file or directory)`,
+				Data: map[string]map[string]string{
+					"data": {
+						"connection": "46",
+						"client":     "192.168.1.255",
+						"server":     "localhost",
+					},
+				},
+			},
+		},
+		{
+			`<187>Oct 13 12:31:40 hostname nginx: 2015/10/13 01:31:40 [error] 1187#1187: *46 connect() failed (111: Connection refused) while connecting to upstream, client: 192.168.1.255, server: localhost, request: "GET /search?q=a,b HTTP/1.1", upstream: "http://10.0.0.5:8080/search?q=a,b&c=d:e", host: "192.168.1.254"`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Error),
+				Facility:  Local7,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 13, 1, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   `connect() failed (111: Connection refused) while connecting to upstream`,
+				Data: map[string]map[string]string{
+					"data": {
+						"connection": "46",
+						"client":     "192.168.1.255",
+						"server":     "localhost",
+						"request":    "GET /search?q=a,b HTTP/1.1",
+						"upstream":   "http://10.0.0.5:8080/search?q=a,b&c=d:e",
+						"host":       "192.168.1.254",
+					},
+				},
+			},
+		},
+		{
+			`<187>Oct 13 12:31:40 hostname nginx: 2015/10/13 01:31:40 [error] 1187#1187: *46 FastCGI sent in stderr: "PHP message: PHP Warning:  Undefined variable $a, in /var/www/index.php on line 2" while reading response header from upstream, client: 192.168.1.255, server: localhost, request: "GET / HTTP/1.1", upstream: "fastcgi://unix:/run/php/php-fpm.sock:", host: "192.168.1.254"`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Error),
+				Facility:  Local7,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 13, 1, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   `FastCGI sent in stderr: "PHP message: PHP Warning:  Undefined variable $a, in /var/www/index.php on line 2" while reading response header from upstream`,
+				Data: map[string]map[string]string{
+					"data": {
+						"connection": "46",
+						"client":     "192.168.1.255",
+						"server":     "localhost",
+						"request":    "GET / HTTP/1.1",
+						"upstream":   "fastcgi://unix:/run/php/php-fpm.sock:",
+						"host":       "192.168.1.254",
+					},
+				},
+			},
+		},
+		{
+			`<187>Oct 13 12:31:40 hostname nginx: 2015/10/13 01:31:40 [error] 1187#1187: *46 upstream prematurely closed connection, client: 192.168.1.255, server: localhost, upstream: http://10.0.0.5:8080/a?b=1,c:2, host: "192.168.1.254"`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Error),
+				Facility:  Local7,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 13, 1, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   `upstream prematurely closed connection`,
+				Data: map[string]map[string]string{
+					"data": {
+						"connection": "46",
+						"client":     "192.168.1.255",
+						"server":     "localhost",
+						"upstream":   "http://10.0.0.5:8080/a?b=1,c:2",
+						"host":       "192.168.1.254",
+					},
+				},
+			},
+		},
+		// Without data.
+		{
+			`<187>Oct 13 12:00:00 hostname nginx: 2015/10/13 12:00:00 [notice] 1187#1187: using the "epoll" event method`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Notice),
+				Facility:  Local7,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 10, 13, 12, 0, 0, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   `using the "epoll" event method`,
+			},
+		},
+		{
+			`<187>Oct 13 12:00:00 hostname nginx: 2015/10/13 12:00:00 [notice] 1187#1187: signal process started`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Notice),
+				Facility:  Local7,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 10, 13, 12, 0, 0, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   `signal process started`,
+			},
+		},
+		{
+			`<187>Oct 13 12:00:00 hostname nginx: 2015/10/13 12:00:00 [notice] 1187#1187: start worker processes, total 4`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Notice),
+				Facility:  Local7,
+				Severity:  Notice,
+				Timestamp: time.Date(2015, 10, 13, 12, 0, 0, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   `start worker processes, total 4`,
+			},
+		},
+		{
+			`<187>Oct 13 12:00:00 hostname nginx: 2015/10/13 12:00:00 [error] 1187#1187: *46 client closed connection`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Error),
+				Facility:  Local7,
+				Severity:  Error,
+				Timestamp: time.Date(2015, 10, 13, 12, 0, 0, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   `client closed connection`,
+				Data: map[string]map[string]string{
+					"data": {
+						"connection": "46",
+					},
+				},
+			},
+		},
+		{
+			`<187>Oct 13 12:00:00 hostname nginx: 2015/10/13 12:00:00 [emerg] bind() to 0.0.0.0:80 failed (98: Address already in use)`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Emergency),
+				Facility:  Local7,
+				Severity:  Emergency,
+				Timestamp: time.Date(2015, 10, 13, 12, 0, 0, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				Message:   `bind() to 0.0.0.0:80 failed (98: Address already in use)`,
+			},
+		},
+		{
+			`<187>Oct 13 12:00:00 hostname nginx: 2015/10/13 12:00:00 [alert] 1187#1187: `,
+			&Message{
+				Priority:  CalculatePriority(Local7, Alert),
+				Facility:  Local7,
+				Severity:  Alert,
+				Timestamp: time.Date(2015, 10, 13, 12, 0, 0, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   ``,
+			},
+		},
+		{
+			// The embedded timestamp can't be parsed, the header one is used.
+			`<187>Oct 13 12:31:40 hostname nginx: 2015/13/13 01:31:40 [error] 1187#1187: msg, client: 192.168.1.255`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Error),
+				Facility:  Local7,
+				Severity:  Error,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   "msg",
+				Data: map[string]map[string]string{
+					"data": {
+						"client": "192.168.1.255",
+					},
+				},
+			},
+		},
+		{
+			`<187>Oct 13 12:31:40 hostname nginx: 13-10-2015 [error] 1187#1187: msg, client: 192.168.1.255`,
+			&Message{
+				Priority:  CalculatePriority(Local7, Error),
+				Facility:  Local7,
+				Severity:  Error,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "nginx",
+				ProcessID: "1187#1187",
+				Message:   "msg",
+				Data: map[string]map[string]string{
+					"data": {
+						"client": "192.168.1.255",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), NginxError)
 		if err != nil {
-			t.Fatalf("Unexpected error parse(%q): %s",
-				test.Input, err.Error())
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err.Error())
 		}
 
 		if !messagesAreEqual(got, test.Expected) {
-			t.Fatalf("Expected parse(%q) to return Message %#v, but got %#v",
+			t.Fatalf("Expected ParseMessage(%q, NginxError) to return Message %#v, but got %#v",
 				test.Input, test.Expected, got)
 		}
 	}
 }
 
-func TestMessage(t *testing.T) {
+func TestParseMessageRFC3164(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		Msg      *Message
-		Expected string
+		Input    string
+		Expected *Message
 	}{
-		{&Message{}, string(minimumInputRFC5424)},
 		{
+			string(minimumInputRFC3164),
 			&Message{
-				Priority:  CalculatePriority(Local7, Debug),
-				Facility:  Local7,
-				Severity:  Debug,
-				Timestamp: time.Date(2015, 10, 16, 14, 38, 12, 0, time.UTC),
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: inferredDate(1, 1, 1, 1, 1, time.Local),
+				Appname:   "a",
+			},
+		},
+		{
+			string(regularInputRFC3164),
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
 				Hostname:  "hostname",
-				Appname:   "appname",
-				Data: map[string]map[string]string{
-					"data": {},
-				},
+				Appname:   "app",
+				ProcessID: "123",
+				Message:   "message",
 			},
-			`<191> 2015-10-16T14:38:12Z hostname appname - - [data]`,
 		},
 		{
+			// RFC 3339 timestamp, e.g. Busybox.
+			`<30>2015-10-13T12:31:40+02:00 hostname app: message`,
 			&Message{
-				Priority:  CalculatePriority(Local7, Debug),
-				Facility:  Local7,
-				Severity:  Debug,
-				Version:   1,
-				Timestamp: time.Date(2015, 10, 16, 14, 38, 12, 0, locationCEST),
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.FixedZone("", 2*60*60)),
 				Hostname:  "hostname",
-				Appname:   "appname",
-				ProcessID: "procid",
-				MessageID: "msgid",
-				Data: map[string]map[string]string{
-					"data": {
-						"name": "value",
-					},
-				},
-				Message: "message",
+				Appname:   "app",
+				Message:   "message",
+			},
+		},
+		{
+			`<30>2015-10-13T12:31:40.123456Z hostname app[123]: message`,
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 123456000, time.UTC),
+				Hostname:  "hostname",
+				Appname:   "app",
+				ProcessID: "123",
+				Message:   "message",
+			},
+		},
+		{
+			// Without hostname.
+			`<30>Oct  5 12:31:40 app[123]: message: with colons`,
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 5, 12, 31, 40, time.Local),
+				Appname:   "app",
+				ProcessID: "123",
+				Message:   "message: with colons",
+			},
+		},
+		{
+			`<30>2015-10-13T12:31:40Z app: message`,
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Appname:   "app",
+				Message:   "message",
+			},
+		},
+		{
+			// Tag without a colon.
+			`<30>Oct 13 12:31:40 hostname app message`,
+			&Message{
+				Priority:  CalculatePriority(System, Informational),
+				Facility:  System,
+				Severity:  Informational,
+				Timestamp: inferredDate(10, 13, 12, 31, 40, time.Local),
+				Hostname:  "hostname",
+				Appname:   "app",
+				Message:   "message",
 			},
-			`<191>1 2015-10-16T14:38:12+02:00 hostname appname procid msgid [data name="value"] message`,
 		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), RFC3164)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err.Error())
+		}
+
+		if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, RFC3164) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParser(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{string(minimumInputRFC5424), &Message{}},
 		{
+			`<191>10 2015-09-30T23:10:11+00:00 hostname appname procid msgid [data]`,
 			&Message{
 				Priority:  CalculatePriority(Local7, Debug),
 				Facility:  Local7,
 				Severity:  Debug,
-				Version:   1,
-				Timestamp: time.Date(2015, 10, 16, 14, 38, 36, 0, time.UTC),
+				Version:   10,
+				Timestamp: time.Date(2015, 9, 30, 23, 10, 11, 0, time.UTC),
 				Hostname:  "hostname",
 				Appname:   "appname",
 				ProcessID: "procid",
 				MessageID: "msgid",
 				Data: map[string]map[string]string{
-					"dataID": {
-						"name":  "value",
-						"name2": "value2",
-					},
-					"dataID2": {
-						"name":  "value",
-						"name2": "value2",
-					},
+					"data": {},
 				},
-				Message: "message",
 			},
-			`<191>1 2015-10-16T14:38:36Z hostname appname procid msgid [dataID name="value" name2="value2"][dataID2 name="value" name2="value2"] message`,
 		},
 	}
 
+	parse := NewParser(RFC5424)
+
 	for _, test := range tests {
+		got, err := parse([]byte(test.Input))
+		if err != nil {
+			t.Fatalf("Unexpected error parse(%q): %s",
+				test.Input, err.Error())
+		}
+
+		if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected parse(%q) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParserStrict(t *testing.T) {
+	t.Parallel()
+
+	input := []byte("<191>1 - hóst app - - - message")
+	if _, err := NewParser(RFC5424)(input); err != nil {
+		t.Fatalf("Unexpected error parse(%q): %s", input, err.Error())
+	}
+
+	_, err := NewParser(RFC5424, WithStrict())(input)
+	formatErr, ok := err.(*FormatError)
+	if !ok {
+		t.Fatalf("Expected strict parse(%q) to return a *FormatError, but got %#v",
+			input, err)
+	} else if formatErr.Pos != 11 {
+		t.Fatalf("Expected strict parse(%q) to return an error at byte 11, but got %d",
+			input, formatErr.Pos)
+	}
+}
+
+func TestParserStrictTimestamp(t *testing.T) {
+	t.Parallel()
+
+	input := []byte("<191>1 2015-10-18T17:05:55-00:00 - - - - -")
+	if _, err := NewParser(RFC5424)(input); err != nil {
+		t.Fatalf("Unexpected error parse(%q): %s", input, err.Error())
+	}
+
+	if _, err := NewParser(RFC5424Lazy, WithStrict())(input); !errors.Is(err, ErrBadTimestamp) {
+		t.Fatalf("Expected strict parse(%q) to return %q, but got %v",
+			input, ErrBadTimestamp, err)
+	}
+}
+
+// Messages and their expected RFC5424 format.
+var messageTests = []struct {
+	Msg      *Message
+	Expected string
+}{
+	{&Message{}, string(minimumInputRFC5424)},
+	{
+		&Message{
+			Priority:  CalculatePriority(Local7, Debug),
+			Facility:  Local7,
+			Severity:  Debug,
+			Timestamp: time.Date(2015, 10, 16, 14, 38, 12, 0, time.UTC),
+			Hostname:  "hostname",
+			Appname:   "appname",
+			Data: map[string]map[string]string{
+				"data": {},
+			},
+		},
+		`<191> 2015-10-16T14:38:12Z hostname appname - - [data]`,
+	},
+	{
+		&Message{
+			Priority:  CalculatePriority(Local7, Debug),
+			Facility:  Local7,
+			Severity:  Debug,
+			Version:   1,
+			Timestamp: time.Date(2015, 10, 16, 14, 38, 12, 0, locationCEST),
+			Hostname:  "hostname",
+			Appname:   "appname",
+			ProcessID: "procid",
+			MessageID: "msgid",
+			Data: map[string]map[string]string{
+				"data": {
+					"name": "value",
+				},
+			},
+			Message: "message",
+		},
+		`<191>1 2015-10-16T14:38:12+02:00 hostname appname procid msgid [data name="value"] message`,
+	},
+	{
+		&Message{
+			Priority:  CalculatePriority(Local7, Debug),
+			Facility:  Local7,
+			Severity:  Debug,
+			Version:   1,
+			Timestamp: time.Date(2015, 10, 16, 14, 38, 36, 0, time.UTC),
+			Hostname:  "hostname",
+			Appname:   "appname",
+			ProcessID: "procid",
+			MessageID: "msgid",
+			Data: map[string]map[string]string{
+				"dataID": {
+					"name":  "value",
+					"name2": "value2",
+				},
+				"dataID2": {
+					"name":  "value",
+					"name2": "value2",
+				},
+			},
+			Message: "message",
+		},
+		`<191>1 2015-10-16T14:38:36Z hostname appname procid msgid [dataID name="value" name2="value2"][dataID2 name="value" name2="value2"] message`,
+	},
+	{
+		&Message{
+			Priority: CalculatePriority(Local7, Debug),
+			Facility: Local7,
+			Severity: Debug,
+			Version:  1,
+			RawData:  `[dataID name="value"]`,
+			Message:  "message",
+		},
+		`<191>1 - - - - - [dataID name="value"] message`,
+	},
+	{
+		&Message{
+			Priority: CalculatePriority(Local7, Debug),
+			Facility: Local7,
+			Severity: Debug,
+			Version:  1,
+			Data: map[string]map[string]string{
+				"data": {
+					"name": "a \"b\" \\c [d] é\n",
+				},
+			},
+		},
+		"<191>1 - - - - - [data name=\"a \\\"b\\\" \\\\c [d\\] é\n\"]",
+	},
+	{
+		&Message{Version: 1, Message: "héllo", UTF8: true},
+		"<0>1 - - - - - - " + string(bom) + "héllo",
+	},
+}
+
+func TestMessage(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range messageTests {
 		got := test.Msg.String()
 		gotBytes := string(test.Msg.Bytes())
 
@@ -541,6 +1341,60 @@ func TestMessage(t *testing.T) {
 			t.Fatalf("Expected msg.String() and msg.Bytes() to return %s, but got %s",
 				test.Expected, got)
 		}
+
+		prefix := "prefix "
+		if got := string(test.Msg.AppendFormat([]byte(prefix))); got != prefix+test.Expected {
+			t.Fatalf("Expected msg.AppendFormat(%q) to return %s, but got %s",
+				prefix, prefix+test.Expected, got)
+		}
+	}
+}
+
+func TestMessageAppendFormatAllocs(t *testing.T) {
+	msg, err := ParseMessage(regularInputRFC5424, RFC5424)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %q: %s", regularInputRFC5424, err.Error())
+	}
+
+	b := make([]byte, 0, 1024)
+	allocs := testing.AllocsPerRun(100, func() {
+		b = msg.AppendFormat(b[:0])
+	})
+	if allocs != 0 {
+		t.Fatalf("Expected msg.AppendFormat() to not allocate, but got %v allocations", allocs)
+	}
+}
+
+func TestMessagePriority(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Msg      *Message
+		Expected string
+	}{
+		{&Message{}, "<0>"},
+		{&Message{Facility: Local3, Severity: Warning}, "<156>"},
+		{&Message{Severity: Debug}, "<7>"},
+		{&Message{Facility: Local7}, "<184>"},
+		{&Message{Priority: CalculatePriority(Local3, Warning)}, "<156>"},
+		{&Message{Priority: CalculatePriority(Local7, Debug), Facility: Local3, Severity: Warning}, "<191>"},
+	}
+
+	for _, test := range tests {
+		if got := test.Msg.String(); !strings.HasPrefix(got, test.Expected) {
+			t.Fatalf("Expected msg.String() to start with %s, but got %s", test.Expected, got)
+		} else if got := string(test.Msg.BytesRFC3164()); !strings.HasPrefix(got, test.Expected) {
+			t.Fatalf("Expected msg.BytesRFC3164() to start with %s, but got %s", test.Expected, got)
+		}
+	}
+
+	// The parser sets all three.
+	msg, err := ParseMessage((&Message{Facility: Local3, Severity: Warning}).Bytes(), RFC5424)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing message: %s", err)
+	} else if msg.Priority != 156 || msg.Facility != Local3 || msg.Severity != Warning {
+		t.Fatalf("Expected the parsed message to have priority 156, facility %s and severity %s, "+
+			"but got %d, %s and %s", Local3, Warning, msg.Priority, msg.Facility, msg.Severity)
 	}
 }
 
@@ -549,14 +1403,125 @@ func messagesAreEqual(got, expected *Message) bool {
 	if !expected.Timestamp.Equal(got.Timestamp) {
 		return false
 	}
-	expected.Timestamp = time.Time{}
-	got.Timestamp = time.Time{}
 
-	if !reflect.DeepEqual(got, expected) {
-		return false
+	// Don't modify the messages, the expected messages may be shared.
+	g, e := *got, *expected
+	g.Timestamp, e.Timestamp = time.Time{}, time.Time{}
+	// The parser configuration is only used by ParsedData.
+	g.dataCfg, e.dataCfg = nil, nil
+	g.dataFormat, e.dataFormat = "", ""
+	return reflect.DeepEqual(g, e)
+}
+
+// TestMessageRoundTrip checks that formatting a message and parsing it again
+// returns the same message, after canonicalizing the message:
+//
+//   - Empty structured data is nil, both are formatted as "-".
+//   - RawData is parsed into Data.
+//   - The whitespace around the message is trimmed, like the parser does.
+//   - UTF8 is false for an empty message, no BOM is formatted without message.
+//
+// Timestamps are compared with time.Time.Equal, only the offset of the
+// location is kept.
+func TestMessageRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var msgs []*Message
+	for _, test := range rfc5424Tests {
+		msgs = append(msgs, test.Expected)
+	}
+	for _, test := range messageTests {
+		msgs = append(msgs, test.Msg)
+	}
+	msgs = append(msgs, &Message{Version: 1, Data: map[string]map[string]string{
+		"d": {"n": "-"},
+	}})
+
+	for _, msg := range msgs {
+		expected := canonicalMessage(t, msg)
+		b := msg.Bytes()
+		got, err := ParseMessage(b, RFC5424)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q, RFC5424): %s", b, err)
+		} else if !messagesAreEqual(got, expected) {
+			t.Fatalf("Expected ParseMessage(%q, RFC5424) to return Message %#v, but got %#v",
+				b, expected, got)
+		}
+	}
+}
+
+// canonicalMessage returns a copy of msg canonicalized as described in
+// TestMessageRoundTrip.
+func canonicalMessage(t *testing.T, msg *Message) *Message {
+	m := *msg
+	if m.RawData != "" && m.Data == nil {
+		data, err := (&Message{RawData: m.RawData}).ParsedData()
+		if err != nil {
+			t.Fatalf("Unexpected error parsing raw data %q: %s", m.RawData, err)
+		}
+		m.Data, m.RawData = data, ""
+	}
+	if len(m.Data) == 0 {
+		m.Data = nil
+	}
+	m.Message = strings.TrimSpace(m.Message)
+	if m.Message == "" {
+		m.UTF8 = false
+	}
+	return &m
+}
+
+func TestMessageParamValues(t *testing.T) {
+	t.Parallel()
+
+	input := []byte(`<191>1 - - - - - [a x="1" y="2" x="3"][a x="4"]`)
+	msg, err := NewParser(RFC5424, WithDuplicates(CollectDuplicates))(input)
+	if err != nil {
+		t.Fatalf("Unexpected error parse(%q): %s", input, err)
+	}
+
+	tests := []struct {
+		DataID, Name string
+		Expected     []string
+	}{
+		{"a", "x", []string{"1", "3", "4"}},
+		{"a", "y", []string{"2"}},
+		{"a", "z", nil},
+		{"b", "x", nil},
+	}
+
+	for _, test := range tests {
+		got := msg.ParamValues(test.DataID, test.Name)
+		if !reflect.DeepEqual(got, test.Expected) {
+			t.Fatalf("Expected msg.ParamValues(%q, %q) to return %v, but got %v",
+				test.DataID, test.Name, test.Expected, got)
+		}
+	}
+}
+
+func TestMessageDataRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []map[string]map[string]string{
+		{"data": {"name": `"qouted"`}},
+		{"data": {"name": `C:\logs\`, "name2": `\\`}},
+		{"data": {"name": "[brackets]", "name2": "]"}},
+		{"data": {"name": "héllo wörld", "name2": "日本語"}},
+		{"data": {"name": `"\]`}, "data2": {"name": ""}},
 	}
 
-	return true
+	for _, data := range tests {
+		input := &Message{Version: 1, Data: data, Message: "message"}
+		b := input.Bytes()
+
+		got, err := ParseMessage(b, RFC5424)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %q: %s", b, err.Error())
+		} else if !reflect.DeepEqual(got.Data, data) {
+			t.Fatalf("Expected ParseMessage(%q) to return data %v, but got %v",
+				b, data, got.Data)
+		}
+	}
 }
 
 func TestGenerateString(t *testing.T) {
@@ -589,6 +1554,17 @@ func TestGenerateString(t *testing.T) {
 	}
 }
 
+// inferredDate returns the date with the year that the formats without a year
+// infer when parsing it now, see inferYear.
+func inferredDate(month time.Month, day, hour, min, sec int, loc *time.Location) time.Time {
+	now := time.Now()
+	for year := now.Year() + 1; ; year-- {
+		if t := time.Date(year, month, day, hour, min, sec, 0, loc); !t.After(now.Add(48 * time.Hour)) {
+			return t
+		}
+	}
+}
+
 func generateString(prefix string, length int) string {
 	var str = prefix
 	for len(str) < length {
@@ -596,3 +1572,88 @@ func generateString(prefix string, length int) string {
 	}
 	return str[:length]
 }
+
+func TestMessageHasStructuredData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input         string
+		ExpectedData  map[string]map[string]string
+		ExpectedBytes string
+		Expected      bool
+	}{
+		{"<0>1 - - - - - -", nil, "<0>1 - - - - - -", false},
+		{"<0>1 - - - - - [data]", map[string]map[string]string{"data": {}}, "<0>1 - - - - - [data]", true},
+		{`<0>1 - - - - - [data name="value"]`, map[string]map[string]string{"data": {"name": "value"}},
+			`<0>1 - - - - - [data name="value"]`, true},
+	}
+
+	for _, test := range tests {
+		msg, err := ParseMessage([]byte(test.Input), RFC5424)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		}
+
+		if !reflect.DeepEqual(msg.Data, test.ExpectedData) {
+			t.Fatalf("Expected ParseMessage(%q) to return data %#v, but got %#v",
+				test.Input, test.ExpectedData, msg.Data)
+		} else if got := msg.HasStructuredData(); got != test.Expected {
+			t.Fatalf("Expected msg.HasStructuredData() for %q to return %t, but got %t",
+				test.Input, test.Expected, got)
+		} else if got := string(msg.Bytes()); got != test.ExpectedBytes {
+			t.Fatalf("Expected msg.Bytes() for %q to return %q, but got %q",
+				test.Input, test.ExpectedBytes, got)
+		}
+	}
+
+	// A nil and empty map are formatted the same.
+	for _, data := range []map[string]map[string]string{nil, {}} {
+		msg := &Message{Data: data}
+		if msg.HasStructuredData() {
+			t.Fatalf("Expected msg.HasStructuredData() with data %#v to return false", data)
+		} else if got, expected := msg.String(), "<0> - - - - - -"; got != expected {
+			t.Fatalf("Expected msg.String() with data %#v to return %q, but got %q",
+				data, expected, got)
+		}
+	}
+
+	lazy := &Message{RawData: "[data]"}
+	if !lazy.HasStructuredData() {
+		t.Fatal("Expected msg.HasStructuredData() with raw data to return true")
+	}
+}
+
+func TestMessageText(t *testing.T) {
+	t.Parallel()
+
+	expected, err := ParseMessage(regularInputRFC5424, RFC5424)
+	if err != nil {
+		t.Fatalf("Unexpected error ParseMessage(%q): %s", regularInputRFC5424, err)
+	}
+
+	var _ encoding.TextMarshaler = expected
+	var _ encoding.TextUnmarshaler = expected
+
+	text, err := expected.MarshalText()
+	if err != nil {
+		t.Fatalf("Unexpected error msg.MarshalText(): %s", err)
+	} else if got := string(text); got != string(regularInputRFC5424) {
+		t.Fatalf("Expected msg.MarshalText() to return %q, but got %q",
+			regularInputRFC5424, got)
+	}
+
+	var got Message
+	if err := got.UnmarshalText(text); err != nil {
+		t.Fatalf("Unexpected error msg.UnmarshalText(%q): %s", text, err)
+	} else if !messagesAreEqual(&got, expected) {
+		t.Fatalf("Expected msg.UnmarshalText(%q) to return %#v, but got %#v",
+			text, expected, got)
+	}
+
+	input := []byte("<1923>1 - - - - - -")
+	err = got.UnmarshalText(input)
+	if formatErr, ok := err.(*FormatError); !ok || formatErr.Pos != 5 {
+		t.Fatalf("Expected msg.UnmarshalText(%q) to return a format error at byte 5, "+
+			"but got %v", input, err)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Maximum version and timestamp year that can be formatted.
+const (
+	maxVersion = 999
+	maxYear    = 9999
+)
+
+// InvalidFieldError is a single problem found by Message.Validate.
+type InvalidFieldError struct {
+	Field string // Name of the field, e.g. "hostname".
+	Msg   string
+	Err   error // Kind of error, e.g. ErrFieldTooLong, may be nil.
+}
+
+func (err *InvalidFieldError) Error() string {
+	return "syslog: invalid " + err.Field + ": " + err.Msg
+}
+
+// Unwrap returns the kind of error.
+func (err *InvalidFieldError) Unwrap() error {
+	return err.Err
+}
+
+// ValidationError is returned by Message.Validate if one or more fields can't
+// be formatted, it holds an error per problem.
+type ValidationError []*InvalidFieldError
+
+func (err ValidationError) Error() string {
+	msgs := make([]string, len(err))
+	for i, fieldErr := range err {
+		msgs[i] = fieldErr.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors, so errors.Is and errors.As can be used to check
+// for the kind of the errors.
+func (err ValidationError) Unwrap() []error {
+	errs := make([]error, len(err))
+	for i, fieldErr := range err {
+		errs[i] = fieldErr
+	}
+	return errs
+}
+
+// Validate checks if the message can be formatted in the RFC5424 format,
+// using the same limits as the parser in strict mode. It returns a
+// ValidationError with all problems found, or nil if there are none. See
+// Encoder.Truncate to fix the fields while formatting instead.
+func (msg *Message) Validate() error {
+	var errs ValidationError
+	addErr := func(field string, kind error, msg string) {
+		errs = append(errs, &InvalidFieldError{Field: field, Msg: msg, Err: kind})
+	}
+
+	if priority := msg.priority(); !priority.IsValid() {
+		addErr("priority", ErrBadPriority, strconv.Itoa(int(priority))+" out of range")
+	}
+	if msg.Version > maxVersion {
+		addErr("version", ErrFieldTooLong, "version too long")
+	}
+	if year := msg.Timestamp.Year(); year < 0 || year > maxYear {
+		addErr("timestamp", ErrBadTimestamp, "year "+strconv.Itoa(year)+" out of range")
+	}
+
+	values := []struct {
+		name      string
+		value     string
+		maxLength int
+	}{
+		{"hostname", msg.Hostname, maxHostnameLength},
+		{"appname", msg.Appname, maxAppNameLength},
+		{"processID", msg.ProcessID, maxProcessIDLength},
+		{"messageID", msg.MessageID, maxMessageIDLength},
+	}
+	for _, v := range values {
+		if err := validateValue(v.name, v.value, v.maxLength, isPrintASCII); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	var dataIDs, names [maxSortedKeys]string
+	for _, dataID := range getSortedMapMapKeys(msg.Data, dataIDs[:0]) {
+		if err := validateName("data-ID", dataID, maxDataIDLength); err != nil {
+			errs = append(errs, err)
+		}
+		for _, name := range getSortedMapKeys(msg.Data[dataID], names[:0]) {
+			if err := validateName("data param name", name, maxDataParamLength); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if msg.UTF8 && !utf8.ValidString(msg.Message) {
+		addErr("message", nil, "message is not valid UTF-8")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateName validates a structured data name, which can't be empty.
+func validateName(field, name string, maxLength int) *InvalidFieldError {
+	if name == "" {
+		return &InvalidFieldError{Field: field, Msg: field + " can't be empty"}
+	}
+	return validateValue(field, name, maxLength, isNameByte)
+}
+
+// validateValue checks the length of value and if all bytes are valid.
+func validateValue(field, value string, maxLength int, valid func(byte) bool) *InvalidFieldError {
+	if len(value) > maxLength {
+		return &InvalidFieldError{Field: field, Msg: field + " too long", Err: ErrFieldTooLong}
+	}
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; !valid(c) {
+			return &InvalidFieldError{Field: field, Msg: field + " contains invalid byte '" +
+				escapeSnippet([]byte{c}) + "'"}
+		}
+	}
+	return nil
+}
+
+// isPrintASCII returns true if c is printable US-ASCII, excluding the space
+// (PRINTUSASCII in RFC 5424).
+func isPrintASCII(c byte) bool {
+	return c >= '!' && c <= '~'
+}
+
+// isNameByte returns true if c is allowed in a structured data name (SD-NAME
+// in RFC 5424).
+func isNameByte(c byte) bool {
+	return isPrintASCII(c) && c != equalByte && c != dataEnd && c != qouteByte
+}
+
+// sanitize truncates value to maxLength bytes and replaces the invalid bytes
+// with an underscore. An empty value is returned as is.
+func sanitize(value string, maxLength int, valid func(byte) bool) string {
+	if len(value) > maxLength {
+		value = value[:maxLength]
+	}
+
+	for i := 0; i < len(value); i++ {
+		if !valid(value[i]) {
+			b := []byte(value)
+			for ; i < len(b); i++ {
+				if !valid(b[i]) {
+					b[i] = '_'
+				}
+			}
+			return string(b)
+		}
+	}
+	return value
+}
+
+// sanitizeName is like sanitize, but replaces an empty name with an
+// underscore.
+func sanitizeName(name string, maxLength int) string {
+	if name == "" {
+		return "_"
+	}
+	return sanitize(name, maxLength, isNameByte)
+}
+
+// sanitizeData returns a copy of data with all data-IDs and param names
+// sanitized. If sanitizing makes names equal the last one in sorted order
+// wins.
+func sanitizeData(data map[string]map[string]string) map[string]map[string]string {
+	if data == nil {
+		return nil
+	}
+
+	sanitized := make(map[string]map[string]string, len(data))
+	var dataIDs, names [maxSortedKeys]string
+	for _, dataID := range getSortedMapMapKeys(data, dataIDs[:0]) {
+		params := data[dataID]
+		sanitizedParams := make(map[string]string, len(params))
+		for _, name := range getSortedMapKeys(params, names[:0]) {
+			sanitizedParams[sanitizeName(name, maxDataParamLength)] = params[name]
+		}
+		sanitized[sanitizeName(dataID, maxDataIDLength)] = sanitizedParams
+	}
+	return sanitized
+}
+
+// sanitizeMessage returns a copy of msg with all fields sanitized, see
+// Encoder.Truncate. An invalid priority is set to the maximum priority, a
+// timestamp outside of the years 0-9999 to the first or last moment of that
+// range and invalid raw structured data is dropped. The message is made valid
+// UTF-8 if UTF8 or bom is true.
+func sanitizeMessage(msg *Message, bom bool) *Message {
+	m := *msg
+	if !m.priority().IsValid() {
+		m.Priority = maxPriority
+	}
+	if m.Version > maxVersion {
+		m.Version = maxVersion
+	}
+	if year := m.Timestamp.Year(); year < 0 {
+		m.Timestamp = time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	} else if year > maxYear {
+		m.Timestamp = time.Date(maxYear, 12, 31, 23, 59, 59, 999999000, time.UTC)
+	}
+	m.Hostname = sanitize(m.Hostname, maxHostnameLength, isPrintASCII)
+	m.Appname = sanitize(m.Appname, maxAppNameLength, isPrintASCII)
+	m.ProcessID = sanitize(m.ProcessID, maxProcessIDLength, isPrintASCII)
+	m.MessageID = sanitize(m.MessageID, maxMessageIDLength, isPrintASCII)
+	if m.Data == nil && m.RawData != "" {
+		// Parse the raw data, so it can be sanitized, dropping it if it's invalid.
+		if data, err := m.ParsedData(); err == nil {
+			m.Data = data
+		}
+		m.RawData = ""
+	}
+	m.Data = sanitizeData(m.Data)
+	if m.UTF8 || bom {
+		m.Message = strings.ToValidUTF8(m.Message, string(utf8.RuneError))
+	}
+	return &m
+}
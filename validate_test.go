@@ -0,0 +1,163 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Messages that Message.Validate should reject.
+var invalidMessages = []struct {
+	Msg      *Message
+	Expected []string
+}{
+	{
+		&Message{Hostname: generateString("hostname", maxHostnameLength+1)},
+		[]string{"syslog: invalid hostname: hostname too long"},
+	},
+	{
+		&Message{
+			Priority:  200,
+			Version:   1000,
+			Timestamp: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC),
+			Appname:   "app name",
+			ProcessID: "pid\n",
+			MessageID: generateString("msgid", maxMessageIDLength+1),
+		},
+		[]string{
+			"syslog: invalid priority: 200 out of range",
+			"syslog: invalid version: version too long",
+			"syslog: invalid timestamp: year 10000 out of range",
+			"syslog: invalid appname: appname contains invalid byte ' '",
+			`syslog: invalid processID: processID contains invalid byte '\n'`,
+			"syslog: invalid messageID: messageID too long",
+		},
+	},
+	{
+		&Message{
+			Data: map[string]map[string]string{
+				"":      {"name": "value"},
+				"data=": {"": "value", "na me": "value"},
+				generateString("data", maxDataIDLength+1): {generateString("name", maxDataParamLength+1): "value"},
+			},
+		},
+		[]string{
+			"syslog: invalid data-ID: data-ID can't be empty",
+			"syslog: invalid data-ID: data-ID contains invalid byte '='",
+			"syslog: invalid data param name: data param name can't be empty",
+			"syslog: invalid data param name: data param name contains invalid byte ' '",
+			"syslog: invalid data-ID: data-ID too long",
+			"syslog: invalid data param name: data param name too long",
+		},
+	},
+	{
+		&Message{Message: "invalid \xff", UTF8: true},
+		[]string{"syslog: invalid message: message is not valid UTF-8"},
+	},
+}
+
+func TestMessageValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range messageTests {
+		if err := test.Msg.Validate(); err != nil {
+			t.Fatalf("Unexpected error msg.Validate(): %s", err)
+		}
+	}
+
+	for _, test := range invalidMessages {
+		err := test.Msg.Validate()
+		if err == nil {
+			t.Fatalf("Expected msg.Validate() to return an error for %#v, but got nil", test.Msg)
+		}
+
+		expected := strings.Join(test.Expected, "; ")
+		if got := err.Error(); got != expected {
+			t.Fatalf("Expected msg.Validate() to return error %q, but got %q", expected, got)
+		}
+	}
+
+	err := invalidMessages[0].Msg.Validate()
+	var fieldErr *InvalidFieldError
+	if !errors.Is(err, ErrFieldTooLong) || !errors.As(err, &fieldErr) || fieldErr.Field != "hostname" {
+		t.Fatalf("Expected msg.Validate() to return a *InvalidFieldError with kind %q, but got %#v",
+			ErrFieldTooLong, err)
+	}
+}
+
+func TestEncoderTruncate(t *testing.T) {
+	t.Parallel()
+
+	enc := &Encoder{Truncate: true}
+	strict := NewParser(RFC5424, WithStrict())
+	msgs := []*Message{
+		{Version: 1, Timestamp: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Version: 1, Timestamp: time.Date(-1, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Version: 1, Priority: 250},
+		{Version: 1, Facility: 30, Severity: Debug},
+		{Version: 1, RawData: `[data name="value"]`},
+		{Version: 1, RawData: `[data name="value"`, Message: "message"},
+		{Version: 1, RawData: `[data n="1"][data n="2"]`},
+	}
+	for _, test := range invalidMessages {
+		msgs = append(msgs, test.Msg)
+	}
+
+	for _, msg := range msgs {
+		b := enc.Bytes(msg)
+		got, err := strict(b)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %q in strict mode: %s", b, err)
+		} else if err := got.Validate(); err != nil {
+			t.Fatalf("Unexpected error validating the parsed message %q: %s", b, err)
+		}
+	}
+
+	tests := []struct {
+		Msg      *Message
+		Expected string
+	}{
+		{
+			&Message{Version: 1000, Appname: "app name", ProcessID: "pid\n", Message: "message"},
+			"<0>999 - - app_name pid_ - - message",
+		},
+		{
+			&Message{Version: 1, Data: map[string]map[string]string{
+				"":      {"": "value"},
+				"data=": {"na me": "value"},
+			}},
+			`<0>1 - - - - - [_ _="value"][data_ na_me="value"]`,
+		},
+		{
+			&Message{Version: 1, Message: "invalid \xff", UTF8: true},
+			"<0>1 - - - - - - " + string(bom) + "invalid �",
+		},
+		{
+			&Message{Version: 1, Priority: 250, Timestamp: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)},
+			"<191>1 9999-12-31T23:59:59.999999Z - - - - -",
+		},
+		{
+			&Message{Version: 1, RawData: `[data name="value"`, Message: "message"},
+			"<0>1 - - - - - - message",
+		},
+		{
+			&Message{Version: 1, RawData: `[data na.me="value"]`},
+			`<0>1 - - - - - [data na.me="value"]`,
+		},
+		{
+			&Message{Version: 1, MessageID: generateString("msgid", maxMessageIDLength+1)},
+			"<0>1 - - - - " + generateString("msgid", maxMessageIDLength) + " -",
+		},
+	}
+
+	for _, test := range tests {
+		if got := string(enc.Bytes(test.Msg)); got != test.Expected {
+			t.Fatalf("Expected Encoder.Bytes() to return %q, but got %q", test.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Gunicorn access logs, the Apache combined log format followed by the
+// duration of the request in microseconds.
+var gunicornAccessLog = accessLog{
+	server: "Gunicorn",
+	dataID: "request",
+	fields: append(apacheAccessFields[:len(apacheAccessFields):len(apacheAccessFields)],
+		accessField{"duration_us", apacheToken}),
+	required:       apacheCommonFields,
+	timestampField: "time_local",
+	layout:         apacheAccessTimestampLayout,
+}
+
+// ParseGunicornAccess parses a Gunicorn access log line, see parseApacheAccess.
+// The optional duration that follows the combined log format fields, in
+// microseconds, is stored in Data["request"]["duration_us"].
+func parseGunicornAccess(buf *buffer, msg *Message) error {
+	return parseAccessLog(buf, msg, &gunicornAccessLog)
+}
+
+// ParseUWSGIAccess parses the default request log line of uWSGI, e.g. "[pid:
+// 1187|app: 0|req: 1/1] 10.0.0.1 () {34 vars in 512 bytes} [Tue Oct 13
+// 12:31:40 2015] GET /api => generated 123 bytes in 12 msecs (HTTP/1.1 200) 2
+// headers in 79 bytes (1 switches on core 0)". The pid is stored as ProcessID
+// and the request in Data["request"], using the field names of
+// parseApacheAccess, with the duration, in milliseconds, in "duration_ms". The
+// timestamp is also parsed as Timestamp.
+func parseUWSGIAccess(buf *buffer, msg *Message) error {
+	startPos := buf.Pos()
+	b := buf.bytes[buf.position:buf.length]
+	buf.position = buf.length
+
+	block, i, err := apacheBlock(b, 0, startPos)
+	if err != nil {
+		return err
+	}
+	for _, part := range strings.Split(string(block), "|") {
+		if pid, ok := strings.CutPrefix(part, "pid: "); ok {
+			if len(pid) > maxProcessIDLength {
+				return newFormatError(startPos+1, ErrFieldTooLong, "pid too long")
+			}
+			msg.ProcessID = pid
+		}
+	}
+
+	data := make(map[string]string, 8)
+	remoteAddr, i, err := uwsgiField(b, i, startPos, apacheToken)
+	if err != nil {
+		return err
+	}
+	data["remote_addr"] = remoteAddr
+
+	// The remote user, e.g. "(alice)", is empty if not known.
+	i, err = uwsgiSeparator(b, i, startPos, '(')
+	if err != nil {
+		return err
+	}
+	end := bytes.IndexByte(b[i:], ')')
+	if end == -1 {
+		return io.EOF
+	} else if end != 0 {
+		data["remote_user"] = string(b[i : i+end])
+	}
+	i += end + 1
+
+	// The number and size of the request variables, e.g. "{34 vars in 512
+	// bytes}", aren't stored.
+	i, err = uwsgiSeparator(b, i, startPos, '{')
+	if err != nil {
+		return err
+	}
+	end = bytes.IndexByte(b[i:], '}')
+	if end == -1 {
+		return io.EOF
+	}
+	i += end + 1
+
+	timeLocal, i, err := uwsgiField(b, i, startPos, apacheBracket)
+	if err != nil {
+		return err
+	}
+	timestamp, ok := parseApacheErrorTimestamp([]byte(timeLocal), buf.cfg.Location())
+	if !ok {
+		return newFormatError(startPos+i-len(timeLocal)-1, ErrBadTimestamp, "invalid uWSGI timestamp")
+	}
+	msg.Timestamp = timestamp
+	data["time_local"] = timeLocal
+
+	// E.g. "GET /api => generated 123 bytes in 12 msecs (HTTP/1.1 200) ...".
+	restPos := startPos + i
+	request, response, ok := strings.Cut(strings.TrimSpace(string(b[i:])), " => ")
+	if !ok {
+		return newFormatError(restPos, nil, "expected uWSGI request, but got '"+escapeSnippet(b[i:])+"'")
+	}
+	fields := strings.Fields(response)
+	if len(fields) < 8 || fields[0] != "generated" || fields[2] != "bytes" || fields[3] != "in" ||
+		fields[5] != "msecs" || !strings.HasPrefix(fields[6], "(") || !strings.HasSuffix(fields[7], ")") {
+		return newFormatError(restPos, nil, "invalid uWSGI response")
+	}
+	data["request"] = request + " " + fields[6][1:]
+	data["status"] = fields[7][:len(fields[7])-1]
+	data["body_bytes_sent"] = fields[1]
+	data["duration_ms"] = fields[4]
+
+	msg.Data = map[string]map[string]string{"request": data}
+	return nil
+}
+
+// uwsgiField returns the value of the field of the given kind, after the space
+// at index i of b, see apacheField.
+func uwsgiField(b []byte, i, pos, kind int) (string, int, error) {
+	i, err := uwsgiSeparator(b, i, pos, 0)
+	if err != nil {
+		return "", i, err
+	}
+	return apacheField(b, i, pos, kind)
+}
+
+// uwsgiSeparator checks that a space, followed by c if it's not zero, is at
+// index i of b and returns the index after it.
+func uwsgiSeparator(b []byte, i, pos int, c byte) (int, error) {
+	for _, expected := range [...]byte{spaceByte, c} {
+		if expected == 0 {
+			break
+		} else if i >= len(b) {
+			return i, io.EOF
+		} else if b[i] != expected {
+			return i, newUnexpectedByteError(pos+i, b[i], expected)
+		}
+		i++
+	}
+	return i, nil
+}
@@ -0,0 +1,219 @@
+// Copyright (C) 2015 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessageGunicornAccess(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputGunicornAccess),
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.UTC),
+				Hostname:  "h",
+				Appname:   "a",
+				Data: map[string]map[string]string{
+					"request": {
+						"remote_addr": "1",
+						"time_local":  "01/Jan/2015:01:01:01 +0000",
+						"request":     "",
+						"status":      "200",
+					},
+				},
+			},
+		},
+		{
+			string(regularInputGunicornAccess),
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.FixedZone("", 2*60*60)),
+				Hostname:  "hostname",
+				Appname:   "gunicorn.access",
+				Data: map[string]map[string]string{
+					"request": {
+						"remote_addr":     "2001:db8::1",
+						"time_local":      "13/Oct/2015:12:31:40 +0200",
+						"request":         "GET /api?page=2 HTTP/1.1",
+						"status":          "200",
+						"body_bytes_sent": "123",
+						"user_agent":      "python-requests/2.7.0",
+						"duration_us":     "4567",
+					},
+				},
+			},
+		},
+		{
+			// Without the duration.
+			`<134>Oct 13 12:31:40 hostname gunicorn.access: ::1 - alice [13/Oct/2015:12:31:40 +0000] "POST /login HTTP/1.0" 302 0 "https://example.com/" "Mozilla/5.0"`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.UTC),
+				Hostname:  "hostname",
+				Appname:   "gunicorn.access",
+				Data: map[string]map[string]string{
+					"request": {
+						"remote_addr":     "::1",
+						"remote_user":     "alice",
+						"time_local":      "13/Oct/2015:12:31:40 +0000",
+						"request":         "POST /login HTTP/1.0",
+						"status":          "302",
+						"body_bytes_sent": "0",
+						"referer":         "https://example.com/",
+						"user_agent":      "Mozilla/5.0",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), GunicornAccess)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, GunicornAccess) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageUWSGIAccess(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected *Message
+	}{
+		{
+			string(minimumInputUWSGIAccess),
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 1, 1, 1, 1, 1, 0, time.Local),
+				Hostname:  "h",
+				Appname:   "a",
+				Data: map[string]map[string]string{
+					"request": {
+						"remote_addr":     "1",
+						"time_local":      "Thu Jan  1 01:01:01 2015",
+						"request":         "GET / HTTP/1.1",
+						"status":          "200",
+						"body_bytes_sent": "0",
+						"duration_ms":     "0",
+					},
+				},
+			},
+		},
+		{
+			string(regularInputUWSGIAccess),
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 13, 12, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "uwsgi",
+				ProcessID: "1187",
+				Data: map[string]map[string]string{
+					"request": {
+						"remote_addr":     "2001:db8::1",
+						"time_local":      "Tue Oct 13 12:31:40 2015",
+						"request":         "GET /api HTTP/1.1",
+						"status":          "200",
+						"body_bytes_sent": "123",
+						"duration_ms":     "12",
+					},
+				},
+			},
+		},
+		{
+			// With a remote user.
+			`<134>Oct 13 12:31:40 hostname uwsgi: [pid: 42|app: 0|req: 7/19] 10.0.0.1 (alice) {40 vars in 701 bytes} [Tue Oct  6 12:31:40 2015] POST /upload?id=1 => generated 17 bytes in 1034 msecs (HTTP/2.0 413) 3 headers in 102 bytes (2 switches on core 1)`,
+			&Message{
+				Priority:  CalculatePriority(Local0, Informational),
+				Facility:  Local0,
+				Severity:  Informational,
+				Timestamp: time.Date(2015, 10, 6, 12, 31, 40, 0, time.Local),
+				Hostname:  "hostname",
+				Appname:   "uwsgi",
+				ProcessID: "42",
+				Data: map[string]map[string]string{
+					"request": {
+						"remote_addr":     "10.0.0.1",
+						"remote_user":     "alice",
+						"time_local":      "Tue Oct  6 12:31:40 2015",
+						"request":         "POST /upload?id=1 HTTP/2.0",
+						"status":          "413",
+						"body_bytes_sent": "17",
+						"duration_ms":     "1034",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMessage([]byte(test.Input), UWSGIAccess)
+		if err != nil {
+			t.Fatalf("Unexpected error ParseMessage(%q): %s", test.Input, err)
+		} else if !messagesAreEqual(got, test.Expected) {
+			t.Fatalf("Expected ParseMessage(%q, UWSGIAccess) to return Message %#v, but got %#v",
+				test.Input, test.Expected, got)
+		}
+	}
+}
+
+func TestParseMessageUWSGIAccessErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`<134>Jan  1 01:01:01 h a: `,
+			"syslog: UWSGIAccess: format incorrect at byte 26: unexpected end of message"},
+		{`<134>Jan  1 01:01:01 h a: [pid: 1] 1`,
+			"syslog: UWSGIAccess: format incorrect at byte 36: unexpected end of message"},
+		{`<134>Jan  1 01:01:01 h a: [pid: 1] 1 user {} [Thu Jan  1 01:01:01 2015] GET / => generated 0 bytes in 0 msecs (HTTP/1.1 200)`,
+			"syslog: UWSGIAccess: format incorrect at byte 38: expected byte '(', but got 'u'"},
+		{`<134>Jan  1 01:01:01 h a: [pid: 1] 1 () {} [1 Jan 2015] GET / => generated 0 bytes in 0 msecs (HTTP/1.1 200)`,
+			"syslog: UWSGIAccess: format incorrect at byte 45: invalid uWSGI timestamp"},
+		{`<134>Jan  1 01:01:01 h a: [pid: 1] 1 () {} [Thu Jan  1 01:01:01 2015] GET /`,
+			"syslog: UWSGIAccess: format incorrect at byte 70: expected uWSGI request, but got ' GET /'"},
+		{`<134>Jan  1 01:01:01 h a: [pid: 1] 1 () {} [Thu Jan  1 01:01:01 2015] GET / => generated 0 bytes`,
+			"syslog: UWSGIAccess: format incorrect at byte 70: invalid uWSGI response"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.Input), UWSGIAccess)
+		formatErr, ok := err.(*FormatError)
+		if !ok {
+			t.Fatalf("Expected ParseMessage(%q) to return a *FormatError, but got %#v",
+				test.Input, err)
+		}
+
+		formatErr.Snippet = nil
+		if got := formatErr.Error(); got != test.Expected {
+			t.Fatalf("Expected ParseMessage(%q) to return error %q, but got %q",
+				test.Input, test.Expected, got)
+		}
+	}
+}